@@ -50,7 +50,7 @@ var (
 	// base is the base command for all actions related to Lookup Vindexes.
 	base = &cobra.Command{
 		Use:                   "LookupVindex --name <name> --table-keyspace <keyspace> [command] [command-flags]",
-		Short:                 "Perform commands related to creating, backfilling, and externalizing Lookup Vindexes using VReplication workflows.",
+		Short:                 "Perform commands related to creating, backfilling, externalizing, and verifying Lookup Vindexes using VReplication workflows.",
 		DisableFlagsInUseLine: true,
 		Aliases:               []string{"lookupvindex"},
 		Args:                  cobra.NoArgs,
@@ -74,6 +74,12 @@ var (
 		Keyspace string
 	}{}
 
+	verifyOptions = struct {
+		Keyspace            string
+		BatchSize           int64
+		MaxReportSampleRows int64
+	}{}
+
 	parseAndValidateCreate = func(cmd *cobra.Command, args []string) error {
 		if createOptions.TableName == "" { // Use vindex name
 			createOptions.TableName = baseOptions.Name
@@ -167,6 +173,18 @@ var (
 		RunE:                  commandExternalize,
 	}
 
+	// repair makes a LookupVindexVerify call to a vtctld, asking it to repair the lookup table.
+	repair = &cobra.Command{
+		Use:                   "repair",
+		Short:                 "Compare the lookup table of the Lookup Vindex with its owner table, and insert the missing rows into the lookup table and delete the orphaned ones.",
+		Example:               `vtctldclient --server localhost:15999 LookupVindex --name corder_lookup_vdx --table-keyspace customer repair`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Repair"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandRepair,
+	}
+
 	// show makes a GetWorkflows call to a vtctld.
 	show = &cobra.Command{
 		Use:                   "show",
@@ -178,6 +196,18 @@ var (
 		Args:                  cobra.NoArgs,
 		RunE:                  commandShow,
 	}
+
+	// verify makes a LookupVindexVerify call to a vtctld.
+	verify = &cobra.Command{
+		Use:                   "verify",
+		Short:                 "Compare the lookup table of the Lookup Vindex with its owner table, and report the rows missing from the lookup table and the orphaned ones.",
+		Example:               `vtctldclient --server localhost:15999 LookupVindex --name corder_lookup_vdx --table-keyspace customer verify`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Verify"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandVerify,
+	}
 )
 
 func commandCancel(cmd *cobra.Command, args []string) error {
@@ -273,6 +303,42 @@ func commandShow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func commandRepair(cmd *cobra.Command, args []string) error {
+	return runVerify(cmd, true)
+}
+
+func commandVerify(cmd *cobra.Command, args []string) error {
+	return runVerify(cmd, false)
+}
+
+func runVerify(cmd *cobra.Command, repair bool) error {
+	if verifyOptions.Keyspace == "" {
+		verifyOptions.Keyspace = baseOptions.TableKeyspace
+	}
+	cli.FinishedParsing(cmd)
+
+	resp, err := common.GetClient().LookupVindexVerify(common.GetCommandCtx(), &vtctldatapb.LookupVindexVerifyRequest{
+		Keyspace:            verifyOptions.Keyspace,
+		Name:                baseOptions.Name,
+		TableKeyspace:       baseOptions.TableKeyspace,
+		Repair:              repair,
+		BatchSize:           verifyOptions.BatchSize,
+		MaxReportSampleRows: verifyOptions.MaxReportSampleRows,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSONPretty(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
 func registerCommands(root *cobra.Command) {
 	base.PersistentFlags().StringVar(&baseOptions.Name, "name", "", "The name of the Lookup Vindex to create. This will also be the name of the VReplication workflow created to backfill the Lookup Vindex.")
 	base.MarkPersistentFlagRequired("name")
@@ -314,6 +380,17 @@ func registerCommands(root *cobra.Command) {
 	// to backfill the lookup vindex. It ends up making a
 	// WorkflowDelete VtctldServer call.
 	base.AddCommand(cancel)
+
+	// The verify and repair commands compare the lookup table with
+	// the owner table of the lookup vindex, so that a lookup vindex
+	// which drifted from its owner table can be found and fixed long
+	// after it was created.
+	for _, cmd := range []*cobra.Command{verify, repair} {
+		cmd.Flags().StringVar(&verifyOptions.Keyspace, "keyspace", "", "The keyspace containing the Lookup Vindex. If no value is specified then the table-keyspace will be used.")
+		cmd.Flags().Int64Var(&verifyOptions.BatchSize, "batch-size", 1000, "The number of rows to read from each shard of the owner and lookup tables at a time.")
+		cmd.Flags().Int64Var(&verifyOptions.MaxReportSampleRows, "max-report-sample-rows", 10, "The maximum number of missing and orphaned rows to report.")
+		base.AddCommand(cmd)
+	}
 }
 
 func init() {
@@ -174,7 +174,7 @@ func Connect(ctx context.Context, params *ConnParams) (*Conn, error) {
 // Ping implements mysql ping command.
 func (c *Conn) Ping() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComPing
 
@@ -229,6 +229,16 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		c.Capabilities = capabilities & (CapabilityClientDeprecateEOF)
 	}
 
+	// Ask for protocol compression if the server supports it, and stay
+	// uncompressed otherwise, like the mysql client does.
+	if params.Compression != "" {
+		compression := compressionCapabilities([]CompressionAlgorithm{params.Compression})
+		if compression == 0 {
+			return sqlerror.NewSQLErrorf(sqlerror.CRUnknownError, sqlerror.SSUnknownSQLState, "unknown compression algorithm: %s", params.Compression)
+		}
+		c.Capabilities |= capabilities & compression
+	}
+
 	// Handle switch to SSL if necessary.
 	if params.SslEnabled() {
 		// If client asked for SSL, but server doesn't support it,
@@ -306,9 +316,19 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return err
 	}
 
+	// The server compresses everything after its OK packet.
+	if algorithm := c.compressionAlgorithm(); algorithm != "" {
+		if err := c.enableCompression(algorithm, c.zstdLevel); err != nil {
+			return sqlerror.NewSQLErrorf(sqlerror.CRUnknownError, sqlerror.SSUnknownSQLState, "cannot enable %s compression: %v", algorithm, err)
+		}
+	}
+
 	// If the server didn't support DbName in its handshake, set
 	// it now. This is what the 'mysql' client does.
 	if capabilities&CapabilityClientConnectWithDB == 0 && params.DbName != "" {
+		// This is a new command, need to reset the sequence.
+		c.resetSequence()
+
 		// Write the packet.
 		if err := c.writeComInitDB(params.DbName); err != nil {
 			return err
@@ -478,6 +498,8 @@ func (c *Conn) writeSSLRequest(capabilities uint32, characterSet uint8, params *
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
 		c.Capabilities&CapabilityClientSessionTrack |
+		// Compression negotiated with the server.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm) |
 		// Pass-through ClientFoundRows flag.
 		CapabilityClientFoundRows&uint32(params.Flags)
 
@@ -537,7 +559,9 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		CapabilityClientFoundRows&uint32(params.Flags) |
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
-		c.Capabilities&CapabilityClientSessionTrack
+		c.Capabilities&CapabilityClientSessionTrack |
+		// Compression negotiated with the server.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	// FIXME(alainjobart) add multi statement.
 
@@ -564,6 +588,15 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		length++
 	}
 
+	// The zstd compression level goes at the end.
+	if c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0 {
+		c.zstdLevel = params.ZstdCompressionLevel
+		if c.zstdLevel == 0 {
+			c.zstdLevel = DefaultZstdCompressionLevel
+		}
+		length++
+	}
+
 	data, pos := c.startEphemeralPacketWithHeader(length)
 
 	// Client capability flags.
@@ -600,6 +633,10 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	// Assume native client during response
 	pos = writeNullString(data, pos, string(c.authPluginName))
 
+	if c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0 {
+		pos = writeByte(data, pos, byte(c.zstdLevel))
+	}
+
 	// Sanity-check the length.
 	if pos != len(data) {
 		return sqlerror.NewSQLErrorf(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "writeHandshakeResponse41: only packed %v bytes, out of %v allocated", pos, len(data))
@@ -611,6 +648,43 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	return nil
 }
 
+// ChangeUser authenticates the connection as another user with
+// COM_CHANGE_USER, and changes its database to params.DbName.
+// The server resets the session, as for ResetConnection.
+// Returns a SQLError.
+func (c *Conn) ChangeUser(params *ConnParams) error {
+	var authResponse []byte
+	switch c.authPluginName {
+	case MysqlClearPassword:
+		authResponse = append([]byte(params.Pass), 0)
+	case CachingSha2Password:
+		authResponse = ScrambleCachingSha2Password(c.salt, []byte(params.Pass))
+	default:
+		authResponse = ScrambleMysqlNativePassword(c.salt, []byte(params.Pass))
+	}
+
+	length := 1 + // Command.
+		lenNullString(params.Uname) +
+		1 + len(authResponse) +
+		lenNullString(params.DbName) +
+		2 + // Character set.
+		lenNullString(string(c.authPluginName))
+
+	c.resetSequence()
+	data, pos := c.startEphemeralPacketWithHeader(length)
+	pos = writeByte(data, pos, ComChangeUser)
+	pos = writeNullString(data, pos, params.Uname)
+	pos = writeByte(data, pos, byte(len(authResponse)))
+	pos += copy(data[pos:], authResponse)
+	pos = writeNullString(data, pos, params.DbName)
+	pos = writeUint16(data, pos, uint16(params.Charset))
+	_ = writeNullString(data, pos, string(c.authPluginName))
+	if err := c.writeEphemeralPacket(); err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRServerGone, sqlerror.SSUnknownSQLState, err.Error())
+	}
+	return c.handleAuthResponse(params)
+}
+
 // handleAuthResponse parses server's response after client sends the password for authentication
 // and handles next steps for AuthSwitchRequestPacket and AuthMoreDataPacket.
 func (c *Conn) handleAuthResponse(params *ConnParams) error {
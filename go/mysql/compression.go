@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// CompressionAlgorithm is an algorithm used to compress the packets
+// exchanged between a client and a server.
+type CompressionAlgorithm string
+
+const (
+	// CompressionZlib is negotiated with CapabilityClientCompress.
+	CompressionZlib CompressionAlgorithm = "zlib"
+
+	// CompressionZstd is negotiated with CapabilityClientZstdCompressionAlgorithm.
+	CompressionZstd CompressionAlgorithm = "zstd"
+
+	// compressionUncompressed is accepted in lists of algorithms, like it is
+	// by MySQL. Uncompressed connections are always allowed.
+	compressionUncompressed = "uncompressed"
+)
+
+const (
+	// DefaultZstdCompressionLevel is the zstd level used when none is
+	// given, same as the MySQL default.
+	DefaultZstdCompressionLevel = 3
+
+	// compressedPacketHeaderSize is the size of the header of a compressed packet:
+	// - 3 bytes for the length of the payload as sent
+	// - 1 byte for the compressed sequence
+	// - 3 bytes for the length of the payload before compression,
+	//   or 0 if the payload was sent as is.
+	compressedPacketHeaderSize = 7
+
+	// minCompressLength is the payload size under which we don't bother
+	// compressing, as MySQL does.
+	minCompressLength = 50
+)
+
+var (
+	zstdEncodersMu sync.Mutex
+	zstdEncoders   = map[zstd.EncoderLevel]*zstd.Encoder{}
+
+	// zstdDecoder is shared by all compressed connections. It's only used with
+	// DecodeAll, which is safe for concurrent use.
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+	zstdDecoderOnce sync.Once
+)
+
+// ParseCompressionAlgorithms parses a comma separated list of compression algorithms.
+func ParseCompressionAlgorithms(list string) ([]CompressionAlgorithm, error) {
+	var algorithms []CompressionAlgorithm
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", compressionUncompressed:
+		case string(CompressionZlib), string(CompressionZstd):
+			algorithms = append(algorithms, CompressionAlgorithm(name))
+		default:
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown compression algorithm: %s", name)
+		}
+	}
+	return algorithms, nil
+}
+
+// compressionCapabilities returns the capability flags advertising the given algorithms.
+func compressionCapabilities(algorithms []CompressionAlgorithm) uint32 {
+	var capabilities uint32
+	for _, algorithm := range algorithms {
+		switch algorithm {
+		case CompressionZlib:
+			capabilities |= CapabilityClientCompress
+		case CompressionZstd:
+			capabilities |= CapabilityClientZstdCompressionAlgorithm
+		}
+	}
+	return capabilities
+}
+
+// compressionAlgorithm returns the compression algorithm negotiated during
+// the handshake, or an empty string if the connection isn't compressed.
+func (c *Conn) compressionAlgorithm() CompressionAlgorithm {
+	switch {
+	case c.Capabilities&CapabilityClientCompress != 0:
+		return CompressionZlib
+	case c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0:
+		return CompressionZstd
+	default:
+		return ""
+	}
+}
+
+func getZstdEncoder(level int) (*zstd.Encoder, error) {
+	encoderLevel := zstd.EncoderLevelFromZstd(level)
+
+	zstdEncodersMu.Lock()
+	defer zstdEncodersMu.Unlock()
+	if enc, ok := zstdEncoders[encoderLevel]; ok {
+		return enc, nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[encoderLevel] = enc
+	return enc, nil
+}
+
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(MaxPacketSize))
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
+// compressedStream frames everything that is written to and read from a
+// connection once compression has been negotiated. The regular packets,
+// with their own headers, are the payload of the compressed packets. A
+// regular packet can span multiple compressed packets, and a compressed
+// packet can hold multiple regular packets.
+//
+// The sequence of the compressed packets is separate from the sequence of
+// the regular packets. It is reset at the start of each command, and
+// continues from the last packet read when writing.
+type compressedStream struct {
+	algorithm CompressionAlgorithm
+
+	r io.Reader
+	w io.Writer
+
+	sequence uint8
+	header   [compressedPacketHeaderSize]byte
+
+	// pending is what's left to be read of the last packet. It points
+	// into readBuf or dataBuf, which are reused once it's empty.
+	pending []byte
+	readBuf []byte
+	dataBuf []byte
+
+	// writeBuf holds the last packet written, header included.
+	writeBuf []byte
+	zlibW    *zlib.Writer
+	zlibR    io.ReadCloser
+	zstdEnc  *zstd.Encoder
+}
+
+func newCompressedStream(algorithm CompressionAlgorithm, zstdLevel int, r io.Reader, w io.Writer) (*compressedStream, error) {
+	cs := &compressedStream{
+		algorithm: algorithm,
+		r:         r,
+		w:         w,
+		writeBuf:  make([]byte, compressedPacketHeaderSize, connBufferSize),
+	}
+	switch algorithm {
+	case CompressionZlib:
+	case CompressionZstd:
+		if zstdLevel == 0 {
+			zstdLevel = DefaultZstdCompressionLevel
+		}
+		enc, err := getZstdEncoder(zstdLevel)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot create zstd encoder")
+		}
+		cs.zstdEnc = enc
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown compression algorithm: %s", algorithm)
+	}
+	return cs, nil
+}
+
+// Read implements io.Reader, returning the uncompressed data.
+func (cs *compressedStream) Read(p []byte) (int, error) {
+	for len(cs.pending) == 0 {
+		if err := cs.readPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cs.pending)
+	cs.pending = cs.pending[n:]
+	return n, nil
+}
+
+func (cs *compressedStream) readPacket() error {
+	if _, err := io.ReadFull(cs.r, cs.header[:]); err != nil {
+		return err
+	}
+	length := int(uint32(cs.header[0]) | uint32(cs.header[1])<<8 | uint32(cs.header[2])<<16)
+	sequence := cs.header[3]
+	uncompressedLength := int(uint32(cs.header[4]) | uint32(cs.header[5])<<8 | uint32(cs.header[6])<<16)
+
+	if sequence != cs.sequence {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed sequence, expected %v got %v", cs.sequence, sequence)
+	}
+	cs.sequence++
+
+	cs.readBuf = growBuffer(cs.readBuf, length)
+	payload := cs.readBuf
+	if _, err := io.ReadFull(cs.r, payload); err != nil {
+		return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+	}
+	if uncompressedLength == 0 {
+		cs.pending = payload
+		return nil
+	}
+
+	data, err := cs.decompress(payload, uncompressedLength)
+	if err != nil {
+		return err
+	}
+	cs.pending = data
+	return nil
+}
+
+func (cs *compressedStream) decompress(payload []byte, uncompressedLength int) ([]byte, error) {
+	cs.dataBuf = growBuffer(cs.dataBuf, uncompressedLength)
+	data := cs.dataBuf
+	switch cs.algorithm {
+	case CompressionZlib:
+		var err error
+		if cs.zlibR == nil {
+			cs.zlibR, err = zlib.NewReader(bytes.NewReader(payload))
+		} else {
+			err = cs.zlibR.(zlib.Resetter).Reset(bytes.NewReader(payload), nil)
+		}
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot decompress zlib packet")
+		}
+		if _, err := io.ReadFull(cs.zlibR, data); err != nil {
+			return nil, vterrors.Wrapf(err, "cannot decompress zlib packet")
+		}
+	case CompressionZstd:
+		dec, err := getZstdDecoder()
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot create zstd decoder")
+		}
+		data, err = dec.DecodeAll(payload, data[:0])
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot decompress zstd packet")
+		}
+		if len(data) != uncompressedLength {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid zstd packet, expected %v uncompressed bytes got %v", uncompressedLength, len(data))
+		}
+	}
+	return data, nil
+}
+
+// Write implements io.Writer. Each call sends at least one compressed packet,
+// so writes should be buffered by the caller when possible.
+func (cs *compressedStream) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > MaxPacketSize {
+			chunk = chunk[:MaxPacketSize]
+		}
+		if err := cs.writePacket(chunk); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (cs *compressedStream) writePacket(data []byte) error {
+	// The header is written to the same buffer as the payload,
+	// so that the whole packet is sent in a single write.
+	var packet []byte
+	if len(data) >= minCompressLength {
+		compressed, err := cs.compress(data)
+		if err != nil {
+			return err
+		}
+		// Some data gets larger when compressed, it's sent as is then.
+		if len(compressed)-compressedPacketHeaderSize < len(data) {
+			packet = compressed
+		}
+	}
+
+	uncompressedLength := len(data)
+	if packet == nil {
+		packet = append(cs.writeBuf[:compressedPacketHeaderSize], data...)
+		uncompressedLength = 0
+	}
+	cs.writeBuf = packet
+
+	length := len(packet) - compressedPacketHeaderSize
+	packet[0] = byte(length)
+	packet[1] = byte(length >> 8)
+	packet[2] = byte(length >> 16)
+	packet[3] = cs.sequence
+	packet[4] = byte(uncompressedLength)
+	packet[5] = byte(uncompressedLength >> 8)
+	packet[6] = byte(uncompressedLength >> 16)
+	cs.sequence++
+
+	if _, err := cs.w.Write(packet); err != nil {
+		return vterrors.Wrapf(err, "Write(compressed packet) failed")
+	}
+	return nil
+}
+
+// compress returns the compressed data, preceded by room for the header.
+func (cs *compressedStream) compress(data []byte) ([]byte, error) {
+	buf := cs.writeBuf[:compressedPacketHeaderSize]
+	switch cs.algorithm {
+	case CompressionZlib:
+		w := bytes.NewBuffer(buf)
+		if cs.zlibW == nil {
+			cs.zlibW = zlib.NewWriter(w)
+		} else {
+			cs.zlibW.Reset(w)
+		}
+		if _, err := cs.zlibW.Write(data); err != nil {
+			return nil, vterrors.Wrapf(err, "cannot compress zlib packet")
+		}
+		if err := cs.zlibW.Close(); err != nil {
+			return nil, vterrors.Wrapf(err, "cannot compress zlib packet")
+		}
+		buf = w.Bytes()
+	case CompressionZstd:
+		buf = cs.zstdEnc.EncodeAll(data, buf)
+	}
+	cs.writeBuf = buf
+	return buf, nil
+}
+
+// growBuffer returns buf resized to length, reallocating it if it's too small.
+func growBuffer(buf []byte, length int) []byte {
+	if cap(buf) < length {
+		return make([]byte, length)
+	}
+	return buf[:length]
+}
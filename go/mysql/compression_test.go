@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestParseCompressionAlgorithms(t *testing.T) {
+	algorithms, err := ParseCompressionAlgorithms("")
+	require.NoError(t, err)
+	assert.Empty(t, algorithms)
+
+	algorithms, err = ParseCompressionAlgorithms("zlib, ZSTD,uncompressed")
+	require.NoError(t, err)
+	assert.Equal(t, []CompressionAlgorithm{CompressionZlib, CompressionZstd}, algorithms)
+	assert.EqualValues(t, CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm, compressionCapabilities(algorithms))
+
+	_, err = ParseCompressionAlgorithms("zlib,lz4")
+	require.EqualError(t, err, "unknown compression algorithm: lz4")
+}
+
+func TestCompressedStreamRoundTrip(t *testing.T) {
+	random := make([]byte, 100_000)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	payloads := [][]byte{
+		[]byte("short"),
+		[]byte(strings.Repeat("compress me please ", 10_000)),
+		random,
+	}
+
+	for _, algorithm := range []CompressionAlgorithm{CompressionZlib, CompressionZstd} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := newCompressedStream(algorithm, 0, nil, &buf)
+			require.NoError(t, err)
+			r, err := newCompressedStream(algorithm, 0, &buf, nil)
+			require.NoError(t, err)
+
+			for _, payload := range payloads {
+				n, err := w.Write(payload)
+				require.NoError(t, err)
+				require.Equal(t, len(payload), n)
+
+				sent := buf.Len() - compressedPacketHeaderSize
+				if len(payload) < minCompressLength || bytes.Equal(payload, random) {
+					assert.Equal(t, len(payload), sent, "payload should be sent as is")
+				} else {
+					assert.Less(t, sent, len(payload)/10, "payload should be compressed")
+				}
+
+				got := make([]byte, len(payload))
+				_, err = io.ReadFull(r, got)
+				require.NoError(t, err)
+				require.Equal(t, payload, got)
+			}
+
+			// Both sides start over with the next command.
+			w.sequence = 0
+			_, err = w.Write([]byte("next command"))
+			require.NoError(t, err)
+			_, err = r.Read(make([]byte, 100))
+			require.EqualError(t, err, "invalid compressed sequence, expected 3 got 0")
+		})
+	}
+}
+
+func TestServerCompression(t *testing.T) {
+	var rows [][]sqltypes.Value
+	for i := 0; i < 1000; i++ {
+		rows = append(rows, []sqltypes.Value{
+			sqltypes.MakeTrusted(querypb.Type_INT32, []byte(fmt.Sprintf("%d", i))),
+			sqltypes.MakeTrusted(querypb.Type_VARCHAR, []byte(strings.Repeat("a rather long and repetitive name ", 10))),
+		})
+	}
+	result := &sqltypes.Result{
+		Fields: []*querypb.Field{
+			{
+				Name:    "id",
+				Type:    querypb.Type_INT32,
+				Charset: collations.CollationBinaryID,
+				Flags:   uint32(querypb.MySqlFlag_NUM_FLAG),
+			},
+			{
+				Name:    "name",
+				Type:    querypb.Type_VARCHAR,
+				Charset: uint32(collations.CollationUtf8mb4ID),
+			},
+		},
+		Rows: rows,
+	}
+
+	tcases := []struct {
+		name       string
+		server     []CompressionAlgorithm
+		client     CompressionAlgorithm
+		zstdLevel  int
+		negotiated CompressionAlgorithm
+	}{{
+		name: "uncompressed",
+	}, {
+		name:       "zlib",
+		server:     []CompressionAlgorithm{CompressionZlib, CompressionZstd},
+		client:     CompressionZlib,
+		negotiated: CompressionZlib,
+	}, {
+		name:       "zstd",
+		server:     []CompressionAlgorithm{CompressionZlib, CompressionZstd},
+		client:     CompressionZstd,
+		zstdLevel:  7,
+		negotiated: CompressionZstd,
+	}, {
+		name:   "zstd not allowed by the server",
+		server: []CompressionAlgorithm{CompressionZlib},
+		client: CompressionZstd,
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			th := &testHandler{}
+			l, err := NewListener("tcp", "127.0.0.1:", NewAuthServerNone(), th, 0, 0, false, false, 0, 0)
+			require.NoError(t, err)
+			defer l.Close()
+			l.CompressionAlgorithms = tcase.server
+			go l.Accept()
+
+			host, port := getHostPort(t, l.Addr())
+			params := &ConnParams{
+				Host:                 host,
+				Port:                 port,
+				Compression:          tcase.client,
+				ZstdCompressionLevel: tcase.zstdLevel,
+			}
+			c, err := Connect(context.Background(), params)
+			require.NoError(t, err)
+			defer c.Close()
+
+			assert.Equal(t, tcase.negotiated, c.compressionAlgorithm())
+			assert.Equal(t, tcase.negotiated, th.LastConn().compressionAlgorithm())
+			if tcase.negotiated == CompressionZstd {
+				assert.Equal(t, tcase.zstdLevel, th.LastConn().zstdLevel)
+			}
+
+			qr, err := c.ExecuteFetch("select rows", 10000, true)
+			require.NoError(t, err)
+			assert.Equal(t, selectRowsResult.Rows, qr.Rows)
+
+			th.mu.Lock()
+			th.result = result
+			th.mu.Unlock()
+
+			qr, err = c.ExecuteFetch("large result", 10000, true)
+			require.NoError(t, err)
+			assert.Equal(t, result.Rows, qr.Rows)
+
+			require.NoError(t, c.Ping())
+		})
+	}
+}
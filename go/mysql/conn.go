@@ -87,7 +87,8 @@ type Conn struct {
 	// fields, this is set to an empty array (but not nil).
 	fields []*querypb.Field
 
-	// salt is sent by the server during initial handshake to be used for authentication
+	// salt is sent by the server during initial handshake to be used for authentication.
+	// On the server side, it is the last one sent to the client, for COM_CHANGE_USER.
 	salt []byte
 
 	// authPluginName is the name of server's authentication plugin.
@@ -182,6 +183,12 @@ type Conn struct {
 	// by Handler methods.
 	StatusFlags uint16
 
+	// QueryAttributes are the attributes sent by the client with the
+	// COM_QUERY or COM_STMT_EXECUTE being handled, if it negotiated
+	// CapabilityClientQueryAttributes. NULL attributes are left out.
+	// It is only used by the server.
+	QueryAttributes map[string]string
+
 	// CharacterSet is the charset for this connection, as negotiated
 	// in our handshake with the server. Note that although the MySQL protocol lists this
 	// as a "character set", the returned byte value is actually a Collation ID,
@@ -194,6 +201,13 @@ type Conn struct {
 	// Packet encoding variables.
 	sequence uint8
 
+	// compression frames all packets once protocol compression has
+	// been negotiated during the handshake. It is nil otherwise.
+	compression *compressedStream
+
+	// zstdLevel is the zstd compression level asked for by the client.
+	zstdLevel int
+
 	// ExpectSemiSyncIndicator is applicable when the connection is used for replication (ComBinlogDump).
 	// When 'true', events are assumed to be padded with 2-byte semi-sync information
 	// See https://dev.mysql.com/doc/internals/en/semi-sync-binlog-event.html
@@ -203,6 +217,14 @@ type Conn struct {
 	// See: ConnParams.EnableQueryInfo
 	enableQueryInfo bool
 
+	// streamingCursor is the cursor whose rows are still being streamed
+	// by the handler, if any. There is at most one, as the handler doesn't
+	// allow concurrent calls.
+	streamingCursor *cursor
+
+	// maxCursorBufferedRows is Listener.MaxCursorBufferedRows.
+	maxCursorBufferedRows int
+
 	// keepAliveOn marks when keep alive is active on the connection.
 	// This is currently used for testing.
 	keepAliveOn bool
@@ -227,6 +249,13 @@ type PrepareData struct {
 	BindVars    map[string]*querypb.BindVariable
 	StatementID uint32
 	ParamsCount uint16
+
+	// CursorType is the cursor type the client asked for in the
+	// COM_STMT_EXECUTE being handled.
+	CursorType byte
+
+	// cursor is the open cursor of the statement, if any.
+	cursor *cursor
 }
 
 // execResult is an enum signifying the result of executing a query
@@ -283,6 +312,8 @@ func newServerConn(conn net.Conn, listener *Listener) *Conn {
 		keepAliveOn:    enabledKeepAlive,
 		flushDelay:     listener.flushDelay,
 		truncateErrLen: listener.truncateErrLen,
+
+		maxCursorBufferedRows: listener.MaxCursorBufferedRows,
 	}
 
 	if listener.connReadBufferSize > 0 {
@@ -323,7 +354,7 @@ func (c *Conn) startWriterBuffering() {
 	defer c.bufMu.Unlock()
 
 	c.bufferedWriter = writersPool.Get().(*bufio.Writer)
-	c.bufferedWriter.Reset(c.conn)
+	c.bufferedWriter.Reset(c.getWriter())
 }
 
 // endWriterBuffering must be called to terminate startWriteBuffering.
@@ -371,14 +402,47 @@ func (c *Conn) startFlushTimer() {
 }
 
 // getReader returns reader for connection. It can be *bufio.Reader or net.Conn
-// depending on which buffer size was passed to newServerConn.
+// depending on which buffer size was passed to newServerConn, or the
+// compressed stream on top of them if compression is enabled.
 func (c *Conn) getReader() io.Reader {
+	if c.compression != nil {
+		return c.compression
+	}
 	if c.bufferedReader != nil {
 		return c.bufferedReader
 	}
 	return c.conn
 }
 
+// getWriter returns the unbuffered writer for the connection, which is
+// the compressed stream if compression is enabled.
+func (c *Conn) getWriter() io.Writer {
+	if c.compression != nil {
+		return c.compression
+	}
+	return c.conn
+}
+
+// enableCompression frames everything read and written from now on with the
+// given compression algorithm. It is called by both sides once the handshake
+// is done.
+func (c *Conn) enableCompression(algorithm CompressionAlgorithm, zstdLevel int) error {
+	cs, err := newCompressedStream(algorithm, zstdLevel, c.getReader(), c.conn)
+	if err != nil {
+		return err
+	}
+	c.compression = cs
+	return nil
+}
+
+// resetSequence resets the packet sequence at the start of a new command.
+func (c *Conn) resetSequence() {
+	c.sequence = 0
+	if c.compression != nil {
+		c.compression.sequence = 0
+	}
+}
+
 func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	// Note io.ReadFull will return two different types of errors:
 	// 1. if the socket is already closed, and the go runtime knows it,
@@ -399,12 +463,14 @@ func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 		return 0, vterrors.Wrapf(err, "io.ReadFull(header size) failed")
 	}
 
+	// With compression, the sequence is checked on the compressed packets instead,
+	// as MySQL does. Clients don't keep the two in sync.
 	sequence := uint8(c.header[3])
-	if sequence != c.sequence {
+	if sequence != c.sequence && c.compression == nil {
 		return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
 	}
 
-	c.sequence++
+	c.sequence = sequence + 1
 
 	return int(uint32(c.header[0]) | uint32(c.header[1])<<8 | uint32(c.header[2])<<16), nil
 }
@@ -611,7 +677,7 @@ func (c *Conn) writePacket(data []byte) error {
 		}()
 	} else {
 		c.bufMu.Unlock()
-		w = c.conn
+		w = c.getWriter()
 	}
 
 	var header [packetHeaderSize]byte
@@ -715,7 +781,7 @@ func (c *Conn) recycleWritePacket() {
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) writeComQuit() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComQuit
@@ -888,7 +954,7 @@ func (c *Conn) writeEOFPacket(flags uint16, warnings uint16) error {
 // handleNextCommand is called in the server loop to process
 // incoming packets.
 func (c *Conn) handleNextCommand(handler Handler) bool {
-	c.sequence = 0
+	c.resetSequence()
 	data, err := c.readEphemeralPacket()
 	if err != nil {
 		// Don't log EOF errors. They cause too much spam.
@@ -905,6 +971,14 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 		return false
 	}
 
+	c.QueryAttributes = nil
+
+	switch data[0] {
+	case ComQuit, ComPing, ComSetOption, ComStmtSendLongData, ComStmtClose, ComStmtReset, ComStmtFetch, ComResetConnection, ComChangeUser:
+	default:
+		c.bufferStreamingCursor(data)
+	}
+
 	switch data[0] {
 	case ComQuit:
 		c.recycleReadPacket()
@@ -930,13 +1004,18 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 		stmtID, ok := c.parseComStmtClose(data)
 		c.recycleReadPacket()
 		if ok {
+			c.closeCursor(c.PrepareData[stmtID])
 			delete(c.PrepareData, stmtID)
 		}
 	case ComStmtReset:
 		return c.handleComStmtReset(data)
+	case ComStmtFetch:
+		return c.handleComStmtFetch(handler, data)
 	case ComResetConnection:
 		c.handleComResetConnection(handler)
 		return true
+	case ComChangeUser:
+		return c.handleComChangeUser(handler, data)
 	case ComFieldList:
 		c.recycleReadPacket()
 		if !c.writeErrorAndLog(sqlerror.ERUnknownComError, sqlerror.SSNetError, "command handling not implemented yet: %v", data[0]) {
@@ -1028,6 +1107,7 @@ func (c *Conn) handleComBinlogDumpGTID(handler Handler, data []byte) (kontinue b
 func (c *Conn) handleComResetConnection(handler Handler) {
 	// Clean up and reset the connection
 	c.recycleReadPacket()
+	c.closeCursors()
 	handler.ComResetConnection(c)
 	// Reset prepared statements
 	c.PrepareData = make(map[uint32]*PrepareData)
@@ -1037,6 +1117,52 @@ func (c *Conn) handleComResetConnection(handler Handler) {
 	}
 }
 
+// handleComChangeUser authenticates the connection as another user, and
+// resets its session. If authentication fails, the connection is closed.
+func (c *Conn) handleComChangeUser(handler Handler, data []byte) bool {
+	user, authMethod, authResponse, db, err := c.parseComChangeUser(data)
+	c.recycleReadPacket()
+	if err != nil {
+		log.Errorf("Cannot parse change user request from %s: %v", c, err)
+		return c.writeErrorAndLog(sqlerror.ERUnknownComError, sqlerror.SSNetError, "error handling packet: %v", data)
+	}
+
+	// The session of the previous user is gone, even if authentication fails.
+	c.closeCursors()
+	c.PrepareData = make(map[uint32]*PrepareData)
+
+	userData, ok := c.listener.authenticate(c, user, authMethod, c.salt, authResponse)
+	if !ok {
+		return false
+	}
+
+	if c.User != "" {
+		connCountPerUser.Add(c.User, -1)
+	}
+	c.User = user
+	c.UserData = userData
+	if c.User != "" {
+		connCountPerUser.Add(c.User, 1)
+	}
+	handler.ComChangeUser(c)
+
+	c.schemaName = db
+	if db != "" {
+		err := handler.ComQuery(c, "use "+sqlescape.EscapeID(db), func(*sqltypes.Result) error {
+			return nil
+		})
+		if err != nil {
+			return c.writeErrorPacketFromErrorAndLog(err)
+		}
+	}
+
+	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
+		log.Errorf("Error writing ComChangeUser result to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
 func (c *Conn) handleComStmtReset(data []byte) bool {
 	stmtID, ok := c.parseComStmtReset(data)
 	c.recycleReadPacket()
@@ -1060,6 +1186,7 @@ func (c *Conn) handleComStmtReset(data []byte) bool {
 			prepare.BindVars[k] = nil
 		}
 	}
+	c.closeCursor(prepare)
 
 	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
 		log.Error("Error writing ComStmtReset OK packet to client %v: %v", c.ConnectionID, err)
@@ -1107,7 +1234,7 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 		}
 	}()
 	queryStart := time.Now()
-	stmtID, _, err := c.parseComStmtExecute(c.PrepareData, data)
+	stmtID, cursorType, err := c.parseComStmtExecute(c.PrepareData, data)
 	c.recycleReadPacket()
 
 	if stmtID != uint32(0) {
@@ -1122,10 +1249,19 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 		return c.writeErrorPacketFromErrorAndLog(err)
 	}
 
+	prepare := c.PrepareData[stmtID]
+	prepare.CursorType = cursorType
+	if cursorType&CursorTypeReadOnly != 0 {
+		if !c.handleComStmtExecuteWithCursor(handler, prepare) {
+			return false
+		}
+		timings.Record(queryTimingKey, queryStart)
+		return true
+	}
+
 	fieldSent := false
 	// sendFinished is set if the response should just be an OK packet.
 	sendFinished := false
-	prepare := c.PrepareData[stmtID]
 	err = handler.ComStmtExecute(c, prepare, func(qr *sqltypes.Result) error {
 		if sendFinished {
 			// Failsafe: Unreachable if server is well-behaved.
@@ -1324,11 +1460,13 @@ func (c *Conn) handleComQuery(handler Handler, data []byte) (kontinue bool) {
 	}()
 
 	queryStart := time.Now()
-	query := c.parseComQuery(data)
+	query, err := c.parseComQuery(data)
 	c.recycleReadPacket()
+	if err != nil {
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
 
 	var queries []string
-	var err error
 	if c.Capabilities&CapabilityClientMultiStatements != 0 {
 		queries, err = handler.Env().Parser().SplitStatementToPieces(query)
 		if err != nil {
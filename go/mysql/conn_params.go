@@ -64,6 +64,15 @@ type ConnParams struct {
 	// FlushDelay is the delay after which buffered response will be flushed to the client.
 	FlushDelay time.Duration
 
+	// Compression is the protocol compression algorithm to ask for during the
+	// handshake. The connection stays uncompressed if the server doesn't
+	// support it. Compression is disabled if empty.
+	Compression CompressionAlgorithm
+
+	// ZstdCompressionLevel is the compression level used with zstd,
+	// DefaultZstdCompressionLevel if not set.
+	ZstdCompressionLevel int
+
 	TruncateErrLen int
 }
 
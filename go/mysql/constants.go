@@ -76,8 +76,9 @@ const (
 	// CLIENT_NO_SCHEMA 1 << 4
 	// Do not permit database.table.column. We do permit it.
 
-	// CLIENT_COMPRESS 1 << 5
-	// We do not support compression. CPU is usually our bottleneck.
+	// CapabilityClientCompress is CLIENT_COMPRESS.
+	// Use zlib compression for the protocol, once the handshake is done.
+	CapabilityClientCompress = 1 << 5
 
 	// CLIENT_ODBC 1 << 6
 	// No special behavior since 3.22.
@@ -148,6 +149,18 @@ const (
 	// CapabilityClientDeprecateEOF is CLIENT_DEPRECATE_EOF
 	// Expects an OK (instead of EOF) after the resultset rows of a Text Resultset.
 	CapabilityClientDeprecateEOF = 1 << 24
+
+	// CLIENT_OPTIONAL_RESULTSET_METADATA 1 << 25
+	// Not yet supported.
+
+	// CapabilityClientZstdCompressionAlgorithm is CLIENT_ZSTD_COMPRESSION_ALGORITHM.
+	// Use zstd compression for the protocol, once the handshake is done.
+	// The client sends the compression level at the end of its handshake response.
+	CapabilityClientZstdCompressionAlgorithm = 1 << 26
+
+	// CapabilityClientQueryAttributes is CLIENT_QUERY_ATTRIBUTES.
+	// The client can send named attributes with COM_QUERY and COM_STMT_EXECUTE.
+	CapabilityClientQueryAttributes = 1 << 27
 )
 
 // Status flags. They are returned by the server in a few cases.
@@ -179,6 +192,21 @@ const (
 	ServerSessionStateChanged uint16 = 0x4000
 )
 
+// Cursor types, sent by the client in COM_STMT_EXECUTE.
+const (
+	// CursorTypeNoCursor is CURSOR_TYPE_NO_CURSOR.
+	CursorTypeNoCursor byte = 0x00
+
+	// CursorTypeReadOnly is CURSOR_TYPE_READ_ONLY. The rows are
+	// then fetched by the client with COM_STMT_FETCH.
+	CursorTypeReadOnly byte = 0x01
+
+	// parameterCountAvailable is PARAMETER_COUNT_AVAILABLE. It is set along
+	// with the cursor type when the parameter count is sent, which is how
+	// query attributes are added to the parameters of the statement.
+	parameterCountAvailable byte = 0x08
+)
+
 // State Change Information
 const (
 	// one or more system variables changed.
@@ -209,6 +237,9 @@ const (
 	// ComPing is COM_PING.
 	ComPing = 0x0e
 
+	// ComChangeUser is COM_CHANGE_USER.
+	ComChangeUser = 0x11
+
 	// ComBinlogDump is COM_BINLOG_DUMP.
 	ComBinlogDump = 0x12
 
@@ -230,7 +261,7 @@ const (
 	// ComStmtReset is COM_STMT_RESET
 	ComStmtReset = 0x1a
 
-	//ComStmtFetch is COM_STMT_FETCH
+	// ComStmtFetch is COM_STMT_FETCH
 	ComStmtFetch = 0x1c
 
 	// ComSetOption is COM_SET_OPTION
@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"errors"
+	"maps"
+	"math"
+
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/tb"
+	"vitess.io/vitess/go/vt/log"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// errCursorClosed is returned to the handler streaming the rows of a
+// cursor once the client has closed it.
+var errCursorClosed = vterrors.Errorf(vtrpcpb.Code_CANCELED, "cursor closed")
+
+// DefaultMaxCursorBufferedRows is the default value of Listener.MaxCursorBufferedRows.
+const DefaultMaxCursorBufferedRows = 10000
+
+// cursor holds the rows of a statement executed with CursorTypeReadOnly.
+// The handler streams them from its own go routine, and they are sent to
+// the client as it asks for them with COM_STMT_FETCH.
+//
+// The handler is paused in its callback while we work with the result it
+// gave us, so that it never runs at the same time as the connection's go routine.
+type cursor struct {
+	stmtID uint32
+	fields []*querypb.Field
+
+	// rows have been streamed by the handler, but not sent to the client yet.
+	rows [][]sqltypes.Value
+
+	results chan *sqltypes.Result
+	more    chan struct{}
+	closed  chan struct{}
+	done    chan struct{}
+
+	// paused is set when the handler waits on more to continue.
+	paused bool
+
+	// err is what the handler returned. It is set before done is closed.
+	err error
+}
+
+// openCursor starts executing the statement in a new go routine.
+func (c *Conn) openCursor(handler Handler, prepare *PrepareData) *cursor {
+	cur := &cursor{
+		stmtID:  prepare.StatementID,
+		results: make(chan *sqltypes.Result),
+		more:    make(chan struct{}),
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(cur.done)
+		defer func() {
+			if x := recover(); x != nil {
+				log.Errorf("mysql_server caught panic:\n%v\n%s", x, tb.Stack(4))
+				cur.err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "%v", x)
+			}
+		}()
+
+		cur.err = handler.ComStmtExecute(c, prepare, func(qr *sqltypes.Result) error {
+			select {
+			case cur.results <- qr:
+			case <-cur.closed:
+				return errCursorClosed
+			}
+			select {
+			case <-cur.more:
+				return nil
+			case <-cur.closed:
+				return errCursorClosed
+			}
+		})
+	}()
+	return cur
+}
+
+// next returns the next result streamed by the handler, or nil once it is done.
+func (cur *cursor) next() *sqltypes.Result {
+	if cur.paused {
+		cur.paused = false
+		cur.more <- struct{}{}
+	}
+	select {
+	case qr := <-cur.results:
+		cur.paused = true
+		return qr
+	case <-cur.done:
+		return nil
+	}
+}
+
+// fill reads results from the handler until there are at least n rows
+// waiting to be sent. It returns false if the handler is done.
+func (cur *cursor) fill(n int) bool {
+	for len(cur.rows) < n {
+		qr := cur.next()
+		if qr == nil {
+			return false
+		}
+		cur.rows = append(cur.rows, qr.Rows...)
+	}
+	return true
+}
+
+// close stops the handler and waits for it to return.
+func (cur *cursor) close() {
+	select {
+	case <-cur.closed:
+	default:
+		close(cur.closed)
+	}
+	<-cur.done
+}
+
+// abort stops the handler and drops the rows that are waiting to be sent,
+// so the next fetch returns err.
+func (cur *cursor) abort(err error) {
+	cur.close()
+	cur.paused = false
+	cur.rows = nil
+	cur.err = err
+}
+
+// closeCursor closes the cursor of the given statement, if it has one.
+func (c *Conn) closeCursor(prepare *PrepareData) {
+	if prepare == nil || prepare.cursor == nil {
+		return
+	}
+	if c.streamingCursor == prepare.cursor {
+		c.streamingCursor = nil
+	}
+	prepare.cursor.close()
+	prepare.cursor = nil
+}
+
+// closeCursors closes the cursors of all statements.
+func (c *Conn) closeCursors() {
+	for _, prepare := range c.PrepareData {
+		c.closeCursor(prepare)
+	}
+}
+
+// bufferStreamingCursor reads the remaining rows of the cursor that is still
+// being streamed by the handler, if any, into memory. It is called before any
+// command that calls the handler, as the handler doesn't allow concurrent calls.
+// At most maxCursorBufferedRows rows are buffered: if the cursor has more,
+// it is aborted and the next fetch fails, instead of holding the whole result.
+// There is no limit if maxCursorBufferedRows is 0.
+func (c *Conn) bufferStreamingCursor(data []byte) {
+	cur := c.streamingCursor
+	if cur == nil {
+		return
+	}
+	c.streamingCursor = nil
+
+	// Executing the same statement again closes its cursor,
+	// so there's no need to keep its rows.
+	if data[0] == ComStmtExecute {
+		if stmtID, _, ok := readUint32(data, 1); ok && stmtID == cur.stmtID {
+			c.closeCursor(c.PrepareData[stmtID])
+			return
+		}
+	}
+	limit := c.maxCursorBufferedRows
+	if limit <= 0 {
+		cur.fill(math.MaxInt)
+		return
+	}
+	if cur.fill(limit + 1) {
+		cur.abort(vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED,
+			"cursor of statement %d aborted: more than %d rows were not fetched before running another command, which exceeds the max cursor buffered rows limit of the server", cur.stmtID, limit))
+	}
+}
+
+// handleComStmtExecuteWithCursor executes a statement for which the client
+// asked for a read-only cursor. Only the fields are sent back, and the rows
+// are sent on COM_STMT_FETCH.
+func (c *Conn) handleComStmtExecuteWithCursor(handler Handler, prepare *PrepareData) bool {
+	c.closeCursor(prepare)
+
+	// The handler keeps running after we return, give it its own
+	// copy as the bind variables of the statement are reset.
+	p := *prepare
+	p.BindVars = maps.Clone(prepare.BindVars)
+	cur := c.openCursor(handler, &p)
+
+	qr := cur.next()
+	if qr == nil {
+		err := cur.err
+		if err == nil {
+			// This is just a failsafe. Should never happen.
+			err = sqlerror.NewSQLErrorFromError(errors.New("unexpected: query ended without no results and no error"))
+		}
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
+
+	if len(qr.Fields) == 0 {
+		// There are no rows to fetch, so there's no need for a cursor.
+		for cur.next() != nil {
+		}
+		if cur.err != nil {
+			return c.writeErrorPacketFromErrorAndLog(cur.err)
+		}
+		ok := PacketOK{
+			affectedRows:     qr.RowsAffected,
+			lastInsertID:     qr.InsertID,
+			statusFlags:      c.StatusFlags,
+			sessionStateData: qr.SessionStateChanges,
+		}
+		if err := c.writeOKPacket(&ok); err != nil {
+			log.Errorf("Error writing result to %s: %v", c, err)
+			return false
+		}
+		return true
+	}
+
+	cur.fields = qr.Fields
+	cur.rows = qr.Rows
+	prepare.cursor = cur
+	c.streamingCursor = cur
+
+	if err := c.sendColumnCount(uint64(len(cur.fields))); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	for _, field := range cur.fields {
+		if err := c.writeColumnDefinition(field); err != nil {
+			log.Errorf("Error writing result to %s: %v", c, err)
+			return false
+		}
+	}
+	// The EOF packet is sent even with CapabilityClientDeprecateEOF,
+	// as this is how the client learns that the cursor exists.
+	if err := c.writeEOFPacket(c.StatusFlags|ServerStatusCursorExists, 0); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
+func (c *Conn) handleComStmtFetch(handler Handler, data []byte) (kontinue bool) {
+	c.startWriterBuffering()
+	defer func() {
+		if err := c.endWriterBuffering(); err != nil {
+			log.Errorf("conn %v: flush() failed: %v", c.ID(), err)
+			kontinue = false
+		}
+	}()
+
+	stmtID, numRows, ok := c.parseComStmtFetch(data)
+	c.recycleReadPacket()
+	if !ok {
+		return c.writeErrorAndLog(sqlerror.ERUnknownComError, sqlerror.SSNetError, "error handling packet: %v", data)
+	}
+
+	prepare, ok := c.PrepareData[stmtID]
+	if !ok {
+		return c.writeErrorAndLog(sqlerror.ERUnknownStmtHandler, sqlerror.SSUnknownSQLState, "Unknown prepared statement handler (%d) given to mysqld_stmt_fetch", stmtID)
+	}
+	cur := prepare.cursor
+	if cur == nil {
+		return c.writeErrorAndLog(sqlerror.ERStmtHasNoOpenCursor, sqlerror.SSUnknownSQLState, "The statement (%d) has no open cursor.", stmtID)
+	}
+
+	// Ask for one more row than needed, to know if these are the last ones.
+	n := int(numRows)
+	lastRows := !cur.fill(n + 1)
+	if lastRows && cur.err != nil && len(cur.rows) == 0 {
+		err := cur.err
+		c.closeCursor(prepare)
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
+
+	n = min(n, len(cur.rows))
+	for _, row := range cur.rows[:n] {
+		if err := c.writeBinaryRow(cur.fields, row); err != nil {
+			log.Errorf("Error writing result to %s: %v", c, err)
+			return false
+		}
+	}
+	cur.rows = cur.rows[n:]
+
+	flags := c.StatusFlags | ServerStatusCursorExists
+	if lastRows && len(cur.rows) == 0 {
+		if cur.err != nil {
+			// We can't send an error after the rows.
+			// All we can do is abort the send, which will cause a 2013.
+			log.Errorf("Error in the middle of a stream to %s: %v", c, cur.err)
+			return false
+		}
+		flags |= ServerStatusLastRowSent
+		c.closeCursor(prepare)
+	}
+
+	var err error
+	if c.Capabilities&CapabilityClientDeprecateEOF == 0 {
+		err = c.writeEOFPacket(flags, handler.WarningCount(c))
+	} else {
+		err = c.writeOKPacketWithEOFHeader(&PacketOK{
+			statusFlags: flags,
+			warnings:    handler.WarningCount(c),
+		})
+	}
+	if err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	return true
+}
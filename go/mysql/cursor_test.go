@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// cursorHandler streams 3 results of 2 rows each for any statement.
+type cursorHandler struct {
+	testRun
+	mu  sync.Mutex
+	err error
+}
+
+func (h *cursorHandler) ComStmtExecute(c *Conn, prepare *PrepareData, callback func(*sqltypes.Result) error) error {
+	fields := []*querypb.Field{{
+		Name:    "id",
+		Type:    querypb.Type_VARCHAR,
+		Charset: uint32(collations.CollationUtf8mb4ID),
+	}}
+	for i := 0; i < 3; i++ {
+		qr := &sqltypes.Result{}
+		if i == 0 {
+			qr.Fields = fields
+		}
+		for j := 1; j <= 2; j++ {
+			qr.Rows = append(qr.Rows, []sqltypes.Value{sqltypes.NewVarChar(fmt.Sprintf("%d", 2*i+j))})
+		}
+		if err := callback(qr); err != nil {
+			h.mu.Lock()
+			h.err = err
+			h.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *cursorHandler) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func TestCursor(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	handler := &cursorHandler{testRun: testRun{t: t}}
+	sConn.PrepareData[1] = &PrepareData{StatementID: 1}
+	defer sConn.closeCursors()
+
+	execute := []byte{0, 0, 0, 0, ComStmtExecute, 1, 0, 0, 0, CursorTypeReadOnly, 1, 0, 0, 0}
+	fetch := []byte{0, 0, 0, 0, ComStmtFetch, 1, 0, 0, 0, 4, 0, 0, 0}
+
+	send := func(packet []byte) {
+		cConn.sequence = 0
+		require.NoError(t, cConn.writePacket(packet))
+		require.True(t, sConn.handleNextCommand(handler))
+	}
+	// readRows reads binary rows until the EOF packet, and returns its status flags.
+	readRows := func() ([]string, uint16) {
+		var rows []string
+		for {
+			data, err := cConn.ReadPacket()
+			require.NoError(t, err)
+			if cConn.isEOFPacket(data) {
+				_, flags, err := parseEOFPacket(data)
+				require.NoError(t, err)
+				return rows, flags
+			}
+			// Binary row: header, NULL bitmap, then the value.
+			require.EqualValues(t, 0, data[0])
+			val, _, ok := readLenEncStringAsBytes(data, 2)
+			require.True(t, ok)
+			rows = append(rows, string(val))
+		}
+	}
+	readFields := func() {
+		data, err := cConn.ReadPacket()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1}, data)
+		_, err = cConn.ReadPacket()
+		require.NoError(t, err)
+		rows, flags := readRows()
+		assert.Empty(t, rows)
+		assert.NotZero(t, flags&ServerStatusCursorExists)
+	}
+
+	send(execute)
+	readFields()
+
+	send(fetch)
+	rows, flags := readRows()
+	assert.Equal(t, []string{"1", "2", "3", "4"}, rows)
+	assert.NotZero(t, flags&ServerStatusCursorExists)
+	assert.Zero(t, flags&ServerStatusLastRowSent)
+
+	// Running a query buffers the rest of the cursor's rows.
+	cConn.sequence = 0
+	require.NoError(t, cConn.WriteComQuery("select rows"))
+	require.True(t, sConn.handleNextCommand(handler))
+	qr, _, _, err := cConn.ReadQueryResult(10, true)
+	require.NoError(t, err)
+	assert.Equal(t, selectRowsResult.Rows, qr.Rows)
+
+	send(fetch)
+	rows, flags = readRows()
+	assert.Equal(t, []string{"5", "6"}, rows)
+	assert.NotZero(t, flags&ServerStatusLastRowSent)
+
+	// The cursor was closed after the last row.
+	send(fetch)
+	data, err := cConn.ReadPacket()
+	require.NoError(t, err)
+	sqlErr, ok := ParseErrorPacket(data).(*sqlerror.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, sqlerror.ERStmtHasNoOpenCursor, sqlErr.Number())
+
+	// Closing the statement stops the handler.
+	send(execute)
+	readFields()
+	cConn.sequence = 0
+	require.NoError(t, cConn.writePacket([]byte{0, 0, 0, 0, ComStmtClose, 1, 0, 0, 0}))
+	require.True(t, sConn.handleNextCommand(handler))
+	assert.Equal(t, errCursorClosed, handler.Err())
+	assert.NotContains(t, sConn.PrepareData, uint32(1))
+	assert.Nil(t, sConn.streamingCursor)
+}
+
+func TestCursorBufferLimit(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	sConn.maxCursorBufferedRows = 3
+
+	handler := &cursorHandler{testRun: testRun{t: t}}
+	sConn.PrepareData[1] = &PrepareData{StatementID: 1}
+	defer sConn.closeCursors()
+
+	send := func(packet []byte) {
+		cConn.sequence = 0
+		require.NoError(t, cConn.writePacket(packet))
+		require.True(t, sConn.handleNextCommand(handler))
+	}
+
+	send([]byte{0, 0, 0, 0, ComStmtExecute, 1, 0, 0, 0, CursorTypeReadOnly, 1, 0, 0, 0})
+	for {
+		data, err := cConn.ReadPacket()
+		require.NoError(t, err)
+		if cConn.isEOFPacket(data) {
+			break
+		}
+	}
+
+	// The cursor has more rows than can be buffered, so running a query aborts it.
+	cConn.sequence = 0
+	require.NoError(t, cConn.WriteComQuery("select rows"))
+	require.True(t, sConn.handleNextCommand(handler))
+	_, _, _, err := cConn.ReadQueryResult(10, true)
+	require.NoError(t, err)
+	assert.Equal(t, errCursorClosed, handler.Err())
+
+	send([]byte{0, 0, 0, 0, ComStmtFetch, 1, 0, 0, 0, 4, 0, 0, 0})
+	data, err := cConn.ReadPacket()
+	require.NoError(t, err)
+	sqlErr, ok := ParseErrorPacket(data).(*sqlerror.SQLError)
+	require.True(t, ok)
+	assert.Contains(t, sqlErr.Error(), "cursor of statement 1 aborted: more than 3 rows were not fetched before running another command")
+
+	// The cursor is closed after the error.
+	send([]byte{0, 0, 0, 0, ComStmtFetch, 1, 0, 0, 0, 4, 0, 0, 0})
+	data, err = cConn.ReadPacket()
+	require.NoError(t, err)
+	sqlErr, ok = ParseErrorPacket(data).(*sqlerror.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, sqlerror.ERStmtHasNoOpenCursor, sqlErr.Number())
+}
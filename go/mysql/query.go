@@ -55,7 +55,7 @@ const (
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) WriteComQuery(query string) error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(len(query) + 1)
 	data[pos] = ComQuery
@@ -574,8 +574,98 @@ func (c *Conn) readComQueryResponse(packetOk *PacketOK) (int, error) {
 // Server side methods.
 //
 
-func (c *Conn) parseComQuery(data []byte) string {
-	return string(data[1:])
+func (c *Conn) parseComQuery(data []byte) (string, error) {
+	payload := data[1:]
+	if c.Capabilities&CapabilityClientQueryAttributes == 0 {
+		return string(payload), nil
+	}
+
+	paramsCount, pos, ok := readLenEncInt(payload, 0)
+	if !ok {
+		return "", sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter count failed")
+	}
+	// parameter set count, always 1
+	_, pos, ok = readLenEncInt(payload, pos)
+	if !ok {
+		return "", sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter set count failed")
+	}
+	if paramsCount > 0 {
+		bitMap, npos, ok := readBytes(payload, pos, (int(paramsCount)+7)/8)
+		if !ok {
+			return "", sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading NULL-bitmap failed")
+		}
+		pos = npos
+		newParamsBoundFlag, npos, ok := readByte(payload, pos)
+		if !ok || newParamsBoundFlag != 0x01 {
+			return "", sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading query attribute types failed")
+		}
+		pos = npos
+
+		types := make([]querypb.Type, paramsCount)
+		names := make([]string, paramsCount)
+		for i := range types {
+			var err error
+			types[i], names[i], pos, err = c.parseParamType(payload, pos, true)
+			if err != nil {
+				return "", err
+			}
+		}
+		var err error
+		pos, err = c.parseQueryAttributes(payload, pos, bitMap, 0, types, names)
+		if err != nil {
+			return "", err
+		}
+	}
+	return string(payload[pos:]), nil
+}
+
+// parseParamType reads the type of a parameter of COM_QUERY or COM_STMT_EXECUTE,
+// followed by its name if withName is set.
+func (c *Conn) parseParamType(payload []byte, pos int, withName bool) (querypb.Type, string, int, error) {
+	mysqlType, pos, ok := readByte(payload, pos)
+	if !ok {
+		return 0, "", 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter type failed")
+	}
+
+	flags, pos, ok := readByte(payload, pos)
+	if !ok {
+		return 0, "", 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter flags failed")
+	}
+
+	// convert MySQL type to internal type.
+	valType, err := sqltypes.MySQLToType(mysqlType, int64(flags))
+	if err != nil {
+		return 0, "", 0, sqlerror.NewSQLErrorf(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "MySQLToType(%v,%v) failed: %v", mysqlType, flags, err)
+	}
+
+	var name string
+	if withName {
+		name, pos, ok = readLenEncString(payload, pos)
+		if !ok {
+			return 0, "", 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter name failed")
+		}
+	}
+	return valType, name, pos, nil
+}
+
+// parseQueryAttributes reads the values of the query attributes into QueryAttributes.
+// The query attributes come after the first offset parameters in the NULL-bitmap.
+func (c *Conn) parseQueryAttributes(payload []byte, pos int, bitMap []byte, offset int, types []querypb.Type, names []string) (int, error) {
+	attributes := make(map[string]string, len(types))
+	for i, typ := range types {
+		bit := offset + i
+		if (bitMap[bit/8] & (1 << uint(bit%8))) > 0 {
+			continue
+		}
+		val, npos, ok := c.parseStmtArgs(payload, typ, pos)
+		if !ok {
+			return 0, sqlerror.NewSQLErrorf(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "decoding query attribute value failed: %v", typ)
+		}
+		pos = npos
+		attributes[names[i]] = val.ToString()
+	}
+	c.QueryAttributes = attributes
+	return pos, nil
 }
 
 func (c *Conn) parseComSetOption(data []byte) (uint16, bool) {
@@ -617,36 +707,50 @@ func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []b
 		return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "iteration count is not equal to 1")
 	}
 
-	if prepare.ParamsCount > 0 {
-		bitMap, pos, ok = readBytes(payload, pos, (int(prepare.ParamsCount)+7)/8)
+	// With query attributes, the parameter count is always sent when the
+	// statement has parameters, and otherwise only when the flag says so.
+	// The attributes come after the parameters.
+	withAttributes := c.Capabilities&CapabilityClientQueryAttributes != 0
+	paramsCount := int(prepare.ParamsCount)
+	if withAttributes && (prepare.ParamsCount > 0 || cursorType&parameterCountAvailable != 0) {
+		var count uint64
+		count, pos, ok = readLenEncInt(payload, pos)
+		if !ok || count < uint64(prepare.ParamsCount) {
+			return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter count failed")
+		}
+		paramsCount = int(count)
+	}
+	cursorType &^= parameterCountAvailable
+
+	if paramsCount > 0 {
+		bitMap, pos, ok = readBytes(payload, pos, (paramsCount+7)/8)
 		if !ok {
 			return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading NULL-bitmap failed")
 		}
 	}
 
+	var attributeTypes []querypb.Type
+	var attributeNames []string
 	newParamsBoundFlag, pos, ok := readByte(payload, pos)
 	if ok && newParamsBoundFlag == 0x01 {
-		var mysqlType, flags byte
-		for i := range uint16(prepare.ParamsCount) {
-			mysqlType, pos, ok = readByte(payload, pos)
-			if !ok {
-				return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter type failed")
-			}
-
-			flags, pos, ok = readByte(payload, pos)
-			if !ok {
-				return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading parameter flags failed")
-			}
-
-			// convert MySQL type to internal type.
-			valType, err := sqltypes.MySQLToType(mysqlType, int64(flags))
+		for i := range paramsCount {
+			valType, name, npos, err := c.parseParamType(payload, pos, withAttributes)
 			if err != nil {
-				return stmtID, 0, sqlerror.NewSQLErrorf(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "MySQLToType(%v,%v) failed: %v", mysqlType, flags, err)
+				return stmtID, 0, err
 			}
+			pos = npos
 
-			prepare.ParamsType[i] = int32(valType)
+			if i < int(prepare.ParamsCount) {
+				prepare.ParamsType[i] = int32(valType)
+			} else {
+				attributeTypes = append(attributeTypes, valType)
+				attributeNames = append(attributeNames, name)
+			}
 		}
 	}
+	if paramsCount > int(prepare.ParamsCount) && len(attributeTypes) == 0 {
+		return stmtID, 0, sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "reading query attribute types failed")
+	}
 
 	for i := range len(prepare.ParamsType) {
 		var val sqltypes.Value
@@ -669,6 +773,12 @@ func (c *Conn) parseComStmtExecute(prepareData map[uint32]*PrepareData, data []b
 		prepare.BindVars[parameterID] = sqltypes.ValueBindVariable(val)
 	}
 
+	if len(attributeTypes) > 0 {
+		if _, err := c.parseQueryAttributes(payload, pos, bitMap, int(prepare.ParamsCount), attributeTypes, attributeNames); err != nil {
+			return stmtID, 0, err
+		}
+	}
+
 	return stmtID, cursorType, nil
 }
 
@@ -928,6 +1038,15 @@ func (c *Conn) parseComStmtReset(data []byte) (uint32, bool) {
 	return val, ok
 }
 
+func (c *Conn) parseComStmtFetch(data []byte) (uint32, uint32, bool) {
+	stmtID, pos, ok := readUint32(data, 1)
+	if !ok {
+		return 0, 0, false
+	}
+	numRows, _, ok := readUint32(data, pos)
+	return stmtID, numRows, ok
+}
+
 func (c *Conn) parseComInitDB(data []byte) string {
 	return string(data[1:])
 }
@@ -254,6 +254,107 @@ func TestComStmtExecute(t *testing.T) {
 
 }
 
+func TestComQueryWithAttributes(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	data := []byte{ComQuery,
+		// parameter count, parameter set count, NULL-bitmap, new params bound flag
+		3, 1, 0x04, 1,
+		// types and names
+		0xfd, 0, 8, 't', 'r', 'a', 'c', 'e', '_', 'i', 'd',
+		0x08, 0, 8, 'p', 'r', 'i', 'o', 'r', 'i', 't', 'y',
+		0x06, 0, 7, 'n', 'o', 't', 'h', 'i', 'n', 'g',
+		// values
+		3, 'a', 'b', 'c',
+		5, 0, 0, 0, 0, 0, 0, 0,
+	}
+	data = append(data, "select 1"...)
+
+	// Without the capability, everything is the query.
+	query, err := sConn.parseComQuery(data)
+	require.NoError(t, err)
+	assert.Equal(t, string(data[1:]), query)
+	assert.Nil(t, sConn.QueryAttributes)
+
+	sConn.Capabilities |= CapabilityClientQueryAttributes
+	query, err = sConn.parseComQuery(data)
+	require.NoError(t, err)
+	assert.Equal(t, "select 1", query)
+	assert.Equal(t, map[string]string{"trace_id": "abc", "priority": "5"}, sConn.QueryAttributes)
+
+	// No attributes.
+	sConn.QueryAttributes = nil
+	query, err = sConn.parseComQuery(append([]byte{ComQuery, 0, 1}, "select 2"...))
+	require.NoError(t, err)
+	assert.Equal(t, "select 2", query)
+	assert.Nil(t, sConn.QueryAttributes)
+
+	_, err = sConn.parseComQuery(data[:20])
+	require.ErrorContains(t, err, "reading parameter name failed")
+}
+
+func TestComStmtExecuteWithAttributes(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+	sConn.Capabilities |= CapabilityClientQueryAttributes
+
+	prepareDataMap := map[uint32]*PrepareData{
+		1: {
+			StatementID: 1,
+			ParamsCount: 1,
+			ParamsType:  make([]int32, 1),
+			BindVars:    map[string]*querypb.BindVariable{},
+		}}
+
+	data := []byte{ComStmtExecute, 1, 0, 0, 0,
+		// cursor type with parameter count available, iteration count
+		CursorTypeReadOnly | parameterCountAvailable, 1, 0, 0, 0,
+		// parameter count, NULL-bitmap, new params bound flag
+		2, 0, 1,
+		// types and names, the parameter of the statement has no name
+		0x08, 0, 0,
+		0xfd, 0, 8, 't', 'r', 'a', 'c', 'e', '_', 'i', 'd',
+		// values
+		42, 0, 0, 0, 0, 0, 0, 0,
+		3, 'a', 'b', 'c',
+	}
+
+	stmtID, cursorType, err := sConn.parseComStmtExecute(prepareDataMap, data)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stmtID)
+	assert.Equal(t, CursorTypeReadOnly, cursorType)
+	assert.Equal(t, sqltypes.Int64BindVariable(42), prepareDataMap[1].BindVars["v1"])
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, sConn.QueryAttributes)
+
+	// The parameter count is sent for a statement with parameters, even without the flag.
+	prepareDataMap[1].BindVars = map[string]*querypb.BindVariable{}
+	data = []byte{ComStmtExecute, 1, 0, 0, 0,
+		// cursor type, iteration count
+		CursorTypeNoCursor, 1, 0, 0, 0,
+		// parameter count, NULL-bitmap, new params bound flag
+		1, 0, 1,
+		// type and name
+		0x08, 0, 0,
+		// value
+		7, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	stmtID, cursorType, err = sConn.parseComStmtExecute(prepareDataMap, data)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stmtID)
+	assert.Equal(t, CursorTypeNoCursor, cursorType)
+	assert.Equal(t, sqltypes.Int64BindVariable(7), prepareDataMap[1].BindVars["v1"])
+}
+
 func TestComStmtExecuteUpdStmt(t *testing.T) {
 	listener, sConn, cConn := createSocketPair(t)
 	defer func() {
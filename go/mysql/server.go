@@ -133,6 +133,11 @@ type Handler interface {
 
 	ComResetConnection(c *Conn)
 
+	// ComChangeUser is called when a connection was authenticated as
+	// another user with COM_CHANGE_USER. The handler should drop the
+	// session state of the previous user.
+	ComChangeUser(c *Conn)
+
 	Env() *vtenv.Environment
 }
 
@@ -146,6 +151,7 @@ func (UnimplementedHandler) NewConnection(*Conn)      {}
 func (UnimplementedHandler) ConnectionReady(*Conn)    {}
 func (UnimplementedHandler) ConnectionClosed(*Conn)   {}
 func (UnimplementedHandler) ComResetConnection(*Conn) {}
+func (UnimplementedHandler) ComChangeUser(*Conn)      {}
 
 // Listener is the MySQL server protocol listener.
 type Listener struct {
@@ -207,6 +213,15 @@ type Listener struct {
 	// RequireSecureTransport configures the server to reject connections from insecure clients
 	RequireSecureTransport bool
 
+	// CompressionAlgorithms are the protocol compression algorithms that
+	// clients can ask for during the handshake. Compression is disabled if empty.
+	CompressionAlgorithms []CompressionAlgorithm
+
+	// MaxCursorBufferedRows is how many rows of a cursor are kept in memory when
+	// the client runs another command before it fetched them all. The cursor is
+	// aborted if it has more rows. There is no limit if it is 0.
+	MaxCursorBufferedRows int
+
 	// PreHandleFunc is called for each incoming connection, immediately after
 	// accepting a new connection. By default it's no-op. Useful for custom
 	// connection inspection or TLS termination. The returned connection is
@@ -316,6 +331,8 @@ func NewListenerWithConfig(cfg ListenerConfig) (*Listener, error) {
 		flushDelay:          cfg.FlushDelay,
 		truncateErrLen:      cfg.Handler.Env().TruncateErrLen(),
 		charset:             cfg.Handler.Env().CollationEnv().DefaultConnectionCharset(),
+
+		MaxCursorBufferedRows: DefaultMaxCursorBufferedRows,
 	}, nil
 }
 
@@ -391,7 +408,7 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 	defer connCount.Add(-1)
 
 	// First build and send the server handshake packet.
-	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, uint8(l.charset), l.TLSConfig.Load() != nil)
+	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, uint8(l.charset), l.TLSConfig.Load() != nil, compressionCapabilities(l.CompressionAlgorithms))
 	if err != nil {
 		if err != io.EOF {
 			log.Errorf("Cannot send HandshakeV10 packet to %s: %v", c, err)
@@ -450,6 +467,80 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		defer connCountByTLSVer.Add(versionNoTLS, -1)
 	}
 
+	userData, ok := l.authenticate(c, user, clientAuthMethod, serverAuthPluginData, clientAuthResponse)
+	if !ok {
+		return
+	}
+
+	c.User = user
+	c.UserData = userData
+
+	// The user can change with COM_CHANGE_USER.
+	defer func() {
+		if c.User != "" {
+			connCountPerUser.Add(c.User, -1)
+		}
+	}()
+	if c.User != "" {
+		connCountPerUser.Add(c.User, 1)
+	}
+
+	// Set initial db name.
+	if c.schemaName != "" {
+		err = l.handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
+			return nil
+		})
+		if err != nil {
+			c.writeErrorPacketFromError(err)
+			return
+		}
+	}
+
+	// Negotiation worked, send OK packet.
+	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
+		log.Errorf("Cannot write OK packet to %s: %v", c, err)
+		return
+	}
+
+	// Everything after the OK packet is compressed, if the client asked for it.
+	if algorithm := c.compressionAlgorithm(); algorithm != "" {
+		if err := c.enableCompression(algorithm, c.zstdLevel); err != nil {
+			log.Errorf("Cannot enable %s compression for %s: %v", algorithm, c, err)
+			return
+		}
+	}
+
+	// Record how long we took to establish the connection
+	timings.Record(connectTimingKey, acceptTime)
+
+	// Log a warning if it took too long to connect
+	connectTime := time.Since(acceptTime).Nanoseconds()
+	if threshold := l.SlowConnectWarnThreshold.Load(); threshold != 0 && connectTime > threshold {
+		connSlow.Add(1)
+		log.Warningf("Slow connection from %s: %v", c, connectTime)
+	}
+
+	// Tell our handler that we're finished handshake and are ready to
+	// process commands.
+	l.handler.ConnectionReady(c)
+
+	// Stop the handler go routines of open cursors when we're done.
+	defer c.closeCursors()
+
+	for {
+		kontinue := c.handleNextCommand(l.handler)
+		// before going for next command check if the connection should be closed or not.
+		if !kontinue || c.IsMarkedForClose() {
+			return
+		}
+	}
+}
+
+// authenticate checks the credentials the client sent for the user, and
+// asks it to switch to another auth method if needed. It is used by the
+// initial handshake and by COM_CHANGE_USER. On failure, the error has
+// been sent to the client, and the connection should be closed.
+func (l *Listener) authenticate(c *Conn, user string, clientAuthMethod AuthMethodDescription, serverAuthPluginData, clientAuthResponse []byte) (Getter, bool) {
 	// See what auth method the AuthServer wants to use for that user.
 	negotiatedAuthMethod, err := negotiateAuthMethod(c, l.authServer, user, clientAuthMethod)
 
@@ -475,86 +566,44 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 
 		if negotiatedAuthMethod == nil {
 			c.writeErrorPacket(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "No authentication methods available for authentication.")
-			return
+			return nil, false
 		}
 
 		if !l.AllowClearTextWithoutTLS.Load() && !c.TLSEnabled() && !negotiatedAuthMethod.AllowClearTextWithoutTLS() {
 			c.writeErrorPacket(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "Cannot use clear text authentication over non-SSL connections.")
-			return
+			return nil, false
 		}
 
 		serverAuthPluginData, err = negotiatedAuthMethod.AuthPluginData()
 		if err != nil {
 			log.Errorf("Error generating auth switch packet for %s: %v", c, err)
-			return
+			return nil, false
 		}
 
 		if err := c.writeAuthSwitchRequest(string(negotiatedAuthMethod.Name()), serverAuthPluginData); err != nil {
 			log.Errorf("Error writing auth switch packet for %s: %v", c, err)
-			return
+			return nil, false
 		}
 
 		clientAuthResponse, err = c.readEphemeralPacket()
 		if err != nil {
 			log.Errorf("Error reading auth switch response for %s: %v", c, err)
-			return
+			return nil, false
 		}
 		c.recycleReadPacket()
 	}
 
-	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, conn.RemoteAddr())
+	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, c.RemoteAddr())
 	if err != nil {
 		log.Warningf("Error authenticating user %s using: %s", user, negotiatedAuthMethod.Name())
 		c.writeErrorPacketFromError(err)
-		return
-	}
-
-	c.User = user
-	c.UserData = userData
-
-	if c.User != "" {
-		connCountPerUser.Add(c.User, 1)
-		defer connCountPerUser.Add(c.User, -1)
-	}
-
-	// Set initial db name.
-	if c.schemaName != "" {
-		err = l.handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
-			return nil
-		})
-		if err != nil {
-			c.writeErrorPacketFromError(err)
-			return
-		}
+		return nil, false
 	}
 
-	// Negotiation worked, send OK packet.
-	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
-		log.Errorf("Cannot write OK packet to %s: %v", c, err)
-		return
-	}
-
-	// Record how long we took to establish the connection
-	timings.Record(connectTimingKey, acceptTime)
-
-	// Log a warning if it took too long to connect
-	connectTime := time.Since(acceptTime).Nanoseconds()
-	if threshold := l.SlowConnectWarnThreshold.Load(); threshold != 0 && connectTime > threshold {
-		connSlow.Add(1)
-		log.Warningf("Slow connection from %s: %v", c, connectTime)
-	}
-
-	// Tell our handler that we're finished handshake and are ready to
-	// process commands.
-	l.handler.ConnectionReady(c)
-
-	for {
-		kontinue := c.handleNextCommand(l.handler)
-		// before going for next command check if the connection should be closed or not.
-		if !kontinue || c.IsMarkedForClose() {
-			return
-		}
-	}
+	// The client scrambles the password with the last data we sent
+	// when it changes user.
+	c.salt = serverAuthPluginData
+	return userData, true
 }
 
 // Close stops the listener, which prevents accept of any new connections. Existing connections won't be closed.
@@ -572,7 +621,7 @@ func (l *Listener) Shutdown() {
 
 // writeHandshakeV10 writes the Initial Handshake Packet, server side.
 // It returns the salt data.
-func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, charset uint8, enableTLS bool) ([]byte, error) {
+func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, charset uint8, enableTLS bool, compression uint32) ([]byte, error) {
 	capabilities := CapabilityClientLongPassword |
 		CapabilityClientFoundRows |
 		CapabilityClientLongFlag |
@@ -585,10 +634,12 @@ func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, ch
 		CapabilityClientPluginAuth |
 		CapabilityClientPluginAuthLenencClientData |
 		CapabilityClientDeprecateEOF |
-		CapabilityClientConnAttr
+		CapabilityClientConnAttr |
+		CapabilityClientQueryAttributes
 	if enableTLS {
 		capabilities |= CapabilityClientSSL
 	}
+	capabilities |= int(compression)
 
 	// Grab the default auth method. This can only be either
 	// mysql_native_password or caching_sha2_password. Both
@@ -705,8 +756,10 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 	// Remember a subset of the capabilities, so we can use them
 	// later in the protocol. If we re-received the handshake packet
 	// after SSL negotiation, do not overwrite capabilities.
+	// The auth related ones tell us how to parse COM_CHANGE_USER.
 	if firstTime {
-		c.Capabilities = clientFlags & (CapabilityClientDeprecateEOF | CapabilityClientFoundRows)
+		c.Capabilities = clientFlags & (CapabilityClientDeprecateEOF | CapabilityClientFoundRows | CapabilityClientQueryAttributes |
+			CapabilityClientSecureConnection | CapabilityClientPluginAuth | CapabilityClientConnAttr)
 	}
 
 	// set connection capability for executing multi statements
@@ -806,8 +859,24 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 
 	// Decode connection attributes send by the client
 	if clientFlags&CapabilityClientConnAttr != 0 {
-		if _, _, err := parseConnAttrs(data, pos); err != nil {
+		var err error
+		if _, pos, err = parseConnAttrs(data, pos); err != nil {
 			log.Warningf("Decode connection attributes send by the client: %v", err)
+			pos = len(data)
+		}
+	}
+
+	// Protocol compression, zlib takes precedence if the client asked for both.
+	compression := clientFlags & compressionCapabilities(l.CompressionAlgorithms)
+	if compression&CapabilityClientCompress != 0 {
+		compression = CapabilityClientCompress
+	}
+	c.Capabilities |= compression
+
+	// zstd compression level, only sent if the client asks for zstd.
+	if clientFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		if level, _, ok := readByte(data, pos); ok {
+			c.zstdLevel = int(level)
 		}
 	}
 
@@ -860,6 +929,76 @@ func parseConnAttrs(data []byte, pos int) (map[string]string, int, error) {
 
 }
 
+// parseComChangeUser parses a COM_CHANGE_USER packet, according to the
+// capabilities the client sent in its handshake.
+// Returns the username, auth method, auth data, db name, error.
+// The original data is not pointed at, and can be freed.
+func (c *Conn) parseComChangeUser(data []byte) (string, AuthMethodDescription, []byte, string, error) {
+	// Skip the command byte.
+	pos := 1
+
+	username, pos, ok := readNullString(data, pos)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read username")
+	}
+
+	var authResponse []byte
+	if c.Capabilities&CapabilityClientSecureConnection != 0 {
+		var l byte
+		l, pos, ok = readByte(data, pos)
+		if !ok {
+			return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read auth-response length")
+		}
+		authResponse, pos, ok = readBytesCopy(data, pos, int(l))
+		if !ok {
+			return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read auth-response")
+		}
+	} else {
+		a := ""
+		a, pos, ok = readNullString(data, pos)
+		if !ok {
+			return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read auth-response")
+		}
+		authResponse = []byte(a)
+	}
+
+	dbname, pos, ok := readNullString(data, pos)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read dbname")
+	}
+
+	// The rest is optional, old clients stop here.
+	authMethod := MysqlNativePassword
+	if pos == len(data) {
+		return username, authMethod, authResponse, dbname, nil
+	}
+
+	characterSet, pos, ok := readUint16(data, pos)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read characterSet")
+	}
+	c.CharacterSet = collations.ID(characterSet)
+
+	if c.Capabilities&CapabilityClientPluginAuth != 0 {
+		var authMethodStr string
+		authMethodStr, pos, ok = readNullString(data, pos)
+		if !ok {
+			return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read authMethod")
+		}
+		if authMethodStr != "" {
+			authMethod = AuthMethodDescription(authMethodStr)
+		}
+	}
+
+	if c.Capabilities&CapabilityClientConnAttr != 0 && pos < len(data) {
+		if _, _, err := parseConnAttrs(data, pos); err != nil {
+			log.Warningf("Decode connection attributes send by the client: %v", err)
+		}
+	}
+
+	return username, authMethod, authResponse, dbname, nil
+}
+
 // writeAuthSwitchRequest writes an auth switch request packet.
 func (c *Conn) writeAuthSwitchRequest(pluginName string, pluginData []byte) error {
 	length := 1 + // AuthSwitchRequestPacket
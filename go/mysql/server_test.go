@@ -528,6 +528,69 @@ func checkCountsForUser(t assert.TestingT, user string, expected int64) {
 	assert.Equal(t, expected, userCount)
 }
 
+func TestChangeUser(t *testing.T) {
+	th := &testHandler{}
+
+	authServer := NewAuthServerStatic("", "", 0)
+	authServer.entries["changeUser1"] = []*AuthServerStaticEntry{{
+		Password: "password1",
+		UserData: "userData1",
+	}}
+	authServer.entries["changeUser2"] = []*AuthServerStaticEntry{{
+		Password: "password2",
+		UserData: "userData2",
+	}}
+	defer authServer.close()
+	l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false, false, 0, 0)
+	require.NoError(t, err)
+	defer l.Close()
+	go l.Accept()
+
+	host, port := getHostPort(t, l.Addr())
+	params := &ConnParams{
+		Host:  host,
+		Port:  port,
+		Uname: "changeUser1",
+		Pass:  "password1",
+	}
+	c, err := Connect(context.Background(), params)
+	require.NoError(t, err)
+	defer c.Close()
+	checkCountsForUser(t, "changeUser1", 1)
+
+	// Switch to the other user, and change the database.
+	err = c.ChangeUser(&ConnParams{
+		Uname:  "changeUser2",
+		Pass:   "password2",
+		DbName: "db2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "changeUser2", c.User)
+	checkCountsForUser(t, "changeUser1", 0)
+	checkCountsForUser(t, "changeUser2", 1)
+
+	result, err := c.ExecuteFetch("userData echo", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "changeUser2", result.Rows[0][0].ToString())
+	assert.Equal(t, "userData2", result.Rows[0][1].ToString())
+
+	result, err = c.ExecuteFetch("schema echo", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "db2", result.Rows[0][0].ToString())
+
+	// A wrong password closes the connection.
+	err = c.ChangeUser(&ConnParams{
+		Uname: "changeUser1",
+		Pass:  "bad password",
+	})
+	require.EqualError(t, err, "Access denied for user 'changeUser1' (errno 1045) (sqlstate 28000)")
+	_, err = c.ExecuteFetch("select rows", 10, false)
+	require.Error(t, err)
+	assert.EventuallyWithT(t, func(t *assert.CollectT) {
+		checkCountsForUser(t, "changeUser2", 0)
+	}, 1*time.Second, 10*time.Millisecond)
+}
+
 func TestServer(t *testing.T) {
 	th := &testHandler{}
 
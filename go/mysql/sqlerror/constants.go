@@ -113,6 +113,7 @@ const (
 	EROperandColumns                = ErrorCode(1241)
 	ERSubqueryNo1Row                = ErrorCode(1242)
 	ERUnknownStmtHandler            = ErrorCode(1243)
+	ERCutValueGroupConcat           = ErrorCode(1260)
 	ERWarnDataOutOfRange            = ErrorCode(1264)
 	ERNonUpdateableTable            = ErrorCode(1288)
 	ERFeatureDisabled               = ErrorCode(1289)
@@ -121,6 +122,7 @@ const (
 	ERSPDoesNotExist                = ErrorCode(1305)
 	ERNoDefaultForField             = ErrorCode(1364)
 	ErSPNotVarArg                   = ErrorCode(1414)
+	ERStmtHasNoOpenCursor           = ErrorCode(1421)
 	ERRowIsReferenced2              = ErrorCode(1451)
 	ErNoReferencedRow2              = ErrorCode(1452)
 	ERInnodbIndexCorrupt            = ErrorCode(1817)
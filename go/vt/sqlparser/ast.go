@@ -3044,6 +3044,13 @@ type (
 		Arg Expr
 	}
 
+	// GroupingExpr represents a call to GROUPING(), which tells the super-aggregate rows
+	// produced by GROUP BY ... WITH ROLLUP apart from the regular rows
+	// see https://dev.mysql.com/doc/refman/8.0/en/miscellaneous-functions.html#function_grouping
+	GroupingExpr struct {
+		Exprs Exprs
+	}
+
 	// RegexpInstrExpr represents REGEXP_INSTR()
 	// For more information, see https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-instr
 	RegexpInstrExpr struct {
@@ -3287,6 +3294,7 @@ func (*CountStar) IsExpr()                          {}
 func (*Count) IsExpr()                              {}
 func (*GroupConcatExpr) IsExpr()                    {}
 func (*AnyValue) IsExpr()                           {}
+func (*GroupingExpr) IsExpr()                       {}
 func (*BitAnd) IsExpr()                             {}
 func (*BitOr) IsExpr()                              {}
 func (*BitXor) IsExpr()                             {}
@@ -3336,6 +3344,7 @@ func (*ConvertUsingExpr) iCallable()                   {}
 func (*MatchExpr) iCallable()                          {}
 func (*GroupConcatExpr) iCallable()                    {}
 func (*AnyValue) iCallable()                           {}
+func (*GroupingExpr) iCallable()                       {}
 func (*JSONSchemaValidFuncExpr) iCallable()            {}
 func (*JSONSchemaValidationReportFuncExpr) iCallable() {}
 func (*JSONPrettyExpr) iCallable()                     {}
@@ -3417,6 +3426,7 @@ func (varP *VarPop) GetArg() Expr               { return varP.Arg }
 func (varS *VarSamp) GetArg() Expr              { return varS.Arg }
 func (variance *Variance) GetArg() Expr         { return variance.Arg }
 func (av *AnyValue) GetArg() Expr               { return av.Arg }
+func (g *GroupingExpr) GetArg() Expr            { return g.Exprs[0] }
 func (jaa *JSONArrayAgg) GetArg() Expr          { return jaa.Expr }
 func (joa *JSONObjectAgg) GetArg() Expr         { return joa.Key }
 
@@ -3438,6 +3448,7 @@ func (varP *VarPop) GetArgs() Exprs               { return Exprs{varP.Arg} }
 func (varS *VarSamp) GetArgs() Exprs              { return Exprs{varS.Arg} }
 func (variance *Variance) GetArgs() Exprs         { return Exprs{variance.Arg} }
 func (av *AnyValue) GetArgs() Exprs               { return Exprs{av.Arg} }
+func (g *GroupingExpr) GetArgs() Exprs            { return g.Exprs }
 func (jaa *JSONArrayAgg) GetArgs() Exprs          { return Exprs{jaa.Expr} }
 func (joa *JSONObjectAgg) GetArgs() Exprs         { return Exprs{joa.Key, joa.Value} }
 
@@ -3459,6 +3470,7 @@ func (varP *VarPop) SetArg(expr Expr)               { varP.Arg = expr }
 func (varS *VarSamp) SetArg(expr Expr)              { varS.Arg = expr }
 func (variance *Variance) SetArg(expr Expr)         { variance.Arg = expr }
 func (av *AnyValue) SetArg(expr Expr)               { av.Arg = expr }
+func (g *GroupingExpr) SetArg(expr Expr)            { g.Exprs = Exprs{expr} }
 func (jaa *JSONArrayAgg) SetArg(expr Expr)          { jaa.Expr = expr }
 func (joa *JSONObjectAgg) SetArg(expr Expr)         { joa.Key = expr }
 
@@ -3496,6 +3508,10 @@ func (grpConcat *GroupConcatExpr) SetArgs(exprs Exprs) error {
 	grpConcat.Exprs = exprs
 	return nil
 }
+func (g *GroupingExpr) SetArgs(exprs Exprs) error {
+	g.Exprs = exprs
+	return nil
+}
 
 func (sum *Sum) IsDistinct() bool                   { return sum.Distinct }
 func (min *Min) IsDistinct() bool                   { return min.Distinct }
@@ -3529,6 +3545,7 @@ func (*VarPop) AggrName() string          { return "var_pop" }
 func (*VarSamp) AggrName() string         { return "var_samp" }
 func (*Variance) AggrName() string        { return "variance" }
 func (*AnyValue) AggrName() string        { return "any_value" }
+func (*GroupingExpr) AggrName() string    { return "grouping" }
 func (*JSONArrayAgg) AggrName() string    { return "json_arrayagg" }
 func (*JSONObjectAgg) AggrName() string   { return "json_objectagg" }
 
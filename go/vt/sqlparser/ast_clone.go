@@ -209,6 +209,8 @@ func CloneSQLNode(in SQLNode) SQLNode {
 		return CloneRefOfGroupBy(in)
 	case *GroupConcatExpr:
 		return CloneRefOfGroupConcatExpr(in)
+	case *GroupingExpr:
+		return CloneRefOfGroupingExpr(in)
 	case IdentifierCI:
 		return CloneIdentifierCI(in)
 	case IdentifierCS:
@@ -1573,6 +1575,16 @@ func CloneRefOfGroupConcatExpr(n *GroupConcatExpr) *GroupConcatExpr {
 	return &out
 }
 
+// CloneRefOfGroupingExpr creates a deep clone of the input.
+func CloneRefOfGroupingExpr(n *GroupingExpr) *GroupingExpr {
+	if n == nil {
+		return nil
+	}
+	out := *n
+	out.Exprs = CloneExprs(n.Exprs)
+	return &out
+}
+
 // CloneIdentifierCI creates a deep clone of the input.
 func CloneIdentifierCI(n IdentifierCI) IdentifierCI {
 	return *CloneRefOfIdentifierCI(&n)
@@ -3499,6 +3511,8 @@ func CloneAggrFunc(in AggrFunc) AggrFunc {
 		return CloneRefOfCountStar(in)
 	case *GroupConcatExpr:
 		return CloneRefOfGroupConcatExpr(in)
+	case *GroupingExpr:
+		return CloneRefOfGroupingExpr(in)
 	case *JSONArrayAgg:
 		return CloneRefOfJSONArrayAgg(in)
 	case *JSONObjectAgg:
@@ -3641,6 +3655,8 @@ func CloneCallable(in Callable) Callable {
 		return CloneRefOfGeomPropertyFuncExpr(in)
 	case *GroupConcatExpr:
 		return CloneRefOfGroupConcatExpr(in)
+	case *GroupingExpr:
+		return CloneRefOfGroupingExpr(in)
 	case *InsertExpr:
 		return CloneRefOfInsertExpr(in)
 	case *IntervalDateExpr:
@@ -3937,6 +3953,8 @@ func CloneExpr(in Expr) Expr {
 		return CloneRefOfGeomPropertyFuncExpr(in)
 	case *GroupConcatExpr:
 		return CloneRefOfGroupConcatExpr(in)
+	case *GroupingExpr:
+		return CloneRefOfGroupingExpr(in)
 	case *InsertExpr:
 		return CloneRefOfInsertExpr(in)
 	case *IntervalDateExpr:
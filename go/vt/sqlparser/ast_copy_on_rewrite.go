@@ -208,6 +208,8 @@ func (c *cow) copyOnRewriteSQLNode(n SQLNode, parent SQLNode) (out SQLNode, chan
 		return c.copyOnRewriteRefOfGroupBy(n, parent)
 	case *GroupConcatExpr:
 		return c.copyOnRewriteRefOfGroupConcatExpr(n, parent)
+	case *GroupingExpr:
+		return c.copyOnRewriteRefOfGroupingExpr(n, parent)
 	case IdentifierCI:
 		return c.copyOnRewriteIdentifierCI(n, parent)
 	case IdentifierCS:
@@ -2703,6 +2705,28 @@ func (c *cow) copyOnRewriteRefOfGroupConcatExpr(n *GroupConcatExpr, parent SQLNo
 	}
 	return
 }
+func (c *cow) copyOnRewriteRefOfGroupingExpr(n *GroupingExpr, parent SQLNode) (out SQLNode, changed bool) {
+	if n == nil || c.cursor.stop {
+		return n, false
+	}
+	out = n
+	if c.pre == nil || c.pre(n, parent) {
+		_Exprs, changedExprs := c.copyOnRewriteExprs(n.Exprs, n)
+		if changedExprs {
+			res := *n
+			res.Exprs, _ = _Exprs.(Exprs)
+			out = &res
+			if c.cloned != nil {
+				c.cloned(n, out)
+			}
+			changed = true
+		}
+	}
+	if c.post != nil {
+		out, changed = c.postVisit(out, parent, changed)
+	}
+	return
+}
 func (c *cow) copyOnRewriteIdentifierCI(n IdentifierCI, parent SQLNode) (out SQLNode, changed bool) {
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
@@ -6776,6 +6800,8 @@ func (c *cow) copyOnRewriteAggrFunc(n AggrFunc, parent SQLNode) (out SQLNode, ch
 		return c.copyOnRewriteRefOfCountStar(n, parent)
 	case *GroupConcatExpr:
 		return c.copyOnRewriteRefOfGroupConcatExpr(n, parent)
+	case *GroupingExpr:
+		return c.copyOnRewriteRefOfGroupingExpr(n, parent)
 	case *JSONArrayAgg:
 		return c.copyOnRewriteRefOfJSONArrayAgg(n, parent)
 	case *JSONObjectAgg:
@@ -6914,6 +6940,8 @@ func (c *cow) copyOnRewriteCallable(n Callable, parent SQLNode) (out SQLNode, ch
 		return c.copyOnRewriteRefOfGeomPropertyFuncExpr(n, parent)
 	case *GroupConcatExpr:
 		return c.copyOnRewriteRefOfGroupConcatExpr(n, parent)
+	case *GroupingExpr:
+		return c.copyOnRewriteRefOfGroupingExpr(n, parent)
 	case *InsertExpr:
 		return c.copyOnRewriteRefOfInsertExpr(n, parent)
 	case *IntervalDateExpr:
@@ -7198,6 +7226,8 @@ func (c *cow) copyOnRewriteExpr(n Expr, parent SQLNode) (out SQLNode, changed bo
 		return c.copyOnRewriteRefOfGeomPropertyFuncExpr(n, parent)
 	case *GroupConcatExpr:
 		return c.copyOnRewriteRefOfGroupConcatExpr(n, parent)
+	case *GroupingExpr:
+		return c.copyOnRewriteRefOfGroupingExpr(n, parent)
 	case *InsertExpr:
 		return c.copyOnRewriteRefOfInsertExpr(n, parent)
 	case *IntervalDateExpr:
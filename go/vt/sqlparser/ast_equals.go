@@ -584,6 +584,12 @@ func (cmp *Comparator) SQLNode(inA, inB SQLNode) bool {
 			return false
 		}
 		return cmp.RefOfGroupConcatExpr(a, b)
+	case *GroupingExpr:
+		b, ok := inB.(*GroupingExpr)
+		if !ok {
+			return false
+		}
+		return cmp.RefOfGroupingExpr(a, b)
 	case IdentifierCI:
 		b, ok := inB.(IdentifierCI)
 		if !ok {
@@ -2839,6 +2845,17 @@ func (cmp *Comparator) RefOfGroupConcatExpr(a, b *GroupConcatExpr) bool {
 		cmp.RefOfLimit(a.Limit, b.Limit)
 }
 
+// RefOfGroupingExpr does deep equals between the two objects.
+func (cmp *Comparator) RefOfGroupingExpr(a, b *GroupingExpr) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	return cmp.Exprs(a.Exprs, b.Exprs)
+}
+
 // IdentifierCI does deep equals between the two objects.
 func (cmp *Comparator) IdentifierCI(a, b IdentifierCI) bool {
 	return a.val == b.val &&
@@ -5069,6 +5086,12 @@ func (cmp *Comparator) AggrFunc(inA, inB AggrFunc) bool {
 			return false
 		}
 		return cmp.RefOfGroupConcatExpr(a, b)
+	case *GroupingExpr:
+		b, ok := inB.(*GroupingExpr)
+		if !ok {
+			return false
+		}
+		return cmp.RefOfGroupingExpr(a, b)
 	case *JSONArrayAgg:
 		b, ok := inB.(*JSONArrayAgg)
 		if !ok {
@@ -5453,6 +5476,12 @@ func (cmp *Comparator) Callable(inA, inB Callable) bool {
 			return false
 		}
 		return cmp.RefOfGroupConcatExpr(a, b)
+	case *GroupingExpr:
+		b, ok := inB.(*GroupingExpr)
+		if !ok {
+			return false
+		}
+		return cmp.RefOfGroupingExpr(a, b)
 	case *InsertExpr:
 		b, ok := inB.(*InsertExpr)
 		if !ok {
@@ -6215,6 +6244,12 @@ func (cmp *Comparator) Expr(inA, inB Expr) bool {
 			return false
 		}
 		return cmp.RefOfGroupConcatExpr(a, b)
+	case *GroupingExpr:
+		b, ok := inB.(*GroupingExpr)
+		if !ok {
+			return false
+		}
+		return cmp.RefOfGroupingExpr(a, b)
 	case *InsertExpr:
 		b, ok := inB.(*InsertExpr)
 		if !ok {
@@ -2853,6 +2853,10 @@ func (node *AnyValue) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "any_value(%v)", node.Arg)
 }
 
+func (node *GroupingExpr) Format(buf *TrackedBuffer) {
+	buf.astPrintf(node, "grouping(%v)", node.Exprs)
+}
+
 func (node *Avg) Format(buf *TrackedBuffer) {
 	buf.WriteString("avg(")
 	if node.Distinct {
@@ -3751,6 +3751,12 @@ func (node *AnyValue) FormatFast(buf *TrackedBuffer) {
 	buf.WriteByte(')')
 }
 
+func (node *GroupingExpr) FormatFast(buf *TrackedBuffer) {
+	buf.WriteString("grouping(")
+	node.Exprs.FormatFast(buf)
+	buf.WriteByte(')')
+}
+
 func (node *Avg) FormatFast(buf *TrackedBuffer) {
 	buf.WriteString("avg(")
 	if node.Distinct {
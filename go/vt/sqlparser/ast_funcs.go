@@ -549,6 +549,18 @@ func (op ComparisonExprOperator) Inverse() ComparisonExprOperator {
 	panic("unreachable")
 }
 
+// Inverse returns the modifier to use together with the inverse of the comparison operator,
+// since NOT (x > ALL (subquery)) is the same as x <= ANY (subquery)
+func (m ComparisonModifier) Inverse() ComparisonModifier {
+	switch m {
+	case Any:
+		return All
+	case All:
+		return Any
+	}
+	return m
+}
+
 // SwitchSides returns the reversed comparison operator if applicable, along with a boolean indicating success.
 // For symmetric operators like '=', '!=', and '<=>', it returns the same operator and true.
 // For directional comparison operators ('<', '>', '<=', '>='), it returns the opposite operator and true.
@@ -2182,8 +2194,12 @@ func (ty ShowCommandType) ToString() string {
 		return VGtidExecGlobalStr
 	case VitessMigrations:
 		return VitessMigrationsStr
+	case VitessQueries:
+		return VitessQueriesStr
 	case VitessReplicationStatus:
 		return VitessReplicationStatusStr
+	case VitessSequences:
+		return VitessSequencesStr
 	case VitessShards:
 		return VitessShardsStr
 	case VitessTablets:
@@ -2324,12 +2340,16 @@ func formatAddress(address string) string {
 func ContainsAggregation(e SQLNode) bool {
 	hasAggregates := false
 	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
-		switch node.(type) {
+		switch node := node.(type) {
 		case *Offset:
 			// offsets here indicate that a possible aggregation has already been handled by an input
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case AggrFunc:
+			if GetOverClause(node) != nil {
+				// window functions do not group rows, so they are not aggregations
+				return true, nil
+			}
 			hasAggregates = true
 			return false, io.EOF
 		}
@@ -2339,6 +2359,77 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// GetOverClause returns the OVER clause of a window function, or nil if the expression is not used as a window function
+func GetOverClause(e Expr) *OverClause {
+	switch node := e.(type) {
+	case *ArgumentLessWindowExpr:
+		return node.OverClause
+	case *FirstOrLastValueExpr:
+		return node.OverClause
+	case *NtileExpr:
+		return node.OverClause
+	case *NTHValueExpr:
+		return node.OverClause
+	case *LagLeadExpr:
+		return node.OverClause
+	case *Count:
+		return node.OverClause
+	case *CountStar:
+		return node.OverClause
+	case *Avg:
+		return node.OverClause
+	case *Max:
+		return node.OverClause
+	case *Min:
+		return node.OverClause
+	case *Sum:
+		return node.OverClause
+	case *BitAnd:
+		return node.OverClause
+	case *BitOr:
+		return node.OverClause
+	case *BitXor:
+		return node.OverClause
+	case *Std:
+		return node.OverClause
+	case *StdDev:
+		return node.OverClause
+	case *StdPop:
+		return node.OverClause
+	case *StdSamp:
+		return node.OverClause
+	case *VarPop:
+		return node.OverClause
+	case *VarSamp:
+		return node.OverClause
+	case *Variance:
+		return node.OverClause
+	case *JSONArrayAgg:
+		return node.OverClause
+	case *JSONObjectAgg:
+		return node.OverClause
+	}
+	return nil
+}
+
+// ContainsWindowFunction returns true if the expression contains a window function
+func ContainsWindowFunction(e SQLNode) bool {
+	found := false
+	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		switch node := node.(type) {
+		case *Subquery:
+			return false, nil
+		case Expr:
+			if GetOverClause(node) != nil {
+				found = true
+				return false, io.EOF
+			}
+		}
+		return true, nil
+	}, e)
+	return found
+}
+
 // setFuncArgs sets the arguments for the aggregation function, while checking that there is only one argument
 func setFuncArgs(aggr AggrFunc, exprs Exprs, name string) error {
 	if len(exprs) != 1 {
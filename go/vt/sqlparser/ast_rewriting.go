@@ -41,6 +41,13 @@ type VSchemaViews interface {
 	FindView(name TableName) SelectStatement
 }
 
+// VSchemaVindexExpressions is implemented by the vschemas that can tell the expressions
+// vindexes are declared over, like `json_unquote(doc->'$.tenant')`. The normalizer keeps
+// these expressions as they are, so that the planner can match them.
+type VSchemaVindexExpressions interface {
+	IsVindexExpression(expr Expr) bool
+}
+
 // PrepareAST will normalize the query
 func PrepareAST(
 	in Statement,
@@ -55,7 +62,8 @@ func PrepareAST(
 	views VSchemaViews,
 ) (*RewriteASTResult, error) {
 	if parameterize {
-		err := Normalize(in, reservedVars, bindVars)
+		vindexExprs, _ := views.(VSchemaVindexExpressions)
+		err := normalize(in, reservedVars, bindVars, vindexExprs)
 		if err != nil {
 			return nil, err
 		}
@@ -281,6 +289,7 @@ func (er *astRewriter) rewriteNotExpr(cursor *Cursor, node *NotExpr) {
 		canChange, inverse := inverseOp(inner.Operator)
 		if canChange {
 			inner.Operator = inverse
+			inner.Modifier = inner.Modifier.Inverse()
 			cursor.Replace(inner)
 		}
 	case *NotExpr:
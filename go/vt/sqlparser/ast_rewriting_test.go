@@ -268,6 +268,12 @@ func TestRewrites(in *testing.T) {
 	}, {
 		in:       "SELECT * FROM tbl WHERE not id not regexp '%foobar'",
 		expected: "select * from tbl where id regexp '%foobar'",
+	}, {
+		in:       "SELECT * FROM tbl WHERE not id > all (select col from other_table)",
+		expected: "select * from tbl where id <= any (select col from other_table)",
+	}, {
+		in:       "SELECT * FROM tbl WHERE not id <> any (select col from other_table)",
+		expected: "select * from tbl where id = all (select col from other_table)",
 	}, {
 		in:       "SELECT * FROM tbl WHERE exists(select col1, col2 from other_table where foo > bar)",
 		expected: "SELECT * FROM tbl WHERE exists(select 1 from other_table where foo > bar)",
@@ -208,6 +208,8 @@ func VisitSQLNode(in SQLNode, f Visit) error {
 		return VisitRefOfGroupBy(in, f)
 	case *GroupConcatExpr:
 		return VisitRefOfGroupConcatExpr(in, f)
+	case *GroupingExpr:
+		return VisitRefOfGroupingExpr(in, f)
 	case IdentifierCI:
 		return VisitIdentifierCI(in, f)
 	case IdentifierCS:
@@ -1923,6 +1925,18 @@ func VisitRefOfGroupConcatExpr(in *GroupConcatExpr, f Visit) error {
 	}
 	return nil
 }
+func VisitRefOfGroupingExpr(in *GroupingExpr, f Visit) error {
+	if in == nil {
+		return nil
+	}
+	if cont, err := f(in); err != nil || !cont {
+		return err
+	}
+	if err := VisitExprs(in.Exprs, f); err != nil {
+		return err
+	}
+	return nil
+}
 func VisitIdentifierCI(in IdentifierCI, f Visit) error {
 	if cont, err := f(in); err != nil || !cont {
 		return err
@@ -4508,6 +4522,8 @@ func VisitAggrFunc(in AggrFunc, f Visit) error {
 		return VisitRefOfCountStar(in, f)
 	case *GroupConcatExpr:
 		return VisitRefOfGroupConcatExpr(in, f)
+	case *GroupingExpr:
+		return VisitRefOfGroupingExpr(in, f)
 	case *JSONArrayAgg:
 		return VisitRefOfJSONArrayAgg(in, f)
 	case *JSONObjectAgg:
@@ -4646,6 +4662,8 @@ func VisitCallable(in Callable, f Visit) error {
 		return VisitRefOfGeomPropertyFuncExpr(in, f)
 	case *GroupConcatExpr:
 		return VisitRefOfGroupConcatExpr(in, f)
+	case *GroupingExpr:
+		return VisitRefOfGroupingExpr(in, f)
 	case *InsertExpr:
 		return VisitRefOfInsertExpr(in, f)
 	case *IntervalDateExpr:
@@ -4930,6 +4948,8 @@ func VisitExpr(in Expr, f Visit) error {
 		return VisitRefOfGeomPropertyFuncExpr(in, f)
 	case *GroupConcatExpr:
 		return VisitRefOfGroupConcatExpr(in, f)
+	case *GroupingExpr:
+		return VisitRefOfGroupingExpr(in, f)
 	case *InsertExpr:
 		return VisitRefOfInsertExpr(in, f)
 	case *IntervalDateExpr:
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -58,6 +59,11 @@ const (
 	// DirectivePriority specifies the priority of a workload. It should be an integer between 0 and MaxPriorityValue,
 	// where 0 is the highest priority, and MaxPriorityValue is the lowest one.
 	DirectivePriority = "PRIORITY"
+	// DirectiveResultCacheTTL caches the results of a select in vtgate for the given duration,
+	// either a number of seconds or a duration like 1m30s.
+	DirectiveResultCacheTTL = "RESULT_CACHE_TTL"
+	// DirectiveScatterConcurrency limits the number of shards vtgate sends the queries of a scatter to at once.
+	DirectiveScatterConcurrency = "SCATTER_CONCURRENCY"
 
 	// MaxPriorityValue specifies the maximum value allowed for the priority query directive. Valid priority values are
 	// between zero and MaxPriorityValue.
@@ -569,17 +575,31 @@ type QueryHints struct {
 	ForeignKeyChecks    *bool
 	Priority            string
 	Timeout             *int
+	ResultCacheTTL      time.Duration
+	ScatterConcurrency  int
 }
 
 func BuildQueryHints(stmt Statement) (qh QueryHints, err error) {
+	return BuildQueryHintsWithDefaults(stmt, nil)
+}
+
+// BuildQueryHintsWithDefaults builds the query hints of the statement, using the given
+// directives for the ones that are not set in its comments. The directive names are
+// matched case-insensitively, so that they can come from the query attributes sent
+// by MySQL clients.
+func BuildQueryHintsWithDefaults(stmt Statement, defaults map[string]string) (qh QueryHints, err error) {
 	qh = QueryHints{}
 
 	comment, ok := stmt.(Commented)
-	if !ok {
+	if !ok && len(defaults) == 0 {
 		return qh, nil
 	}
 
-	directives := comment.GetParsedComments().Directives()
+	var directives *CommentDirectives
+	if ok {
+		directives = comment.GetParsedComments().Directives()
+	}
+	directives = directives.withDefaults(defaults)
 
 	qh.Priority, err = getPriority(directives)
 	if err != nil {
@@ -588,12 +608,33 @@ func BuildQueryHints(stmt Statement) (qh QueryHints, err error) {
 	qh.IgnoreMaxMemoryRows = directives.IsSet(DirectiveIgnoreMaxMemoryRows)
 	qh.Consolidator = getConsolidator(stmt, directives)
 	qh.Workload = getWorkload(directives)
-	qh.ForeignKeyChecks = getForeignKeyChecksState(comment)
+	if ok {
+		qh.ForeignKeyChecks = getForeignKeyChecksState(comment)
+	}
 	qh.Timeout = getQueryTimeout(directives)
+	qh.ResultCacheTTL = getResultCacheTTL(stmt, directives)
+	qh.ScatterConcurrency = getScatterConcurrency(directives)
 
 	return qh, nil
 }
 
+// withDefaults returns the directives, with the given ones added when they're not set.
+func (d *CommentDirectives) withDefaults(defaults map[string]string) *CommentDirectives {
+	if len(defaults) == 0 {
+		return d
+	}
+	merged := &CommentDirectives{m: make(map[string]string, len(defaults))}
+	for key, val := range defaults {
+		merged.m[strings.ToLower(key)] = val
+	}
+	if d != nil {
+		for key, val := range d.m {
+			merged.m[key] = val
+		}
+	}
+	return merged
+}
+
 // getConsolidator returns the consolidator option.
 func getConsolidator(stmt Statement, directives *CommentDirectives) querypb.ExecuteOptions_Consolidator {
 	if _, isSelect := stmt.(SelectStatement); !isSelect {
@@ -662,3 +703,39 @@ func getQueryTimeout(directives *CommentDirectives) *int {
 	}
 	return &timeout
 }
+
+// getResultCacheTTL gets the result cache TTL of a select, using DirectiveResultCacheTTL.
+// Invalid values are ignored.
+func getResultCacheTTL(stmt Statement, directives *CommentDirectives) time.Duration {
+	if _, isSelect := stmt.(SelectStatement); !isSelect {
+		return 0
+	}
+	ttlString, ok := directives.GetString(DirectiveResultCacheTTL, "")
+	if !ok || ttlString == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(ttlString); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	ttl, err := time.ParseDuration(ttlString)
+	if err != nil {
+		return 0
+	}
+	return max(ttl, 0)
+}
+
+// getScatterConcurrency gets the scatter concurrency from the provided Statement, using DirectiveScatterConcurrency.
+// It returns zero if it is not set, or not a positive number.
+func getScatterConcurrency(directives *CommentDirectives) int {
+	concurrencyString, ok := directives.GetString(DirectiveScatterConcurrency, "")
+	if !ok || concurrencyString == "" {
+		return 0
+	}
+
+	concurrency, err := strconv.Atoi(concurrencyString)
+	if err != nil || concurrency < 0 {
+		return 0
+	}
+	return concurrency
+}
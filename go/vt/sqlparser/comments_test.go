@@ -19,6 +19,7 @@ package sqlparser
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -547,6 +548,83 @@ func TestGetPriorityFromStatement(t *testing.T) {
 	}
 }
 
+func TestResultCacheTTL(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected time.Duration
+	}{
+		{"select * from users", 0},
+		{"select /*vt+ RESULT_CACHE_TTL=30 */ * from users", 30 * time.Second},
+		{"select /*vt+ RESULT_CACHE_TTL=1m30s */ * from users", 90 * time.Second},
+		{"select /*vt+ RESULT_CACHE_TTL=-5 */ * from users", 0},
+		{"select /*vt+ RESULT_CACHE_TTL=soon */ * from users", 0},
+		{"update /*vt+ RESULT_CACHE_TTL=30 */ users set name=1", 0},
+	}
+
+	parser := NewTestParser()
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			stmt, err := parser.Parse(test.query)
+			require.NoError(t, err)
+			qh, err := BuildQueryHints(stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, qh.ResultCacheTTL)
+		})
+	}
+}
+
+func TestScatterConcurrency(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected int
+	}{
+		{"select * from users", 0},
+		{"select /*vt+ SCATTER_CONCURRENCY=16 */ * from users", 16},
+		{"update /*vt+ SCATTER_CONCURRENCY=4 */ users set name=1", 4},
+		{"select /*vt+ SCATTER_CONCURRENCY=-5 */ * from users", 0},
+		{"select /*vt+ SCATTER_CONCURRENCY=many */ * from users", 0},
+	}
+
+	parser := NewTestParser()
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			stmt, err := parser.Parse(test.query)
+			require.NoError(t, err)
+			qh, err := BuildQueryHints(stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, qh.ScatterConcurrency)
+		})
+	}
+}
+
+func TestBuildQueryHintsWithDefaults(t *testing.T) {
+	defaults := map[string]string{
+		"workload_name": "reports",
+		"PRIORITY":      "20",
+		"Consolidator":  "enabled",
+		"trace_id":      "abc",
+	}
+
+	parser := NewTestParser()
+	stmt, err := parser.Parse("select /*vt+ PRIORITY=33 */ * from a_table")
+	require.NoError(t, err)
+	qh, err := BuildQueryHintsWithDefaults(stmt, defaults)
+	require.NoError(t, err)
+	assert.Equal(t, "reports", qh.Workload)
+	assert.Equal(t, "33", qh.Priority, "the comments should take precedence")
+	assert.Equal(t, querypb.ExecuteOptions_CONSOLIDATOR_ENABLED, qh.Consolidator)
+
+	stmt, err = parser.Parse("begin")
+	require.NoError(t, err)
+	qh, err = BuildQueryHintsWithDefaults(stmt, defaults)
+	require.NoError(t, err)
+	assert.Equal(t, "reports", qh.Workload)
+	assert.Equal(t, "20", qh.Priority)
+
+	_, err = BuildQueryHintsWithDefaults(stmt, map[string]string{"priority": "high"})
+	assert.ErrorIs(t, err, ErrInvalidPriority)
+}
+
 // TestGetMySQLSetVarValue tests the functionality of GetMySQLSetVarValue
 func TestGetMySQLSetVarValue(t *testing.T) {
 	tests := []struct {
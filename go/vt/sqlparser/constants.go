@@ -317,7 +317,9 @@ const (
 	VGtidExecGlobalStr         = " global vgtid_executed"
 	KeyspaceStr                = " keyspaces"
 	VitessMigrationsStr        = " vitess_migrations"
+	VitessQueriesStr           = " vitess_queries"
 	VitessReplicationStatusStr = " vitess_replication_status"
+	VitessSequencesStr         = " vitess_sequences"
 	VitessShardsStr            = " vitess_shards"
 	VitessTabletsStr           = " vitess_tablets"
 	VitessTargetStr            = " vitess_target"
@@ -874,7 +876,9 @@ const (
 	VariableSession
 	VGtidExecGlobal
 	VitessMigrations
+	VitessQueries
 	VitessReplicationStatus
+	VitessSequences
 	VitessShards
 	VitessTablets
 	VitessTarget
@@ -309,7 +309,7 @@ var keywords = []keyword{
 	{"gtid_subtract", GTID_SUBTRACT},
 	{"grant", UNUSED},
 	{"group", GROUP},
-	{"grouping", UNUSED},
+	{"grouping", GROUPING},
 	{"groups", UNUSED},
 	{"group_concat", GROUP_CONCAT},
 	{"hash", HASH},
@@ -767,7 +767,9 @@ var keywords = []keyword{
 	{"vitess_metadata", VITESS_METADATA},
 	{"vitess_migration", VITESS_MIGRATION},
 	{"vitess_migrations", VITESS_MIGRATIONS},
+	{"vitess_queries", VITESS_QUERIES},
 	{"vitess_replication_status", VITESS_REPLICATION_STATUS},
+	{"vitess_sequences", VITESS_SEQUENCES},
 	{"vitess_shards", VITESS_SHARDS},
 	{"vitess_tablets", VITESS_TABLETS},
 	{"vitess_target", VITESS_TARGET},
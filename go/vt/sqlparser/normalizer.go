@@ -38,17 +38,24 @@ type BindVars map[string]struct{}
 // us to identify vindex equality. Otherwise, every value is
 // treated as distinct.
 func Normalize(stmt Statement, reserved *ReservedVars, bindVars map[string]*querypb.BindVariable) error {
+	return normalize(stmt, reserved, bindVars, nil)
+}
+
+// normalize is like Normalize, but keeps the expressions that vindexes are declared over as they are.
+func normalize(stmt Statement, reserved *ReservedVars, bindVars map[string]*querypb.BindVariable, vindexExprs VSchemaVindexExpressions) error {
 	nz := newNormalizer(reserved, bindVars)
+	nz.vindexExprs = vindexExprs
 	_ = SafeRewrite(stmt, nz.walkStatementDown, nz.walkStatementUp)
 	return nz.err
 }
 
 type normalizer struct {
-	bindVars  map[string]*querypb.BindVariable
-	reserved  *ReservedVars
-	vals      map[Literal]string
-	err       error
-	inDerived bool
+	bindVars    map[string]*querypb.BindVariable
+	reserved    *ReservedVars
+	vals        map[Literal]string
+	vindexExprs VSchemaVindexExpressions
+	err         error
+	inDerived   bool
 }
 
 func newNormalizer(reserved *ReservedVars, bindVars map[string]*querypb.BindVariable) *normalizer {
@@ -65,7 +72,7 @@ func (nz *normalizer) walkStatementUp(cursor *Cursor) bool {
 		return false
 	}
 	node, isLiteral := cursor.Node().(*Literal)
-	if !isLiteral {
+	if !isLiteral || isWindowFuncCount(node, cursor.Parent()) {
 		return true
 	}
 	nz.convertLiteral(node, cursor)
@@ -76,6 +83,9 @@ func (nz *normalizer) walkStatementUp(cursor *Cursor) bool {
 // If it encounters a Select, it switches to a mode
 // where variables are deduped.
 func (nz *normalizer) walkStatementDown(node, parent SQLNode) bool {
+	if nz.isVindexExpression(node) {
+		return false
+	}
 	switch node := node.(type) {
 	// no need to normalize the statement types
 	case *Set, *Show, *Begin, *Commit, *Rollback, *Savepoint, DDLStatement, *SRollback, *Release, *OtherAdmin, *Analyze:
@@ -104,6 +114,9 @@ func (nz *normalizer) walkStatementDown(node, parent SQLNode) bool {
 
 // walkDownSelect normalizes the AST in Select mode.
 func (nz *normalizer) walkDownSelect(node, parent SQLNode) bool {
+	if nz.isVindexExpression(node) {
+		return false
+	}
 	switch node := node.(type) {
 	case *Select:
 		_, isDerived := parent.(*DerivedTable)
@@ -147,6 +160,9 @@ func (nz *normalizer) walkUpSelect(cursor *Cursor) bool {
 		return true
 	}
 	parent := cursor.Parent()
+	if isWindowFuncCount(node, parent) {
+		return true
+	}
 	switch parent.(type) {
 	case *Order, *GroupBy:
 		return true
@@ -158,6 +174,32 @@ func (nz *normalizer) walkUpSelect(cursor *Cursor) bool {
 	return nz.err == nil // only continue if we haven't found any errors
 }
 
+// isVindexExpression returns true if the node is an expression that a vindex is declared over.
+// The literals of these expressions, like the path of `doc->'$.tenant'`, are kept so that the
+// planner can match the expression with the one of the vindex.
+func (nz *normalizer) isVindexExpression(node SQLNode) bool {
+	if nz.vindexExprs == nil {
+		return false
+	}
+	expr, ok := node.(Expr)
+	return ok && nz.vindexExprs.IsVindexExpression(expr)
+}
+
+// isWindowFuncCount returns true if the literal is the count of NTILE, LAG, LEAD or NTH_VALUE.
+// Like the offsets of a window frame, these are kept as literals so that vtgate can evaluate
+// the window function when it cannot be pushed down to a single shard.
+func isWindowFuncCount(node *Literal, parent SQLNode) bool {
+	switch parent := parent.(type) {
+	case *NtileExpr:
+		return parent.N == Expr(node)
+	case *LagLeadExpr:
+		return parent.N == Expr(node)
+	case *NTHValueExpr:
+		return parent.N == Expr(node)
+	}
+	return false
+}
+
 func validateLiteral(node *Literal) error {
 	switch node.Type {
 	case DateVal:
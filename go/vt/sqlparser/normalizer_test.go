@@ -457,6 +457,67 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+type fakeVindexExpressions []string
+
+func (fakeVindexExpressions) FindView(TableName) SelectStatement {
+	return nil
+}
+
+func (f fakeVindexExpressions) IsVindexExpression(expr Expr) bool {
+	for _, vindexExpr := range f {
+		if String(expr) == vindexExpr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNormalizeVindexExpressions(t *testing.T) {
+	vindexExprs := fakeVindexExpressions{"json_unquote(doc -> '$.tenant')"}
+	testcases := []struct {
+		in      string
+		outstmt string
+		outbv   map[string]*querypb.BindVariable
+	}{{
+		// the expressions of the vindexes are kept, but not the other json paths
+		in:      "select * from t where json_unquote(doc->'$.tenant') = 'acme' and doc->>'$.id' = 1",
+		outstmt: "select * from t where json_unquote(doc -> '$.tenant') = :bv1 /* VARCHAR */ and doc ->> :bv2 /* VARCHAR */ = :bv3 /* INT64 */",
+		outbv: map[string]*querypb.BindVariable{
+			"bv1": sqltypes.StringBindVariable("acme"),
+			"bv2": sqltypes.StringBindVariable("$.id"),
+			"bv3": sqltypes.Int64BindVariable(1),
+		},
+	}, {
+		in:      "update t set a = json_extract(doc, '$.a') where json_unquote(doc->'$.tenant') = 'acme'",
+		outstmt: "update t set a = json_extract(doc, :bv1 /* VARCHAR */) where json_unquote(doc -> '$.tenant') = :bv2 /* VARCHAR */",
+		outbv: map[string]*querypb.BindVariable{
+			"bv1": sqltypes.StringBindVariable("$.a"),
+			"bv2": sqltypes.StringBindVariable("acme"),
+		},
+	}, {
+		// a different json path is not the expression of the vindex
+		in:      "select * from t where json_unquote(doc->'$.other') = 'acme'",
+		outstmt: "select * from t where json_unquote(doc -> :bv1 /* VARCHAR */) = :bv2 /* VARCHAR */",
+		outbv: map[string]*querypb.BindVariable{
+			"bv1": sqltypes.StringBindVariable("$.other"),
+			"bv2": sqltypes.StringBindVariable("acme"),
+		},
+	}}
+	parser := NewTestParser()
+	for _, tc := range testcases {
+		t.Run(tc.in, func(t *testing.T) {
+			stmt, err := parser.Parse(tc.in)
+			require.NoError(t, err)
+			known := GetBindvars(stmt)
+			bv := make(map[string]*querypb.BindVariable)
+			result, err := PrepareAST(stmt, NewReservedVars("bv", known), bv, true, "ks", SQLSelectLimitUnset, "", nil, nil, vindexExprs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.outstmt, String(result.AST))
+			assert.Equal(t, tc.outbv, bv)
+		})
+	}
+}
+
 func TestNormalizeInvalidDates(t *testing.T) {
 	testcases := []struct {
 		in  string
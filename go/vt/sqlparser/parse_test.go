@@ -1154,6 +1154,9 @@ var (
 		input: "select /* order by asc */ 1 from t order by a asc",
 	}, {
 		input: "select a, b, c, count(*), sum(foo) from t group by a, b, c with rollup",
+	}, {
+		input:  "select a, b, GROUPING(a, b), count(*) from t group by a, b with rollup having grouping(b) = 1",
+		output: "select a, b, grouping(a, b), count(*) from t group by a, b with rollup having grouping(b) = 1",
 	}, {
 		input: "select /* order by desc */ 1 from t order by a desc",
 	}, {
@@ -2398,10 +2401,14 @@ var (
 		output: "show keyspaces like '%'",
 	}, {
 		input: "show vitess_metadata variables",
+	}, {
+		input: "show vitess_queries",
 	}, {
 		input: "show vitess_replication_status",
 	}, {
 		input: "show vitess_replication_status like '%'",
+	}, {
+		input: "show vitess_sequences",
 	}, {
 		input: "show vitess_shards",
 	}, {
@@ -359,7 +359,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 // SHOW tokens
 %token <str> CODE COLLATION COLUMNS DATABASES ENGINES EVENT EXTENDED FIELDS FULL FUNCTION GTID_EXECUTED
 %token <str> KEYSPACES OPEN PLUGINS PRIVILEGES PROCESSLIST SCHEMAS TABLES TRIGGERS USER
-%token <str> VGTID_EXECUTED VITESS_KEYSPACES VITESS_METADATA VITESS_MIGRATIONS VITESS_REPLICATION_STATUS VITESS_SHARDS VITESS_TABLETS VITESS_TARGET VSCHEMA VITESS_THROTTLED_APPS
+%token <str> VGTID_EXECUTED VITESS_KEYSPACES VITESS_METADATA VITESS_MIGRATIONS VITESS_QUERIES VITESS_REPLICATION_STATUS VITESS_SEQUENCES VITESS_SHARDS VITESS_TABLETS VITESS_TARGET VSCHEMA VITESS_THROTTLED_APPS
 
 // SET tokens
 %token <str> NAMES GLOBAL SESSION ISOLATION LEVEL READ WRITE ONLY REPEATABLE COMMITTED UNCOMMITTED SERIALIZABLE
@@ -4186,6 +4186,14 @@ show_statement:
   {
     $$ = &Show{&ShowBasic{Command: Warnings}}
   }
+| SHOW VITESS_QUERIES
+  {
+    $$ = &Show{&ShowBasic{Command: VitessQueries}}
+  }
+| SHOW VITESS_SEQUENCES
+  {
+    $$ = &Show{&ShowBasic{Command: VitessSequences}}
+  }
 | SHOW VITESS_SHARDS like_or_where_opt
   {
     $$ = &Show{&ShowBasic{Command: VitessShards, Filter: $3}}
@@ -6118,6 +6126,10 @@ UTC_DATE func_paren_opt
   {
     $$ = &AnyValue{Arg:$3}
   }
+| GROUPING openb expression_list closeb
+  {
+    $$ = &GroupingExpr{Exprs: $3}
+  }
 | TIMESTAMPADD openb timestampadd_interval ',' expression ',' expression closeb
   {
     $$ = &IntervalDateExpr{Syntax: IntervalDateExprTimestampadd, Date: $7, Interval: $5, Unit: $3}
@@ -8691,7 +8703,9 @@ non_reserved_keyword:
 | VITESS_METADATA
 | VITESS_MIGRATION
 | VITESS_MIGRATIONS
+| VITESS_QUERIES
 | VITESS_REPLICATION_STATUS
+| VITESS_SEQUENCES
 | VITESS_SHARDS
 | VITESS_TABLETS
 | VITESS_TARGET
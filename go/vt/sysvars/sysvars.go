@@ -184,6 +184,7 @@ var (
 		{Name: "transaction_write_set_extraction"},
 	}
 	UseReservedConn = []SystemVariable{
+		{Name: "cte_max_recursion_depth", SupportSetVar: true},
 		{Name: "default_week_format"},
 		{Name: "end_markers_in_json", IsBoolean: true, SupportSetVar: true},
 		{Name: "eq_range_index_dive_limit", SupportSetVar: true},
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topo
+
+import (
+	"context"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// This file provides the utility methods to lease blocks of values of the
+// sequences stored in the topology global cell.
+
+const sequencesPath = "sequences"
+
+func pathForSequence(keyspace, sequence string) string {
+	return path.Join(KeyspacesPath, keyspace, sequencesPath, sequence)
+}
+
+// LeaseSequenceBlock leases a block of count values of a sequence stored in
+// the global cell, and returns the first one. The file of the sequence holds
+// the next value to lease, as a decimal number. It is created with the start
+// value on the first lease.
+func (ts *Server) LeaseSequenceBlock(ctx context.Context, keyspace, sequence string, start, count int64) (int64, error) {
+	if count <= 0 {
+		return 0, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid number of values to lease from sequence %s.%s: %d", keyspace, sequence, count)
+	}
+	filePath := pathForSequence(keyspace, sequence)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		contents, version, err := ts.globalCell.Get(ctx, filePath)
+		if IsErrType(err, NoNode) {
+			if start > math.MaxInt64-count {
+				return 0, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "sequence %s.%s is exhausted", keyspace, sequence)
+			}
+			_, err = ts.globalCell.Create(ctx, filePath, []byte(strconv.FormatInt(start+count, 10)))
+			if IsErrType(err, NodeExists) {
+				// Another vtgate leased the first block, lease the next one.
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			return start, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		next, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+		if err != nil {
+			return 0, vterrors.Wrapf(err, "invalid next value of sequence %s.%s in %s", keyspace, sequence, filePath)
+		}
+		if next > math.MaxInt64-count {
+			return 0, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "sequence %s.%s is exhausted", keyspace, sequence)
+		}
+		_, err = ts.globalCell.Update(ctx, filePath, []byte(strconv.FormatInt(next+count, 10)), version)
+		if IsErrType(err, BadVersion) {
+			// Another vtgate leased a block in the meantime, try again.
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return next, nil
+	}
+}
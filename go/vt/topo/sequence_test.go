@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topo_test
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+)
+
+func TestLeaseSequenceBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+
+	// The first lease creates the sequence from its start value.
+	first, err := ts.LeaseSequenceBlock(ctx, "ks", "seq", 100, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 100, first)
+
+	// The start value is only used by the first lease.
+	first, err = ts.LeaseSequenceBlock(ctx, "ks", "seq", 1, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 110, first)
+
+	// The sequences of other keyspaces are distinct.
+	first, err = ts.LeaseSequenceBlock(ctx, "other", "seq", 1, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, first)
+
+	_, err = ts.LeaseSequenceBlock(ctx, "ks", "seq", 1, 0)
+	assert.ErrorContains(t, err, "invalid number of values to lease from sequence ks.seq: 0")
+	_, err = ts.LeaseSequenceBlock(ctx, "ks", "seq", 1, math.MaxInt64)
+	assert.ErrorContains(t, err, "sequence ks.seq is exhausted")
+}
+
+func TestLeaseSequenceBlockConcurrently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+
+	const leasers, leases, count = 10, 20, 3
+	var (
+		mu     sync.Mutex
+		firsts []int64
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < leasers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < leases; j++ {
+				first, err := ts.LeaseSequenceBlock(ctx, "ks", "seq", 1, count)
+				assert.NoError(t, err)
+				mu.Lock()
+				firsts = append(firsts, first)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every block was leased once, without gaps.
+	sort.Slice(firsts, func(i, j int) bool { return firsts[i] < firsts[j] })
+	require.Len(t, firsts, leasers*leases)
+	for i, first := range firsts {
+		assert.EqualValues(t, 1+i*count, first)
+	}
+}
@@ -596,6 +596,15 @@ func (client *gRPCVtctldClient) LookupVindexExternalize(ctx context.Context, in
 	return client.c.LookupVindexExternalize(ctx, in, opts...)
 }
 
+// LookupVindexVerify is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) LookupVindexVerify(ctx context.Context, in *vtctldatapb.LookupVindexVerifyRequest, opts ...grpc.CallOption) (*vtctldatapb.LookupVindexVerifyResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.LookupVindexVerify(ctx, in, opts...)
+}
+
 // MaterializeCreate is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) MaterializeCreate(ctx context.Context, in *vtctldatapb.MaterializeCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.MaterializeCreateResponse, error) {
 	if client.c == nil {
@@ -2935,6 +2935,22 @@ func (s *VtctldServer) LookupVindexExternalize(ctx context.Context, req *vtctlda
 	return resp, err
 }
 
+// LookupVindexVerify is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) LookupVindexVerify(ctx context.Context, req *vtctldatapb.LookupVindexVerifyRequest) (resp *vtctldatapb.LookupVindexVerifyResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.LookupVindexVerify")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("name", req.Name)
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("table_keyspace", req.TableKeyspace)
+	span.Annotate("repair", req.Repair)
+
+	resp, err = s.ws.LookupVindexVerify(ctx, req)
+	return resp, err
+}
+
 // MaterializeCreate is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) MaterializeCreate(ctx context.Context, req *vtctldatapb.MaterializeCreateRequest) (resp *vtctldatapb.MaterializeCreateResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.MaterializeCreate")
@@ -436,6 +436,11 @@ func (client *localVtctldClient) LookupVindexExternalize(ctx context.Context, in
 	return client.s.LookupVindexExternalize(ctx, in)
 }
 
+// LookupVindexVerify is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) LookupVindexVerify(ctx context.Context, in *vtctldatapb.LookupVindexVerifyRequest, opts ...grpc.CallOption) (*vtctldatapb.LookupVindexVerifyResponse, error) {
+	return client.s.LookupVindexVerify(ctx, in)
+}
+
 // MaterializeCreate is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) MaterializeCreate(ctx context.Context, in *vtctldatapb.MaterializeCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.MaterializeCreateResponse, error) {
 	return client.s.MaterializeCreate(ctx, in)
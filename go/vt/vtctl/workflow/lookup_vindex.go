@@ -0,0 +1,673 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtenv"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vdiff"
+	"vitess.io/vitess/go/vt/vttablet/tmclient"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	defaultLookupVindexVerifyBatchSize  = 1000
+	defaultLookupVindexVerifySampleRows = 10
+)
+
+// lookupVerifierHashedTo tells, for every type of lookup vindex that can be
+// verified, whether its lookup table stores the keyspace ids as the uint64
+// they are the hash of. The other types of lookup vindexes, like the ones
+// hashing the from values, can't be verified.
+var lookupVerifierHashedTo = map[string]bool{
+	"lookup":                   false,
+	"lookup_unique":            false,
+	"consistent_lookup":        false,
+	"consistent_lookup_unique": false,
+	"lookup_hash":              true,
+	"lookup_hash_unique":       true,
+}
+
+// lookupVerifier compares the lookup table of a lookup vindex with its owner
+// table, and repairs the lookup table when asked to.
+//
+// Both tables are read from the primary of every shard, a batch at a time,
+// sorted by the from columns, and compared by the lookup differ of VDiff.
+// The keyspace id of the rows of the owner table is computed by vtctld from
+// their primary vindex, which must therefore be functional. The rows with
+// a NULL from column are skipped on both sides.
+//
+// The to column of the lookup table of the lookup_hash vindexes holds the
+// uint64 the keyspace id is the hash of: it is hashed when the lookup table
+// is read, and unhashed back when the lookup table is repaired.
+//
+// The lookup table is repaired online: the missing rows are inserted, with
+// an insert ignore in case vtgate already inserted them, and the orphaned
+// rows are deleted unless the owner table has the row by then. The owner
+// table is checked with a locking read, which waits for the transactions
+// inserting into it: consistent lookup vindexes commit the row of the lookup
+// table before the one of the owner table, so an orphaned row may just be
+// the row of an owner that is being committed. As vtgate may also take over
+// the row for a new owner between the check and the delete, the owner table
+// is checked again after the delete, and the row is put back if it is owned.
+type lookupVerifier struct {
+	tmc tmclient.TabletManagerClient
+	env *vtenv.Environment
+	req *vtctldatapb.LookupVindexVerifyRequest
+
+	batchSize       int64
+	maxSampleRows   int64
+	ownerTable      string
+	ownerCols       []string // the columns of the owner table the vindex is on
+	ownerPKCols     []string
+	ownerVindex     vindexes.Vindex // the primary vindex of the owner table
+	ownerVindexCols []string
+	ownerShards     []*lookupVerifierShard
+
+	lookupTable      string
+	fromCols         []string
+	toCol            string
+	toHash           *vindexes.Hash  // set if the to column holds unhashed keyspace ids
+	lookupVindex     vindexes.Vindex // the primary vindex of the lookup table, if it is sharded
+	lookupVindexCols []int           // the offsets of the columns of lookupVindex in fromCols
+	lookupShards     []*lookupVerifierShard
+
+	// fields and collations describe the rows which are compared:
+	// the from columns followed by the keyspace id.
+	fields     []*querypb.Field
+	collations []collations.ID
+
+	resp *vtctldatapb.LookupVindexVerifyResponse
+}
+
+type lookupVerifierShard struct {
+	name     string
+	keyRange *topodatapb.KeyRange
+	primary  *topodatapb.Tablet
+}
+
+// newLookupVerifier reads the definition of the lookup vindex, of its owner
+// table and of its lookup table from the vschema and the topo.
+func (s *Server) newLookupVerifier(ctx context.Context, req *vtctldatapb.LookupVindexVerifyRequest) (*lookupVerifier, error) {
+	vschema, err := s.ts.GetVSchema(ctx, req.Keyspace)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "failed to get vschema for the %s keyspace", req.Keyspace)
+	}
+	vindex := vschema.Vindexes[req.Name]
+	if vindex == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s not found in the %s keyspace", req.Name, req.Keyspace)
+	}
+	if !strings.Contains(vindex.Type, "lookup") {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "vindex %s is not a lookup type", vindex.Type)
+	}
+	hashedTo, ok := lookupVerifierHashedTo[vindex.Type]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the lookup table of vindex %s of type %s can't be verified", req.Name, vindex.Type)
+	}
+	if vindex.Owner == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s has no owner table to verify its lookup table against", req.Name)
+	}
+	tableKeyspace, lookupTable, err := s.env.Parser().ParseTable(vindex.Params["table"])
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid vindex table name (%s) for vindex %s", vindex.Params["table"], req.Name)
+	}
+	if tableKeyspace == "" {
+		tableKeyspace = req.Keyspace
+	}
+	if req.TableKeyspace != "" && req.TableKeyspace != tableKeyspace {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the lookup table of vindex %s is in the %s keyspace, not in the %s keyspace", req.Name, tableKeyspace, req.TableKeyspace)
+	}
+
+	lv := &lookupVerifier{
+		tmc:           s.tmc,
+		env:           s.env,
+		req:           req,
+		batchSize:     req.BatchSize,
+		maxSampleRows: req.MaxReportSampleRows,
+		ownerTable:    vindex.Owner,
+		lookupTable:   lookupTable,
+		toCol:         vindex.Params["to"],
+		resp:          &vtctldatapb.LookupVindexVerifyResponse{},
+	}
+	if hashedTo {
+		lv.toHash = &vindexes.Hash{}
+	}
+	if lv.batchSize <= 0 {
+		lv.batchSize = defaultLookupVindexVerifyBatchSize
+	}
+	if lv.maxSampleRows <= 0 {
+		lv.maxSampleRows = defaultLookupVindexVerifySampleRows
+	}
+	for _, col := range strings.Split(vindex.Params["from"], ",") {
+		lv.fromCols = append(lv.fromCols, strings.TrimSpace(col))
+	}
+
+	ksSchema, err := vindexes.BuildKeyspaceSchema(vschema, req.Keyspace, s.env.Parser())
+	if err != nil {
+		return nil, err
+	}
+	if !ksSchema.Keyspace.Sharded {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the owner table %s of vindex %s is not in a sharded keyspace", lv.ownerTable, req.Name)
+	}
+	owner := ksSchema.Tables[lv.ownerTable]
+	if owner == nil || len(owner.ColumnVindexes) == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the owner table %s of vindex %s has no primary vindex in the %s keyspace", lv.ownerTable, req.Name, req.Keyspace)
+	}
+	for _, cv := range owner.ColumnVindexes {
+		if cv.Name != req.Name {
+			continue
+		}
+		for _, col := range cv.Columns {
+			lv.ownerCols = append(lv.ownerCols, col.String())
+		}
+	}
+	if len(lv.ownerCols) == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the owner table %s does not use vindex %s", lv.ownerTable, req.Name)
+	}
+	if len(lv.ownerCols) != len(lv.fromCols) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s has %d from columns but is on %d columns of table %s", req.Name, len(lv.fromCols), len(lv.ownerCols), lv.ownerTable)
+	}
+	primary := owner.ColumnVindexes[0]
+	if err := checkVerifiableVindex(primary, lv.ownerTable); err != nil {
+		return nil, err
+	}
+	lv.ownerVindex = primary.Vindex
+	for _, col := range primary.Columns {
+		lv.ownerVindexCols = append(lv.ownerVindexCols, col.String())
+	}
+
+	lookupKsSchema := ksSchema
+	if tableKeyspace != req.Keyspace {
+		lookupVSchema, err := s.ts.GetVSchema(ctx, tableKeyspace)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "failed to get vschema for the %s keyspace", tableKeyspace)
+		}
+		if lookupKsSchema, err = vindexes.BuildKeyspaceSchema(lookupVSchema, tableKeyspace, s.env.Parser()); err != nil {
+			return nil, err
+		}
+	}
+	if lookupKsSchema.Keyspace.Sharded {
+		table := lookupKsSchema.Tables[lv.lookupTable]
+		if table == nil || len(table.ColumnVindexes) == 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the lookup table %s has no primary vindex in the %s keyspace", lv.lookupTable, tableKeyspace)
+		}
+		cv := table.ColumnVindexes[0]
+		if err := checkVerifiableVindex(cv, lv.lookupTable); err != nil {
+			return nil, err
+		}
+		lv.lookupVindex = cv.Vindex
+		for _, col := range cv.Columns {
+			offset := -1
+			for i, from := range lv.fromCols {
+				if col.EqualString(from) {
+					offset = i
+					break
+				}
+			}
+			if offset < 0 {
+				return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the primary vindex %s of the lookup table %s is not on the from columns of vindex %s", cv.Name, lv.lookupTable, req.Name)
+			}
+			lv.lookupVindexCols = append(lv.lookupVindexCols, offset)
+		}
+	}
+
+	if lv.ownerShards, err = s.getLookupVerifierShards(ctx, req.Keyspace); err != nil {
+		return nil, err
+	}
+	if lv.lookupShards, err = s.getLookupVerifierShards(ctx, tableKeyspace); err != nil {
+		return nil, err
+	}
+
+	schema, err := s.tmc.GetSchema(ctx, lv.ownerShards[0].primary, &tabletmanagerdatapb.GetSchemaRequest{Tables: []string{lv.ownerTable}})
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.TableDefinitions) == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "table %s not found on tablet %s", lv.ownerTable, topoproto.TabletAliasString(lv.ownerShards[0].primary.Alias))
+	}
+	td := schema.TableDefinitions[0]
+	if len(td.PrimaryKeyColumns) == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the owner table %s has no primary key", lv.ownerTable)
+	}
+	lv.ownerPKCols = td.PrimaryKeyColumns
+	for i, col := range lv.ownerCols {
+		var field *querypb.Field
+		for _, f := range td.Fields {
+			if strings.EqualFold(f.Name, col) {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "column %s not found in table %s", col, lv.ownerTable)
+		}
+		lv.fields = append(lv.fields, &querypb.Field{Name: lv.fromCols[i], Type: field.Type, Charset: field.Charset})
+		lv.collations = append(lv.collations, collations.ID(field.Charset))
+	}
+	lv.fields = append(lv.fields, &querypb.Field{Name: lv.toCol, Type: sqltypes.VarBinary, Charset: collations.CollationBinaryID})
+	return lv, nil
+}
+
+// checkVerifiableVindex returns an error if vtctld can't compute the keyspace
+// ids of the rows of a table from its primary vindex.
+func checkVerifiableVindex(cv *vindexes.ColumnVindex, table string) error {
+	if cv.Expression != nil || cv.Vindex.NeedsVCursor() {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the keyspace ids of table %s can't be computed from its primary vindex %s", table, cv.Name)
+	}
+	return nil
+}
+
+func (s *Server) getLookupVerifierShards(ctx context.Context, keyspace string) ([]*lookupVerifierShard, error) {
+	shards, err := s.ts.GetServingShards(ctx, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	var out []*lookupVerifierShard
+	for _, si := range shards {
+		if si.PrimaryAlias == nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "shard %s/%s has no primary", keyspace, si.ShardName())
+		}
+		primary, err := s.ts.GetTablet(ctx, si.PrimaryAlias)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &lookupVerifierShard{
+			name:     si.ShardName(),
+			keyRange: si.KeyRange,
+			primary:  primary.Tablet,
+		})
+	}
+	return out, nil
+}
+
+// verify compares the tables and repairs the lookup table if requested.
+func (lv *lookupVerifier) verify(ctx context.Context) (*vtctldatapb.LookupVindexVerifyResponse, error) {
+	ld := &vdiff.LookupDiffer{
+		FromCollations: lv.collations,
+		CollationEnv:   lv.env.CollationEnv(),
+		Owner:          make(map[string]vdiff.LookupRowStreamer, len(lv.ownerShards)),
+		Lookup:         make(map[string]vdiff.LookupRowStreamer, len(lv.lookupShards)),
+		OnMissing: func(row []sqltypes.Value) error {
+			return lv.missing(ctx, row)
+		},
+		OnOrphaned: func(row []sqltypes.Value) error {
+			return lv.orphaned(ctx, row)
+		},
+	}
+	for _, shard := range lv.ownerShards {
+		ld.Owner[shard.name] = lv.streamOwnerShard(shard)
+	}
+	for _, shard := range lv.lookupShards {
+		ld.Lookup[shard.name] = lv.streamLookupShard(shard)
+	}
+	dr, err := ld.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lv.resp.OwnerRows = dr.OwnerRows
+	lv.resp.LookupRows = dr.LookupRows
+	lv.resp.MatchingRows = dr.MatchingRows
+	lv.resp.MissingRows = dr.MissingRows
+	lv.resp.OrphanedRows = dr.OrphanedRows
+	return lv.resp, nil
+}
+
+// streamOwnerShard pages through the owner table on a shard, sorted by the
+// columns of the vindex and then by the primary key, and maps every row to
+// its keyspace id.
+func (lv *lookupVerifier) streamOwnerShard(shard *lookupVerifierShard) vdiff.LookupRowStreamer {
+	return func(ctx context.Context, callback func(*sqltypes.Result) error) error {
+		nfrom, nvindex := len(lv.ownerCols), len(lv.ownerVindexCols)
+		selectCols := append(append(append([]string{}, lv.ownerCols...), lv.ownerVindexCols...), lv.ownerPKCols...)
+		orderCols := append(append([]string{}, lv.ownerCols...), lv.ownerPKCols...)
+		fields := lv.fields
+		var last []sqltypes.Value
+		for {
+			query := lv.buildPageQuery(lv.ownerTable, selectCols, orderCols, last)
+			qr, err := lv.execute(ctx, shard, query, uint64(lv.batchSize))
+			if err != nil {
+				return err
+			}
+			rowsColValues := make([][]sqltypes.Value, len(qr.Rows))
+			for i, row := range qr.Rows {
+				rowsColValues[i] = row[nfrom : nfrom+nvindex]
+			}
+			destinations, err := vindexes.Map(ctx, lv.ownerVindex, nil, rowsColValues)
+			if err != nil {
+				return err
+			}
+			result := &sqltypes.Result{Fields: fields}
+			for i, row := range qr.Rows {
+				ksid, ok := destinations[i].(key.DestinationKeyspaceID)
+				if !ok {
+					return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "could not map %v of table %s to a keyspace id", rowsColValues[i], lv.ownerTable)
+				}
+				out := append(append([]sqltypes.Value{}, row[:nfrom]...), sqltypes.MakeTrusted(sqltypes.VarBinary, ksid))
+				result.Rows = append(result.Rows, out)
+			}
+			fields = nil
+			if err := callback(result); err != nil {
+				return err
+			}
+			if int64(len(qr.Rows)) < lv.batchSize {
+				return nil
+			}
+			row := qr.Rows[len(qr.Rows)-1]
+			last = append(append([]sqltypes.Value{}, row[:nfrom]...), row[nfrom+nvindex:]...)
+		}
+	}
+}
+
+// streamLookupShard pages through the lookup table on a shard, sorted by
+// the from columns and then by the keyspace id.
+func (lv *lookupVerifier) streamLookupShard(shard *lookupVerifierShard) vdiff.LookupRowStreamer {
+	return func(ctx context.Context, callback func(*sqltypes.Result) error) error {
+		cols := append(append([]string{}, lv.fromCols...), lv.toCol)
+		fields := lv.fields
+		var last []sqltypes.Value
+		for {
+			query := lv.buildPageQuery(lv.lookupTable, cols, cols, last)
+			qr, err := lv.execute(ctx, shard, query, uint64(lv.batchSize))
+			if err != nil {
+				return err
+			}
+			rows := qr.Rows
+			if lv.toHash != nil {
+				rows = make([][]sqltypes.Value, len(qr.Rows))
+				for i, row := range qr.Rows {
+					ksid, err := lv.toHash.Hash(row[len(lv.fromCols)])
+					if err != nil {
+						return vterrors.Wrapf(err, "invalid %s in the lookup table %s", lv.toCol, lv.lookupTable)
+					}
+					rows[i] = append(append([]sqltypes.Value{}, row[:len(lv.fromCols)]...), sqltypes.MakeTrusted(sqltypes.VarBinary, ksid))
+				}
+			}
+			if err := callback(&sqltypes.Result{Fields: fields, Rows: rows}); err != nil {
+				return err
+			}
+			fields = nil
+			if int64(len(qr.Rows)) < lv.batchSize {
+				return nil
+			}
+			last = qr.Rows[len(qr.Rows)-1]
+		}
+	}
+}
+
+// buildPageQuery returns the query reading the next batch of rows of a table
+// after the last one read, if any. The first columns of the table are the
+// from columns, which must not be NULL.
+func (lv *lookupVerifier) buildPageQuery(table string, selectCols, orderCols []string, last []sqltypes.Value) string {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.WriteString("select ")
+	writeColumnList(buf, selectCols)
+	buf.Myprintf(" from %v where ", sqlparser.NewIdentifierCS(table))
+	for i, col := range selectCols[:len(lv.fromCols)] {
+		if i > 0 {
+			buf.WriteString(" and ")
+		}
+		buf.Myprintf("%v is not null", sqlparser.NewIdentifierCI(col))
+	}
+	if last != nil {
+		buf.WriteString(" and (")
+		writeColumnList(buf, orderCols)
+		buf.WriteString(") > (")
+		for i, value := range last {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			encodeLookupValue(buf.Builder, value)
+		}
+		buf.WriteString(")")
+	}
+	buf.WriteString(" order by ")
+	writeColumnList(buf, orderCols)
+	buf.Myprintf(" limit %d", lv.batchSize)
+	return buf.String()
+}
+
+// missing reports a row missing from the lookup table, and inserts it when repairing.
+func (lv *lookupVerifier) missing(ctx context.Context, row []sqltypes.Value) error {
+	if int64(len(lv.resp.MissingRowSamples)) < lv.maxSampleRows {
+		lv.resp.MissingRowSamples = append(lv.resp.MissingRowSamples, lv.formatRow(row))
+	}
+	if !lv.req.Repair {
+		return nil
+	}
+	shard, err := lv.lookupShard(ctx, row)
+	if err != nil {
+		return err
+	}
+	qr, err := lv.insert(ctx, shard, row)
+	if err != nil {
+		return err
+	}
+	lv.resp.InsertedRows += int64(qr.RowsAffected)
+	return nil
+}
+
+// insert inserts a row into the lookup table, unless it is already there.
+func (lv *lookupVerifier) insert(ctx context.Context, shard *lookupVerifierShard, row []sqltypes.Value) (*sqltypes.Result, error) {
+	lookupRow, err := lv.lookupTableRow(row)
+	if err != nil {
+		return nil, err
+	}
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("insert ignore into %v(", sqlparser.NewIdentifierCS(lv.lookupTable))
+	writeColumnList(buf, append(append([]string{}, lv.fromCols...), lv.toCol))
+	buf.WriteString(") values (")
+	for i, value := range lookupRow {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		encodeLookupValue(buf.Builder, value)
+	}
+	buf.WriteString(")")
+	return lv.execute(ctx, shard, buf.String(), 0)
+}
+
+// orphaned reports a row of the lookup table without owner, and deletes it when
+// repairing, unless the owner table has the row by now or once it is deleted.
+func (lv *lookupVerifier) orphaned(ctx context.Context, row []sqltypes.Value) error {
+	if int64(len(lv.resp.OrphanedRowSamples)) < lv.maxSampleRows {
+		lv.resp.OrphanedRowSamples = append(lv.resp.OrphanedRowSamples, lv.formatRow(row))
+	}
+	if !lv.req.Repair {
+		return nil
+	}
+	ksid := row[len(lv.fromCols)].Raw()
+	ownerShard := findLookupVerifierShard(lv.ownerShards, ksid)
+	if ownerShard != nil {
+		owned, err := lv.isOwned(ctx, ownerShard, row)
+		if err != nil || owned {
+			return err
+		}
+	}
+	shard, err := lv.lookupShard(ctx, row)
+	if err != nil {
+		return err
+	}
+	lookupRow, err := lv.lookupTableRow(row)
+	if err != nil {
+		return err
+	}
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("delete from %v where ", sqlparser.NewIdentifierCS(lv.lookupTable))
+	lv.writeRowCondition(buf, append(append([]string{}, lv.fromCols...), lv.toCol), lookupRow)
+	qr, err := lv.execute(ctx, shard, buf.String(), 0)
+	if err != nil || qr.RowsAffected == 0 {
+		return err
+	}
+	if ownerShard != nil {
+		owned, err := lv.isOwned(ctx, ownerShard, row)
+		if err != nil {
+			return err
+		}
+		if owned {
+			_, err := lv.insert(ctx, shard, row)
+			return err
+		}
+	}
+	lv.resp.DeletedRows += int64(qr.RowsAffected)
+	return nil
+}
+
+// isOwned returns true if the owner table has a row for the from values of the
+// row which maps to its keyspace id. If too many rows have the same from values
+// to tell, the row is considered owned. The read locks the rows, so that it sees
+// the rows of the transactions that are committing.
+func (lv *lookupVerifier) isOwned(ctx context.Context, shard *lookupVerifierShard, row []sqltypes.Value) (bool, error) {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.WriteString("select ")
+	writeColumnList(buf, lv.ownerVindexCols)
+	buf.Myprintf(" from %v where ", sqlparser.NewIdentifierCS(lv.ownerTable))
+	lv.writeRowCondition(buf, lv.ownerCols, row)
+	buf.Myprintf(" limit %d for update", lv.batchSize)
+	qr, err := lv.execute(ctx, shard, buf.String(), uint64(lv.batchSize))
+	if err != nil {
+		return false, err
+	}
+	if int64(len(qr.Rows)) >= lv.batchSize {
+		return true, nil
+	}
+	destinations, err := vindexes.Map(ctx, lv.ownerVindex, nil, qr.Rows)
+	if err != nil {
+		return false, err
+	}
+	ksid := row[len(lv.fromCols)].Raw()
+	for _, destination := range destinations {
+		if owner, ok := destination.(key.DestinationKeyspaceID); ok && string(owner) == string(ksid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lookupTableRow returns a compared row as it is stored in the lookup table.
+func (lv *lookupVerifier) lookupTableRow(row []sqltypes.Value) ([]sqltypes.Value, error) {
+	if lv.toHash == nil {
+		return row, nil
+	}
+	to, err := lv.toHash.ReverseMap(nil, [][]byte{row[len(lv.fromCols)].Raw()})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]sqltypes.Value{}, row[:len(lv.fromCols)]...), to[0]), nil
+}
+
+// lookupShard returns the shard of the lookup table which holds the row.
+func (lv *lookupVerifier) lookupShard(ctx context.Context, row []sqltypes.Value) (*lookupVerifierShard, error) {
+	if lv.lookupVindex == nil {
+		return lv.lookupShards[0], nil
+	}
+	values := make([]sqltypes.Value, len(lv.lookupVindexCols))
+	for i, offset := range lv.lookupVindexCols {
+		values[i] = row[offset]
+	}
+	destinations, err := vindexes.Map(ctx, lv.lookupVindex, nil, [][]sqltypes.Value{values})
+	if err != nil {
+		return nil, err
+	}
+	if ksid, ok := destinations[0].(key.DestinationKeyspaceID); ok {
+		if shard := findLookupVerifierShard(lv.lookupShards, ksid); shard != nil {
+			return shard, nil
+		}
+	}
+	return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no shard of the lookup table %s holds %v", lv.lookupTable, values)
+}
+
+func (lv *lookupVerifier) execute(ctx context.Context, shard *lookupVerifierShard, query string, maxRows uint64) (*sqltypes.Result, error) {
+	qr, err := lv.tmc.ExecuteFetchAsApp(ctx, shard.primary, true, &tabletmanagerdatapb.ExecuteFetchAsAppRequest{
+		Query:   []byte(query),
+		MaxRows: maxRows,
+	})
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "ExecuteFetchAsApp(%s, %s)", topoproto.TabletAliasString(shard.primary.Alias), query)
+	}
+	return sqltypes.Proto3ToResult(qr), nil
+}
+
+// writeRowCondition writes the condition matching the given columns of a table
+// to the values of a row.
+func (lv *lookupVerifier) writeRowCondition(buf *sqlparser.TrackedBuffer, cols []string, row []sqltypes.Value) {
+	for i, col := range cols {
+		if i > 0 {
+			buf.WriteString(" and ")
+		}
+		buf.Myprintf("%v = ", sqlparser.NewIdentifierCI(col))
+		encodeLookupValue(buf.Builder, row[i])
+	}
+}
+
+// formatRow formats a row for the report, like sku='abc', keyspace_id=x'166b40b44aba4bd6'.
+func (lv *lookupVerifier) formatRow(row []sqltypes.Value) string {
+	var buf strings.Builder
+	for i, field := range lv.fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(field.Name)
+		buf.WriteByte('=')
+		encodeLookupValue(&buf, row[i])
+	}
+	return buf.String()
+}
+
+func findLookupVerifierShard(shards []*lookupVerifierShard, ksid []byte) *lookupVerifierShard {
+	for _, shard := range shards {
+		if key.KeyRangeContains(shard.keyRange, ksid) {
+			return shard
+		}
+	}
+	return nil
+}
+
+func writeColumnList(buf *sqlparser.TrackedBuffer, cols []string) {
+	for i, col := range cols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.Myprintf("%v", sqlparser.NewIdentifierCI(col))
+	}
+}
+
+// encodeLookupValue encodes a value for a query, binary values such as the
+// keyspace ids as hexadecimal literals.
+func encodeLookupValue(buf *strings.Builder, value sqltypes.Value) {
+	if value.Type() == sqltypes.VarBinary || value.Type() == sqltypes.Binary {
+		buf.WriteString("x'")
+		buf.WriteString(hex.EncodeToString(value.Raw()))
+		buf.WriteByte('\'')
+		return
+	}
+	value.EncodeSQLStringBuilder(buf)
+}
@@ -0,0 +1,490 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+// lookupTableResult returns the rows of a lookup table, given as from|keyspace id
+// with the keyspace id in hexadecimal.
+func lookupTableResult(t *testing.T, rows ...string) *sqltypes.Result {
+	result := &sqltypes.Result{
+		Fields: sqltypes.MakeTestFields("c1|keyspace_id", "varchar|varbinary"),
+	}
+	for _, row := range rows {
+		from, ksid, _ := strings.Cut(row, "|")
+		raw, err := hex.DecodeString(ksid)
+		require.NoError(t, err)
+		result.Rows = append(result.Rows, []sqltypes.Value{
+			sqltypes.NewVarChar(from),
+			sqltypes.MakeTrusted(sqltypes.VarBinary, raw),
+		})
+	}
+	return result
+}
+
+func TestLookupVindexVerify(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+	}
+	ownerFields := "c1|id|id"
+	ownerTypes := "varchar|int64|int64"
+
+	testcases := []struct {
+		name    string
+		repair  bool
+		batch   int64
+		queries map[int][]*queryResult
+		want    *vtctldatapb.LookupVindexVerifyResponse
+	}{
+		{
+			name:  "verify",
+			batch: 2,
+			queries: map[int][]*queryResult{
+				// The -a0 shard holds ids 2 and 4, the a0- shard ids 1, 3 and 5.
+				startingSourceTabletUID: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 2",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "b|2|2", "d|4|4")),
+				}, {
+					query:  "select c1, id, id from t1 where c1 is not null and (c1, id) > ('d', 4) order by c1, id limit 2",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes))),
+				}},
+				startingSourceTabletUID + tabletUIDStep: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 2",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "a|1|1", "c|3|3")),
+				}, {
+					query:  "select c1, id, id from t1 where c1 is not null and (c1, id) > ('c', 3) order by c1, id limit 2",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "e|5|5")),
+				}},
+				startingTargetTabletUID: {{
+					query:  "select c1, keyspace_id from lookup where c1 is not null order by c1, keyspace_id limit 2",
+					result: sqltypes.ResultToProto3(lookupTableResult(t, "a|d46405367612b4b7", "b|8b59801662b52160")),
+				}, {
+					query:  "select c1, keyspace_id from lookup where c1 is not null and (c1, keyspace_id) > ('b', x'8b59801662b52160') order by c1, keyspace_id limit 2",
+					result: sqltypes.ResultToProto3(lookupTableResult(t, "c|ed48b60574b4816a", "e|ed48b60574b4816a")),
+				}, {
+					query:  "select c1, keyspace_id from lookup where c1 is not null and (c1, keyspace_id) > ('e', x'ed48b60574b4816a') order by c1, keyspace_id limit 2",
+					result: sqltypes.ResultToProto3(lookupTableResult(t, "z|f77c5a6468bd2e12")),
+				}},
+			},
+			want: &vtctldatapb.LookupVindexVerifyResponse{
+				OwnerRows:          5,
+				LookupRows:         5,
+				MatchingRows:       3,
+				MissingRows:        2,
+				OrphanedRows:       2,
+				MissingRowSamples:  []string{"c1='c', keyspace_id=x'a42c16f52a7c1626'", "c1='d', keyspace_id=x'896ba42c32143991'"},
+				OrphanedRowSamples: []string{"c1='c', keyspace_id=x'ed48b60574b4816a'", "c1='z', keyspace_id=x'f77c5a6468bd2e12'"},
+			},
+		},
+		{
+			name:   "repair",
+			repair: true,
+			queries: map[int][]*queryResult{
+				startingSourceTabletUID: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "b|2|2", "d|4|4")),
+				}},
+				startingSourceTabletUID + tabletUIDStep: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "a|1|1", "c|3|3", "e|5|5")),
+				}, {
+					// The owner table has c with another keyspace id, before and after the delete.
+					query:  "select id from t1 where c1 = 'c' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "3")),
+				}, {
+					query:  "select id from t1 where c1 = 'c' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "3")),
+				}, {
+					query:  "select id from t1 where c1 = 'z' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"))),
+				}, {
+					query:  "select id from t1 where c1 = 'z' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"))),
+				}},
+				startingTargetTabletUID: {{
+					query:  "select c1, keyspace_id from lookup where c1 is not null order by c1, keyspace_id limit 1000",
+					result: sqltypes.ResultToProto3(lookupTableResult(t, "a|d46405367612b4b7", "b|8b59801662b52160", "c|ed48b60574b4816a", "e|ed48b60574b4816a", "z|f77c5a6468bd2e12")),
+				}, {
+					query:  "delete from lookup where c1 = 'c' and keyspace_id = x'ed48b60574b4816a'",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}, {
+					query:  "insert ignore into lookup(c1, keyspace_id) values ('c', x'a42c16f52a7c1626')",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}, {
+					// vtgate inserted this one in the meantime.
+					query:  "insert ignore into lookup(c1, keyspace_id) values ('d', x'896ba42c32143991')",
+					result: &querypb.QueryResult{},
+				}, {
+					query:  "delete from lookup where c1 = 'z' and keyspace_id = x'f77c5a6468bd2e12'",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}},
+			},
+			want: &vtctldatapb.LookupVindexVerifyResponse{
+				OwnerRows:          5,
+				LookupRows:         5,
+				MatchingRows:       3,
+				MissingRows:        2,
+				OrphanedRows:       2,
+				MissingRowSamples:  []string{"c1='c', keyspace_id=x'a42c16f52a7c1626'", "c1='d', keyspace_id=x'896ba42c32143991'"},
+				OrphanedRowSamples: []string{"c1='c', keyspace_id=x'ed48b60574b4816a'", "c1='z', keyspace_id=x'f77c5a6468bd2e12'"},
+				InsertedRows:       1,
+				DeletedRows:        2,
+			},
+		},
+		{
+			name:   "repair an orphaned row whose owner is being inserted",
+			repair: true,
+			queries: map[int][]*queryResult{
+				startingSourceTabletUID: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "b|2|2")),
+				}},
+				startingSourceTabletUID + tabletUIDStep: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "a|1|1")),
+				}, {
+					query:  "select id from t1 where c1 = 'c' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"))),
+				}, {
+					// The owner of c was inserted by the time the row was deleted.
+					query:  "select id from t1 where c1 = 'c' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "3")),
+				}},
+				startingTargetTabletUID: {{
+					query:  "select c1, keyspace_id from lookup where c1 is not null order by c1, keyspace_id limit 1000",
+					result: sqltypes.ResultToProto3(lookupTableResult(t, "a|d46405367612b4b7", "b|8b59801662b52160", "c|a42c16f52a7c1626")),
+				}, {
+					query:  "delete from lookup where c1 = 'c' and keyspace_id = x'a42c16f52a7c1626'",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}, {
+					query:  "insert ignore into lookup(c1, keyspace_id) values ('c', x'a42c16f52a7c1626')",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}},
+			},
+			want: &vtctldatapb.LookupVindexVerifyResponse{
+				OwnerRows:          2,
+				LookupRows:         3,
+				MatchingRows:       2,
+				OrphanedRows:       1,
+				OrphanedRowSamples: []string{"c1='c', keyspace_id=x'a42c16f52a7c1626'"},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			env := newTestMaterializerEnv(t, ctx, ms, []string{"-a0", "a0-"}, []string{"0"})
+			defer env.close()
+
+			sourceVSchema := &vschemapb.Keyspace{
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"xxhash": {
+						Type: "xxhash",
+					},
+					"c1_lookup": {
+						Type: "lookup_unique",
+						Params: map[string]string{
+							"table": "targetks.lookup",
+							"from":  "c1",
+							"to":    "keyspace_id",
+						},
+						Owner: "t1",
+					},
+				},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Name:   "xxhash",
+							Column: "id",
+						}, {
+							Name:   "c1_lookup",
+							Column: "c1",
+						}},
+					},
+				},
+			}
+			require.NoError(t, env.topoServ.SaveVSchema(ctx, ms.SourceKeyspace, sourceVSchema))
+			env.tmc.schema[ms.SourceKeyspace+".t1"] = &tabletmanagerdatapb.SchemaDefinition{
+				TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+					Name:              "t1",
+					PrimaryKeyColumns: []string{"id"},
+					Fields: []*querypb.Field{{
+						Name: "id",
+						Type: querypb.Type_INT64,
+					}, {
+						Name:    "c1",
+						Type:    querypb.Type_VARCHAR,
+						Charset: uint32(collations.MySQL8().DefaultConnectionCharset()),
+					}},
+				}},
+			}
+			for tabletID, qrs := range tc.queries {
+				env.tmc.vrQueries[tabletID] = append(env.tmc.vrQueries[tabletID], qrs...)
+			}
+
+			resp, err := env.ws.LookupVindexVerify(ctx, &vtctldatapb.LookupVindexVerifyRequest{
+				Keyspace:      ms.SourceKeyspace,
+				Name:          "c1_lookup",
+				TableKeyspace: ms.TargetKeyspace,
+				Repair:        tc.repair,
+				BatchSize:     tc.batch,
+			})
+			require.NoError(t, err)
+			utils.MustMatch(t, tc.want, resp)
+			env.tmc.verifyQueries(t)
+		})
+	}
+}
+
+// TestLookupVindexVerifyHashedTo tests that the keyspace ids of a lookup_hash vindex,
+// which its lookup table stores unhashed, are compared and repaired as such.
+func TestLookupVindexVerifyHashedTo(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+	}
+	ownerFields := "c1|id|id"
+	ownerTypes := "varchar|int64|int64"
+	// to returns the value the lookup table stores for a keyspace id.
+	to := func(ksid string) string {
+		raw, err := hex.DecodeString(ksid)
+		require.NoError(t, err)
+		values, err := (&vindexes.Hash{}).ReverseMap(nil, [][]byte{raw})
+		require.NoError(t, err)
+		return values[0].ToString()
+	}
+	lookupFields := sqltypes.MakeTestFields("c1|keyspace_id", "varchar|uint64")
+
+	testcases := []struct {
+		name    string
+		repair  bool
+		queries map[int][]*queryResult
+		want    *vtctldatapb.LookupVindexVerifyResponse
+	}{
+		{
+			name: "verify",
+			queries: map[int][]*queryResult{
+				startingSourceTabletUID: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "a|1|1", "b|2|2")),
+				}},
+				startingTargetTabletUID: {{
+					query:  "select c1, keyspace_id from lookup where c1 is not null order by c1, keyspace_id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(lookupFields, "a|"+to("d46405367612b4b7"), "b|"+to("8b59801662b52160"))),
+				}},
+			},
+			want: &vtctldatapb.LookupVindexVerifyResponse{
+				OwnerRows:    2,
+				LookupRows:   2,
+				MatchingRows: 2,
+			},
+		},
+		{
+			name:   "repair",
+			repair: true,
+			queries: map[int][]*queryResult{
+				startingSourceTabletUID: {{
+					query:  "select c1, id, id from t1 where c1 is not null order by c1, id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields(ownerFields, ownerTypes), "a|1|1", "b|2|2")),
+				}, {
+					query:  "select id from t1 where c1 = 'z' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"))),
+				}, {
+					query:  "select id from t1 where c1 = 'z' limit 1000 for update",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"))),
+				}},
+				startingTargetTabletUID: {{
+					query:  "select c1, keyspace_id from lookup where c1 is not null order by c1, keyspace_id limit 1000",
+					result: sqltypes.ResultToProto3(sqltypes.MakeTestResult(lookupFields, "a|"+to("d46405367612b4b7"), "z|"+to("f77c5a6468bd2e12"))),
+				}, {
+					query:  "insert ignore into lookup(c1, keyspace_id) values ('b', " + to("8b59801662b52160") + ")",
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}, {
+					query:  "delete from lookup where c1 = 'z' and keyspace_id = " + to("f77c5a6468bd2e12"),
+					result: &querypb.QueryResult{RowsAffected: 1},
+				}},
+			},
+			want: &vtctldatapb.LookupVindexVerifyResponse{
+				OwnerRows:          2,
+				LookupRows:         2,
+				MatchingRows:       1,
+				MissingRows:        1,
+				OrphanedRows:       1,
+				MissingRowSamples:  []string{"c1='b', keyspace_id=x'8b59801662b52160'"},
+				OrphanedRowSamples: []string{"c1='z', keyspace_id=x'f77c5a6468bd2e12'"},
+				InsertedRows:       1,
+				DeletedRows:        1,
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			env := newTestMaterializerEnv(t, ctx, ms, []string{"0"}, []string{"0"})
+			defer env.close()
+
+			sourceVSchema := &vschemapb.Keyspace{
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"xxhash": {
+						Type: "xxhash",
+					},
+					"c1_lookup": {
+						Type: "lookup_hash",
+						Params: map[string]string{
+							"table": "targetks.lookup",
+							"from":  "c1",
+							"to":    "keyspace_id",
+						},
+						Owner: "t1",
+					},
+				},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Name:   "xxhash",
+							Column: "id",
+						}, {
+							Name:   "c1_lookup",
+							Column: "c1",
+						}},
+					},
+				},
+			}
+			require.NoError(t, env.topoServ.SaveVSchema(ctx, ms.SourceKeyspace, sourceVSchema))
+			env.tmc.schema[ms.SourceKeyspace+".t1"] = &tabletmanagerdatapb.SchemaDefinition{
+				TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+					Name:              "t1",
+					PrimaryKeyColumns: []string{"id"},
+					Fields: []*querypb.Field{{
+						Name: "id",
+						Type: querypb.Type_INT64,
+					}, {
+						Name:    "c1",
+						Type:    querypb.Type_VARCHAR,
+						Charset: uint32(collations.MySQL8().DefaultConnectionCharset()),
+					}},
+				}},
+			}
+			for tabletID, qrs := range tc.queries {
+				env.tmc.vrQueries[tabletID] = append(env.tmc.vrQueries[tabletID], qrs...)
+			}
+
+			resp, err := env.ws.LookupVindexVerify(ctx, &vtctldatapb.LookupVindexVerifyRequest{
+				Keyspace:      ms.SourceKeyspace,
+				Name:          "c1_lookup",
+				TableKeyspace: ms.TargetKeyspace,
+				Repair:        tc.repair,
+			})
+			require.NoError(t, err)
+			utils.MustMatch(t, tc.want, resp)
+			env.tmc.verifyQueries(t)
+		})
+	}
+}
+
+func TestLookupVindexVerifyErrors(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestMaterializerEnv(t, ctx, ms, []string{"0"}, []string{"0"})
+	defer env.close()
+
+	sourceVSchema := &vschemapb.Keyspace{
+		Sharded: true,
+		Vindexes: map[string]*vschemapb.Vindex{
+			"xxhash": {
+				Type: "xxhash",
+			},
+			"md5_lookup": {
+				Type: "lookup_unicodeloosemd5_hash",
+				Params: map[string]string{
+					"table": "targetks.lookup",
+					"from":  "c1",
+					"to":    "keyspace_id",
+				},
+				Owner: "t1",
+			},
+			"unowned": {
+				Type: "lookup",
+				Params: map[string]string{
+					"table": "targetks.lookup",
+					"from":  "c1",
+					"to":    "keyspace_id",
+				},
+			},
+		},
+	}
+	require.NoError(t, env.topoServ.SaveVSchema(ctx, ms.SourceKeyspace, sourceVSchema))
+
+	testcases := []struct {
+		name    string
+		req     *vtctldatapb.LookupVindexVerifyRequest
+		wantErr string
+	}{
+		{
+			name:    "not found",
+			req:     &vtctldatapb.LookupVindexVerifyRequest{Keyspace: ms.SourceKeyspace, Name: "nope"},
+			wantErr: "vindex nope not found in the sourceks keyspace",
+		},
+		{
+			name:    "not a lookup",
+			req:     &vtctldatapb.LookupVindexVerifyRequest{Keyspace: ms.SourceKeyspace, Name: "xxhash"},
+			wantErr: "vindex xxhash is not a lookup type",
+		},
+		{
+			name:    "hashed from values",
+			req:     &vtctldatapb.LookupVindexVerifyRequest{Keyspace: ms.SourceKeyspace, Name: "md5_lookup"},
+			wantErr: "the lookup table of vindex md5_lookup of type lookup_unicodeloosemd5_hash can't be verified",
+		},
+		{
+			name:    "no owner",
+			req:     &vtctldatapb.LookupVindexVerifyRequest{Keyspace: ms.SourceKeyspace, Name: "unowned"},
+			wantErr: "vindex unowned has no owner table to verify its lookup table against",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := env.ws.LookupVindexVerify(ctx, tc.req)
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
@@ -1276,6 +1276,26 @@ func (s *Server) LookupVindexExternalize(ctx context.Context, req *vtctldatapb.L
 	return resp, s.ts.RebuildSrvVSchema(ctx, nil)
 }
 
+// LookupVindexVerify compares the lookup table of an owned lookup
+// vindex with its owner table, and reports the rows missing from the
+// lookup table and the orphaned ones. If requested, it also repairs
+// the lookup table online.
+func (s *Server) LookupVindexVerify(ctx context.Context, req *vtctldatapb.LookupVindexVerifyRequest) (*vtctldatapb.LookupVindexVerifyResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.LookupVindexVerify")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("name", req.Name)
+	span.Annotate("table_keyspace", req.TableKeyspace)
+	span.Annotate("repair", req.Repair)
+
+	lv, err := s.newLookupVerifier(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return lv.verify(ctx)
+}
+
 // Materialize performs the steps needed to materialize a list of
 // tables based on the materialization specs.
 func (s *Server) Materialize(ctx context.Context, ms *vtctldatapb.MaterializeSettings) error {
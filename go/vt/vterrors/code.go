@@ -59,6 +59,9 @@ var (
 	VT03031 = errorWithoutState("VT03031", vtrpcpb.Code_INVALID_ARGUMENT, "EXPLAIN is only supported for single keyspace", "EXPLAIN has to be sent down as a single query to the underlying MySQL, and this is not possible if it uses tables from multiple keyspaces")
 	VT03032 = errorWithState("VT03032", vtrpcpb.Code_INVALID_ARGUMENT, NonUpdateableTable, "the target table %s of the UPDATE is not updatable", "You cannot update a table that is not a real MySQL table.")
 	VT03033 = errorWithState("VT03033", vtrpcpb.Code_INVALID_ARGUMENT, ViewWrongList, "In definition of view, derived table or common table expression, SELECT list and column names list have different column counts", "The table column list and derived column list have different column counts.")
+	VT03034 = errorWithoutState("VT03034", vtrpcpb.Code_INVALID_ARGUMENT, "window name '%s' is not defined", "The window function refers to a named window that is not declared in the WINDOW clause.")
+	VT03035 = errorWithoutState("VT03035", vtrpcpb.Code_INVALID_ARGUMENT, "Argument #%d of GROUPING function is not in GROUP BY", "Every argument of the GROUPING function has to match one of the expressions in the GROUP BY clause.")
+	VT03036 = errorWithoutState("VT03036", vtrpcpb.Code_INVALID_ARGUMENT, "GROUPING function can only be used with GROUP BY WITH ROLLUP", "The GROUPING function tells super-aggregate rows apart from regular rows, which only exist when the query uses GROUP BY ... WITH ROLLUP.")
 
 	VT05001 = errorWithState("VT05001", vtrpcpb.Code_NOT_FOUND, DbDropExists, "cannot drop database '%s'; database does not exists", "The given database does not exist; Vitess cannot drop it.")
 	VT05002 = errorWithState("VT05002", vtrpcpb.Code_NOT_FOUND, BadDb, "cannot alter database '%s'; unknown database", "The given database does not exist; Vitess cannot alter it.")
@@ -101,7 +104,7 @@ var (
 	VT09027 = errorWithState("VT09027", vtrpcpb.Code_FAILED_PRECONDITION, CTERecursiveForbidsAggregation, "Recursive Common Table Expression '%s' can contain neither aggregation nor window functions in recursive query block", "")
 	VT09028 = errorWithState("VT09028", vtrpcpb.Code_FAILED_PRECONDITION, CTERecursiveForbiddenJoinOrder, "In recursive query block of Recursive Common Table Expression '%s', the recursive table must neither be in the right argument of a LEFT JOIN, nor be forced to be non-first with join order hints", "")
 	VT09029 = errorWithState("VT09029", vtrpcpb.Code_FAILED_PRECONDITION, CTERecursiveRequiresSingleReference, "In recursive query block of Recursive Common Table Expression %s, the recursive table must be referenced only once, and not in any subquery", "")
-	VT09030 = errorWithState("VT09030", vtrpcpb.Code_FAILED_PRECONDITION, CTEMaxRecursionDepth, "Recursive query aborted after 1000 iterations.", "")
+	VT09030 = errorWithState("VT09030", vtrpcpb.Code_FAILED_PRECONDITION, CTEMaxRecursionDepth, "Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.", "")
 
 	VT10001 = errorWithoutState("VT10001", vtrpcpb.Code_ABORTED, "foreign key constraints are not allowed", "Foreign key constraints are not allowed, see https://vitess.io/blog/2021-06-15-online-ddl-why-no-fk/.")
 	VT10002 = errorWithoutState("VT10002", vtrpcpb.Code_ABORTED, "atomic distributed transaction not allowed: %s", "The distributed transaction cannot be committed. A rollback decision is taken.")
@@ -155,6 +158,9 @@ var (
 		VT03031,
 		VT03032,
 		VT03033,
+		VT03034,
+		VT03035,
+		VT03036,
 		VT05001,
 		VT05002,
 		VT05003,
@@ -192,6 +198,7 @@ var (
 		VT09027,
 		VT09028,
 		VT09029,
+		VT09030,
 		VT10001,
 		VT10002,
 		VT12001,
@@ -18,9 +18,14 @@ package engine
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/sqlerror"
 	"vitess.io/vitess/go/slice"
 	"vitess.io/vitess/go/sqltypes"
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -50,6 +55,16 @@ type AggregateParams struct {
 	// not what we use to aggregate at the engine primitive level.
 	OrigOpcode AggregateOpcode
 
+	// GroupingKeys is used only for the grouping opcode. It holds the
+	// index in the grouping keys of each argument of the GROUPING() call.
+	GroupingKeys []int
+
+	// Args and OrderBy are used only for the group_concat opcode, when the values
+	// are not concatenated by the shards. Args holds the arguments of the call,
+	// and OrderBy the ORDER BY clause inside of it.
+	Args    evalengine.Comparison
+	OrderBy evalengine.Comparison
+
 	CollationEnv *collations.Environment
 }
 
@@ -79,6 +94,9 @@ func (ap *AggregateParams) String() string {
 	if sqltypes.IsText(ap.Type.Type()) && ap.CollationEnv.IsSupported(ap.Type.Collation()) {
 		keyCol += " COLLATE " + ap.CollationEnv.LookupName(ap.Type.Collation())
 	}
+	if len(ap.Args) > 0 {
+		keyCol = ap.groupConcatArgs()
+	}
 	dispOrigOp := ""
 	if ap.OrigOpcode != AggregateUnassigned && ap.OrigOpcode != ap.Opcode {
 		dispOrigOp = "_" + ap.OrigOpcode.String()
@@ -89,6 +107,31 @@ func (ap *AggregateParams) String() string {
 	return fmt.Sprintf("%s%s(%s)", ap.Opcode.String(), dispOrigOp, keyCol)
 }
 
+func (ap *AggregateParams) groupConcatArgs() string {
+	var sb strings.Builder
+	if gc, ok := ap.Func.(*sqlparser.GroupConcatExpr); ok && gc.Distinct {
+		sb.WriteString("distinct ")
+	}
+	for i, arg := range ap.Args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(arg.Col))
+		if arg.WeightStringCol != -1 && arg.WeightStringCol != arg.Col {
+			fmt.Fprintf(&sb, "|%d", arg.WeightStringCol)
+		}
+	}
+	for i, order := range ap.OrderBy {
+		if i == 0 {
+			sb.WriteString(" order by ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(order.String())
+	}
+	return sb.String()
+}
+
 func (ap *AggregateParams) typ(inputType querypb.Type) querypb.Type {
 	if ap.OrigOpcode != AggregateUnassigned {
 		return ap.OrigOpcode.SQLType(inputType)
@@ -254,39 +297,256 @@ func (a *aggregatorScalar) reset() {
 	a.init = false
 }
 
+// defaultGroupConcatMaxLen is the default value of group_concat_max_len
+const defaultGroupConcatMaxLen = 1024
+
 type aggregatorGroupConcat struct {
 	from      int
 	type_     sqltypes.Type
 	separator []byte
+	limit     *groupConcatLimit
+
+	// args, distinct and orderBy are only used when the values of the group are not
+	// concatenated by the shards, so we have to see all of them before building the result
+	args     evalengine.Comparison
+	distinct bool
+	orderBy  evalengine.Comparison
+
+	// vcursor is used to spill the rows of the group to disk once they don't fit in memory
+	vcursor VCursor
+
+	// seen holds the distinct rows sorted by their arguments, and seenRuns the ones
+	// already written to disk. sorted holds the rows to sort by the ORDER BY clause.
+	seen     []sqltypes.Row
+	seenRuns []*spillFile
+	sorted   *spillSorter
+
+	// pos is the position of the next row in the group. It is added at the end of the
+	// rows, in posCol, so that the rows that compare the same keep the order they came in.
+	pos    int64
+	posCol int
 
 	concat []byte
 	n      int
 }
 
-func (a *aggregatorGroupConcat) add(row []sqltypes.Value) error {
-	if row[a.from].IsNull() {
+func (a *aggregatorGroupConcat) add(row []sqltypes.Value) (err error) {
+	if len(a.args) == 0 {
+		if row[a.from].IsNull() {
+			return nil
+		}
+		a.append(row)
+		return nil
+	}
+
+	for _, arg := range a.args {
+		if row[arg.Col].IsNull() {
+			// rows with a NULL in any of the arguments are skipped
+			return nil
+		}
+	}
+	if !a.distinct && len(a.orderBy) == 0 {
+		a.append(row)
+		return nil
+	}
+
+	defer evalengine.PanicHandler(&err)
+	a.posCol = len(row)
+	row = append(row[:len(row):len(row)], sqltypes.NewInt64(a.pos))
+	a.pos++
+	if !a.distinct {
+		return a.sorter().push(row)
+	}
+
+	idx, found := slices.BinarySearchFunc(a.seen, row, a.args.Compare)
+	if found {
+		return nil
+	}
+	a.seen = slices.Insert(a.seen, idx, row)
+	return a.spillSeen()
+}
+
+// spillSeen writes the distinct rows to disk once max_memory_rows is exceeded. The runs
+// on disk can then hold the same values, which are skipped when they are merged back.
+func (a *aggregatorGroupConcat) spillSeen() error {
+	if a.vcursor == nil || !a.vcursor.ExceedsMaxMemoryRows(len(a.seen)) {
 		return nil
 	}
+	spiller := a.vcursor.Spiller()
+	if spiller == nil {
+		return nil
+	}
+	run, err := spiller.writeRows(a.seen)
+	if err != nil {
+		return err
+	}
+	a.seenRuns = append(a.seenRuns, run)
+	a.seen = nil
+	return nil
+}
+
+// byPosition adds the position of the rows to the comparison
+func (a *aggregatorGroupConcat) byPosition(cmp evalengine.Comparison) evalengine.Comparison {
+	return append(slices.Clip(cmp), evalengine.OrderByParams{
+		Col:             a.posCol,
+		WeightStringCol: -1,
+		Type:            evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID),
+	})
+}
+
+func (a *aggregatorGroupConcat) sorter() *spillSorter {
+	if a.sorted == nil {
+		a.sorted = &spillSorter{compare: a.byPosition(a.orderBy), vcursor: a.vcursor}
+	}
+	return a.sorted
+}
+
+// forEachDistinct calls fn with the first row of each of the distinct values, in the order of the arguments
+func (a *aggregatorGroupConcat) forEachDistinct(fn func(sqltypes.Row) error) error {
+	var last sqltypes.Row
+	return mergeRuns(a.byPosition(a.args), a.seenRuns, a.seen, func(row sqltypes.Row) error {
+		if last != nil && a.args.Compare(last, row) == 0 {
+			return nil
+		}
+		last = row
+		return fn(row)
+	})
+}
+
+// append adds the values of the row at the end of the concatenation
+func (a *aggregatorGroupConcat) append(row []sqltypes.Value) {
 	if a.n > 0 {
 		a.concat = append(a.concat, a.separator...)
 	}
-	a.concat = append(a.concat, row[a.from].Raw()...)
+	if len(a.args) == 0 {
+		a.concat = append(a.concat, row[a.from].Raw()...)
+	}
+	for _, arg := range a.args {
+		a.concat = append(a.concat, row[arg.Col].Raw()...)
+	}
 	a.n++
-	return nil
 }
 
+// finish panics if the rows spilled to disk cannot be read back, see aggregationState.finish
 func (a *aggregatorGroupConcat) finish() sqltypes.Value {
+	appendRow := func(row sqltypes.Row) error {
+		a.append(row)
+		return nil
+	}
+	if a.distinct {
+		// MySQL returns the distinct values in the order of the arguments
+		next := appendRow
+		if len(a.orderBy) > 0 {
+			next = func(row sqltypes.Row) error {
+				return a.sorter().push(row)
+			}
+		}
+		if err := a.forEachDistinct(next); err != nil {
+			panic(err)
+		}
+	}
+	if a.sorted != nil {
+		if err := a.sorted.forEach(appendRow); err != nil {
+			panic(err)
+		}
+	}
 	if a.n == 0 {
 		return sqltypes.NULL
 	}
-	return sqltypes.MakeTrusted(a.type_, a.concat)
+	return sqltypes.MakeTrusted(a.type_, a.limit.cut(a.concat, a.type_))
 }
 
 func (a *aggregatorGroupConcat) reset() {
 	a.n = 0
 	a.concat = nil // not safe to reuse this byte slice as it's returned as MakeTrusted
+	a.pos = 0
+	a.seen = nil
+	for _, run := range a.seenRuns {
+		run.close()
+	}
+	a.seenRuns = nil
+	a.sorted.close()
+	a.sorted = nil
 }
 
+// groupConcatLimit cuts the results of GROUP_CONCAT that are longer than
+// group_concat_max_len, and records a warning for each of them like MySQL does
+type groupConcatLimit struct {
+	maxLen  int
+	session SessionActions
+
+	// row is the number of results that have been produced so far
+	row int
+}
+
+func newGroupConcatLimit(vcursor VCursor) *groupConcatLimit {
+	limit := &groupConcatLimit{maxLen: defaultGroupConcatMaxLen}
+	if vcursor == nil {
+		return limit
+	}
+	limit.session = vcursor.Session()
+	limit.session.GetSystemVariables(func(k string, v string) {
+		if k != "group_concat_max_len" {
+			return
+		}
+		if maxLen, err := strconv.Atoi(v); err == nil {
+			limit.maxLen = maxLen
+		}
+	})
+	return limit
+}
+
+func (l *groupConcatLimit) cut(concat []byte, typ sqltypes.Type) []byte {
+	if l == nil {
+		return concat
+	}
+	l.row++
+	if len(concat) <= l.maxLen {
+		return concat
+	}
+
+	end := l.maxLen
+	if sqltypes.IsText(typ) {
+		// don't cut a multibyte character in half
+		for end > 0 && !utf8.RuneStart(concat[end]) {
+			end--
+		}
+	}
+	if l.session != nil {
+		l.session.RecordWarning(&querypb.QueryWarning{
+			Code:    uint32(sqlerror.ERCutValueGroupConcat),
+			Message: fmt.Sprintf("Row %d was cut by GROUP_CONCAT()", l.row),
+		})
+	}
+	return concat[:end]
+}
+
+// aggregatorGrouping evaluates GROUPING(). The result has one bit per argument,
+// set when the grouping key of that argument has been rolled up in the current row.
+type aggregatorGrouping struct {
+	keys []int
+
+	// level is the number of grouping keys that are not rolled up
+	level int
+}
+
+func (a *aggregatorGrouping) add([]sqltypes.Value) error {
+	return nil
+}
+
+func (a *aggregatorGrouping) finish() sqltypes.Value {
+	var bits int64
+	for _, key := range a.keys {
+		bits <<= 1
+		if key >= a.level {
+			bits |= 1
+		}
+	}
+	return sqltypes.NewInt64(bits)
+}
+
+func (a *aggregatorGrouping) reset() {}
+
 type aggregatorGtid struct {
 	from   int
 	shards []*binlogdatapb.ShardGtid
@@ -321,12 +581,15 @@ func (a aggregationState) add(row []sqltypes.Value) error {
 	return nil
 }
 
-func (a aggregationState) finish() (row []sqltypes.Value) {
+// finish returns the aggregated row. Reading back the rows an aggregation spilled to disk
+// can fail, in which case the aggregation panics with the error, which is returned here.
+func (a aggregationState) finish() (row []sqltypes.Value, err error) {
+	defer evalengine.PanicHandler(&err)
 	row = make([]sqltypes.Value, 0, len(a))
 	for _, st := range a {
 		row = append(row, st.finish())
 	}
-	return
+	return row, nil
 }
 
 func (a aggregationState) reset() {
@@ -335,6 +598,21 @@ func (a aggregationState) reset() {
 	}
 }
 
+// close releases what the aggregations still hold, like the rows they spilled to disk
+func (a aggregationState) close() {
+	a.reset()
+}
+
+// setRollupLevel marks the grouping keys from the given level on as rolled up
+// for the GROUPING() aggregations of this state
+func (a aggregationState) setRollupLevel(level int) {
+	for _, st := range a {
+		if grouping, ok := st.(*aggregatorGrouping); ok {
+			grouping.level = level
+		}
+	}
+}
+
 func isComparable(typ sqltypes.Type) bool {
 	if typ == sqltypes.Null || sqltypes.IsNumber(typ) || sqltypes.IsBinary(typ) {
 		return true
@@ -354,9 +632,11 @@ func isComparable(typ sqltypes.Type) bool {
 	return false
 }
 
-func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (aggregationState, []*querypb.Field, error) {
+func newAggregation(vcursor VCursor, fields []*querypb.Field, aggregates []*AggregateParams) (aggregationState, []*querypb.Field, error) {
 	fields = slice.Map(fields, func(from *querypb.Field) *querypb.Field { return from.CloneVT() })
 
+	var limit *groupConcatLimit
+
 	agstate := make([]aggregator, len(fields))
 	for _, aggr := range aggregates {
 		sourceType := fields[aggr.Col].Type
@@ -432,16 +712,27 @@ func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (agg
 		case AggregateGtid:
 			ag = &aggregatorGtid{from: aggr.Col}
 
+		case AggregateGrouping:
+			ag = &aggregatorGrouping{keys: aggr.GroupingKeys, level: math.MaxInt}
+
 		case AggregateAnyValue:
 			ag = &aggregatorScalar{from: aggr.Col}
 
 		case AggregateGroupConcat:
 			gcFunc := aggr.Func.(*sqlparser.GroupConcatExpr)
 			separator := []byte(gcFunc.Separator)
+			if limit == nil {
+				limit = newGroupConcatLimit(vcursor)
+			}
 			ag = &aggregatorGroupConcat{
 				from:      aggr.Col,
 				type_:     targetType,
 				separator: separator,
+				limit:     limit,
+				args:      aggr.Args,
+				distinct:  gcFunc.Distinct,
+				orderBy:   aggr.OrderBy,
+				vcursor:   vcursor,
 			}
 
 		default:
@@ -35,7 +35,7 @@ func (cached *AggregateParams) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(112)
+		size += int64(192)
 	}
 	// field Type vitess.io/vitess/go/vt/vtgate/evalengine.Type
 	size += cached.Type.CachedSize(false)
@@ -47,6 +47,24 @@ func (cached *AggregateParams) CachedSize(alloc bool) int64 {
 	}
 	// field Original *vitess.io/vitess/go/vt/sqlparser.AliasedExpr
 	size += cached.Original.CachedSize(true)
+	// field GroupingKeys []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.GroupingKeys)) * int64(8))
+	}
+	// field Args vitess.io/vitess/go/vt/vtgate/evalengine.Comparison
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Args)) * int64(56))
+		for _, elem := range cached.Args {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field OrderBy vitess.io/vitess/go/vt/vtgate/evalengine.Comparison
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.OrderBy)) * int64(56))
+		for _, elem := range cached.OrderBy {
+			size += elem.CachedSize(false)
+		}
+	}
 	// field CollationEnv *vitess.io/vitess/go/mysql/collations.Environment
 	size += cached.CollationEnv.CachedSize(true)
 	return size
@@ -113,6 +131,72 @@ func (cached *Concatenate) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+
+//go:nocheckptr
+func (cached *CorrelatedSubquery) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(208)
+	}
+	// field SubqueryResult string
+	size += hack.RuntimeAllocSize(int64(len(cached.SubqueryResult)))
+	// field HasValues string
+	size += hack.RuntimeAllocSize(int64(len(cached.HasValues)))
+	// field Vars map[string]int
+	if cached.Vars != nil {
+		size += int64(48)
+		hmap := reflect.ValueOf(cached.Vars)
+		numBuckets := int(math.Pow(2, float64((*(*uint8)(unsafe.Pointer(hmap.Pointer() + uintptr(9)))))))
+		numOldBuckets := (*(*uint16)(unsafe.Pointer(hmap.Pointer() + uintptr(10))))
+		size += hack.RuntimeAllocSize(int64(numOldBuckets * 208))
+		if len(cached.Vars) > 0 || numBuckets > 1 {
+			size += hack.RuntimeAllocSize(int64(numBuckets * 208))
+		}
+		for k := range cached.Vars {
+			size += hack.RuntimeAllocSize(int64(len(k)))
+		}
+	}
+	// field Predicate vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Predicate.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field ASTPredicate vitess.io/vitess/go/vt/sqlparser.Expr
+	if cc, ok := cached.ASTPredicate.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Outer vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Outer.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Subquery vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Subquery.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Batch vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Batch.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field BatchVar string
+	size += hack.RuntimeAllocSize(int64(len(cached.BatchVar)))
+	// field BatchKeys []string
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.BatchKeys)) * int64(16))
+		for _, elem := range cached.BatchKeys {
+			size += hack.RuntimeAllocSize(int64(len(elem)))
+		}
+	}
+	// field BatchTypes []vitess.io/vitess/go/vt/vtgate/evalengine.Type
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.BatchTypes)) * int64(24))
+		for _, elem := range cached.BatchTypes {
+			size += elem.CachedSize(false)
+		}
+	}
+	return size
+}
 func (cached *DBDDL) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -389,12 +473,16 @@ func (cached *Generate) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(48)
+		size += int64(80)
 	}
 	// field Keyspace *vitess.io/vitess/go/vt/vtgate/vindexes.Keyspace
 	size += cached.Keyspace.CachedSize(true)
 	// field Query string
 	size += hack.RuntimeAllocSize(int64(len(cached.Query)))
+	// field Sequence string
+	size += hack.RuntimeAllocSize(int64(len(cached.Sequence)))
+	// field Backend *vitess.io/vitess/go/vt/vtgate/vindexes.SequenceBackend
+	size += cached.Backend.CachedSize(true)
 	// field Values vitess.io/vitess/go/vt/vtgate/evalengine.Expr
 	if cc, ok := cached.Values.(cachedObject); ok {
 		size += cc.CachedSize(true)
@@ -425,7 +513,7 @@ func (cached *HashJoin) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(128)
+		size += int64(208)
 	}
 	// field Left vitess.io/vitess/go/vt/vtgate/engine.Primitive
 	if cc, ok := cached.Left.(cachedObject); ok {
@@ -439,19 +527,34 @@ func (cached *HashJoin) CachedSize(alloc bool) int64 {
 	{
 		size += hack.RuntimeAllocSize(int64(cap(cached.Cols)) * int64(8))
 	}
+	// field LHSKeys []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.LHSKeys)) * int64(8))
+	}
+	// field RHSKeys []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.RHSKeys)) * int64(8))
+	}
 	// field ASTPred vitess.io/vitess/go/vt/sqlparser.Expr
 	if cc, ok := cached.ASTPred.(cachedObject); ok {
 		size += cc.CachedSize(true)
 	}
+	// field ComparisonTypes []vitess.io/vitess/go/vt/vtgate/evalengine.Type
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ComparisonTypes)) * int64(24))
+		for _, elem := range cached.ComparisonTypes {
+			size += elem.CachedSize(false)
+		}
+	}
 	// field CollationEnv *vitess.io/vitess/go/mysql/collations.Environment
 	size += cached.CollationEnv.CachedSize(true)
-	// field Values *vitess.io/vitess/go/vt/vtgate/evalengine.EnumSetValues
-	if cached.Values != nil {
-		size += int64(24)
-		size += hack.RuntimeAllocSize(int64(cap(*cached.Values)) * int64(16))
-		for _, elem := range *cached.Values {
-			size += hack.RuntimeAllocSize(int64(len(elem)))
-		}
+	// field Residual vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Residual.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field ResidualCols []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ResidualCols)) * int64(8))
 	}
 	return size
 }
@@ -461,7 +564,7 @@ func (cached *Insert) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(224)
+		size += int64(240)
 	}
 	// field InsertCommon vitess.io/vitess/go/vt/vtgate/engine.InsertCommon
 	size += cached.InsertCommon.CachedSize(false)
@@ -510,7 +613,7 @@ func (cached *InsertCommon) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(144)
+		size += int64(160)
 	}
 	// field Keyspace *vitess.io/vitess/go/vt/vtgate/vindexes.Keyspace
 	size += cached.Keyspace.CachedSize(true)
@@ -525,6 +628,15 @@ func (cached *InsertCommon) CachedSize(alloc bool) int64 {
 			size += elem.CachedSize(true)
 		}
 	}
+	// field VindexExprs []vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.VindexExprs)) * int64(16))
+		for _, elem := range cached.VindexExprs {
+			if cc, ok := elem.(cachedObject); ok {
+				size += cc.CachedSize(true)
+			}
+		}
+	}
 	// field Prefix string
 	size += hack.RuntimeAllocSize(int64(len(cached.Prefix)))
 	// field Suffix vitess.io/vitess/go/vt/sqlparser.OnDup
@@ -542,7 +654,7 @@ func (cached *InsertSelect) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(176)
+		size += int64(208)
 	}
 	// field InsertCommon vitess.io/vitess/go/vt/vtgate/engine.InsertCommon
 	size += cached.InsertCommon.CachedSize(false)
@@ -801,7 +913,7 @@ func (cached *Plan) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(144)
+		size += int64(160)
 	}
 	// field Original string
 	size += hack.RuntimeAllocSize(int64(len(cached.Original)))
@@ -865,7 +977,7 @@ func (cached *RecurseCTE) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(48)
+		size += int64(64)
 	}
 	// field Seed vitess.io/vitess/go/vt/vtgate/engine.Primitive
 	if cc, ok := cached.Seed.(cachedObject); ok {
@@ -889,6 +1001,13 @@ func (cached *RecurseCTE) CachedSize(alloc bool) int64 {
 			size += hack.RuntimeAllocSize(int64(len(k)))
 		}
 	}
+	// field CheckCols []vitess.io/vitess/go/vt/vtgate/engine.CheckCol
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.CheckCols)) * int64(48))
+		for _, elem := range cached.CheckCols {
+			size += elem.CachedSize(false)
+		}
+	}
 	return size
 }
 func (cached *RenameFields) CachedSize(alloc bool) int64 {
@@ -1597,6 +1716,61 @@ func (cached *VitessMetadata) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.Value)))
 	return size
 }
+func (cached *Window) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(96)
+	}
+	// field PartitionBy vitess.io/vitess/go/vt/vtgate/evalengine.Comparison
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PartitionBy)) * int64(56))
+		for _, elem := range cached.PartitionBy {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field OrderBy vitess.io/vitess/go/vt/vtgate/evalengine.Comparison
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.OrderBy)) * int64(56))
+		for _, elem := range cached.OrderBy {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field Funcs []*vitess.io/vitess/go/vt/vtgate/engine.WindowFuncParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Funcs)) * int64(8))
+		for _, elem := range cached.Funcs {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field Input vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Input.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
+func (cached *WindowFuncParams) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(96)
+	}
+	// field Frame *vitess.io/vitess/go/vt/vtgate/engine.WindowFrame
+	if cached.Frame != nil {
+		size += hack.RuntimeAllocSize(int64(40))
+	}
+	// field Alias string
+	size += hack.RuntimeAllocSize(int64(len(cached.Alias)))
+	// field Type vitess.io/vitess/go/vt/vtgate/evalengine.Type
+	size += cached.Type.CachedSize(false)
+	// field CollationEnv *vitess.io/vitess/go/mysql/collations.Environment
+	size += cached.CollationEnv.CachedSize(true)
+	return size
+}
 func (cached *percentBasedMirror) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
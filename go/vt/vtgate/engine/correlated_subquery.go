@@ -0,0 +1,463 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"maps"
+	"slices"
+	"sync"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vthash"
+)
+
+var _ Primitive = (*CorrelatedSubquery)(nil)
+
+// correlatedBatchSize is the largest number of combinations of outer values sent in one batch
+const correlatedBatchSize = 500
+
+// CorrelatedSubquery evaluates a subquery that uses values from the rows of the outer query.
+// The subquery is evaluated once for every distinct combination of the outer values it uses,
+// and the result is shared by all the outer rows that have the same values.
+// When there is a Batch, many combinations of outer values are evaluated in a single query.
+type CorrelatedSubquery struct {
+	Opcode PulloutOpcode
+
+	// SubqueryResult and HasValues are the bind variables used to send the subquery result to the predicate
+	SubqueryResult string
+	HasValues      string
+
+	// Vars are the columns of the outer rows that are sent to the subquery as bind variables
+	Vars map[string]int
+
+	// Predicate is evaluated for every outer row, and the rows it is not true for are dropped.
+	// When there is no predicate, the value of the subquery is added as the first column of the rows instead.
+	Predicate    evalengine.Expr
+	ASTPredicate sqlparser.Expr
+
+	Outer    Primitive
+	Subquery Primitive
+
+	// Batch evaluates the subquery for the outer values sent in the BatchVar list bind variable.
+	// Its first columns are the inner values compared with the BatchKeys vars, using the BatchTypes,
+	// and the columns of the subquery follow. When BatchFallback is set, the outer values Batch returns
+	// no rows for are evaluated one at a time with Subquery, since the subquery returns a row for them too.
+	Batch         Primitive
+	BatchVar      string
+	BatchKeys     []string
+	BatchTypes    []evalengine.Type
+	BatchFallback bool
+}
+
+// correlatedResult is the result of the subquery for one combination of outer values
+type correlatedResult struct {
+	bindVars map[string]*querypb.BindVariable
+	value    sqltypes.Value
+}
+
+// correlatedState holds the subquery results seen during a single execution.
+// The memory they use is charged to the memory tracker of the query until the execution is done.
+type correlatedState struct {
+	cols      []int
+	batchCols []int
+	cache     map[string]*correlatedResult
+	usage     *memoryUsage
+}
+
+// Inputs returns the input primitives for this CorrelatedSubquery
+func (cs *CorrelatedSubquery) Inputs() ([]Primitive, []map[string]any) {
+	inputs := []Primitive{cs.Outer, cs.Subquery}
+	infos := []map[string]any{{
+		inputName: "Outer",
+	}, {
+		inputName: "SubQuery",
+	}}
+	if cs.Batch != nil {
+		inputs = append(inputs, cs.Batch)
+		infos = append(infos, map[string]any{inputName: "Batch"})
+	}
+	return inputs, infos
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (cs *CorrelatedSubquery) RouteType() string {
+	return cs.Opcode.String()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (cs *CorrelatedSubquery) GetKeyspaceName() string {
+	if cs.Outer.GetKeyspaceName() == cs.Subquery.GetKeyspaceName() {
+		return cs.Outer.GetKeyspaceName()
+	}
+	return cs.Outer.GetKeyspaceName() + "_" + cs.Subquery.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (cs *CorrelatedSubquery) GetTableName() string {
+	return cs.Outer.GetTableName() + "_" + cs.Subquery.GetTableName()
+}
+
+// NeedsTransaction implements the Primitive interface
+func (cs *CorrelatedSubquery) NeedsTransaction() bool {
+	return cs.Subquery.NeedsTransaction() || cs.Outer.NeedsTransaction()
+}
+
+// TryExecute performs a non-streaming exec.
+func (cs *CorrelatedSubquery) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	outer, err := vcursor.ExecutePrimitive(ctx, cs.Outer, bindVars, wantfields)
+	if err != nil {
+		return nil, err
+	}
+
+	state := cs.newState(vcursor)
+	defer state.usage.release()
+	rows, err := cs.evaluate(ctx, vcursor, bindVars, state, outer.Rows)
+	if err != nil {
+		return nil, err
+	}
+	result := &sqltypes.Result{Rows: rows}
+	if wantfields {
+		result.Fields, err = cs.fields(ctx, vcursor, bindVars, outer.Fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// TryStreamExecute performs a streaming exec.
+func (cs *CorrelatedSubquery) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	var mu sync.Mutex
+	state := cs.newState(vcursor)
+	defer state.usage.release()
+	return vcursor.StreamExecutePrimitive(ctx, cs.Outer, bindVars, wantfields, func(outer *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		rows, err := cs.evaluate(ctx, vcursor, bindVars, state, outer.Rows)
+		if err != nil {
+			return err
+		}
+		result := &sqltypes.Result{Rows: rows}
+		if len(outer.Fields) > 0 {
+			result.Fields, err = cs.fields(ctx, vcursor, bindVars, outer.Fields)
+			if err != nil {
+				return err
+			}
+		}
+		return callback(result)
+	})
+}
+
+// GetFields fetches the field info.
+func (cs *CorrelatedSubquery) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	outer, err := cs.Outer.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := cs.fields(ctx, vcursor, bindVars, outer.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{Fields: fields}, nil
+}
+
+func (cs *CorrelatedSubquery) fields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, outer []*querypb.Field) ([]*querypb.Field, error) {
+	if cs.Predicate != nil {
+		return outer, nil
+	}
+
+	field := &querypb.Field{Name: cs.SubqueryResult, Type: sqltypes.Int64}
+	if cs.Opcode == PulloutValue {
+		subqueryVars := maps.Clone(bindVars)
+		if subqueryVars == nil {
+			subqueryVars = make(map[string]*querypb.BindVariable, len(cs.Vars))
+		}
+		for k := range cs.Vars {
+			subqueryVars[k] = sqltypes.NullBindVariable
+		}
+		result, err := cs.Subquery.GetFields(ctx, vcursor, subqueryVars)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Fields) != 1 {
+			return nil, errSqColumn
+		}
+		field = result.Fields[0]
+	}
+	return append([]*querypb.Field{field}, outer...), nil
+}
+
+func (cs *CorrelatedSubquery) newState(vcursor VCursor) *correlatedState {
+	state := &correlatedState{
+		cols:  slices.Sorted(maps.Values(cs.Vars)),
+		cache: make(map[string]*correlatedResult),
+		usage: newMemoryUsage(vcursor),
+	}
+	for _, name := range cs.BatchKeys {
+		state.batchCols = append(state.batchCols, cs.Vars[name])
+	}
+	return state
+}
+
+// key returns the cache key for the outer values of the row
+func (state *correlatedState) key(row sqltypes.Row) string {
+	var key []byte
+	for _, col := range state.cols {
+		key = binary.AppendUvarint(key, uint64(row[col].Type()))
+		key = binary.AppendUvarint(key, uint64(row[col].Len()))
+		key = append(key, row[col].Raw()...)
+	}
+	return string(key)
+}
+
+// add caches the result for the outer values of the key, charging the memory tracker for it
+func (state *correlatedState) add(key string, res *correlatedResult, rows []sqltypes.Row) error {
+	if err := state.usage.consume(sqltypes.Row{sqltypes.NewVarBinary(key)}); err != nil {
+		return err
+	}
+	if err := state.usage.consume(rows...); err != nil {
+		return err
+	}
+	state.cache[key] = res
+	return nil
+}
+
+// evaluate runs the subquery for the outer rows, and returns the rows the predicate accepts,
+// or the rows with the subquery value added when there is no predicate
+func (cs *CorrelatedSubquery) evaluate(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, state *correlatedState, rows []sqltypes.Row) ([]sqltypes.Row, error) {
+	if cs.Batch != nil {
+		if err := cs.runBatches(ctx, vcursor, bindVars, state, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	// the predicate sees the bind variables of the query, and the ones holding the subquery result
+	predicateVars := make(map[string]*querypb.BindVariable, len(bindVars)+2)
+	maps.Copy(predicateVars, bindVars)
+	env := evalengine.NewExpressionEnv(ctx, predicateVars, vcursor)
+	var out []sqltypes.Row
+	for _, row := range rows {
+		res, err := cs.subqueryResult(ctx, vcursor, bindVars, state, row)
+		if err != nil {
+			return nil, err
+		}
+		if cs.Predicate == nil {
+			out = append(out, append(sqltypes.Row{res.value}, row...))
+			continue
+		}
+
+		maps.Copy(predicateVars, res.bindVars)
+		env.Row = row
+		evalResult, err := env.Evaluate(cs.Predicate)
+		if err != nil {
+			return nil, err
+		}
+		if evalResult.ToBoolean() {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (cs *CorrelatedSubquery) subqueryResult(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, state *correlatedState, row sqltypes.Row) (*correlatedResult, error) {
+	key := state.key(row)
+	if res, ok := state.cache[key]; ok {
+		return res, nil
+	}
+
+	joinVars := make(map[string]*querypb.BindVariable, len(bindVars)+len(cs.Vars))
+	maps.Copy(joinVars, bindVars)
+	for k, col := range cs.Vars {
+		joinVars[k] = sqltypes.ValueBindVariable(row[col])
+	}
+	result, err := vcursor.ExecutePrimitive(ctx, cs.Subquery, joinVars, false)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cs.newResult(result.Rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.add(key, res, result.Rows); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// newResult builds the result of the subquery from the rows it returned for one combination of outer values.
+// Only the bind variables holding the result are kept, not the ones used to run the subquery.
+func (cs *CorrelatedSubquery) newResult(rows []sqltypes.Row) (*correlatedResult, error) {
+	res := &correlatedResult{bindVars: make(map[string]*querypb.BindVariable, 2)}
+	if err := bindSubqueryResult(res.bindVars, cs.Opcode, cs.SubqueryResult, cs.HasValues, &sqltypes.Result{Rows: rows}); err != nil {
+		return nil, err
+	}
+	switch {
+	case cs.Opcode == PulloutExists:
+		res.value = sqltypes.NewInt64(0)
+		if len(rows) > 0 {
+			res.value = sqltypes.NewInt64(1)
+		}
+	case len(rows) > 0:
+		res.value = rows[0][0]
+	default:
+		res.value = sqltypes.NULL
+	}
+	return res, nil
+}
+
+// runBatches evaluates the subquery for the outer values of the rows that are not cached yet,
+// sending up to correlatedBatchSize combinations of them in each query
+func (cs *CorrelatedSubquery) runBatches(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, state *correlatedState, rows []sqltypes.Row) error {
+	var keys []string
+	var outer []sqltypes.Row
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		key := state.key(row)
+		if _, cached := state.cache[key]; cached || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+		outer = append(outer, row)
+		if len(keys) == correlatedBatchSize {
+			if err := cs.runBatch(ctx, vcursor, bindVars, state, keys, outer); err != nil {
+				return err
+			}
+			keys, outer = keys[:0], outer[:0]
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return cs.runBatch(ctx, vcursor, bindVars, state, keys, outer)
+}
+
+// runBatch evaluates the subquery for the outer values of the given rows in a single query,
+// and caches the result for each of them
+func (cs *CorrelatedSubquery) runBatch(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, state *correlatedState, keys []string, outer []sqltypes.Row) error {
+	hasher := vthash.New()
+	hashes := make([]*vthash.Hash, len(outer))
+	list := &querypb.BindVariable{Type: querypb.Type_TUPLE}
+	for i, row := range outer {
+		values := make([]sqltypes.Value, 0, len(state.batchCols))
+		for _, col := range state.batchCols {
+			values = append(values, row[col])
+		}
+		if slices.ContainsFunc(values, sqltypes.Value.IsNull) {
+			// a NULL is not equal to anything, so no inner row can match
+			continue
+		}
+		hash, err := cs.hashBatchKey(&hasher, values)
+		if err != nil {
+			return err
+		}
+		hashes[i] = &hash
+		if len(values) == 1 {
+			list.Values = append(list.Values, sqltypes.ValueToProto(values[0]))
+		} else {
+			list.Values = append(list.Values, sqltypes.TupleToProto(values))
+		}
+	}
+
+	matches := make(map[vthash.Hash][]sqltypes.Row)
+	if len(list.Values) > 0 {
+		batchVars := make(map[string]*querypb.BindVariable, len(bindVars)+1)
+		maps.Copy(batchVars, bindVars)
+		batchVars[cs.BatchVar] = list
+		result, err := vcursor.ExecutePrimitive(ctx, cs.Batch, batchVars, false)
+		if err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			hash, err := cs.hashBatchKey(&hasher, row[:len(cs.BatchKeys)])
+			if err != nil {
+				return err
+			}
+			matches[hash] = append(matches[hash], row[len(cs.BatchKeys):])
+		}
+	}
+
+	for i, key := range keys {
+		var rows []sqltypes.Row
+		if hashes[i] != nil {
+			rows = matches[*hashes[i]]
+		}
+		if len(rows) == 0 && cs.BatchFallback {
+			continue
+		}
+		res, err := cs.newResult(rows)
+		if err != nil {
+			return err
+		}
+		if err := state.add(key, res, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashBatchKey hashes the values the same way for the outer rows and the rows returned by Batch,
+// so the values that are equal in the comparison types have the same hash
+func (cs *CorrelatedSubquery) hashBatchKey(hasher *vthash.Hasher, values []sqltypes.Value) (vthash.Hash, error) {
+	defer hasher.Reset()
+	for i, value := range values {
+		typ := cs.BatchTypes[i]
+		if err := evalengine.NullsafeHashcode128(hasher, value, typ.Collation(), typ.Type(), 0, typ.Values()); err != nil {
+			return vthash.Hash{}, err
+		}
+	}
+	return hasher.Sum128(), nil
+}
+
+func (cs *CorrelatedSubquery) description() PrimitiveDescription {
+	other := map[string]any{}
+	var pulloutVars []string
+	if cs.HasValues != "" {
+		pulloutVars = append(pulloutVars, cs.HasValues)
+	}
+	if cs.SubqueryResult != "" {
+		pulloutVars = append(pulloutVars, cs.SubqueryResult)
+	}
+	if len(pulloutVars) > 0 {
+		other["PulloutVars"] = pulloutVars
+	}
+	if len(cs.Vars) > 0 {
+		other["JoinVars"] = orderedStringIntMap(cs.Vars)
+	}
+	if cs.Batch != nil {
+		other["BatchVar"] = cs.BatchVar
+		other["BatchKeys"] = cs.BatchKeys
+		if cs.BatchFallback {
+			other["BatchFallback"] = true
+		}
+	}
+	if cs.ASTPredicate != nil {
+		other["Predicate"] = sqlparser.String(cs.ASTPredicate)
+	}
+	return PrimitiveDescription{
+		OperatorType: "CorrelatedSubquery",
+		Variant:      cs.Opcode.String(),
+		Other:        other,
+	}
+}
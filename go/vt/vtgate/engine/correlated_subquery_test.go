@@ -0,0 +1,348 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func correlatedOuter() *fakePrimitive {
+	return &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"id|col",
+				"int64|int64",
+			),
+			"1|10",
+			"2|20",
+			"3|10",
+			"4|30",
+		)},
+	}
+}
+
+func translateForTest(t *testing.T, expr sqlparser.Expr, fields []*querypb.Field) evalengine.Expr {
+	pred, err := evalengine.Translate(expr, &evalengine.Config{
+		Collation:     collations.MySQL8().DefaultConnectionCharset(),
+		ResolveColumn: evalengine.FieldResolver(fields).Column,
+		Environment:   vtenv.NewTestEnv(),
+	})
+	require.NoError(t, err)
+	return pred
+}
+
+func TestCorrelatedSubqueryPredicate(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("max", "int64")
+	outer := correlatedOuter()
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(subFields, "1"),
+			sqltypes.MakeTestResult(subFields, "5"),
+			sqltypes.MakeTestResult(subFields),
+		},
+	}
+	predicate := &sqlparser.ComparisonExpr{
+		Operator: sqlparser.GreaterThanOp,
+		Left:     sqlparser.NewColName("id"),
+		Right:    sqlparser.NewArgument("__sq1"),
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"col": 1},
+		Predicate:      translateForTest(t, predicate, outer.results[0].Fields),
+		ASTPredicate:   predicate,
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	outer.ExpectLog(t, []string{
+		`Execute  true`,
+	})
+	// the subquery is only executed once for each distinct value of the outer column
+	subquery.ExpectLog(t, []string{
+		`Execute col: type:INT64 value:"10" false`,
+		`Execute col: type:INT64 value:"20" false`,
+		`Execute col: type:INT64 value:"30" false`,
+	})
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"id|col",
+			"int64|int64",
+		),
+		"3|10",
+	), r)
+}
+
+func TestCorrelatedSubqueryIn(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("id", "int64")
+	outer := correlatedOuter()
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(subFields, "1", "3"),
+			sqltypes.MakeTestResult(subFields, "4"),
+			sqltypes.MakeTestResult(subFields),
+		},
+	}
+	predicate := &sqlparser.OrExpr{
+		Left: sqlparser.NewNotExpr(sqlparser.NewArgument("__sq_has_values")),
+		Right: &sqlparser.ComparisonExpr{
+			Operator: sqlparser.NotInOp,
+			Left:     sqlparser.NewColName("id"),
+			Right:    sqlparser.NewListArg("__sq1"),
+		},
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutNotIn,
+		SubqueryResult: "__sq1",
+		HasValues:      "__sq_has_values",
+		Vars:           map[string]int{"col": 1},
+		Predicate:      translateForTest(t, predicate, outer.results[0].Fields),
+		ASTPredicate:   predicate,
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	r, err := wrapStreamExecute(cs, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"id|col",
+			"int64|int64",
+		),
+		"2|20",
+		"4|30",
+	), r)
+}
+
+func TestCorrelatedSubqueryValue(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("name", "varchar")
+	outer := correlatedOuter()
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(subFields, "a"),
+			sqltypes.MakeTestResult(subFields),
+			sqltypes.MakeTestResult(subFields, "c"),
+			// the last result is used by GetFields
+			sqltypes.MakeTestResult(subFields),
+		},
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"col": 1},
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"name|id|col",
+			"varchar|int64|int64",
+		),
+		"a|1|10",
+		"null|2|20",
+		"a|3|10",
+		"c|4|30",
+	), r)
+}
+
+func TestCorrelatedSubqueryExistsValue(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("1", "int64")
+	outer := correlatedOuter()
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(subFields),
+			sqltypes.MakeTestResult(subFields, "1"),
+			sqltypes.MakeTestResult(subFields, "1"),
+		},
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:   PulloutExists,
+		Vars:     map[string]int{"col": 1},
+		Outer:    outer,
+		Subquery: subquery,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	utils.MustMatch(t, []sqltypes.Row{
+		{sqltypes.NewInt64(0), sqltypes.NewInt64(1), sqltypes.NewInt64(10)},
+		{sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(20)},
+		{sqltypes.NewInt64(0), sqltypes.NewInt64(3), sqltypes.NewInt64(10)},
+		{sqltypes.NewInt64(1), sqltypes.NewInt64(4), sqltypes.NewInt64(30)},
+	}, r.Rows)
+}
+
+func TestCorrelatedSubqueryTooManyRows(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("id", "int64")
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"col": 1},
+		Outer:          correlatedOuter(),
+		Subquery: &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(subFields, "1", "2")},
+		},
+	}
+
+	_, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, false)
+	require.EqualError(t, err, "subquery returned more than one row")
+}
+
+func batchOuter() *fakePrimitive {
+	return &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"id|col",
+				"int64|int64",
+			),
+			"1|10",
+			"2|20",
+			"3|10",
+			"4|30",
+			"5|null",
+		)},
+	}
+}
+
+func TestCorrelatedSubqueryBatch(t *testing.T) {
+	outer := batchOuter()
+	subquery := &fakePrimitive{}
+	batch := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("col|id", "int64|int64"),
+			"10|1",
+			"30|5",
+			"10|3",
+		)},
+	}
+	predicate := &sqlparser.AndExpr{
+		Left: sqlparser.NewArgument("__sq_has_values"),
+		Right: &sqlparser.ComparisonExpr{
+			Operator: sqlparser.InOp,
+			Left:     sqlparser.NewColName("id"),
+			Right:    sqlparser.ListArg("__sq1"),
+		},
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutIn,
+		SubqueryResult: "__sq1",
+		HasValues:      "__sq_has_values",
+		Vars:           map[string]int{"col": 1},
+		Predicate:      translateForTest(t, predicate, outer.results[0].Fields),
+		ASTPredicate:   predicate,
+		Outer:          outer,
+		Subquery:       subquery,
+		Batch:          batch,
+		BatchVar:       "sq_batch",
+		BatchKeys:      []string{"col"},
+		BatchTypes:     []evalengine.Type{evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+	}
+
+	vc := &noopVCursor{memory: NewMemoryTracker("query", 0, nil)}
+	r, err := cs.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	// all the outer values are sent in a single query, except for the NULL that can't match anything
+	batch.ExpectLog(t, []string{
+		`Execute sq_batch: type:TUPLE values:{type:INT64 value:"10"} values:{type:INT64 value:"20"} values:{type:INT64 value:"30"} false`,
+	})
+	subquery.ExpectLog(t, nil)
+	utils.MustMatch(t, []sqltypes.Row{
+		{sqltypes.NewInt64(1), sqltypes.NewInt64(10)},
+		{sqltypes.NewInt64(3), sqltypes.NewInt64(10)},
+	}, r.Rows)
+
+	// the cached results are charged to the query, and released once it is done
+	require.Zero(t, vc.memory.Used())
+	require.NotZero(t, vc.memory.Peak())
+
+	outer.rewind()
+	batch.rewind()
+	vc = &noopVCursor{memory: NewMemoryTracker("query", 100, nil)}
+	_, err = cs.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.EqualError(t, err, "query memory exceeded allowed limit of 100 bytes")
+	require.Zero(t, vc.memory.Used())
+}
+
+func TestCorrelatedSubqueryBatchFallback(t *testing.T) {
+	subFields := sqltypes.MakeTestFields("count(*)", "int64")
+	outer := batchOuter()
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(subFields, "0"),
+			sqltypes.MakeTestResult(subFields, "0"),
+		},
+	}
+	batch := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("col|count(*)", "int64|int64"),
+			"10|2",
+			"30|1",
+		)},
+	}
+
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"col": 1},
+		Outer:          outer,
+		Subquery:       subquery,
+		Batch:          batch,
+		BatchVar:       "sq_batch",
+		BatchKeys:      []string{"col"},
+		BatchTypes:     []evalengine.Type{evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+		BatchFallback:  true,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	// an aggregation returns a row even when nothing matches, so the values missing
+	// from the batch are evaluated one at a time
+	subquery.ExpectLog(t, []string{
+		`Execute col: type:INT64 value:"20" false`,
+		`Execute col:  false`,
+	})
+	utils.MustMatch(t, []sqltypes.Row{
+		{sqltypes.NewInt64(2), sqltypes.NewInt64(1), sqltypes.NewInt64(10)},
+		{sqltypes.NewInt64(0), sqltypes.NewInt64(2), sqltypes.NewInt64(20)},
+		{sqltypes.NewInt64(2), sqltypes.NewInt64(3), sqltypes.NewInt64(10)},
+		{sqltypes.NewInt64(1), sqltypes.NewInt64(4), sqltypes.NewInt64(30)},
+		{sqltypes.NewInt64(0), sqltypes.NewInt64(5), sqltypes.NULL},
+	}, r.Rows)
+}
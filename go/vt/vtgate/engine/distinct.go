@@ -67,6 +67,18 @@ func (pt *probeTable) exists(inputRow sqltypes.Row) (sqltypes.Row, error) {
 	return inputRow, nil
 }
 
+// spillIfNotSeen writes the row to its partition on disk, unless it is a duplicate of a row already in the probe table
+func (pt *probeTable) spillIfNotSeen(partitions *spillPartitions, inputRow sqltypes.Row) error {
+	code, err := pt.hashCodeForRow(inputRow)
+	if err != nil {
+		return err
+	}
+	if _, found := pt.seenRows[code]; found {
+		return nil
+	}
+	return partitions.write(code, inputRow)
+}
+
 func (pt *probeTable) hashCodeForRow(inputRow sqltypes.Row) (vthash.Hash, error) {
 	hasher := vthash.New()
 	for i, checkCol := range pt.checkCols {
@@ -102,6 +114,10 @@ func newProbeTable(checkCols []CheckCol, collationEnv *collations.Environment) *
 
 // TryExecute implements the Primitive interface
 func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.Spiller() != nil {
+		// the input is streamed, so that it is spilled to disk instead of being read into memory at once
+		return executeStreaming(ctx, vcursor, d, bindVars, wantfields)
+	}
 	input, err := vcursor.ExecutePrimitive(ctx, d.Source, bindVars, wantfields)
 	if err != nil {
 		return nil, err
@@ -113,6 +129,8 @@ func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 	}
 
 	pt := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
 
 	for _, row := range input.Rows {
 		appendRow, err := pt.exists(row)
@@ -120,6 +138,9 @@ func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 			return nil, err
 		}
 		if appendRow != nil {
+			if err := usage.consume(appendRow); err != nil {
+				return nil, err
+			}
 			result.Rows = append(result.Rows, appendRow)
 		}
 	}
@@ -134,6 +155,13 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 	var mu sync.Mutex
 
 	pt := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+	// once the probe table is full, rows that have not been seen yet are partitioned to disk
+	var partitions *spillPartitions
+	defer func() {
+		partitions.close()
+	}()
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
 	err := vcursor.StreamExecutePrimitive(ctx, d.Source, bindVars, wantfields, func(input *sqltypes.Result) error {
 		result := &sqltypes.Result{
 			Fields:   input.Fields,
@@ -142,18 +170,93 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 		mu.Lock()
 		defer mu.Unlock()
 		for _, row := range input.Rows {
+			if partitions != nil {
+				if err := pt.spillIfNotSeen(partitions, row); err != nil {
+					return err
+				}
+				continue
+			}
 			appendRow, err := pt.exists(row)
 			if err != nil {
 				return err
 			}
 			if appendRow != nil {
+				// the probe table keeps the row to find its duplicates
+				if err := usage.consume(appendRow); err != nil {
+					return err
+				}
 				result.Rows = append(result.Rows, appendRow)
 			}
 		}
+		if partitions == nil && vcursor.ExceedsMaxMemoryRows(len(pt.seenRows)) {
+			if spiller := vcursor.Spiller(); spiller != nil {
+				var err error
+				if partitions, err = spiller.newPartitions(0); err != nil {
+					return err
+				}
+			}
+		}
 		return callback(result.Truncate(len(d.CheckCols)))
 	})
+	if err != nil || partitions == nil {
+		return err
+	}
+
+	// the rows in a partition can only be duplicates of other rows in the same partition,
+	// so each partition can be made unique on its own
+	return partitions.forEach(func(_ int, file *spillFile) error {
+		return d.distinctPartition(vcursor, partitions.depth, file, callback)
+	})
+}
 
-	return err
+// distinctPartition sends the rows of a partition that are not duplicates. When the partition
+// has more distinct rows than fit in memory, the rows that have not been seen yet are split
+// again with another seed, and the new partitions are made unique in turn.
+func (d *Distinct) distinctPartition(vcursor VCursor, depth int, file *spillFile, callback func(*sqltypes.Result) error) error {
+	partition := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+	var partitions *spillPartitions
+	defer func() {
+		partitions.close()
+	}()
+	var rows []sqltypes.Row
+	err := file.readAll(func(row sqltypes.Row) error {
+		if partitions != nil {
+			return partition.spillIfNotSeen(partitions, row)
+		}
+		appendRow, err := partition.exists(row)
+		if err != nil || appendRow == nil {
+			return err
+		}
+		rows = append(rows, appendRow)
+		if vcursor.ExceedsMaxMemoryRows(len(partition.seenRows)) {
+			if depth == spillMaxRepartitions {
+				return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+			}
+			if partitions, err = vcursor.Spiller().newPartitions(depth + 1); err != nil {
+				return err
+			}
+		}
+		if len(rows) < spillReadBatchSize {
+			return nil
+		}
+		err = callback((&sqltypes.Result{Rows: rows}).Truncate(len(d.CheckCols)))
+		rows = nil
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		if err := callback((&sqltypes.Result{Rows: rows}).Truncate(len(d.CheckCols))); err != nil {
+			return err
+		}
+	}
+	if partitions == nil {
+		return nil
+	}
+	return partitions.forEach(func(_ int, file *spillFile) error {
+		return d.distinctPartition(vcursor, partitions.depth, file, callback)
+	})
 }
 
 // RouteType implements the Primitive interface
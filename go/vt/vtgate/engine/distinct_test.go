@@ -184,6 +184,95 @@ func TestDistinctStreamAsync(t *testing.T) {
 [VARCHAR("a") INT64(1) INT64(1) VARCHAR("t")]]`, qr.Rows))
 }
 
+func TestDistinctSpillToDisk(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+	}()
+
+	distinct := &Distinct{
+		Source: &fakePrimitive{
+			results: sqltypes.MakeTestStreamingResults(sqltypes.MakeTestFields("myid|id", "varchar|int64"),
+				"a|1",
+				"a|1",
+				"b|1",
+				"---",
+				"c|1",
+				"a|1",
+				"z|null",
+				"a|2",
+				"---",
+				"c|1",
+				"z|null",
+				"b|1",
+				"a|2",
+			),
+			allResultsInOneCall: true,
+		},
+		CheckCols: []CheckCol{
+			{Col: 0, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+			{Col: 1, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+		},
+	}
+
+	vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+	qr, err := wrapStreamExecute(distinct, vc, nil, true)
+	require.NoError(t, err)
+	require.NotZero(t, vc.spiller.SpilledBytes())
+	expectResultAnyOrder(t, qr, sqltypes.MakeTestResult(sqltypes.MakeTestFields("myid|id", "varchar|int64"),
+		"a|1",
+		"b|1",
+		"c|1",
+		"z|null",
+		"a|2",
+	))
+
+	// the Execute path spills the same way
+	distinct.Source.(*fakePrimitive).rewind()
+	vc = &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+	qr, err = distinct.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	require.NotZero(t, vc.spiller.SpilledBytes())
+	expectResultAnyOrder(t, qr, sqltypes.MakeTestResult(sqltypes.MakeTestFields("myid|id", "varchar|int64"),
+		"a|1",
+		"b|1",
+		"c|1",
+		"z|null",
+		"a|2",
+	))
+}
+
+func TestDistinctSpillRepartition(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+	}()
+
+	// there are more distinct values than fit in memory in any partition,
+	// so the partitions have to be split again when they are read back
+	fields := sqltypes.MakeTestFields("id", "int64")
+	var rows, expected []string
+	for i := 0; i < 200; i++ {
+		rows = append(rows, fmt.Sprintf("%d", i%100))
+		if i < 100 {
+			expected = append(expected, fmt.Sprintf("%d", i))
+		}
+	}
+	distinct := &Distinct{
+		Source: &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, rows...)}},
+		CheckCols: []CheckCol{
+			{Col: 0, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+		},
+	}
+
+	vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+	qr, err := distinct.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	expectResultAnyOrder(t, qr, sqltypes.MakeTestResult(fields, expected...))
+}
+
 func TestWeightStringFallBack(t *testing.T) {
 	offsetOne := 1
 	checkCols := []CheckCol{{
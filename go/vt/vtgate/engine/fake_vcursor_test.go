@@ -58,7 +58,11 @@ var (
 
 // noopVCursor is used to build other vcursors.
 type noopVCursor struct {
-	inTx bool
+	inTx      bool
+	spiller   *Spiller
+	memory    *MemoryTracker
+	limiter   *ScatterLimiter
+	sequences *SequenceCache
 }
 
 func (t *noopVCursor) SetExecQueryTimeout(timeout *int) {
@@ -188,7 +192,6 @@ func (t *noopVCursor) HasSystemVariables() bool {
 }
 
 func (t *noopVCursor) GetSystemVariables(func(k string, v string)) {
-	panic("implement me")
 }
 
 func (t *noopVCursor) GetWarnings() []*querypb.QueryWarning {
@@ -357,6 +360,22 @@ func (t *noopVCursor) ExceedsMaxMemoryRows(numRows int) bool {
 	return !testIgnoreMaxMemoryRows && numRows > testMaxMemoryRows
 }
 
+func (t *noopVCursor) Spiller() *Spiller {
+	return t.spiller
+}
+
+func (t *noopVCursor) MemoryTracker() *MemoryTracker {
+	return t.memory
+}
+
+func (t *noopVCursor) ScatterLimiter() *ScatterLimiter {
+	return t.limiter
+}
+
+func (t *noopVCursor) SequenceCache() *SequenceCache {
+	return t.sequences
+}
+
 func (t *noopVCursor) GetKeyspace() string {
 	return "test_ks"
 }
@@ -493,8 +512,10 @@ func (f *loggingVCursor) HasSystemVariables() bool {
 	return len(f.systemVariables) > 0
 }
 
-func (f *loggingVCursor) GetSystemVariables(func(k string, v string)) {
-	panic("implement me")
+func (f *loggingVCursor) GetSystemVariables(fn func(k string, v string)) {
+	for k, v := range f.systemVariables {
+		fn(k, v)
+	}
 }
 
 func (f *loggingVCursor) SetFoundRows(u uint64) {
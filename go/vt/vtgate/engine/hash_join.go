@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -38,7 +39,9 @@ type (
 	// Hash joins work by fetch all the input from the LHS, and building a hash map, known as the probe table, for this input.
 	// The key to the map is the hashcode of the value for column that we are joining by.
 	// Then the RHS is fetched, and we can check if the rows from the RHS matches any from the LHS.
-	// When they match by hash code, we double-check that we are not working with a false positive by comparing the values.
+	// When there are multiple join keys, the values of all of them are hashed together.
+	// Predicates that can't be solved using the hash code, such as non-equality comparisons,
+	// are evaluated as a residual predicate on the rows that matched by hash code.
 	HashJoin struct {
 		Opcode JoinOpcode
 
@@ -55,33 +58,39 @@ type (
 		// the returned result will be {Left0, Left1, Right0, Right1}.
 		Cols []int
 
-		// The keys correspond to the column offset in the inputs where
+		// The keys correspond to the column offsets in the inputs where
 		// the join columns can be found
-		LHSKey, RHSKey int
+		LHSKeys, RHSKeys []int
 
 		// The join condition. Used for plan descriptions
 		ASTPred sqlparser.Expr
 
-		// collation and type are used to hash the incoming values correctly
-		Collation      collations.ID
-		ComparisonType querypb.Type
+		// ComparisonTypes are used to hash the incoming values of each key correctly
+		ComparisonTypes []evalengine.Type
 
 		CollationEnv *collations.Environment
 
-		// Values for enum and set types
-		Values *evalengine.EnumSetValues
+		// Residual is evaluated on the rows that matched by hash code,
+		// and only the rows where it is true are joined.
+		// ResidualCols builds the row the residual is evaluated on,
+		// using the same notation as Cols.
+		Residual     evalengine.Expr
+		ResidualCols []int
 	}
 
 	hashJoinProbeTable struct {
 		innerMap map[vthash.Hash]*probeTableEntry
+		rows     int
 
-		coll           collations.ID
-		typ            querypb.Type
-		lhsKey, rhsKey int
-		cols           []int
-		hasher         vthash.Hasher
-		sqlmode        evalengine.SQLMode
-		values         *evalengine.EnumSetValues
+		types            []evalengine.Type
+		lhsKeys, rhsKeys []int
+		cols             []int
+		hasher           vthash.Hasher
+		sqlmode          evalengine.SQLMode
+
+		residual     evalengine.Expr
+		residualCols []int
+		env          *evalengine.ExpressionEnv
 	}
 
 	probeTableEntry struct {
@@ -93,13 +102,22 @@ type (
 
 // TryExecute implements the Primitive interface
 func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.Spiller() != nil {
+		// the input is streamed, so that it is spilled to disk instead of being read into memory at once
+		return executeStreaming(ctx, vcursor, hj, bindVars, wantfields)
+	}
 	lresult, err := vcursor.ExecutePrimitive(ctx, hj.Left, bindVars, wantfields)
 	if err != nil {
 		return nil, err
 	}
 
-	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
+	pt := hj.newProbeTable(ctx, vcursor, bindVars)
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
 	// build the probe table from the LHS result
+	if err := usage.consume(lresult.Rows...); err != nil {
+		return nil, err
+	}
 	for _, row := range lresult.Rows {
 		err := pt.addLeftRow(row)
 		if err != nil {
@@ -121,11 +139,18 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 		if err != nil {
 			return nil, err
 		}
+		if err := usage.consume(matches...); err != nil {
+			return nil, err
+		}
 		result.Rows = append(result.Rows, matches...)
 	}
 
 	if hj.Opcode == LeftJoin {
-		result.Rows = append(result.Rows, pt.notFetched()...)
+		notFetched := pt.notFetched()
+		if err := usage.consume(notFetched...); err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, notFetched...)
 	}
 
 	return result, nil
@@ -134,9 +159,16 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 // TryStreamExecute implements the Primitive interface
 func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	// build the probe table from the LHS result
-	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
+	pt := hj.newProbeTable(ctx, vcursor, bindVars)
 	var lfields []*querypb.Field
 	var mu sync.Mutex
+	// once the LHS does not fit in memory anymore, its rows are partitioned to disk
+	var lhsPartitions *spillPartitions
+	defer func() {
+		lhsPartitions.close()
+	}()
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
 	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
 		mu.Lock()
 		defer mu.Unlock()
@@ -144,17 +176,34 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 			lfields = result.Fields
 		}
 		for _, current := range result.Rows {
-			err := pt.addLeftRow(current)
+			var err error
+			if lhsPartitions != nil {
+				err = pt.spillLeftRow(lhsPartitions, current)
+			} else if err = pt.addLeftRow(current); err == nil {
+				err = usage.consume(current)
+			}
 			if err != nil {
 				return err
 			}
 		}
+		if lhsPartitions == nil && vcursor.ExceedsMaxMemoryRows(pt.rows) {
+			if spiller := vcursor.Spiller(); spiller != nil {
+				var err error
+				lhsPartitions, err = pt.spill(spiller, 0)
+				usage.release()
+				return err
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	if lhsPartitions != nil {
+		return hj.streamPartitioned(ctx, vcursor, bindVars, wantfields, lfields, lhsPartitions, callback)
+	}
+
 	var sendFields atomic.Bool
 	sendFields.Store(wantfields)
 
@@ -201,6 +250,160 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 	return nil
 }
 
+// streamPartitioned is used when the LHS did not fit in memory, and has been partitioned to disk.
+// The RHS is partitioned in the same way, and then each pair of partitions is joined in memory.
+func (hj *HashJoin) streamPartitioned(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, lfields []*querypb.Field, lhsPartitions *spillPartitions, callback func(*sqltypes.Result) error) error {
+	pt := hj.newProbeTable(ctx, vcursor, bindVars)
+	rhsPartitions, err := vcursor.Spiller().newPartitions(lhsPartitions.depth)
+	if err != nil {
+		return err
+	}
+	defer rhsPartitions.close()
+
+	var rfields []*querypb.Field
+	var mu sync.Mutex
+	err = vcursor.StreamExecutePrimitive(ctx, hj.Right, bindVars, wantfields, func(result *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(rfields) == 0 && len(result.Fields) != 0 {
+			rfields = result.Fields
+		}
+		for _, current := range result.Rows {
+			if err := pt.spillRightRow(rhsPartitions, current); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if wantfields {
+		if len(rfields) == 0 {
+			rres, err := hj.Right.GetFields(ctx, vcursor, bindVars)
+			if err != nil {
+				return err
+			}
+			rfields = rres.Fields
+		}
+		if err := callback(&sqltypes.Result{Fields: joinFields(lfields, rfields, hj.Cols)}); err != nil {
+			return err
+		}
+	}
+
+	var rows []sqltypes.Row
+	emit := func(matches []sqltypes.Row) error {
+		rows = append(rows, matches...)
+		if len(rows) < spillReadBatchSize {
+			return nil
+		}
+		err := callback(&sqltypes.Result{Rows: rows})
+		rows = nil
+		return err
+	}
+	err = lhsPartitions.forEach(func(i int, lhs *spillFile) error {
+		return hj.joinPartition(ctx, vcursor, bindVars, lhsPartitions.depth, lhs, rhsPartitions.files[i], emit)
+	})
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	return callback(&sqltypes.Result{Rows: rows})
+}
+
+// joinPartition joins a partition of the LHS with the same partition of the RHS. When the LHS
+// partition does not fit in memory, both are split again with another seed, unless they have
+// been split too many times already, as the rows then mostly share the same join key. The LHS
+// partition is then joined a chunk of rows at a time, reading the RHS partition for each chunk.
+func (hj *HashJoin) joinPartition(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, depth int, lhs, rhs *spillFile, emit func([]sqltypes.Row) error) error {
+	if err := lhs.rewind(); err != nil {
+		return err
+	}
+	pt := hj.newProbeTable(ctx, vcursor, bindVars)
+	for {
+		row, err := lhs.read()
+		if err == io.EOF {
+			if pt.rows == 0 {
+				return nil
+			}
+			return hj.probePartition(pt, rhs, emit)
+		}
+		if err != nil {
+			return err
+		}
+		if err := pt.addLeftRow(row); err != nil {
+			return err
+		}
+		if !vcursor.ExceedsMaxMemoryRows(pt.rows) {
+			continue
+		}
+		if depth < spillMaxRepartitions {
+			return hj.repartition(ctx, vcursor, bindVars, depth+1, pt, lhs, rhs, emit)
+		}
+		if err := hj.probePartition(pt, rhs, emit); err != nil {
+			return err
+		}
+		pt = hj.newProbeTable(ctx, vcursor, bindVars)
+	}
+}
+
+// repartition splits a partition of the LHS, whose rows in the probe table have been read
+// already, and the same partition of the RHS, and then joins the new partitions.
+func (hj *HashJoin) repartition(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, depth int, pt *hashJoinProbeTable, lhs, rhs *spillFile, emit func([]sqltypes.Row) error) error {
+	spiller := vcursor.Spiller()
+	lhsPartitions, err := pt.spill(spiller, depth)
+	if err != nil {
+		return err
+	}
+	defer lhsPartitions.close()
+	err = lhs.readAll(func(row sqltypes.Row) error {
+		return pt.spillLeftRow(lhsPartitions, row)
+	})
+	if err != nil {
+		return err
+	}
+
+	rhsPartitions, err := spiller.newPartitions(depth)
+	if err != nil {
+		return err
+	}
+	defer rhsPartitions.close()
+	if err := rhs.rewind(); err != nil {
+		return err
+	}
+	err = rhs.readAll(func(row sqltypes.Row) error {
+		return pt.spillRightRow(rhsPartitions, row)
+	})
+	if err != nil {
+		return err
+	}
+
+	return lhsPartitions.forEach(func(i int, lhs *spillFile) error {
+		return hj.joinPartition(ctx, vcursor, bindVars, depth, lhs, rhsPartitions.files[i], emit)
+	})
+}
+
+// probePartition joins the rows of a partition of the RHS with the LHS rows in the probe table
+func (hj *HashJoin) probePartition(pt *hashJoinProbeTable, rhs *spillFile, emit func([]sqltypes.Row) error) error {
+	if err := rhs.rewind(); err != nil {
+		return err
+	}
+	err := rhs.readAll(func(row sqltypes.Row) error {
+		matches, err := pt.get(row)
+		if err != nil {
+			return err
+		}
+		return emit(matches)
+	})
+	if err != nil {
+		return err
+	}
+	if hj.Opcode == LeftJoin {
+		return emit(pt.notFetched())
+	}
+	return nil
+}
+
 // RouteType implements the Primitive interface
 func (hj *HashJoin) RouteType() string {
 	return "HashJoin"
@@ -251,11 +454,17 @@ func (hj *HashJoin) description() PrimitiveDescription {
 		"TableName":         hj.GetTableName(),
 		"JoinColumnIndexes": strings.Trim(strings.Join(strings.Fields(fmt.Sprint(hj.Cols)), ","), "[]"),
 		"Predicate":         sqlparser.String(hj.ASTPred),
-		"ComparisonType":    hj.ComparisonType.String(),
 	}
-	coll := hj.Collation
-	if coll != collations.Unknown {
-		other["Collation"] = hj.CollationEnv.LookupName(coll)
+	var types, colls []string
+	for _, typ := range hj.ComparisonTypes {
+		types = append(types, typ.Type().String())
+		if coll := typ.Collation(); coll != collations.Unknown {
+			colls = append(colls, hj.CollationEnv.LookupName(coll))
+		}
+	}
+	other["ComparisonType"] = strings.Join(types, ", ")
+	if len(colls) == len(types) {
+		other["Collation"] = strings.Join(colls, ", ")
 	}
 	return PrimitiveDescription{
 		OperatorType: "Join",
@@ -264,21 +473,25 @@ func (hj *HashJoin) description() PrimitiveDescription {
 	}
 }
 
-func newHashJoinProbeTable(coll collations.ID, typ querypb.Type, lhsKey, rhsKey int, cols []int, values *evalengine.EnumSetValues) *hashJoinProbeTable {
-	return &hashJoinProbeTable{
-		innerMap: map[vthash.Hash]*probeTableEntry{},
-		coll:     coll,
-		typ:      typ,
-		lhsKey:   lhsKey,
-		rhsKey:   rhsKey,
-		cols:     cols,
-		hasher:   vthash.New(),
-		values:   values,
+func (hj *HashJoin) newProbeTable(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) *hashJoinProbeTable {
+	pt := &hashJoinProbeTable{
+		innerMap:     map[vthash.Hash]*probeTableEntry{},
+		types:        hj.ComparisonTypes,
+		lhsKeys:      hj.LHSKeys,
+		rhsKeys:      hj.RHSKeys,
+		cols:         hj.Cols,
+		hasher:       vthash.New(),
+		residual:     hj.Residual,
+		residualCols: hj.ResidualCols,
 	}
+	if hj.Residual != nil {
+		pt.env = evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	}
+	return pt
 }
 
 func (pt *hashJoinProbeTable) addLeftRow(r sqltypes.Row) error {
-	hash, err := pt.hash(r[pt.lhsKey])
+	hash, err := pt.hash(r, pt.lhsKeys)
 	if err != nil {
 		return err
 	}
@@ -286,33 +499,90 @@ func (pt *hashJoinProbeTable) addLeftRow(r sqltypes.Row) error {
 		row:  r,
 		next: pt.innerMap[hash],
 	}
+	pt.rows++
 
 	return nil
 }
 
-func (pt *hashJoinProbeTable) hash(val sqltypes.Value) (vthash.Hash, error) {
-	err := evalengine.NullsafeHashcode128(&pt.hasher, val, pt.coll, pt.typ, pt.sqlmode, pt.values)
+// spill moves all the LHS rows in the probe table to disk partitions of the given depth
+func (pt *hashJoinProbeTable) spill(spiller *Spiller, depth int) (*spillPartitions, error) {
+	partitions, err := spiller.newPartitions(depth)
+	if err != nil {
+		return nil, err
+	}
+	for hash, e := range pt.innerMap {
+		for ; e != nil; e = e.next {
+			if err := partitions.write(hash, e.row); err != nil {
+				partitions.close()
+				return nil, err
+			}
+		}
+	}
+	pt.innerMap = map[vthash.Hash]*probeTableEntry{}
+	pt.rows = 0
+	return partitions, nil
+}
+
+func (pt *hashJoinProbeTable) spillLeftRow(partitions *spillPartitions, r sqltypes.Row) error {
+	hash, err := pt.hash(r, pt.lhsKeys)
 	if err != nil {
-		return vthash.Hash{}, err
+		return err
 	}
+	return partitions.write(hash, r)
+}
 
-	res := pt.hasher.Sum128()
-	pt.hasher.Reset()
-	return res, nil
+func (pt *hashJoinProbeTable) spillRightRow(partitions *spillPartitions, r sqltypes.Row) error {
+	if hasNullKey(r, pt.rhsKeys) {
+		// a NULL can never match any row on the LHS
+		return nil
+	}
+	hash, err := pt.hash(r, pt.rhsKeys)
+	if err != nil {
+		return err
+	}
+	return partitions.write(hash, r)
+}
+
+// hash calculates the hash code of the values in the key columns of the row
+func (pt *hashJoinProbeTable) hash(r sqltypes.Row, keys []int) (vthash.Hash, error) {
+	defer pt.hasher.Reset()
+	for i, key := range keys {
+		typ := pt.types[i]
+		err := evalengine.NullsafeHashcode128(&pt.hasher, r[key], typ.Collation(), typ.Type(), pt.sqlmode, typ.Values())
+		if err != nil {
+			return vthash.Hash{}, err
+		}
+	}
+	return pt.hasher.Sum128(), nil
+}
+
+func hasNullKey(r sqltypes.Row, keys []int) bool {
+	for _, key := range keys {
+		if r[key].IsNull() {
+			return true
+		}
+	}
+	return false
 }
 
 func (pt *hashJoinProbeTable) get(rrow sqltypes.Row) (result []sqltypes.Row, err error) {
-	val := rrow[pt.rhsKey]
-	if val.IsNull() {
+	if hasNullKey(rrow, pt.rhsKeys) {
 		return
 	}
 
-	hash, err := pt.hash(val)
+	hash, err := pt.hash(rrow, pt.rhsKeys)
 	if err != nil {
 		return nil, err
 	}
 
 	for e := pt.innerMap[hash]; e != nil; e = e.next {
+		match, err := pt.residualMatches(e.row, rrow)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			continue
+		}
 		e.seen = true
 		result = append(result, joinRows(e.row, rrow, pt.cols))
 	}
@@ -320,6 +590,19 @@ func (pt *hashJoinProbeTable) get(rrow sqltypes.Row) (result []sqltypes.Row, err
 	return
 }
 
+// residualMatches evaluates the residual predicate, if there is one, on the two rows
+func (pt *hashJoinProbeTable) residualMatches(lrow, rrow sqltypes.Row) (bool, error) {
+	if pt.residual == nil {
+		return true, nil
+	}
+	pt.env.Row = joinRows(lrow, rrow, pt.residualCols)
+	res, err := pt.env.Evaluate(pt.residual)
+	if err != nil {
+		return false, err
+	}
+	return res.ToBoolean(), nil
+}
+
 func (pt *hashJoinProbeTable) notFetched() (rows []sqltypes.Row) {
 	for _, e := range pt.innerMap {
 		for ; e != nil; e = e.next {
@@ -18,6 +18,7 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -25,6 +26,8 @@ import (
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 )
 
@@ -136,13 +139,12 @@ func TestHashJoinVariations(t *testing.T) {
 		require.NoError(t, err)
 
 		jn := &HashJoin{
-			Opcode:         tc.typ,
-			Cols:           []int{-1, -2, 1, 2},
-			LHSKey:         tc.lhs,
-			RHSKey:         tc.rhs,
-			Collation:      typ.Collation(),
-			ComparisonType: typ.Type(),
-			CollationEnv:   collations.MySQL8(),
+			Opcode:          tc.typ,
+			Cols:            []int{-1, -2, 1, 2},
+			LHSKeys:         []int{tc.lhs},
+			RHSKeys:         []int{tc.rhs},
+			ComparisonTypes: []evalengine.Type{typ},
+			CollationEnv:    collations.MySQL8(),
 		}
 
 		t.Run(tc.name, func(t *testing.T) {
@@ -159,6 +161,186 @@ func TestHashJoinVariations(t *testing.T) {
 			require.NoError(t, err)
 			expectResultAnyOrder(t, r, expected)
 		})
+		t.Run("Spilled streaming "+tc.name, func(t *testing.T) {
+			saveMax := testMaxMemoryRows
+			testMaxMemoryRows = 1
+			defer func() {
+				testMaxMemoryRows = saveMax
+			}()
+
+			jn.Left = first()
+			jn.Right = last()
+			vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+			r, err := wrapStreamExecute(jn, vc, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, expected)
+			require.NotZero(t, vc.spiller.SpilledBytes())
+		})
+		t.Run("Spilled "+tc.name, func(t *testing.T) {
+			saveMax := testMaxMemoryRows
+			testMaxMemoryRows = 1
+			defer func() {
+				testMaxMemoryRows = saveMax
+			}()
+
+			jn.Left = first()
+			jn.Right = last()
+			vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+			r, err := jn.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, expected)
+			require.NotZero(t, vc.spiller.SpilledBytes())
+		})
+	}
+}
+
+func TestHashJoinSpillSkewedKeys(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+	}()
+
+	// most rows have the same key, so the partition they end up in never
+	// fits in memory, however many times it is split again
+	var lhsRows, rhsRows, expected []string
+	for i := 0; i < 5; i++ {
+		lhsRows = append(lhsRows, fmt.Sprintf("1|l%d", i))
+		for j := 0; j < 3; j++ {
+			expected = append(expected, fmt.Sprintf("1|l%d|1|r%d", i, j))
+		}
+	}
+	for j := 0; j < 3; j++ {
+		rhsRows = append(rhsRows, fmt.Sprintf("1|r%d", j))
+	}
+	lhsRows = append(lhsRows, "2|l5")
+	rhsRows = append(rhsRows, "3|r3")
+
+	for _, opcode := range []JoinOpcode{InnerJoin, LeftJoin} {
+		t.Run(opcode.String(), func(t *testing.T) {
+			want := expected
+			if opcode == LeftJoin {
+				want = append(want[:len(want):len(want)], "2|l5|null|null")
+			}
+			jn := &HashJoin{
+				Opcode:          opcode,
+				Left:            &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(sqltypes.MakeTestFields("col1|col2", "int64|varchar"), lhsRows...)}},
+				Right:           &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(sqltypes.MakeTestFields("col3|col4", "int64|varchar"), rhsRows...)}},
+				Cols:            []int{-1, -2, 1, 2},
+				LHSKeys:         []int{0},
+				RHSKeys:         []int{0},
+				ComparisonTypes: []evalengine.Type{evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+				CollationEnv:    collations.MySQL8(),
+			}
+			vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+			r, err := jn.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, sqltypes.MakeTestResult(sqltypes.MakeTestFields("col1|col2|col3|col4", "int64|varchar|int64|varchar"), want...))
+		})
+	}
+}
+
+func TestHashJoinMultipleKeysAndResidual(t *testing.T) {
+	lhs := func() Primitive {
+		return &fakePrimitive{
+			results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(
+					sqltypes.MakeTestFields(
+						"col1|col2|col3",
+						"int64|varchar|int64",
+					),
+					"1|a|10",
+					"1|b|20",
+					"2|a|30",
+					"2|null|40",
+				),
+			},
+		}
+	}
+	rhs := func() Primitive {
+		return &fakePrimitive{
+			results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(
+					sqltypes.MakeTestFields(
+						"col4|col5|col6",
+						"int64|varchar|int64",
+					),
+					"1|a|5",
+					"1|A|15",
+					"1|b|25",
+					"2|b|35",
+					"2|null|45",
+				),
+			},
+		}
+	}
+
+	// lhs.col1 = rhs.col4 and lhs.col2 = rhs.col5 and lhs.col3 < rhs.col6
+	residual, err := evalengine.Translate(&sqlparser.ComparisonExpr{
+		Operator: sqlparser.LessThanOp,
+		Left:     sqlparser.NewColName("col3"),
+		Right:    sqlparser.NewColName("col6"),
+	}, &evalengine.Config{
+		ResolveColumn: evalengine.FieldResolver(sqltypes.MakeTestFields("col3|col6", "int64|int64")).Column,
+		Environment:   vtenv.NewTestEnv(),
+	})
+	require.NoError(t, err)
+
+	fields := sqltypes.MakeTestFields(
+		"col1|col2|col3|col6",
+		"int64|varchar|int64|int64",
+	)
+	tests := []struct {
+		name     string
+		typ      JoinOpcode
+		residual evalengine.Expr
+		expected []string
+	}{{
+		name:     "inner join",
+		typ:      InnerJoin,
+		expected: []string{"1|a|10|5", "1|a|10|15", "1|b|20|25"},
+	}, {
+		name:     "inner join with residual",
+		typ:      InnerJoin,
+		residual: residual,
+		expected: []string{"1|a|10|15", "1|b|20|25"},
+	}, {
+		name:     "left join with residual",
+		typ:      LeftJoin,
+		residual: residual,
+		expected: []string{"1|a|10|15", "1|b|20|25", "2|a|30|null", "2|null|40|null"},
+	}}
+
+	for _, tc := range tests {
+		jn := &HashJoin{
+			Opcode:  tc.typ,
+			Cols:    []int{-1, -2, -3, 3},
+			LHSKeys: []int{0, 1},
+			RHSKeys: []int{0, 1},
+			ComparisonTypes: []evalengine.Type{
+				evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID),
+				evalengine.NewType(sqltypes.VarChar, collations.MySQL8().DefaultConnectionCharset()),
+			},
+			CollationEnv: collations.MySQL8(),
+			Residual:     tc.residual,
+			ResidualCols: []int{-3, 3},
+		}
+		expected := sqltypes.MakeTestResult(fields, tc.expected...)
+
+		t.Run(tc.name, func(t *testing.T) {
+			jn.Left = lhs()
+			jn.Right = rhs()
+			r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, expected)
+		})
+		t.Run("Streaming "+tc.name, func(t *testing.T) {
+			jn.Left = lhs()
+			jn.Right = rhs()
+			r, err := wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, expected)
+		})
 	}
 }
 
@@ -77,6 +77,10 @@ type (
 		// ColVindexes are the vindexes that will use the VindexValues
 		ColVindexes []*vindexes.ColumnVindex
 
+		// VindexExprs are the expressions of the ColVindexes declared over an expression,
+		// evaluated over the values of the columns of the vindex. Nil for the other vindexes.
+		VindexExprs []evalengine.Expr
+
 		// Prefix, Suffix are for sharded insert plans.
 		Prefix string
 		Suffix sqlparser.OnDup
@@ -89,6 +93,10 @@ type (
 	Generate struct {
 		Keyspace *vindexes.Keyspace
 		Query    string
+		// Sequence is the name of the sequence table. When it has a
+		// Backend, the values come from the blocks cached by vtgate instead.
+		Sequence string
+		Backend  *vindexes.SequenceBackend
 		// Values are the supplied values for the column, which
 		// will be stored as a list within the expression. New
 		// values will be generated based on how many were not
@@ -176,7 +184,11 @@ func (ins *InsertCommon) executeUnshardedTableQuery(ctx context.Context, vcursor
 
 func (ins *InsertCommon) processVindexes(ctx context.Context, vcursor VCursor, vindexRowsValues [][]sqltypes.Row) ([]ksID, error) {
 	colVindexes := ins.ColVindexes
-	keyspaceIDs, err := ins.processPrimary(ctx, vcursor, vindexRowsValues[0], colVindexes[0])
+	primaryKeys, err := ins.vindexKeys(ctx, vcursor, 0, vindexRowsValues[0])
+	if err != nil {
+		return nil, err
+	}
+	keyspaceIDs, err := ins.processPrimary(ctx, vcursor, primaryKeys, colVindexes[0])
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +198,12 @@ func (ins *InsertCommon) processVindexes(ctx context.Context, vcursor VCursor, v
 		if colVindex.Owned {
 			err = ins.processOwned(ctx, vcursor, vindexRowsValues[vIdx], colVindex, keyspaceIDs)
 		} else {
-			err = ins.processUnowned(ctx, vcursor, vindexRowsValues[vIdx], colVindex, keyspaceIDs)
+			var keys []sqltypes.Row
+			keys, err = ins.vindexKeys(ctx, vcursor, vIdx, vindexRowsValues[vIdx])
+			if err != nil {
+				return nil, err
+			}
+			err = ins.processUnowned(ctx, vcursor, keys, colVindex, keyspaceIDs)
 		}
 		if err != nil {
 			return nil, err
@@ -195,6 +212,25 @@ func (ins *InsertCommon) processVindexes(ctx context.Context, vcursor VCursor, v
 	return keyspaceIDs, nil
 }
 
+// vindexKeys returns the values to map with a vindex for every row: the values of
+// the columns of the vindex, or the value of its expression computed from them.
+func (ic *InsertCommon) vindexKeys(ctx context.Context, vcursor VCursor, vIdx int, vindexColumnsKeys []sqltypes.Row) ([]sqltypes.Row, error) {
+	if vIdx >= len(ic.VindexExprs) || ic.VindexExprs[vIdx] == nil {
+		return vindexColumnsKeys, nil
+	}
+	env := evalengine.NewExpressionEnv(ctx, nil, vcursor)
+	keys := make([]sqltypes.Row, 0, len(vindexColumnsKeys))
+	for _, rowColumnKeys := range vindexColumnsKeys {
+		env.Row = rowColumnKeys
+		result, err := env.Evaluate(ic.VindexExprs[vIdx])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sqltypes.Row{result.Value(vcursor.ConnCollation())})
+	}
+	return keys, nil
+}
+
 // processPrimary maps the primary vindex values to the keyspace ids.
 func (ic *InsertCommon) processPrimary(ctx context.Context, vcursor VCursor, vindexColumnsKeys []sqltypes.Row, colVindex *vindexes.ColumnVindex) ([]ksID, error) {
 	destinations, err := vindexes.Map(ctx, colVindex.Vindex, vcursor, vindexColumnsKeys)
@@ -270,8 +306,10 @@ func (ic *InsertCommon) processUnowned(ctx context.Context, vcursor VCursor, vin
 	var verifyKeys []sqltypes.Row
 	var verifyKsids []ksID
 
-	// Check if this VIndex is reversible or not.
+	// Check if this VIndex is reversible or not. The value of an expression can't be
+	// reverse mapped into the values of its columns.
 	reversibleVindex, isReversible := colVindex.Vindex.(vindexes.Reversible)
+	isReversible = isReversible && colVindex.Expression == nil
 
 	for rowNum, rowColumnKeys := range vindexColumnsKeys {
 		// If we weren't able to determine a keyspace id from the primary VIndex, skip this row
@@ -442,6 +480,14 @@ func (ic *InsertCommon) processGenerateFromValues(
 }
 
 func (ic *InsertCommon) execGenerate(ctx context.Context, vcursor VCursor, loggingPrimitive Primitive, count int64) (int64, error) {
+	if ic.Generate.Backend != nil {
+		sequences := vcursor.SequenceCache()
+		if sequences == nil {
+			return 0, vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "sequence %s has a backend but its values are not cached", ic.Generate.Sequence)
+		}
+		return sequences.Next(ctx, vcursor, loggingPrimitive, ic.Generate, count)
+	}
+
 	// If generation is needed, generate the requested number of values (as one call).
 	rss, _, err := vcursor.ResolveDestinations(ctx, ic.Generate.Keyspace.Name, nil, []key.Destination{key.DestinationAnyShard{}})
 	if err != nil {
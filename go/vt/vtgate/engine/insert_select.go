@@ -360,6 +360,16 @@ func (ic *InsertCommon) commonDesc() map[string]any {
 			other["AutoIncrement"] = fmt.Sprintf("%s:Values::%s", ic.Generate.Query, sqlparser.String(ic.Generate.Values))
 		}
 	}
+
+	if len(ic.VindexExprs) > 0 {
+		vindexExprs := map[string]string{}
+		for idx, expr := range ic.VindexExprs {
+			if expr != nil {
+				vindexExprs[ic.ColVindexes[idx].Name] = sqlparser.String(ic.ColVindexes[idx].Expression)
+			}
+		}
+		other["VindexExpressions"] = vindexExprs
+	}
 	return other
 }
 
@@ -23,11 +23,13 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
 	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 )
@@ -315,6 +317,74 @@ func TestInsertShardedSimple(t *testing.T) {
 	})
 }
 
+func TestInsertShardedExpressionVindex(t *testing.T) {
+	invschema := &vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"binary": {
+						Type: "binary",
+					},
+				},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Name:       "binary",
+							Expression: "json_unquote(doc->'$.tenant')",
+						}},
+					},
+				},
+			},
+		},
+	}
+	vs := vindexes.BuildVSchema(invschema, sqlparser.NewTestParser())
+	ks := vs.Keyspaces["sharded"]
+	colVindex := ks.Tables["t1"].ColumnVindexes[0]
+
+	ins := newInsert(
+		InsertSharded,
+		false,
+		ks.Keyspace,
+		[][][]evalengine.Expr{{
+			// colVindex columns: doc
+			{
+				evalengine.NewLiteralString([]byte(`{"tenant": "acme"}`), collations.SystemCollation),
+				evalengine.NewLiteralString([]byte(`{"tenant": "initech"}`), collations.SystemCollation),
+			},
+		}},
+		ks.Tables["t1"],
+		"prefix",
+		sqlparser.Values{
+			{&sqlparser.Argument{Name: "_doc_0", Type: sqltypes.VarChar}},
+			{&sqlparser.Argument{Name: "_doc_1", Type: sqltypes.VarChar}},
+		},
+		nil,
+	)
+	vindexExpr, err := evalengine.Translate(colVindex.Expression, &evalengine.Config{
+		Collation:     collations.MySQL8().DefaultConnectionCharset(),
+		ResolveColumn: func(*sqlparser.ColName) (int, error) { return 0, nil },
+		Environment:   vtenv.NewTestEnv(),
+	})
+	require.NoError(t, err)
+	ins.VindexExprs = []evalengine.Expr{vindexExpr}
+
+	vc := newDMLTestVCursor("-80", "80-")
+	vc.shardForKsid = []string{"-80", "80-"}
+
+	_, err = ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		// The keyspace ids are the values of the expression, mapped by the binary vindex.
+		`ResolveDestinations sharded [value:"0" value:"1"] Destinations:DestinationKeyspaceID(61636d65),DestinationKeyspaceID(696e6974656368)`,
+		// The rows keep the values of the column.
+		`ExecuteMultiShard ` +
+			`sharded.-80: prefix(:_doc_0 /* VARCHAR */) {_doc_0: type:VARCHAR value:"{\"tenant\": \"acme\"}"} ` +
+			`sharded.80-: prefix(:_doc_1 /* VARCHAR */) {_doc_1: type:VARCHAR value:"{\"tenant\": \"initech\"}"} ` +
+			`true false`,
+	})
+}
+
 func TestInsertShardWithONDuplicateKey(t *testing.T) {
 	invschema := &vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -60,6 +60,8 @@ func (jn *Join) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[st
 		return nil, err
 	}
 	result := &sqltypes.Result{}
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
 	if len(lresult.Rows) == 0 && wantfields {
 		for k, col := range jn.Vars {
 			joinVars[k] = bindvarForType(lresult.Fields[col])
@@ -83,12 +85,16 @@ func (jn *Join) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[st
 			wantfields = false
 			result.Fields = joinFields(lresult.Fields, rresult.Fields, jn.Cols)
 		}
+		joined := len(result.Rows)
 		for _, rrow := range rresult.Rows {
 			result.Rows = append(result.Rows, joinRows(lrow, rrow, jn.Cols))
 		}
 		if jn.Opcode == LeftJoin && len(rresult.Rows) == 0 {
 			result.Rows = append(result.Rows, joinRows(lrow, nil, jn.Cols))
 		}
+		if err := usage.consume(result.Rows[joined:]...); err != nil {
+			return nil, err
+		}
 		if vcursor.ExceedsMaxMemoryRows(len(result.Rows)) {
 			return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
 		}
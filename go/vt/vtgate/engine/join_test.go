@@ -204,6 +204,61 @@ func TestJoinExecuteMaxMemoryRows(t *testing.T) {
 	}
 }
 
+func TestJoinExecuteMemoryTracker(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|varchar",
+				),
+				"1|a",
+				"2|b",
+			),
+		},
+	}
+	rightFields := sqltypes.MakeTestFields(
+		"col3",
+		"varchar",
+	)
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				rightFields,
+				"c",
+				"d",
+			),
+			sqltypes.MakeTestResult(
+				rightFields,
+				"e",
+			),
+		},
+	}
+	jn := &Join{
+		Opcode: InnerJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, 1},
+		Vars: map[string]int{
+			"bv": 1,
+		},
+	}
+
+	vc := &noopVCursor{memory: NewMemoryTracker("query", 0, nil)}
+	_, err := jn.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	// only the joined rows are accounted for, and they are released once they have been returned
+	require.Zero(t, vc.memory.Used())
+	require.EqualValues(t, 3*(24+2*valueMemorySize+2), vc.memory.Peak())
+
+	leftPrim.rewind()
+	rightPrim.rewind()
+	vc = &noopVCursor{memory: NewMemoryTracker("query", 200, nil)}
+	_, err = jn.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "query memory exceeded allowed limit of 200 bytes")
+	require.Zero(t, vc.memory.Used())
+}
+
 func TestJoinExecuteNoResult(t *testing.T) {
 	leftPrim := &fakePrimitive{
 		results: []*sqltypes.Result{
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"sync/atomic"
+
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// valueMemorySize is the size of a sqltypes.Value without its contents
+const valueMemorySize = 32
+
+// MemoryTracker accounts for the bytes held in memory by the rows a query
+// buffers: the rows kept by sorts, joins, distincts and window functions
+// while they are working on them. Each query has its own tracker, whose
+// parent tracks all the queries of the vtgate, so that going over either
+// limit fails the query that is asking for more memory.
+// All the methods can be called on a nil tracker, which tracks nothing.
+type MemoryTracker struct {
+	name   string
+	limit  int64
+	parent *MemoryTracker
+
+	used atomic.Int64
+	peak atomic.Int64
+}
+
+// NewMemoryTracker creates a MemoryTracker. If limit is larger than zero,
+// consuming more than limit bytes fails. The name is used in the error.
+func NewMemoryTracker(name string, limit int64, parent *MemoryTracker) *MemoryTracker {
+	return &MemoryTracker{
+		name:   name,
+		limit:  limit,
+		parent: parent,
+	}
+}
+
+// Consume accounts for bytes more, in this tracker and its parents. Nothing
+// is accounted for if this goes over the limit of any of them.
+func (m *MemoryTracker) Consume(bytes int64) error {
+	if m == nil || bytes == 0 {
+		return nil
+	}
+	used := m.used.Add(bytes)
+	if m.limit > 0 && used > m.limit {
+		m.used.Add(-bytes)
+		return vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "%s memory exceeded allowed limit of %d bytes", m.name, m.limit)
+	}
+	if err := m.parent.Consume(bytes); err != nil {
+		m.used.Add(-bytes)
+		return err
+	}
+	for peak := m.peak.Load(); used > peak; peak = m.peak.Load() {
+		if m.peak.CompareAndSwap(peak, used) {
+			break
+		}
+	}
+	return nil
+}
+
+// Release gives back bytes that were consumed
+func (m *MemoryTracker) Release(bytes int64) {
+	if m == nil || bytes == 0 {
+		return
+	}
+	m.used.Add(-bytes)
+	m.parent.Release(bytes)
+}
+
+// Close releases all the bytes still accounted for in this tracker from its parents
+func (m *MemoryTracker) Close() {
+	if m == nil {
+		return
+	}
+	m.parent.Release(m.used.Swap(0))
+}
+
+// Used returns the number of bytes currently accounted for
+func (m *MemoryTracker) Used() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.used.Load()
+}
+
+// Peak returns the highest number of bytes that were accounted for at once
+func (m *MemoryTracker) Peak() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.peak.Load()
+}
+
+// memoryUsage is the memory accounted for by a single primitive, which
+// releases it all at once when it is done with its rows.
+type memoryUsage struct {
+	tracker *MemoryTracker
+	bytes   int64
+}
+
+func newMemoryUsage(vcursor VCursor) *memoryUsage {
+	return &memoryUsage{tracker: vcursor.MemoryTracker()}
+}
+
+func (u *memoryUsage) consume(rows ...sqltypes.Row) error {
+	bytes := rowsMemorySize(rows)
+	if err := u.tracker.Consume(bytes); err != nil {
+		return err
+	}
+	u.bytes += bytes
+	return nil
+}
+
+func (u *memoryUsage) release() {
+	u.tracker.Release(u.bytes)
+	u.bytes = 0
+}
+
+// rowsMemorySize estimates the number of bytes the rows hold in memory
+func rowsMemorySize(rows []sqltypes.Row) int64 {
+	var size int64
+	for _, row := range rows {
+		size += 24 + int64(len(row))*valueMemorySize
+		for _, value := range row {
+			size += int64(len(value.Raw()))
+		}
+	}
+	return size
+}
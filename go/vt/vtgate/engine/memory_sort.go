@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strconv"
@@ -61,6 +62,10 @@ func (ms *MemorySort) GetTableName() string {
 
 // TryExecute satisfies the Primitive interface.
 func (ms *MemorySort) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if vcursor.Spiller() != nil {
+		// the input is streamed, so that it is spilled to disk instead of being read into memory at once
+		return executeStreaming(ctx, vcursor, ms, bindVars, wantfields)
+	}
 	count, err := ms.fetchCount(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -70,6 +75,11 @@ func (ms *MemorySort) TryExecute(ctx context.Context, vcursor VCursor, bindVars
 	if err != nil {
 		return nil, err
 	}
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
+	if err := usage.consume(result.Rows...); err != nil {
+		return nil, err
+	}
 
 	if err = ms.OrderBy.SortResult(result); err != nil {
 		return nil, err
@@ -98,6 +108,16 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		Limit:   count,
 	}
 
+	// runs are the sorted runs that have been spilled to disk
+	var runs []*spillFile
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+	}()
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
+
 	var mu sync.Mutex
 	err = vcursor.StreamExecutePrimitive(ctx, ms.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
 		mu.Lock()
@@ -108,17 +128,63 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 			}
 		}
 		for _, row := range qr.Rows {
+			// with a limit, the sorter only keeps the rows that can still be part of the result
+			before := sorter.Len()
 			sorter.Push(row)
+			if sorter.Len() > before {
+				if err := usage.consume(row); err != nil {
+					return err
+				}
+			}
 		}
 		if vcursor.ExceedsMaxMemoryRows(sorter.Len()) {
-			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+			spiller := vcursor.Spiller()
+			if spiller == nil {
+				return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+			}
+			run, err := spiller.writeRows(sorter.Sorted())
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			usage.release()
+			sorter = &evalengine.Sorter{
+				Compare: ms.OrderBy,
+				Limit:   count,
+			}
 		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
-	return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	if len(runs) == 0 {
+		return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	}
+	return ms.mergeRuns(runs, sorter.Sorted(), count, cb)
+}
+
+// mergeRuns merges the sorted runs that were spilled to disk with the rows
+// still in memory, and sends the first count rows of the result to the callback.
+func (ms *MemorySort) mergeRuns(runs []*spillFile, inMemory []sqltypes.Row, count int, callback func(*sqltypes.Result) error) error {
+	var rows []sqltypes.Row
+	err := mergeRuns(ms.OrderBy, runs, inMemory, func(row sqltypes.Row) error {
+		if count == 0 {
+			return io.EOF
+		}
+		rows = append(rows, row)
+		count--
+		if len(rows) < spillReadBatchSize {
+			return nil
+		}
+		err := callback(&sqltypes.Result{Rows: rows})
+		rows = nil
+		return err
+	})
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	return callback(&sqltypes.Result{Rows: rows})
 }
 
 // GetFields satisfies the Primitive interface.
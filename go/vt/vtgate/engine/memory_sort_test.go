@@ -611,6 +611,127 @@ func TestMemorySortMaxMemoryRows(t *testing.T) {
 	}
 }
 
+func TestMemorySortSpillToDisk(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+	}()
+
+	fields := sqltypes.MakeTestFields(
+		"c1|c2",
+		"varbinary|decimal",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"a|1",
+			"g|2",
+			"a|1",
+			"c|4",
+			"c|3",
+			"null|null",
+			"e|5",
+		)},
+	}
+
+	ms := &MemorySort{
+		OrderBy: []evalengine.OrderByParams{{
+			WeightStringCol: -1,
+			Col:             1,
+		}},
+		Input: fp,
+	}
+
+	vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+	result, err := wrapStreamExecute(ms, vc, nil, true)
+	require.NoError(t, err)
+	require.NotZero(t, vc.spiller.SpilledBytes())
+
+	wantResult := sqltypes.MakeTestResult(
+		fields,
+		"null|null",
+		"a|1",
+		"a|1",
+		"g|2",
+		"c|3",
+		"c|4",
+		"e|5",
+	)
+	utils.MustMatch(t, wantResult, result)
+
+	// the Execute path spills the same way
+	fp.rewind()
+	vc = &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+	result, err = ms.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	require.NotZero(t, vc.spiller.SpilledBytes())
+	utils.MustMatch(t, wantResult, result)
+
+	fp.rewind()
+	ms.UpperLimit = evalengine.NewBindVar("__upper_limit", evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID))
+	bv := map[string]*querypb.BindVariable{"__upper_limit": sqltypes.Int64BindVariable(3)}
+	result, err = wrapStreamExecute(ms, vc, bv, true)
+	require.NoError(t, err)
+
+	wantResult = sqltypes.MakeTestResult(
+		fields,
+		"null|null",
+		"a|1",
+		"a|1",
+	)
+	utils.MustMatch(t, wantResult, result)
+
+	fp.rewind()
+	ms.UpperLimit = nil
+	_, err = wrapStreamExecute(ms, &noopVCursor{spiller: NewSpiller(t.TempDir(), 10)}, nil, true)
+	require.EqualError(t, err, "spilled bytes exceeded allowed limit of 10")
+}
+
+func TestMemorySortMemoryTracker(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2",
+		"varbinary|decimal",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"a|1",
+			"g|2",
+			"c|3",
+		)},
+	}
+
+	ms := &MemorySort{
+		OrderBy: []evalengine.OrderByParams{{
+			WeightStringCol: -1,
+			Col:             1,
+		}},
+		Input: fp,
+	}
+
+	vc := &noopVCursor{memory: NewMemoryTracker("query", 0, nil)}
+	_, err := wrapStreamExecute(ms, vc, nil, true)
+	require.NoError(t, err)
+	// the sorted rows are released once they have been sent
+	require.Zero(t, vc.memory.Used())
+	require.EqualValues(t, 3*(24+2*valueMemorySize+2), vc.memory.Peak())
+
+	fp.rewind()
+	vc = &noopVCursor{memory: NewMemoryTracker("query", 200, nil)}
+	_, err = wrapStreamExecute(ms, vc, nil, true)
+	require.EqualError(t, err, "query memory exceeded allowed limit of 200 bytes")
+	require.Zero(t, vc.memory.Used())
+
+	// the Execute path accounts for the rows it sorts the same way
+	fp.rewind()
+	vc = &noopVCursor{memory: NewMemoryTracker("query", 0, nil)}
+	_, err = ms.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	require.Zero(t, vc.memory.Used())
+	require.EqualValues(t, 3*(24+2*valueMemorySize+2), vc.memory.Peak())
+}
+
 func TestMemorySortExecuteNoVarChar(t *testing.T) {
 	fields := sqltypes.MakeTestFields(
 		"c1|c2",
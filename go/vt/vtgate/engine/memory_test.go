@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func TestMemoryTracker(t *testing.T) {
+	vtgate := NewMemoryTracker("vtgate", 100, nil)
+	query1 := NewMemoryTracker("query", 60, vtgate)
+	query2 := NewMemoryTracker("query", 60, vtgate)
+
+	require.NoError(t, query1.Consume(50))
+	require.EqualError(t, query1.Consume(20), "query memory exceeded allowed limit of 60 bytes")
+	assert.EqualValues(t, 50, query1.Used())
+	assert.EqualValues(t, 50, vtgate.Used())
+
+	require.NoError(t, query2.Consume(40))
+	require.EqualError(t, query2.Consume(20), "vtgate memory exceeded allowed limit of 100 bytes")
+	assert.EqualValues(t, 40, query2.Used())
+	assert.EqualValues(t, 90, vtgate.Used())
+
+	query1.Release(30)
+	assert.EqualValues(t, 20, query1.Used())
+	assert.EqualValues(t, 50, query1.Peak())
+	assert.EqualValues(t, 60, vtgate.Used())
+
+	query1.Close()
+	query2.Close()
+	assert.Zero(t, vtgate.Used())
+	assert.EqualValues(t, 90, vtgate.Peak())
+
+	// a nil tracker tracks nothing
+	var untracked *MemoryTracker
+	require.NoError(t, untracked.Consume(1000))
+	untracked.Release(1000)
+	untracked.Close()
+	assert.Zero(t, untracked.Used())
+}
+
+func TestRowsMemorySize(t *testing.T) {
+	rows := []sqltypes.Row{
+		{sqltypes.NewVarChar("abc"), sqltypes.NewInt64(12)},
+		{sqltypes.NULL, sqltypes.NewInt64(1)},
+	}
+	assert.EqualValues(t, 2*(24+2*valueMemorySize)+3+2+1, rowsMemorySize(rows))
+	assert.Zero(t, rowsMemorySize(nil))
+}
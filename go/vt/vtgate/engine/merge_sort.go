@@ -82,14 +82,24 @@ func (ms *MergeSort) TryStreamExecute(ctx context.Context, vcursor VCursor, bind
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
-	gotFields := wantfields
+	gotFields := make([]bool, len(ms.Primitives))
+	for i := range ms.Primitives {
+		// we only need the fields from the first input, unless we allow ScatterErrorsAsWarnings.
+		// in that case, we need to ask all the inputs for fields - we don't know which will return anything
+		gotFields[i] = wantfields && (i == 0 || ms.ScatterErrorsAsWarnings)
+	}
 	handles := make([]*streamHandle, len(ms.Primitives))
-	for i, input := range ms.Primitives {
-		handles[i] = runOneStream(ctx, vcursor, input, bindVars, gotFields)
-		if !ms.ScatterErrorsAsWarnings {
-			// we only need the fields from the first input, unless we allow ScatterErrorsAsWarnings.
-			// in that case, we need to ask all the inputs for fields - we don't know which will return anything
-			gotFields = false
+	if limiter := vcursor.ScatterLimiter(); limiter.Limits(len(ms.Primitives)) {
+		// Not all the streams can run at once, but we need a row from each of them to
+		// start merging. Every stream holds its slot until it ends, and only keeps its
+		// rows in memory while other streams wait for a slot.
+		slots := limiter.slots(ctx, len(ms.Primitives))
+		for i, input := range ms.Primitives {
+			handles[i] = runLimitedStream(ctx, vcursor, slots, i, input, bindVars, gotFields[i])
+		}
+	} else {
+		for i, input := range ms.Primitives {
+			handles[i] = runOneStream(ctx, vcursor, input, bindVars, gotFields[i])
 		}
 	}
 
@@ -221,12 +231,16 @@ type streamHandle struct {
 	err    error
 }
 
-// runOnestream starts a streaming query on one shard, and returns a streamHandle for it.
-func runOneStream(ctx context.Context, vcursor VCursor, input StreamExecutor, bindVars map[string]*querypb.BindVariable, wantfields bool) *streamHandle {
-	handle := &streamHandle{
+func newStreamHandle() *streamHandle {
+	return &streamHandle{
 		fields: make(chan []*querypb.Field, 1),
 		row:    make(chan []sqltypes.Value, 10),
 	}
+}
+
+// runOnestream starts a streaming query on one shard, and returns a streamHandle for it.
+func runOneStream(ctx context.Context, vcursor VCursor, input StreamExecutor, bindVars map[string]*querypb.BindVariable, wantfields bool) *streamHandle {
+	handle := newStreamHandle()
 
 	go func() {
 		defer close(handle.fields)
@@ -254,3 +268,83 @@ func runOneStream(ctx context.Context, vcursor VCursor, input StreamExecutor, bi
 
 	return handle
 }
+
+// runLimitedStream starts the i-th streaming query once it gets its slot, and returns a
+// streamHandle for it. The rows are sent as the merge-sorter asks for them, except while other
+// streams wait for a slot: the merge-sorter may be waiting for the first row of these streams,
+// so the rows are kept in memory instead, to let the stream end and free its slot.
+func runLimitedStream(ctx context.Context, vcursor VCursor, slots *scatterSlots, i int, input StreamExecutor, bindVars map[string]*querypb.BindVariable, wantfields bool) *streamHandle {
+	handle := newStreamHandle()
+
+	go func() {
+		defer close(handle.fields)
+		defer close(handle.row)
+
+		if err := slots.acquire(ctx, i); err != nil {
+			handle.err = err
+			return
+		}
+		memory := newMemoryUsage(vcursor)
+		defer memory.release()
+
+		var pending []sqltypes.Row
+		send := func(row sqltypes.Row) bool {
+			select {
+			case handle.row <- row:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		sendPending := func() bool {
+			for _, row := range pending {
+				if !send(row) {
+					return false
+				}
+			}
+			pending = nil
+			memory.release()
+			return true
+		}
+
+		err := input.StreamExecute(ctx, vcursor, bindVars, wantfields, func(qr *sqltypes.Result) error {
+			if len(qr.Fields) != 0 {
+				select {
+				case handle.fields <- qr.Fields:
+				case <-ctx.Done():
+					return io.EOF
+				}
+			}
+
+			for _, row := range qr.Rows {
+				if !slots.contended() {
+					if !sendPending() || !send(row) {
+						return io.EOF
+					}
+					continue
+				}
+				if len(pending) == 0 {
+					select {
+					case handle.row <- row:
+						continue
+					default:
+					}
+				}
+				if err := memory.consume(row); err != nil {
+					return err
+				}
+				pending = append(pending, row)
+			}
+			return nil
+		})
+		slots.release()
+
+		if !sendPending() {
+			handle.err = ctx.Err()
+			return
+		}
+		handle.err = err
+	}()
+
+	return handle
+}
@@ -19,6 +19,10 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
@@ -93,6 +97,72 @@ func TestMergeSortNormal(t *testing.T) {
 	utils.MustMatch(t, wantResults, results)
 }
 
+// TestMergeSortScatterLimit tests that the rows are still merged in order
+// when the shards cannot all be queried at once, and that only the shards
+// that run while others wait for their turn keep their rows in memory.
+func TestMergeSortScatterLimit(t *testing.T) {
+	idColFields := sqltypes.MakeTestFields("id|col", "int32|varchar")
+	// the shards have more rows than a stream handle holds
+	shardRows := [][]string{make([]string, 20), make([]string, 20), make([]string, 40)}
+	var allRows []string
+	for shard, rows := range shardRows {
+		for i := range rows {
+			rows[i] = fmt.Sprintf("%d|%c", 3*i+shard, 'a'+shard)
+		}
+		allRows = append(allRows, rows...)
+	}
+	slices.SortFunc(allRows, func(a, b string) int {
+		idA, _ := strconv.Atoi(strings.Split(a, "|")[0])
+		idB, _ := strconv.Atoi(strings.Split(b, "|")[0])
+		return idA - idB
+	})
+
+	shardResults := make([]*shardResult, 0, len(shardRows))
+	prims := make([]StreamExecutor, 0, len(shardRows))
+	for _, rows := range shardRows {
+		sr := &shardResult{results: sqltypes.MakeTestStreamingResults(idColFields, rows...)}
+		shardResults = append(shardResults, sr)
+		prims = append(prims, sr)
+	}
+	ms := MergeSort{
+		Primitives: prims,
+		OrderBy: []evalengine.OrderByParams{{
+			WeightStringCol: -1,
+			Col:             0,
+		}},
+	}
+	vc := &noopVCursor{limiter: NewScatterLimiter(1), memory: NewMemoryTracker("query", 0, nil)}
+
+	var results []*sqltypes.Result
+	err := ms.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var wantRows []string
+	for i, row := range allRows {
+		if i > 0 {
+			wantRows = append(wantRows, "---")
+		}
+		wantRows = append(wantRows, row)
+	}
+	utils.MustMatch(t, sqltypes.MakeTestStreamingResults(idColFields, wantRows...), results)
+	require.Zero(t, vc.memory.Used(), "the buffered rows must be released")
+	require.NotZero(t, vc.memory.Peak())
+	// the last shard runs once no other shard waits, so it waits for the merge instead
+	var buffered []sqltypes.Row
+	for _, sr := range shardResults[:2] {
+		buffered = append(buffered, sr.results[1].Rows...)
+	}
+	require.LessOrEqual(t, vc.memory.Peak(), rowsMemorySize(buffered))
+
+	// a failing shard fails the merge
+	shardResults[1].sendErr = errors.New("shard error")
+	err = ms.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error { return nil })
+	require.EqualError(t, err, "shard error")
+}
+
 func TestMergeSortWeightString(t *testing.T) {
 	idColFields := sqltypes.MakeTestFields("id|col", "varbinary|varchar")
 	shardResults := []*shardResult{{
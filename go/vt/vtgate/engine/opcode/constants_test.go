@@ -34,6 +34,13 @@ func TestCheckAllAggrOpCodes(t *testing.T) {
 	}
 }
 
+func TestCheckAllWindowOpCodes(t *testing.T) {
+	// This test is just checking that we never reach the panic when using SQLType() on valid opcodes
+	for i := WindowOpcode(0); i < _NumOfWindowOpCodes; i++ {
+		i.SQLType(sqltypes.Null)
+	}
+}
+
 func TestType(t *testing.T) {
 	tt := []struct {
 		opcode AggregateOpcode
@@ -49,6 +49,11 @@ type OrderedAggregate struct {
 	// from the result received. If 0, no truncation happens.
 	TruncateColumnCount int
 
+	// WithRollup is set for GROUP BY ... WITH ROLLUP. The super-aggregate
+	// rows are computed here, by merging the aggregates of the rows received
+	// from the input, and emitted right after the last group they cover.
+	WithRollup bool
+
 	// Input is the primitive that will feed into this Primitive.
 	Input Primitive
 }
@@ -96,6 +101,10 @@ func (oa *OrderedAggregate) GetTableName() string {
 
 // TryExecute is a Primitive function.
 func (oa *OrderedAggregate) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	if vcursor.Spiller() != nil {
+		// the input is streamed, so that it is spilled to disk instead of being read into memory at once
+		return executeStreaming(ctx, vcursor, oa, bindVars, true)
+	}
 	qr, err := oa.execute(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -142,14 +151,24 @@ func (oa *OrderedAggregate) execute(ctx context.Context, vcursor VCursor, bindVa
 	if err != nil {
 		return nil, err
 	}
-	if len(oa.Aggregates) == 0 {
+	if len(oa.Aggregates) == 0 && !oa.WithRollup {
 		return oa.executeGroupBy(result)
 	}
 
-	agg, fields, err := newAggregation(result.Fields, oa.Aggregates)
+	agg, fields, err := newAggregation(vcursor, result.Fields, oa.Aggregates)
 	if err != nil {
 		return nil, err
 	}
+	defer agg.close()
+
+	var roll *rollup
+	if oa.WithRollup {
+		roll, err = oa.newRollup(vcursor, result.Fields)
+		if err != nil {
+			return nil, err
+		}
+		defer roll.close()
+	}
 
 	out := &sqltypes.Result{
 		Fields: fields,
@@ -166,17 +185,40 @@ func (oa *OrderedAggregate) execute(ctx context.Context, vcursor VCursor, bindVa
 		}
 
 		if nextGroup {
-			out.Rows = append(out.Rows, agg.finish())
+			aggRow, err := agg.finish()
+			if err != nil {
+				return nil, err
+			}
+			out.Rows = append(out.Rows, aggRow)
 			agg.reset()
 		}
 
 		if err := agg.add(row); err != nil {
 			return nil, err
 		}
+
+		if roll != nil {
+			rows, err := roll.add(row, nextGroup)
+			if err != nil {
+				return nil, err
+			}
+			out.Rows = append(out.Rows, rows...)
+		}
 	}
 
 	if currentKey != nil {
-		out.Rows = append(out.Rows, agg.finish())
+		aggRow, err := agg.finish()
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, aggRow)
+		if roll != nil {
+			rows, err := roll.flush(0)
+			if err != nil {
+				return nil, err
+			}
+			out.Rows = append(out.Rows, rows...)
+		}
 	}
 
 	return out, nil
@@ -235,7 +277,7 @@ func (oa *OrderedAggregate) executeStreamGroupBy(ctx context.Context, vcursor VC
 
 // TryStreamExecute is a Primitive function.
 func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) error {
-	if len(oa.Aggregates) == 0 {
+	if len(oa.Aggregates) == 0 && !oa.WithRollup {
 		return oa.executeStreamGroupBy(ctx, vcursor, bindVars, callback)
 	}
 
@@ -244,17 +286,28 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 	}
 
 	var agg aggregationState
+	var roll *rollup
 	var fields []*querypb.Field
 	var currentKey []sqltypes.Value
+	defer func() {
+		agg.close()
+		roll.close()
+	}()
 
 	visitor := func(qr *sqltypes.Result) error {
 		var err error
 
 		if agg == nil && len(qr.Fields) != 0 {
-			agg, fields, err = newAggregation(qr.Fields, oa.Aggregates)
+			agg, fields, err = newAggregation(vcursor, qr.Fields, oa.Aggregates)
 			if err != nil {
 				return err
 			}
+			if oa.WithRollup {
+				roll, err = oa.newRollup(vcursor, qr.Fields)
+				if err != nil {
+					return err
+				}
+			}
 			if err = cb(&sqltypes.Result{Fields: fields}); err != nil {
 				return err
 			}
@@ -271,7 +324,11 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 
 			if nextGroup {
 				// this is a new grouping. let's yield the old one, and start a new
-				if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{agg.finish()}}); err != nil {
+				aggRow, err := agg.finish()
+				if err != nil {
+					return err
+				}
+				if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{aggRow}}); err != nil {
 					return err
 				}
 
@@ -281,6 +338,18 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 			if err := agg.add(row); err != nil {
 				return err
 			}
+
+			if roll != nil {
+				rows, err := roll.add(row, nextGroup)
+				if err != nil {
+					return err
+				}
+				if len(rows) > 0 {
+					if err := cb(&sqltypes.Result{Rows: rows}); err != nil {
+						return err
+					}
+				}
+			}
 		}
 		return nil
 	}
@@ -292,7 +361,19 @@ func (oa *OrderedAggregate) TryStreamExecute(ctx context.Context, vcursor VCurso
 	}
 
 	if currentKey != nil {
-		if err := cb(&sqltypes.Result{Rows: [][]sqltypes.Value{agg.finish()}}); err != nil {
+		aggRow, err := agg.finish()
+		if err != nil {
+			return err
+		}
+		rows := [][]sqltypes.Value{aggRow}
+		if roll != nil {
+			rollRows, err := roll.flush(0)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, rollRows...)
+		}
+		if err := cb(&sqltypes.Result{Rows: rows}); err != nil {
 			return err
 		}
 	}
@@ -306,7 +387,7 @@ func (oa *OrderedAggregate) GetFields(ctx context.Context, vcursor VCursor, bind
 		return nil, err
 	}
 
-	_, fields, err := newAggregation(qr.Fields, oa.Aggregates)
+	_, fields, err := newAggregation(vcursor, qr.Fields, oa.Aggregates)
 	if err != nil {
 		return nil, err
 	}
@@ -330,30 +411,119 @@ func (oa *OrderedAggregate) nextGroupBy(currentKey, nextRow []sqltypes.Value) (n
 		return nextRow, false, nil
 	}
 
-	for _, gb := range oa.GroupByKeys {
+	changed, err := oa.firstChangedKey(currentKey, nextRow)
+	if err != nil {
+		return nil, false, err
+	}
+	if changed < len(oa.GroupByKeys) {
+		return nextRow, true, nil
+	}
+	return currentKey, false, nil
+}
+
+// firstChangedKey returns the index of the first grouping key that differs between the two rows,
+// or the number of grouping keys if the rows belong to the same group
+func (oa *OrderedAggregate) firstChangedKey(currentKey, nextRow []sqltypes.Value) (int, error) {
+	for idx, gb := range oa.GroupByKeys {
 		v1 := currentKey[gb.KeyCol]
 		v2 := nextRow[gb.KeyCol]
 		if v1.TinyWeightCmp(v2) != 0 {
-			return nextRow, true, nil
+			return idx, nil
 		}
 
 		cmp, err := evalengine.NullsafeCompare(v1, v2, gb.CollationEnv, gb.Type.Collation(), gb.Type.Values())
 		if err != nil {
 			_, isCollationErr := err.(evalengine.UnsupportedCollationError)
 			if !isCollationErr || gb.WeightStringCol == -1 {
-				return nil, false, err
+				return 0, err
 			}
 			gb.KeyCol = gb.WeightStringCol
 			cmp, err = evalengine.NullsafeCompare(currentKey[gb.WeightStringCol], nextRow[gb.WeightStringCol], gb.CollationEnv, gb.Type.Collation(), gb.Type.Values())
 			if err != nil {
-				return nil, false, err
+				return 0, err
 			}
 		}
 		if cmp != 0 {
-			return nextRow, true, nil
+			return idx, nil
 		}
 	}
-	return currentKey, false, nil
+	return len(oa.GroupByKeys), nil
+}
+
+// rollup computes the super-aggregate rows of GROUP BY ... WITH ROLLUP.
+// levels[i] aggregates all the rows that share the values of the first i grouping keys.
+type rollup struct {
+	oa      *OrderedAggregate
+	levels  []aggregationState
+	lastRow sqltypes.Row
+}
+
+func (oa *OrderedAggregate) newRollup(vcursor VCursor, fields []*querypb.Field) (*rollup, error) {
+	r := &rollup{oa: oa}
+	for level := range oa.GroupByKeys {
+		agg, _, err := newAggregation(vcursor, fields, oa.Aggregates)
+		if err != nil {
+			return nil, err
+		}
+		agg.setRollupLevel(level)
+		r.levels = append(r.levels, agg)
+	}
+	return r, nil
+}
+
+// add aggregates the row in every level. When the row starts a new group, the levels
+// covering the grouping keys that changed are complete, and their rows are returned.
+func (r *rollup) add(row sqltypes.Row, nextGroup bool) ([]sqltypes.Row, error) {
+	var out []sqltypes.Row
+	if nextGroup {
+		changed, err := r.oa.firstChangedKey(r.lastRow, row)
+		if err != nil {
+			return nil, err
+		}
+		out, err = r.flush(changed + 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.lastRow = row
+
+	for _, agg := range r.levels {
+		if err := agg.add(row); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// flush returns the super-aggregate rows of all the levels starting at the given one,
+// from the most to the least detailed, and resets them
+func (r *rollup) flush(from int) ([]sqltypes.Row, error) {
+	var out []sqltypes.Row
+	for level := len(r.levels) - 1; level >= from; level-- {
+		row, err := r.levels[level].finish()
+		if err != nil {
+			return nil, err
+		}
+		for _, gb := range r.oa.GroupByKeys[level:] {
+			row[gb.KeyCol] = sqltypes.NULL
+			if gb.WeightStringCol >= 0 {
+				row[gb.WeightStringCol] = sqltypes.NULL
+			}
+		}
+		r.levels[level].reset()
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// close releases what the levels still hold
+func (r *rollup) close() {
+	if r == nil {
+		return
+	}
+	for _, agg := range r.levels {
+		agg.close()
+	}
 }
 func aggregateParamsToString(in any) string {
 	return in.(*AggregateParams).String()
@@ -373,6 +543,9 @@ func (oa *OrderedAggregate) description() PrimitiveDescription {
 	if oa.TruncateColumnCount > 0 {
 		other["ResultColumns"] = oa.TruncateColumnCount
 	}
+	if oa.WithRollup {
+		other["WithRollup"] = true
+	}
 	return PrimitiveDescription{
 		OperatorType: "Aggregate",
 		Variant:      "Ordered",
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/sqlerror"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/test/utils"
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -70,6 +71,88 @@ func TestOrderedAggregateExecute(t *testing.T) {
 	utils.MustMatch(t, wantResult, result)
 }
 
+func TestOrderedAggregateWithRollup(t *testing.T) {
+	input := func() *fakePrimitive {
+		return &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"a|b|sum(c)|grouping(a, b)",
+					"varbinary|varbinary|decimal|int64",
+				),
+				"x|1|1|0",
+				"x|1|2|0",
+				"x|2|3|0",
+				"y|1|4|0",
+			)},
+		}
+	}
+
+	oa := &OrderedAggregate{
+		Aggregates: []*AggregateParams{
+			NewAggregateParam(AggregateSum, 2, "", collations.MySQL8()),
+			{Opcode: AggregateGrouping, Col: 3, GroupingKeys: []int{0, 1}},
+		},
+		GroupByKeys: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}, {KeyCol: 1, WeightStringCol: -1}},
+		WithRollup:  true,
+		Input:       input(),
+	}
+
+	wantFields := sqltypes.MakeTestFields(
+		"a|b|sum(c)|grouping(a, b)",
+		"varbinary|varbinary|decimal|int64",
+	)
+	wantRows := []string{
+		"x|1|3|0",
+		"x|2|3|0",
+		"x|null|6|1",
+		"y|1|4|0",
+		"y|null|4|1",
+		"null|null|10|3",
+	}
+
+	result, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(wantFields, wantRows...), result)
+
+	oa.Input = input()
+	result, err = wrapStreamExecute(oa, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(wantFields, wantRows...), result)
+}
+
+func TestOrderedAggregateWithRollupNoAggregates(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"a",
+				"int64",
+			),
+			"1",
+			"2",
+		)},
+	}
+
+	oa := &OrderedAggregate{
+		GroupByKeys: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		WithRollup:  true,
+		Input:       fp,
+	}
+
+	result, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.NoError(t, err)
+
+	wantResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"a",
+			"int64",
+		),
+		"1",
+		"2",
+		"null",
+	)
+	utils.MustMatch(t, wantResult, result)
+}
+
 func TestOrderedAggregateExecuteTruncate(t *testing.T) {
 	fp := &fakePrimitive{
 		results: []*sqltypes.Result{sqltypes.MakeTestResult(
@@ -1169,3 +1252,117 @@ func TestGroupConcat(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupConcatEvaluatedAtVTGate(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2|c3",
+		"int64|varchar|int64",
+	)
+	input := sqltypes.MakeTestResult(fields,
+		"10|b|1", "10|a|3", "10|b|2", "10|null|4",
+		"20|c|1", "20|a|1", "20|b|2",
+		"30|null|1")
+
+	varcharArg := evalengine.OrderByParams{Col: 1, WeightStringCol: -1, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID), CollationEnv: collations.MySQL8()}
+	intArg := evalengine.OrderByParams{Col: 2, WeightStringCol: -1, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID), CollationEnv: collations.MySQL8()}
+	intDesc := intArg
+	intDesc.Desc = true
+
+	var tcases = []struct {
+		name     string
+		distinct bool
+		args     evalengine.Comparison
+		orderBy  evalengine.Comparison
+		expected []string
+	}{{
+		name:     "distinct",
+		distinct: true,
+		args:     evalengine.Comparison{varcharArg},
+		expected: []string{"10|a,b", "20|a,b,c", "30|null"},
+	}, {
+		name:     "order by",
+		args:     evalengine.Comparison{varcharArg},
+		orderBy:  evalengine.Comparison{intDesc},
+		expected: []string{"10|a,b,b", "20|b,c,a", "30|null"},
+	}, {
+		name:     "distinct with order by",
+		distinct: true,
+		args:     evalengine.Comparison{varcharArg},
+		orderBy:  evalengine.Comparison{intArg},
+		expected: []string{"10|b,a", "20|c,a,b", "30|null"},
+	}, {
+		name:     "multiple arguments",
+		args:     evalengine.Comparison{varcharArg, intArg},
+		orderBy:  evalengine.Comparison{intArg},
+		expected: []string{"10|b1,b2,a3", "20|c1,a1,b2", "30|null"},
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			agp := NewAggregateParam(AggregateGroupConcat, 1, "", collations.MySQL8())
+			agp.Func = &sqlparser.GroupConcatExpr{Distinct: tcase.distinct, Separator: ","}
+			agp.Args = tcase.args
+			agp.OrderBy = tcase.orderBy
+			oa := &OrderedAggregate{
+				Aggregates:          []*AggregateParams{agp},
+				GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+				TruncateColumnCount: 2,
+				Input:               &fakePrimitive{results: []*sqltypes.Result{input}},
+			}
+			qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+			require.NoError(t, err)
+			assert.Equal(t, sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1|c2", "int64|text"), tcase.expected...).Rows, qr.Rows)
+		})
+		t.Run("spilled "+tcase.name, func(t *testing.T) {
+			saveMax := testMaxMemoryRows
+			testMaxMemoryRows = 1
+			defer func() {
+				testMaxMemoryRows = saveMax
+			}()
+
+			agp := NewAggregateParam(AggregateGroupConcat, 1, "", collations.MySQL8())
+			agp.Func = &sqlparser.GroupConcatExpr{Distinct: tcase.distinct, Separator: ","}
+			agp.Args = tcase.args
+			agp.OrderBy = tcase.orderBy
+			oa := &OrderedAggregate{
+				Aggregates:          []*AggregateParams{agp},
+				GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+				TruncateColumnCount: 2,
+				Input:               &fakePrimitive{results: []*sqltypes.Result{input}},
+			}
+			vc := &noopVCursor{spiller: NewSpiller(t.TempDir(), 0)}
+			qr, err := oa.TryExecute(context.Background(), vc, nil, false)
+			require.NoError(t, err)
+			require.NotZero(t, vc.spiller.SpilledBytes())
+			assert.Equal(t, sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1|c2", "int64|text"), tcase.expected...).Rows, qr.Rows)
+		})
+	}
+}
+
+func TestGroupConcatMaxLen(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|group_concat(c2)",
+		"int64|text",
+	)
+	fp := &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields,
+		"10|abc", "10|def",
+		"20|ab",
+		"30|äöü", "30|ä")}}
+
+	agp := NewAggregateParam(AggregateGroupConcat, 1, "", collations.MySQL8())
+	agp.Func = &sqlparser.GroupConcatExpr{Separator: ","}
+	oa := &OrderedAggregate{
+		Aggregates:  []*AggregateParams{agp},
+		GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+		Input:       fp,
+	}
+	vc := &loggingVCursor{systemVariables: map[string]string{"group_concat_max_len": "5"}}
+	qr, err := oa.TryExecute(context.Background(), vc, nil, false)
+	require.NoError(t, err)
+	// multibyte characters are never cut in half
+	assert.Equal(t, sqltypes.MakeTestResult(fields, "10|abc,d", "20|ab", "30|äö").Rows, qr.Rows)
+	vc.ExpectWarnings(t, []*querypb.QueryWarning{
+		{Code: uint32(sqlerror.ERCutValueGroupConcat), Message: "Row 1 was cut by GROUP_CONCAT()"},
+		{Code: uint32(sqlerror.ERCutValueGroupConcat), Message: "Row 3 was cut by GROUP_CONCAT()"},
+	})
+}
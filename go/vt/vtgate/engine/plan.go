@@ -45,6 +45,8 @@ type Plan struct {
 	RowsReturned uint64 // Total number of rows
 	RowsAffected uint64 // Total number of rows
 	Errors       uint64 // Total number of errors
+	SpilledBytes uint64 // Total number of bytes spilled to disk
+	QueueTime    uint64 // Total time shard queries waited for the scatter concurrency limit
 }
 
 // AddStats updates the plan execution statistics
@@ -57,6 +59,16 @@ func (p *Plan) AddStats(execCount uint64, execTime time.Duration, shardQueries,
 	atomic.AddUint64(&p.Errors, errors)
 }
 
+// AddSpilledBytes updates the number of bytes the plan has spilled to disk
+func (p *Plan) AddSpilledBytes(spilledBytes uint64) {
+	atomic.AddUint64(&p.SpilledBytes, spilledBytes)
+}
+
+// AddQueueTime updates the time the shard queries of the plan waited for the scatter concurrency limit
+func (p *Plan) AddQueueTime(queueTime time.Duration) {
+	atomic.AddUint64(&p.QueueTime, uint64(queueTime))
+}
+
 // Stats returns a copy of the plan execution statistics
 func (p *Plan) Stats() (execCount uint64, execTime time.Duration, shardQueries, rowsAffected, rowsReturned, errors uint64) {
 	execCount = atomic.LoadUint64(&p.ExecCount)
@@ -86,6 +98,8 @@ func (p *Plan) MarshalJSON() ([]byte, error) {
 		RowsAffected uint64                `json:",omitempty"`
 		RowsReturned uint64                `json:",omitempty"`
 		Errors       uint64                `json:",omitempty"`
+		SpilledBytes uint64                `json:",omitempty"`
+		QueueTime    time.Duration         `json:",omitempty"`
 		TablesUsed   []string              `json:",omitempty"`
 	}{
 		QueryType:    p.Type.String(),
@@ -97,6 +111,8 @@ func (p *Plan) MarshalJSON() ([]byte, error) {
 		RowsAffected: atomic.LoadUint64(&p.RowsAffected),
 		RowsReturned: atomic.LoadUint64(&p.RowsReturned),
 		Errors:       atomic.LoadUint64(&p.Errors),
+		SpilledBytes: atomic.LoadUint64(&p.SpilledBytes),
+		QueueTime:    time.Duration(atomic.LoadUint64(&p.QueueTime)),
 		TablesUsed:   p.TablesUsed,
 	}
 
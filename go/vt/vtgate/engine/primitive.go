@@ -56,6 +56,22 @@ type (
 		// if the max memory rows override directive is set to true
 		ExceedsMaxMemoryRows(numRows int) bool
 
+		// Spiller returns the Spiller primitives can use to move rows to disk
+		// once max memory rows has been exceeded. Returns nil if spilling is disabled
+		Spiller() *Spiller
+
+		// MemoryTracker returns the tracker of the memory held by the rows of the query.
+		// Returns nil if the memory is not tracked
+		MemoryTracker() *MemoryTracker
+
+		// ScatterLimiter returns the limiter of the number of shards the query
+		// runs on at once. Returns nil if it is not limited
+		ScatterLimiter() *ScatterLimiter
+
+		// SequenceCache returns the cache of the values of the sequences with a backend.
+		// Returns nil if they are not cached
+		SequenceCache() *SequenceCache
+
 		Execute(ctx context.Context, method string, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		AutocommitApproval() bool
 
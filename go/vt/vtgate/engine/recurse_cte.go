@@ -18,7 +18,11 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync"
 
+	"vitess.io/vitess/go/slice"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -33,21 +37,101 @@ type RecurseCTE struct {
 	Seed, Term Primitive
 
 	Vars map[string]int
+
+	// CheckCols is only set for UNION DISTINCT. Rows that have already been produced are dropped
+	// and not used for the next recursion, which is what stops the recursion on cyclic data.
+	CheckCols []CheckCol
 }
 
 var _ Primitive = (*RecurseCTE)(nil)
 
+// defaultCTEMaxRecursionDepth is the default value of cte_max_recursion_depth
+const defaultCTEMaxRecursionDepth = 1000
+
+// recurseState keeps track of the rows produced by a single execution of a recursive CTE
+type recurseState struct {
+	mu sync.Mutex
+
+	maxDepth int
+	seen     *probeTable
+
+	// rows is the number of rows produced so far. It's only checked against
+	// the max memory rows when limitRows is set
+	rows      int
+	limitRows bool
+}
+
+func (r *RecurseCTE) newState(vcursor VCursor, limitRows bool) *recurseState {
+	state := &recurseState{
+		maxDepth:  defaultCTEMaxRecursionDepth,
+		limitRows: limitRows,
+	}
+	vcursor.Session().GetSystemVariables(func(k, v string) {
+		if k != "cte_max_recursion_depth" {
+			return
+		}
+		if depth, err := strconv.Atoi(v); err == nil {
+			state.maxDepth = depth
+		}
+	})
+	if len(r.CheckCols) > 0 {
+		state.seen = newProbeTable(r.CheckCols, vcursor.Environment().CollationEnv())
+	}
+	return state
+}
+
+// newRows returns the rows that have to be added to the result, and checks that we are still within the limits
+func (s *recurseState) newRows(vcursor VCursor, rows []sqltypes.Row) ([]sqltypes.Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen != nil {
+		var unique []sqltypes.Row
+		for _, row := range rows {
+			newRow, err := s.seen.exists(row)
+			if err != nil {
+				return nil, err
+			}
+			if newRow != nil {
+				unique = append(unique, newRow)
+			}
+		}
+		rows = unique
+	}
+
+	s.rows += len(rows)
+	if s.limitRows && vcursor.ExceedsMaxMemoryRows(s.rows) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+	}
+	return rows, nil
+}
+
+// checkDepth fails like MySQL does when the next iteration would go deeper than cte_max_recursion_depth
+func (s *recurseState) checkDepth(depth int) error {
+	if depth > s.maxDepth {
+		return vterrors.VT09030(depth)
+	}
+	return nil
+}
+
 func (r *RecurseCTE) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
 	res, err := vcursor.ExecutePrimitive(ctx, r.Seed, bindVars, wantfields)
 	if err != nil {
 		return nil, err
 	}
+	state := r.newState(vcursor, true)
+	res.Rows, err = state.newRows(vcursor, res.Rows)
+	if err != nil {
+		return nil, err
+	}
 
 	// recurseRows contains the rows used in the next recursion
 	recurseRows := res.Rows
 	joinVars := make(map[string]*querypb.BindVariable)
-	loops := 0
-	for len(recurseRows) > 0 {
+	for depth := 1; len(recurseRows) > 0; depth++ {
+		if err := state.checkDepth(depth); err != nil {
+			return nil, err
+		}
 		// copy over the results from the previous recursion
 		theseRows := recurseRows
 		recurseRows = nil
@@ -63,12 +147,12 @@ func (r *RecurseCTE) TryExecute(ctx context.Context, vcursor VCursor, bindVars m
 			if err != nil {
 				return nil, err
 			}
-			recurseRows = append(recurseRows, rresult.Rows...)
-			res.Rows = append(res.Rows, rresult.Rows...)
-			loops++
-			if loops > 1000 { // TODO: This should be controlled with a system variable setting
-				return nil, vterrors.VT09030("")
+			rows, err := state.newRows(vcursor, rresult.Rows)
+			if err != nil {
+				return nil, err
 			}
+			recurseRows = append(recurseRows, rows...)
+			res.Rows = append(res.Rows, rows...)
 		}
 	}
 	return res, nil
@@ -82,31 +166,38 @@ func (r *RecurseCTE) TryStreamExecute(ctx context.Context, vcursor VCursor, bind
 		}
 		return callback(res)
 	}
+	// when streaming, the rows are only kept in memory to find the duplicates
+	state := r.newState(vcursor, len(r.CheckCols) > 0)
 	return vcursor.StreamExecutePrimitive(ctx, r.Seed, bindVars, wantfields, func(result *sqltypes.Result) error {
-		err := callback(result)
-		if err != nil {
-			return err
-		}
-		return r.recurse(ctx, vcursor, bindVars, result, callback)
+		return r.recurse(ctx, vcursor, bindVars, state, result, 0, callback)
 	})
 }
 
-func (r *RecurseCTE) recurse(ctx context.Context, vcursor VCursor, bindvars map[string]*querypb.BindVariable, result *sqltypes.Result, callback func(*sqltypes.Result) error) error {
-	if len(result.Rows) == 0 {
+// recurse sends the rows of the result to the callback, and then uses them for the next recursion
+func (r *RecurseCTE) recurse(ctx context.Context, vcursor VCursor, bindvars map[string]*querypb.BindVariable, state *recurseState, result *sqltypes.Result, depth int, callback func(*sqltypes.Result) error) error {
+	rows, err := state.newRows(vcursor, result.Rows)
+	if err != nil {
+		return err
+	}
+	result.Rows = rows
+	if err := callback(result); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
 		return nil
 	}
+	if err := state.checkDepth(depth + 1); err != nil {
+		return err
+	}
+
 	joinVars := make(map[string]*querypb.BindVariable)
-	for _, row := range result.Rows {
+	for _, row := range rows {
 		for k, col := range r.Vars {
 			joinVars[k] = sqltypes.ValueBindVariable(row[col])
 		}
 
 		err := vcursor.StreamExecutePrimitive(ctx, r.Term, combineVars(bindvars, joinVars), false, func(result *sqltypes.Result) error {
-			err := callback(result)
-			if err != nil {
-				return err
-			}
-			return r.recurse(ctx, vcursor, bindvars, result, callback)
+			return r.recurse(ctx, vcursor, bindvars, state, result, depth+1, callback)
 		})
 		if err != nil {
 			return err
@@ -146,6 +237,9 @@ func (r *RecurseCTE) description() PrimitiveDescription {
 	other := map[string]interface{}{
 		"JoinVars": orderedStringIntMap(r.Vars),
 	}
+	if len(r.CheckCols) > 0 {
+		other["Distinct"] = slice.Map(r.CheckCols, CheckCol.String)
+	}
 
 	return PrimitiveDescription{
 		OperatorType: "RecurseCTE",
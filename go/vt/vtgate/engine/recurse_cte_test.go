@@ -18,12 +18,15 @@ package engine
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 )
 
 func TestRecurseDualQuery(t *testing.T) {
@@ -127,3 +130,78 @@ func TestRecurseDualQuery(t *testing.T) {
 	expectResult(t, r, wantRes)
 
 }
+
+func TestRecurseCTEDistinct(t *testing.T) {
+	// WITH RECURSIVE cte AS (SELECT 1 as col1 UNION SELECT next FROM cte JOIN edges ON cte.col1 = edges.prev) SELECT * FROM cte;
+	// The edges form a cycle, so the recursion only stops because the rows already produced are dropped.
+	fields := sqltypes.MakeTestFields("col1", "int64")
+	seed := &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, "1")}}
+	term := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(fields, "2", "1"),
+			sqltypes.MakeTestResult(fields, "1", "2"),
+		},
+	}
+
+	cte := &RecurseCTE{
+		Seed:      seed,
+		Term:      term,
+		Vars:      map[string]int{"col1": 0},
+		CheckCols: []CheckCol{{Col: 0, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)}},
+	}
+
+	r, err := cte.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	term.ExpectLog(t, []string{
+		`Execute col1: type:INT64 value:"1" false`,
+		`Execute col1: type:INT64 value:"2" false`,
+	})
+	wantRes := sqltypes.MakeTestResult(fields, "1", "2")
+	expectResult(t, r, wantRes)
+
+	seed.rewind()
+	term.rewind()
+	r, err = wrapStreamExecute(cte, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	term.ExpectLog(t, []string{
+		`StreamExecute col1: type:INT64 value:"1" false`,
+		`StreamExecute col1: type:INT64 value:"2" false`,
+	})
+	expectResult(t, r, wantRes)
+}
+
+func TestRecurseCTELimits(t *testing.T) {
+	// WITH RECURSIVE cte AS (SELECT 1 as col1 UNION ALL SELECT col1+1 FROM cte) SELECT * FROM cte;
+	fields := sqltypes.MakeTestFields("col1", "int64")
+	seed := &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, "1")}}
+	term := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(fields, "2"),
+			sqltypes.MakeTestResult(fields, "3"),
+			sqltypes.MakeTestResult(fields, "4"),
+		},
+	}
+	cte := &RecurseCTE{
+		Seed: seed,
+		Term: term,
+		Vars: map[string]int{"col1": 0},
+	}
+
+	vc := &loggingVCursor{systemVariables: map[string]string{"cte_max_recursion_depth": "2"}}
+	_, err := cte.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "VT09030: Recursive query aborted after 3 iterations. Try increasing @@cte_max_recursion_depth to a larger value.")
+
+	seed.rewind()
+	term.rewind()
+	_, err = wrapStreamExecute(cte, vc, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "VT09030: Recursive query aborted after 3 iterations. Try increasing @@cte_max_recursion_depth to a larger value.")
+
+	// the rows of the recursion are kept in memory, so they can't go above the max memory rows
+	var rows []string
+	for i := 0; i <= testMaxMemoryRows; i++ {
+		rows = append(rows, strconv.Itoa(i))
+	}
+	cte.Seed = &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, rows...)}}
+	_, err = cte.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "in-memory row count exceeded allowed limit of 100")
+}
@@ -261,6 +261,76 @@ func TestSelectEqualUnique(t *testing.T) {
 	expectResult(t, result, defaultSelectResult)
 }
 
+func TestSelectKeyRange(t *testing.T) {
+	vindex, _ := vindexes.CreateVindex("numeric", "", nil)
+	sel := NewRoute(
+		KeyRange,
+		&vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: true,
+		},
+		"dummy_select",
+		"dummy_select_field",
+	)
+	sel.Vindex = vindex.(vindexes.SingleColumn)
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralInt(1),
+		evalengine.NewLiteralInt(10),
+	}
+
+	vc := &loggingVCursor{
+		shards:       []string{"-20", "20-"},
+		shardForKsid: []string{"-20"},
+		results:      []*sqltypes.Result{defaultSelectResult},
+	}
+	result, err := sel.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationKeyRange(0000000000000001-000000000000000b)`,
+		`ExecuteMultiShard ks.-20: dummy_select {} false false`,
+	})
+	expectResult(t, result, defaultSelectResult)
+
+	// an unbounded side of the range is NULL
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralInt(10),
+		evalengine.NullExpr,
+	}
+	vc.Rewind()
+	result, err = wrapStreamExecute(sel, vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationKeyRange(000000000000000a-)`,
+		`StreamExecuteMulti dummy_select ks.-20: {} `,
+	})
+	expectResult(t, result, defaultSelectResult)
+
+	// an empty range does not go to any shard
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralInt(10),
+		evalengine.NewLiteralInt(1),
+	}
+	vc.Rewind()
+	_, err = sel.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationNone()`,
+	})
+
+	// a range the vindex cannot map goes to all the shards
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralString([]byte("abc"), collations.SystemCollation),
+		evalengine.NewLiteralInt(10),
+	}
+	vc.Rewind()
+	_, err = sel.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationAllShards()`,
+		`ExecuteMultiShard ks.-20: dummy_select {} ks.20-: dummy_select {} false false`,
+	})
+}
+
 func TestSelectNone(t *testing.T) {
 	vindex, _ := vindexes.CreateVindex("hash", "", nil)
 	sel := NewRoute(
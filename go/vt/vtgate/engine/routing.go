@@ -56,6 +56,10 @@ const (
 	MultiEqual
 	// SubShard is for when we are missing one or more columns from a composite vindex
 	SubShard
+	// KeyRange is for routing a statement with a range predicate to the shards
+	// holding the range of the vindex values.
+	// Requires: A RangeMapper Vindex, and two Values, the bounds of the range.
+	KeyRange
 	// Scatter is for routing a scattered statement.
 	Scatter
 	// Next is for fetching from a sequence.
@@ -86,6 +90,7 @@ var opName = map[Opcode]string{
 	None:          "None",
 	ByDestination: "ByDestination",
 	SubShard:      "SubShard",
+	KeyRange:      "KeyRange",
 }
 
 // MarshalJSON serializes the Opcode as a JSON string.
@@ -164,6 +169,8 @@ func (rp *RoutingParameters) findRoute(ctx context.Context, vcursor VCursor, bin
 		default:
 			return rp.multiEqual(ctx, vcursor, bindVars)
 		}
+	case KeyRange:
+		return rp.keyRange(ctx, vcursor, bindVars)
 	default:
 		// Unreachable.
 		return nil, nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unsupported opcode: %v", rp.Opcode)
@@ -348,6 +355,24 @@ func (rp *RoutingParameters) equal(ctx context.Context, vcursor VCursor, bindVar
 	return rss, multiBindVars, nil
 }
 
+func (rp *RoutingParameters) keyRange(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) ([]*srvtopo.ResolvedShard, []map[string]*querypb.BindVariable, error) {
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	var bounds [2]sqltypes.Value
+	for i, rvalue := range rp.Values {
+		v, err := env.Evaluate(rvalue)
+		if err != nil {
+			return nil, nil, err
+		}
+		bounds[i] = v.Value(vcursor.ConnCollation())
+	}
+	destination, err := rp.Vindex.(vindexes.RangeMapper).MapRange(bounds[0], bounds[1])
+	if err != nil {
+		// the vindex cannot tell where the rows of this range are
+		destination = key.DestinationAllShards{}
+	}
+	return rp.byDestination(ctx, vcursor, bindVars, destination)
+}
+
 func (rp *RoutingParameters) equalMultiCol(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) ([]*srvtopo.ResolvedShard, []map[string]*querypb.BindVariable, error) {
 	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
 	var rowValue []sqltypes.Value
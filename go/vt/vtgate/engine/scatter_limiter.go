@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScatterLimiter limits the number of shards a query sends queries to at once,
+// and accounts for the time these queries waited for their turn.
+// The limit applies to every fan out of the query on its own, since a primitive
+// can start a fan out while another one is still running, like a streaming join.
+// All the methods can be called on a nil limiter, which does not limit anything.
+type ScatterLimiter struct {
+	concurrency int
+	queueTime   atomic.Int64
+}
+
+// NewScatterLimiter creates a ScatterLimiter. If concurrency is zero, the number
+// of shards queried at once is not limited.
+func NewScatterLimiter(concurrency int) *ScatterLimiter {
+	return &ScatterLimiter{concurrency: max(concurrency, 0)}
+}
+
+// Concurrency returns the maximum number of shards queried at once, zero meaning no limit
+func (l *ScatterLimiter) Concurrency() int {
+	if l == nil {
+		return 0
+	}
+	return l.concurrency
+}
+
+// Limits returns true if running queries on n shards at once goes over the limit
+func (l *ScatterLimiter) Limits(n int) bool {
+	concurrency := l.Concurrency()
+	return concurrency > 0 && n > concurrency
+}
+
+// QueueTime returns the total time the queries waited to be sent
+func (l *ScatterLimiter) QueueTime() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return time.Duration(l.queueTime.Load())
+}
+
+// Go calls action for every i in [0, n), each in its own goroutine, and waits for all of them.
+// At most Concurrency actions run at once, the others being started in order as the running
+// ones return.
+func (l *ScatterLimiter) Go(n int, action func(i int)) {
+	var wg sync.WaitGroup
+	if !l.Limits(n) {
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				action(i)
+			}(i)
+		}
+		wg.Wait()
+		return
+	}
+
+	start := time.Now()
+	next := make(chan int)
+	wg.Add(l.concurrency)
+	for w := 0; w < l.concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				action(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+		l.queueTime.Add(int64(time.Since(start)))
+	}
+	close(next)
+	wg.Wait()
+}
+
+// scatterSlots hands out the slots of a limited fan out to the queries that hold their
+// slot for as long as they run, instead of the limiter running them one after the other.
+// Like with Go, the queries get their slot in order.
+type scatterSlots struct {
+	slots chan struct{}
+	turns []chan struct{}
+	// waiting is the number of queries that don't have a slot yet. It only goes down.
+	waiting atomic.Int64
+}
+
+// slots returns the slots for n queries, which are handed out until the context is done.
+func (l *ScatterLimiter) slots(ctx context.Context, n int) *scatterSlots {
+	s := &scatterSlots{
+		slots: make(chan struct{}, l.Concurrency()),
+		turns: make([]chan struct{}, n),
+	}
+	for i := range s.turns {
+		s.turns[i] = make(chan struct{})
+	}
+	s.waiting.Store(int64(n))
+
+	start := time.Now()
+	go func() {
+		for _, turn := range s.turns {
+			select {
+			case s.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			l.queueTime.Add(int64(time.Since(start)))
+			s.waiting.Add(-1)
+			close(turn)
+		}
+	}()
+	return s
+}
+
+// acquire waits for the slot of the i-th query, or for the context to be done.
+func (s *scatterSlots) acquire(ctx context.Context, i int) error {
+	select {
+	case <-s.turns[i]:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the slot of a query that ended.
+func (s *scatterSlots) release() {
+	<-s.slots
+}
+
+// contended returns true if some queries are still waiting for a slot
+func (s *scatterSlots) contended() bool {
+	return s.waiting.Load() > 0
+}
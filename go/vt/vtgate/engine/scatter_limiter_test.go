@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScatterLimiter(t *testing.T) {
+	run := func(limiter *ScatterLimiter, n int) (maxRunning int, started []int) {
+		var mu sync.Mutex
+		running := 0
+		limiter.Go(n, func(i int) {
+			mu.Lock()
+			started = append(started, i)
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+		return maxRunning, started
+	}
+
+	limiter := NewScatterLimiter(2)
+	maxRunning, started := run(limiter, 6)
+	require.EqualValues(t, 2, maxRunning)
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5}, started)
+	require.Less(t, started[0], 2, "the first shards start first")
+	require.Greater(t, limiter.QueueTime(), 20*time.Millisecond, "the last shards waited for the first ones")
+
+	// not limited
+	for _, limiter := range []*ScatterLimiter{nil, NewScatterLimiter(0), NewScatterLimiter(10)} {
+		maxRunning, started = run(limiter, 6)
+		require.EqualValues(t, 6, maxRunning)
+		require.Len(t, started, 6)
+		require.Zero(t, limiter.QueueTime())
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// SequenceTopoLeaser leases a block of count values of a sequence stored in the topo,
+// which starts at start if it does not exist yet, and returns the first one.
+type SequenceTopoLeaser func(ctx context.Context, keyspace, sequence string, start, count int64) (int64, error)
+
+// SequenceCache hands out the values of the sequences with a backend from blocks
+// leased ahead of time, so that an insert only waits for the topo or for a sequence
+// shard when the block of its sequence runs out. The blocks are leased from the topo,
+// or from any available shard of the keyspace of the sequence, starting with the one
+// the previous block came from. A block leased from a shard has to be in the range
+// of values the VSchema gives to that shard, as the shards share no counter.
+// Every vtgate has its own blocks, so the values are unique but not ordered across
+// vtgates, and the values left in its blocks when a vtgate stops are never used.
+type SequenceCache struct {
+	leaseFromTopo SequenceTopoLeaser
+	now           func() time.Time
+
+	mu        sync.Mutex
+	sequences map[string]*cachedSequence
+}
+
+// cachedSequence is the block of values of a sequence. Its lock is held while
+// a block is leased, so that the inserts waiting for values share the new block.
+type cachedSequence struct {
+	mu sync.Mutex
+
+	keyspace string
+	name     string
+	backend  *vindexes.SequenceBackend
+
+	// start and end delimit the values of the current block, and next
+	// is the next one to hand out.
+	start, next, end int64
+	// shard is the index of the shard the current block was leased from,
+	// and leasedFrom its name, or "topo".
+	shard      int
+	leasedFrom string
+	leases     int64
+	handedOut  int64
+
+	// The exhaustion rate is measured from the lease before the last one,
+	// so that it covers a whole block once two blocks have been leased.
+	lastLease            time.Time
+	handedOutAtLastLease int64
+	prevLease            time.Time
+	handedOutAtPrevLease int64
+}
+
+// SequenceCacheStats describes the block of values of a sequence cached by a vtgate.
+type SequenceCacheStats struct {
+	Keyspace  string
+	Sequence  string
+	Backend   string
+	BlockSize int64
+	// BlockStart and BlockEnd are the first and last values of the current block.
+	BlockStart int64
+	BlockEnd   int64
+	NextValue  int64
+	// Cached is the number of values left in the current block.
+	Cached     int64
+	Leases     int64
+	LeasedFrom string
+	LastLease  time.Time
+	// ExhaustionRate is the number of values handed out per second.
+	ExhaustionRate float64
+}
+
+// NewSequenceCache creates a SequenceCache, which leases the blocks of the sequences
+// stored in the topo with leaseFromTopo. It can be nil if there is no topo to lease from.
+func NewSequenceCache(leaseFromTopo SequenceTopoLeaser) *SequenceCache {
+	return &SequenceCache{
+		leaseFromTopo: leaseFromTopo,
+		now:           time.Now,
+		sequences:     make(map[string]*cachedSequence),
+	}
+}
+
+// Next returns the first of count consecutive values of the sequence of gen, leasing
+// a new block when the values left in the current one do not suffice. These values
+// are then skipped, to keep the values of an insert consecutive.
+func (sc *SequenceCache) Next(ctx context.Context, vcursor VCursor, primitive Primitive, gen *Generate, count int64) (int64, error) {
+	seq := sc.sequence(gen.Keyspace.Name, gen.Sequence)
+	seq.mu.Lock()
+	defer seq.mu.Unlock()
+
+	// The backend changes with the VSchema, and the current block can only be
+	// kept while the values still come from the same place.
+	if backend := gen.Backend; seq.backend != backend {
+		if seq.backend != nil && seq.backend.Type != backend.Type {
+			seq.next = seq.end
+		}
+		seq.backend = backend
+	}
+
+	if seq.end-seq.next < count {
+		size := max(seq.backend.BlockSize, count)
+		var (
+			first int64
+			from  string
+			err   error
+		)
+		switch seq.backend.Type {
+		case vindexes.SequenceBackendTopo:
+			if sc.leaseFromTopo == nil {
+				return 0, vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "no topo to lease the values of sequence %s.%s from", seq.keyspace, seq.name)
+			}
+			first, err = sc.leaseFromTopo(ctx, seq.keyspace, seq.name, seq.backend.Start, size)
+			from = vindexes.SequenceBackendTopo
+		case vindexes.SequenceBackendShards:
+			first, from, err = seq.leaseFromShards(ctx, vcursor, primitive, gen.Query, size)
+		default:
+			return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] unknown backend %s for sequence %s.%s", seq.backend.Type, seq.keyspace, seq.name)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		seq.prevLease, seq.handedOutAtPrevLease = seq.lastLease, seq.handedOutAtLastLease
+		seq.lastLease, seq.handedOutAtLastLease = sc.now(), seq.handedOut
+		seq.start, seq.next, seq.end = first, first, first+size
+		seq.leasedFrom = from
+		seq.leases++
+	}
+
+	first := seq.next
+	seq.next += count
+	seq.handedOut += count
+	return first, nil
+}
+
+// sequence returns the cached block of a sequence, creating an empty one the first time.
+func (sc *SequenceCache) sequence(keyspace, name string) *cachedSequence {
+	key := keyspace + "." + name
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	seq, ok := sc.sequences[key]
+	if !ok {
+		seq = &cachedSequence{
+			keyspace: keyspace,
+			name:     name,
+		}
+		sc.sequences[key] = seq
+	}
+	return seq
+}
+
+// leaseFromShards leases a block from the sequence table on one of the shards of its
+// keyspace, trying the shard of the previous block first and the next ones when it fails.
+// It returns the first value of the block, and the shard it comes from. A shard whose
+// block is out of its range is skipped, so that no value of another shard is handed out.
+func (seq *cachedSequence) leaseFromShards(ctx context.Context, vcursor VCursor, primitive Primitive, query string, count int64) (int64, string, error) {
+	rss, _, err := vcursor.ResolveDestinations(ctx, seq.keyspace, nil, []key.Destination{key.DestinationAllShards{}})
+	if err != nil {
+		return 0, "", err
+	}
+	if len(rss) == 0 {
+		return 0, "", vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "no shard to lease the values of sequence %s.%s from", seq.keyspace, seq.name)
+	}
+
+	bindVars := map[string]*querypb.BindVariable{nextValBV: sqltypes.Int64BindVariable(count)}
+	var errs []error
+	for i := range rss {
+		shard := (seq.shard + i) % len(rss)
+		qr, err := vcursor.ExecuteStandalone(ctx, primitive, query, bindVars, rss[shard])
+		if err == nil && len(qr.Rows) == 0 {
+			err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "no value returned by sequence %s.%s", seq.keyspace, seq.name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		first, err := qr.Rows[0][0].ToCastInt64()
+		if err != nil {
+			return 0, "", err
+		}
+		name := rss[shard].Target.Shard
+		r, ok := seq.backend.ShardRange(name)
+		if !ok {
+			errs = append(errs, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no range of values for shard %s of sequence %s.%s", name, seq.keyspace, seq.name))
+			continue
+		}
+		if first < r.Start || first > r.End-count {
+			errs = append(errs, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "values %d to %d of shard %s are out of its range [%d, %d) for sequence %s.%s", first, first+count-1, name, r.Start, r.End, seq.keyspace, seq.name))
+			continue
+		}
+		seq.shard = shard
+		return first, name, nil
+	}
+	return 0, "", vterrors.Wrapf(vterrors.Aggregate(errs), "could not lease the values of sequence %s.%s from any shard", seq.keyspace, seq.name)
+}
+
+// Stats returns the blocks of the cached sequences, sorted by keyspace and name.
+func (sc *SequenceCache) Stats() []SequenceCacheStats {
+	sc.mu.Lock()
+	sequences := make([]*cachedSequence, 0, len(sc.sequences))
+	for _, seq := range sc.sequences {
+		sequences = append(sequences, seq)
+	}
+	sc.mu.Unlock()
+
+	now := sc.now()
+	stats := make([]SequenceCacheStats, 0, len(sequences))
+	for _, seq := range sequences {
+		seq.mu.Lock()
+		s := SequenceCacheStats{
+			Keyspace:   seq.keyspace,
+			Sequence:   seq.name,
+			Leases:     seq.leases,
+			LeasedFrom: seq.leasedFrom,
+			LastLease:  seq.lastLease,
+		}
+		if seq.backend != nil {
+			s.Backend = seq.backend.Type
+			s.BlockSize = seq.backend.BlockSize
+		}
+		if seq.leases > 0 {
+			s.BlockStart = seq.start
+			s.BlockEnd = seq.end - 1
+			s.NextValue = seq.next
+			s.Cached = seq.end - seq.next
+			since := seq.prevLease
+			if since.IsZero() {
+				since = seq.lastLease
+			}
+			if elapsed := now.Sub(since).Seconds(); elapsed > 0 {
+				s.ExhaustionRate = float64(seq.handedOut-seq.handedOutAtPrevLease) / elapsed
+			}
+		}
+		seq.mu.Unlock()
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Keyspace != stats[j].Keyspace {
+			return stats[i].Keyspace < stats[j].Keyspace
+		}
+		return stats[i].Sequence < stats[j].Sequence
+	})
+	return stats
+}
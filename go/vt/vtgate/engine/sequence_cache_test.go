@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// fakeTopoSequences leases the blocks of the sequences like the topo does.
+type fakeTopoSequences struct {
+	next   map[string]int64
+	leases []string
+}
+
+func (f *fakeTopoSequences) lease(ctx context.Context, keyspace, sequence string, start, count int64) (int64, error) {
+	if f.next == nil {
+		f.next = make(map[string]int64)
+	}
+	name := keyspace + "." + sequence
+	first, ok := f.next[name]
+	if !ok {
+		first = start
+	}
+	f.next[name] = first + count
+	f.leases = append(f.leases, name)
+	return first, nil
+}
+
+func newSequenceGenerate(backend *vindexes.SequenceBackend) *Generate {
+	return &Generate{
+		Keyspace: &vindexes.Keyspace{Name: "ks2", Sharded: true},
+		Query:    "dummy_generate",
+		Sequence: "seq",
+		Backend:  backend,
+	}
+}
+
+func TestSequenceCacheTopo(t *testing.T) {
+	ctx := context.Background()
+	topo := &fakeTopoSequences{}
+	sc := NewSequenceCache(topo.lease)
+	gen := newSequenceGenerate(&vindexes.SequenceBackend{Type: vindexes.SequenceBackendTopo, BlockSize: 3, Start: 100})
+
+	var values []int64
+	for i := 0; i < 4; i++ {
+		first, err := sc.Next(ctx, &noopVCursor{}, nil, gen, 1)
+		require.NoError(t, err)
+		values = append(values, first)
+	}
+	assert.Equal(t, []int64{100, 101, 102, 103}, values)
+	assert.Equal(t, []string{"ks2.seq", "ks2.seq"}, topo.leases)
+
+	// The values of an insert are consecutive, so the two values left
+	// in the block are skipped, and the block is as large as the insert.
+	first, err := sc.Next(ctx, &noopVCursor{}, nil, gen, 4)
+	require.NoError(t, err)
+	assert.EqualValues(t, 106, first)
+	first, err = sc.Next(ctx, &noopVCursor{}, nil, gen, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 110, first)
+	assert.Len(t, topo.leases, 4)
+
+	sc = NewSequenceCache(nil)
+	_, err = sc.Next(ctx, &noopVCursor{}, nil, gen, 1)
+	assert.EqualError(t, err, "no topo to lease the values of sequence ks2.seq from")
+}
+
+// newShardsBackend returns a shards backend whose shards -80 and 80- hand
+// out the values below and from 1000.
+func newShardsBackend() *vindexes.SequenceBackend {
+	return &vindexes.SequenceBackend{
+		Type:      vindexes.SequenceBackendShards,
+		BlockSize: 5,
+		Start:     1,
+		ShardRanges: []vindexes.SequenceShardRange{
+			{Shard: "-80", Start: 1, End: 1000},
+			{Shard: "80-", Start: 1000, End: 3000},
+		},
+	}
+}
+
+func TestSequenceCacheShards(t *testing.T) {
+	ctx := context.Background()
+	sc := NewSequenceCache(nil)
+	gen := newSequenceGenerate(newShardsBackend())
+
+	// The first shard fails, so the block is leased from the second one.
+	vc := &loggingVCursor{
+		shards:    []string{"-80", "80-"},
+		resultErr: errors.New("shard down"),
+		results: []*sqltypes.Result{
+			nil,
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "1000"),
+		},
+	}
+	first, err := sc.Next(ctx, vc, nil, gen, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1000, first)
+	first, err = sc.Next(ctx, vc, nil, gen, 3)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1002, first)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks2 [] Destinations:DestinationAllShards()`,
+		`ExecuteStandalone dummy_generate n: type:INT64 value:"5" ks2 -80`,
+		`ExecuteStandalone dummy_generate n: type:INT64 value:"5" ks2 80-`,
+	})
+
+	// The next block is leased from the shard of the previous one.
+	vc.Rewind()
+	vc.results = []*sqltypes.Result{
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "2000"),
+	}
+	first, err = sc.Next(ctx, vc, nil, gen, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2000, first)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks2 [] Destinations:DestinationAllShards()`,
+		`ExecuteStandalone dummy_generate n: type:INT64 value:"5" ks2 80-`,
+	})
+
+	// The current block is dropped when the backend changes.
+	topo := &fakeTopoSequences{}
+	sc.leaseFromTopo = topo.lease
+	gen = newSequenceGenerate(&vindexes.SequenceBackend{Type: vindexes.SequenceBackendTopo, BlockSize: 5, Start: 3000})
+	first, err = sc.Next(ctx, vc, nil, gen, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3000, first)
+}
+
+func TestSequenceCacheShardsOutOfRange(t *testing.T) {
+	ctx := context.Background()
+	sc := NewSequenceCache(nil)
+	gen := newSequenceGenerate(newShardsBackend())
+
+	// The block of the first shard overlaps the range of the second one,
+	// so it is skipped and the block is leased from the second shard.
+	vc := &loggingVCursor{
+		shards: []string{"-80", "80-"},
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "998"),
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "1000"),
+		},
+	}
+	first, err := sc.Next(ctx, vc, nil, gen, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1000, first)
+
+	// No value is handed out when every shard ran out of its range,
+	// or when a shard has no range.
+	vc = &loggingVCursor{
+		shards: []string{"80-", "c0-"},
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "100"),
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("nextval", "int64"), "2998"),
+		},
+	}
+	_, err = sc.Next(ctx, vc, nil, gen, 5)
+	assert.ErrorContains(t, err, "could not lease the values of sequence ks2.seq from any shard")
+	assert.ErrorContains(t, err, "no range of values for shard c0- of sequence ks2.seq")
+	assert.ErrorContains(t, err, "values 2998 to 3002 of shard 80- are out of its range [1000, 3000) for sequence ks2.seq")
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks2 [] Destinations:DestinationAllShards()`,
+		`ExecuteStandalone dummy_generate n: type:INT64 value:"5" ks2 c0-`,
+		`ExecuteStandalone dummy_generate n: type:INT64 value:"5" ks2 80-`,
+	})
+}
+
+func TestSequenceCacheShardsUnavailable(t *testing.T) {
+	sc := NewSequenceCache(nil)
+	gen := newSequenceGenerate(newShardsBackend())
+	vc := &loggingVCursor{
+		shards:    []string{"-80", "80-"},
+		resultErr: errors.New("shard down"),
+		results:   []*sqltypes.Result{nil, nil},
+	}
+	_, err := sc.Next(context.Background(), vc, nil, gen, 1)
+	assert.ErrorContains(t, err, "could not lease the values of sequence ks2.seq from any shard")
+	assert.ErrorContains(t, err, "shard down")
+}
+
+func TestSequenceCacheStats(t *testing.T) {
+	ctx := context.Background()
+	topo := &fakeTopoSequences{}
+	sc := NewSequenceCache(topo.lease)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sc.now = func() time.Time { return now }
+
+	gen := newSequenceGenerate(&vindexes.SequenceBackend{Type: vindexes.SequenceBackendTopo, BlockSize: 10, Start: 1})
+	_, err := sc.Next(ctx, &noopVCursor{}, nil, gen, 4)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Second)
+	_, err = sc.Next(ctx, &noopVCursor{}, nil, gen, 4)
+	require.NoError(t, err)
+
+	other := newSequenceGenerate(&vindexes.SequenceBackend{Type: vindexes.SequenceBackendTopo, BlockSize: 10, Start: 1})
+	other.Keyspace = &vindexes.Keyspace{Name: "ks1"}
+	_, err = sc.Next(ctx, &noopVCursor{}, nil, other, 1)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Second)
+
+	lastLease := time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC)
+	assert.Equal(t, []SequenceCacheStats{{
+		Keyspace:       "ks1",
+		Sequence:       "seq",
+		Backend:        "topo",
+		BlockSize:      10,
+		BlockStart:     1,
+		BlockEnd:       10,
+		NextValue:      2,
+		Cached:         9,
+		Leases:         1,
+		LeasedFrom:     "topo",
+		LastLease:      lastLease,
+		ExhaustionRate: 0.5,
+	}, {
+		Keyspace:       "ks2",
+		Sequence:       "seq",
+		Backend:        "topo",
+		BlockSize:      10,
+		BlockStart:     1,
+		BlockEnd:       10,
+		NextValue:      9,
+		Cached:         2,
+		Leases:         1,
+		LeasedFrom:     "topo",
+		LastLease:      now.Add(-4 * time.Second),
+		ExhaustionRate: 2,
+	}}, sc.Stats())
+}
+
+func TestInsertUnshardedGenerateSequenceCache(t *testing.T) {
+	ins := newQueryInsert(
+		InsertUnsharded,
+		&vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: false,
+		},
+		"dummy_insert",
+	)
+	ins.Generate = newSequenceGenerate(&vindexes.SequenceBackend{Type: vindexes.SequenceBackendTopo, BlockSize: 10, Start: 4})
+	ins.Generate.Values = evalengine.NewTupleExpr(
+		evalengine.NewLiteralInt(1),
+		evalengine.NullExpr,
+		evalengine.NewLiteralInt(2),
+		evalengine.NullExpr,
+		evalengine.NewLiteralInt(3),
+	)
+
+	topo := &fakeTopoSequences{}
+	vc := newDMLTestVCursor("0")
+	vc.sequences = NewSequenceCache(topo.lease)
+	vc.results = []*sqltypes.Result{{InsertID: 1}}
+
+	result, err := ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	// The values come from the cache, without a query to the sequence.
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationAllShards()`,
+		`ExecuteMultiShard ks.0: dummy_insert {__seq0: type:INT64 value:"1" __seq1: type:INT64 value:"4" __seq2: type:INT64 value:"2" __seq3: type:INT64 value:"5" __seq4: type:INT64 value:"3"} true true`,
+	})
+	expectResult(t, result, &sqltypes.Result{InsertID: 4})
+	assert.Equal(t, []string{"ks2.seq"}, topo.leases)
+
+	// Without a cache, the values of a sequence with a backend cannot be generated.
+	vc = newDMLTestVCursor("0")
+	_, err = ins.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	assert.EqualError(t, err, "sequence seq has a backend but its values are not cached")
+}
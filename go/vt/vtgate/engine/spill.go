@@ -0,0 +1,386 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vthash"
+)
+
+const (
+	// spillPartitionCount is the number of partitions that hash based
+	// primitives split their input into once it no longer fits in memory
+	spillPartitionCount = 16
+
+	// spillReadBatchSize is the number of rows sent to the callback at a time
+	// when reading spilled rows back from disk
+	spillReadBatchSize = 1000
+
+	// spillMaxRepartitions is the number of times a partition that does not fit in
+	// memory when it is read back is split again, with a different hash seed
+	spillMaxRepartitions = 4
+)
+
+// Spiller is used by the primitives that buffer rows in memory to move them
+// to temporary files once max_memory_rows is exceeded. A single Spiller is
+// shared by all the primitives of a query, and keeps track of how many bytes
+// they have written to disk.
+type Spiller struct {
+	dir      string
+	maxBytes int64
+	written  atomic.Int64
+}
+
+// NewSpiller creates a Spiller that writes files to dir. If maxBytes is larger
+// than zero, the query fails once more than maxBytes have been spilled.
+func NewSpiller(dir string, maxBytes int64) *Spiller {
+	return &Spiller{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+}
+
+// SpilledBytes returns the number of bytes written to disk so far
+func (s *Spiller) SpilledBytes() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.written.Load()
+}
+
+func (s *Spiller) newFile() (*spillFile, error) {
+	file, err := os.CreateTemp(s.dir, "vtgate-spill-")
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "failed to create spill file")
+	}
+	return &spillFile{
+		spiller: s,
+		file:    file,
+		w:       bufio.NewWriter(file),
+	}, nil
+}
+
+// writeRows creates a new file containing all the given rows
+func (s *Spiller) writeRows(rows []sqltypes.Row) (*spillFile, error) {
+	f, err := s.newFile()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := f.write(row); err != nil {
+			f.close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// newPartitions creates the files of a set of partitions. The partitions of a
+// different depth use a different seed, so that the rows of a partition are
+// spread over all the partitions when it is split again.
+func (s *Spiller) newPartitions(depth int) (*spillPartitions, error) {
+	p := &spillPartitions{depth: depth}
+	for i := 0; i < spillPartitionCount; i++ {
+		f, err := s.newFile()
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+		p.files = append(p.files, f)
+	}
+	return p, nil
+}
+
+// spillFile is a temporary file that rows are first written to, and then read back from.
+// The file is removed from disk when it is closed.
+type spillFile struct {
+	spiller *Spiller
+	file    *os.File
+	w       *bufio.Writer
+	r       *bufio.Reader
+	buf     []byte
+}
+
+func (f *spillFile) write(row sqltypes.Row) error {
+	buf := binary.AppendUvarint(f.buf[:0], uint64(len(row)))
+	for _, val := range row {
+		raw := val.Raw()
+		buf = binary.AppendUvarint(buf, uint64(val.Type()))
+		buf = binary.AppendUvarint(buf, uint64(len(raw)))
+		buf = append(buf, raw...)
+	}
+	f.buf = buf
+
+	s := f.spiller
+	if written := s.written.Add(int64(len(buf))); s.maxBytes > 0 && written > s.maxBytes {
+		return vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "spilled bytes exceeded allowed limit of %d", s.maxBytes)
+	}
+	_, err := f.w.Write(buf)
+	return err
+}
+
+// rewind flushes everything written so far, and positions the file so that
+// the rows can be read back from the start
+func (f *spillFile) rewind() error {
+	if err := f.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if f.r == nil {
+		f.r = bufio.NewReader(f.file)
+	} else {
+		f.r.Reset(f.file)
+	}
+	return nil
+}
+
+// read returns the next row in the file, or io.EOF once all rows have been read
+func (f *spillFile) read() (sqltypes.Row, error) {
+	cols, err := binary.ReadUvarint(f.r)
+	if err != nil {
+		return nil, err
+	}
+	row := make(sqltypes.Row, cols)
+	for i := range row {
+		typ, err := binary.ReadUvarint(f.r)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		size, err := binary.ReadUvarint(f.r)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		var raw []byte
+		if size > 0 {
+			raw = make([]byte, size)
+			if _, err := io.ReadFull(f.r, raw); err != nil {
+				return nil, noEOF(err)
+			}
+		}
+		row[i] = sqltypes.MakeTrusted(querypb.Type(typ), raw)
+	}
+	return row, nil
+}
+
+// readAll calls fn with each of the rows left in the file
+func (f *spillFile) readAll(fn func(sqltypes.Row) error) error {
+	for {
+		row, err := f.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (f *spillFile) close() {
+	_ = f.file.Close()
+	_ = os.Remove(f.file.Name())
+}
+
+// noEOF turns an EOF in the middle of a row into an error,
+// so it is not mistaken for the end of the file
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// spillPartitions splits rows over a fixed number of files using their hash code,
+// so that rows with the same hash code always end up in the same partition
+type spillPartitions struct {
+	files []*spillFile
+	depth int
+}
+
+func (p *spillPartitions) write(hash vthash.Hash, row sqltypes.Row) error {
+	partition := hash[0]
+	if p.depth > 0 {
+		hasher := vthash.New()
+		hasher.Init(uint64(p.depth))
+		_, _ = hasher.Write(hash[:])
+		partition = hasher.Sum128()[0]
+	}
+	return p.files[int(partition)%len(p.files)].write(row)
+}
+
+// forEach rewinds every partition and calls f with each one of them in turn
+func (p *spillPartitions) forEach(f func(i int, file *spillFile) error) error {
+	for i, file := range p.files {
+		if err := file.rewind(); err != nil {
+			return err
+		}
+		if err := f(i, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *spillPartitions) close() {
+	if p == nil {
+		return
+	}
+	for _, f := range p.files {
+		f.close()
+	}
+}
+
+// mergeRuns merges the sorted runs spilled to disk with the sorted rows still in memory,
+// and calls fn with each row in order. It stops without an error once fn returns io.EOF.
+func mergeRuns(compare evalengine.Comparison, runs []*spillFile, inMemory []sqltypes.Row, fn func(sqltypes.Row) error) error {
+	merge := &evalengine.Merger{Compare: compare}
+
+	// the rows in memory are treated as one more source, after all the runs
+	memorySource := len(runs)
+	next := func(source int) (sqltypes.Row, error) {
+		if source == memorySource {
+			if len(inMemory) == 0 {
+				return nil, io.EOF
+			}
+			row := inMemory[0]
+			inMemory = inMemory[1:]
+			return row, nil
+		}
+		return runs[source].read()
+	}
+
+	for _, run := range runs {
+		if err := run.rewind(); err != nil {
+			return err
+		}
+	}
+	for source := 0; source <= memorySource; source++ {
+		row, err := next(source)
+		switch {
+		case err == io.EOF:
+		case err != nil:
+			return err
+		default:
+			merge.Push(row, source)
+		}
+	}
+	merge.Init()
+
+	for merge.Len() > 0 {
+		row, source := merge.Pop()
+		if err := fn(row); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		row, err := next(source)
+		switch {
+		case err == io.EOF:
+		case err != nil:
+			return err
+		default:
+			merge.Push(row, source)
+		}
+	}
+	return nil
+}
+
+// spillSorter sorts rows that might not fit in memory. Once max_memory_rows is exceeded,
+// the rows in memory are sorted and written to disk as a run, and the runs are merged
+// with the rows left in memory when the rows are read back.
+type spillSorter struct {
+	compare evalengine.Comparison
+	vcursor VCursor
+
+	rows []sqltypes.Row
+	runs []*spillFile
+}
+
+func (s *spillSorter) push(row sqltypes.Row) error {
+	s.rows = append(s.rows, row)
+	if s.vcursor == nil || !s.vcursor.ExceedsMaxMemoryRows(len(s.rows)) {
+		return nil
+	}
+	spiller := s.vcursor.Spiller()
+	if spiller == nil {
+		return nil
+	}
+	s.compare.Sort(s.rows)
+	run, err := spiller.writeRows(s.rows)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, run)
+	s.rows = nil
+	return nil
+}
+
+// forEach calls fn with all the rows pushed so far, in order
+func (s *spillSorter) forEach(fn func(sqltypes.Row) error) error {
+	s.compare.Sort(s.rows)
+	return mergeRuns(s.compare, s.runs, s.rows, fn)
+}
+
+func (s *spillSorter) close() {
+	if s == nil {
+		return
+	}
+	for _, run := range s.runs {
+		run.close()
+	}
+	s.runs = nil
+	s.rows = nil
+}
+
+// executeStreaming runs a primitive that can spill through its streaming implementation
+// and collects its rows. Its input is then streamed instead of being read into memory at
+// once, which fails once max_memory_rows is exceeded.
+func executeStreaming(ctx context.Context, vcursor VCursor, p Primitive, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	result := &sqltypes.Result{}
+	var mu sync.Mutex
+	err := p.TryStreamExecute(ctx, vcursor, bindVars, wantfields, func(qr *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if result.Fields == nil && len(qr.Fields) != 0 {
+			result.Fields = qr.Fields
+		}
+		result.Rows = append(result.Rows, qr.Rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func TestSpillFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	spiller := NewSpiller(dir, 0)
+
+	rows := []sqltypes.Row{
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("foo"), sqltypes.NULL},
+		{sqltypes.NewInt64(-42), sqltypes.NewVarChar(""), sqltypes.NewVarBinary("\x00\xff")},
+		{},
+		{sqltypes.TestValue(sqltypes.Decimal, "1.50"), sqltypes.NewFloat64(2.5), sqltypes.NewDatetime("2024-01-01 10:00:00")},
+	}
+	f, err := spiller.writeRows(rows)
+	require.NoError(t, err)
+
+	// the file can be read more than once
+	for i := 0; i < 2; i++ {
+		require.NoError(t, f.rewind())
+		var got []sqltypes.Row
+		for {
+			row, err := f.read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			got = append(got, row)
+		}
+		require.Len(t, got, len(rows))
+		for j := range rows {
+			require.Equal(t, sqltypes.RowToProto3(rows[j]), sqltypes.RowToProto3(got[j]))
+		}
+	}
+	require.NotZero(t, spiller.SpilledBytes())
+
+	f.close()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,543 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vterrors"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+// Window is a primitive that evaluates window functions on the vtgate.
+// It expects the underlying primitive to feed results sorted by the
+// PartitionBy keys, followed by the OrderBy keys.
+// The values of the window functions are added in front of the input columns,
+// so the output of this primitive is the window functions followed by the
+// columns produced by the input.
+type Window struct {
+	// PartitionBy specifies the columns that divide the input into partitions.
+	// The window functions are evaluated separately for each partition.
+	PartitionBy evalengine.Comparison
+
+	// OrderBy specifies the ordering of the rows within a partition.
+	// It is used to find peer rows, which are rows with equal ordering values.
+	OrderBy evalengine.Comparison
+
+	// Funcs lists the window functions evaluated by this primitive.
+	Funcs []*WindowFuncParams
+
+	// Input is the primitive that will feed into this Primitive.
+	Input Primitive
+}
+
+// FrameUnit is the unit of a window frame.
+type FrameUnit int
+
+const (
+	// FrameRows counts the frame bounds in rows
+	FrameRows FrameUnit = iota
+	// FrameRange treats peer rows as a single unit when calculating the frame bounds
+	FrameRange
+)
+
+// FrameBoundType specifies where a window frame starts or ends
+type FrameBoundType int
+
+const (
+	UnboundedPreceding FrameBoundType = iota
+	Preceding
+	CurrentRow
+	Following
+	UnboundedFollowing
+)
+
+type (
+	// WindowFrame is the set of rows, relative to the current row, that framing window functions are evaluated over.
+	WindowFrame struct {
+		Unit       FrameUnit
+		Start, End FrameBound
+	}
+
+	// FrameBound is one of the ends of a WindowFrame
+	FrameBound struct {
+		Type FrameBoundType
+		// Offset is the number of rows used by Preceding and Following bounds
+		Offset int64
+	}
+
+	// WindowFuncParams specify the parameters for each window function.
+	WindowFuncParams struct {
+		Opcode WindowOpcode
+
+		// Col is the offset of the argument column. It is -1 for functions that don't take an argument.
+		Col int
+
+		// N is the static argument used by NTILE, NTH_VALUE, LAG and LEAD
+		N int64
+
+		// DefaultCol is the offset of the default value column used by LAG and LEAD.
+		// It is -1 when no default value was given.
+		DefaultCol int
+
+		// Frame is the frame to use for framing functions.
+		// If nil, the default frame is used, which is the whole partition when
+		// the window does not have an ORDER BY, and from the start of the partition
+		// to the last peer of the current row when it does.
+		Frame *WindowFrame
+
+		Alias string
+		Type  evalengine.Type
+
+		CollationEnv *collations.Environment
+	}
+)
+
+// String returns a string. Used for plan descriptions
+func (wf *WindowFuncParams) String() string {
+	var args []string
+	if wf.Col >= 0 {
+		args = append(args, strconv.Itoa(wf.Col))
+	}
+	switch wf.Opcode {
+	case WindowNtile:
+		args = append(args, strconv.FormatInt(wf.N, 10))
+	case WindowNthValue, WindowLag, WindowLead:
+		args = append(args, strconv.FormatInt(wf.N, 10))
+		if wf.DefaultCol >= 0 {
+			args = append(args, strconv.Itoa(wf.DefaultCol))
+		}
+	}
+	out := fmt.Sprintf("%s(%s)", wf.Opcode.String(), strings.Join(args, ", "))
+	if wf.Frame != nil && wf.Opcode.UsesFrame() {
+		out += " " + wf.Frame.String()
+	}
+	if wf.Alias != "" {
+		out += " AS " + wf.Alias
+	}
+	return out
+}
+
+func (fb FrameBound) String() string {
+	switch fb.Type {
+	case UnboundedPreceding:
+		return "UNBOUNDED PRECEDING"
+	case Preceding:
+		return fmt.Sprintf("%d PRECEDING", fb.Offset)
+	case CurrentRow:
+		return "CURRENT ROW"
+	case Following:
+		return fmt.Sprintf("%d FOLLOWING", fb.Offset)
+	case UnboundedFollowing:
+		return "UNBOUNDED FOLLOWING"
+	}
+	return "ERROR"
+}
+
+func (wf *WindowFrame) String() string {
+	unit := "ROWS"
+	if wf.Unit == FrameRange {
+		unit = "RANGE"
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", unit, wf.Start.String(), wf.End.String())
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (w *Window) RouteType() string {
+	return w.Input.RouteType()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (w *Window) GetKeyspaceName() string {
+	return w.Input.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (w *Window) GetTableName() string {
+	return w.Input.GetTableName()
+}
+
+// TryExecute is a Primitive function.
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (_ *sqltypes.Result, err error) {
+	defer evalengine.PanicHandler(&err)
+
+	result, err := vcursor.ExecutePrimitive(ctx, w.Input, bindVars, true /* we need the input fields to calculate the output types */)
+	if err != nil {
+		return nil, err
+	}
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
+	if err := usage.consume(result.Rows...); err != nil {
+		return nil, err
+	}
+
+	out := &sqltypes.Result{
+		Fields: w.fields(result.Fields),
+		Rows:   make([]sqltypes.Row, 0, len(result.Rows)),
+	}
+
+	start := 0
+	for i := 1; i <= len(result.Rows); i++ {
+		if i < len(result.Rows) && w.PartitionBy.Compare(result.Rows[start], result.Rows[i]) == 0 {
+			continue
+		}
+		rows, err := w.evaluatePartition(result.Fields, result.Rows[start:i])
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, rows...)
+		start = i
+	}
+	return out, nil
+}
+
+// TryStreamExecute is a Primitive function.
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) (err error) {
+	defer evalengine.PanicHandler(&err)
+
+	var fields []*querypb.Field
+	var partition []sqltypes.Row
+	usage := newMemoryUsage(vcursor)
+	defer usage.release()
+
+	flush := func() error {
+		if len(partition) == 0 {
+			return nil
+		}
+		rows, err := w.evaluatePartition(fields, partition)
+		if err != nil {
+			return err
+		}
+		partition = nil
+		usage.release()
+		return callback(&sqltypes.Result{Rows: rows})
+	}
+
+	visitor := func(qr *sqltypes.Result) error {
+		if fields == nil && len(qr.Fields) > 0 {
+			fields = qr.Fields
+			if err := callback(&sqltypes.Result{Fields: w.fields(fields)}); err != nil {
+				return err
+			}
+		}
+		for _, row := range qr.Rows {
+			if len(partition) > 0 && w.PartitionBy.Compare(partition[0], row) != 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			partition = append(partition, row)
+			if err := usage.consume(row); err != nil {
+				return err
+			}
+		}
+		if vcursor.ExceedsMaxMemoryRows(len(partition)) {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		return nil
+	}
+
+	/* we need the input fields types to correctly calculate the output types */
+	if err := vcursor.StreamExecutePrimitive(ctx, w.Input, bindVars, true, visitor); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// GetFields is a Primitive function.
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Input.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{Fields: w.fields(qr.Fields)}, nil
+}
+
+// Inputs returns the Primitive input for this window
+func (w *Window) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{w.Input}, nil
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Input.NeedsTransaction()
+}
+
+func (w *Window) fields(input []*querypb.Field) []*querypb.Field {
+	if input == nil {
+		return nil
+	}
+	fields := make([]*querypb.Field, 0, len(w.Funcs)+len(input))
+	for _, wf := range w.Funcs {
+		var argType querypb.Type
+		if wf.Col >= 0 {
+			argType = input[wf.Col].Type
+		}
+		name := wf.Alias
+		if name == "" {
+			name = wf.Opcode.String()
+		}
+		fields = append(fields, &querypb.Field{
+			Name: name,
+			Type: wf.Opcode.SQLType(argType),
+		})
+	}
+	for _, f := range input {
+		fields = append(fields, f.CloneVT())
+	}
+	return fields
+}
+
+// evaluatePartition calculates the window functions for all rows of a single partition,
+// and returns the output rows with the window function values in front of the input columns.
+func (w *Window) evaluatePartition(fields []*querypb.Field, rows []sqltypes.Row) ([]sqltypes.Row, error) {
+	peerStart, peerEnd := w.peers(rows)
+	out := make([]sqltypes.Row, len(rows))
+	for i, row := range rows {
+		outRow := make(sqltypes.Row, len(w.Funcs), len(w.Funcs)+len(row))
+		out[i] = append(outRow, row...)
+	}
+
+	for idx, wf := range w.Funcs {
+		var agg aggregator
+		if wf.isAggregate() {
+			var err error
+			agg, err = wf.newAggregator(fields)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// frameStart and frameEnd track the rows currently added to the aggregator,
+		// so that frames that only grow at the end can be aggregated incrementally
+		frameStart, frameEnd := -1, -1
+
+		for i := range rows {
+			var value sqltypes.Value
+			switch wf.Opcode {
+			case WindowRowNumber:
+				value = sqltypes.NewUint64(uint64(i + 1))
+			case WindowRank:
+				value = sqltypes.NewUint64(uint64(peerStart[i] + 1))
+			case WindowDenseRank:
+				value = sqltypes.NewUint64(uint64(denseRank(peerStart, i)))
+			case WindowPercentRank:
+				var pr float64
+				if len(rows) > 1 {
+					pr = float64(peerStart[i]) / float64(len(rows)-1)
+				}
+				value = sqltypes.NewFloat64(pr)
+			case WindowCumeDist:
+				value = sqltypes.NewFloat64(float64(peerEnd[i]) / float64(len(rows)))
+			case WindowNtile:
+				value = sqltypes.NewUint64(ntile(i, len(rows), wf.N))
+			case WindowLag, WindowLead:
+				target := i - int(wf.N)
+				if wf.Opcode == WindowLead {
+					target = i + int(wf.N)
+				}
+				switch {
+				case target >= 0 && target < len(rows):
+					value = rows[target][wf.Col]
+				case wf.DefaultCol >= 0:
+					value = rows[i][wf.DefaultCol]
+				default:
+					value = sqltypes.NULL
+				}
+			default:
+				start, end := w.frame(wf.Frame, i, len(rows), peerStart, peerEnd)
+				switch wf.Opcode {
+				case WindowFirstValue:
+					value = valueAt(rows, start, start, end, wf.Col)
+				case WindowLastValue:
+					value = valueAt(rows, end-1, start, end, wf.Col)
+				case WindowNthValue:
+					value = valueAt(rows, start+int(wf.N)-1, start, end, wf.Col)
+				default:
+					if start != frameStart || end < frameEnd {
+						agg.reset()
+						frameStart, frameEnd = start, start
+					}
+					for ; frameEnd < end; frameEnd++ {
+						if err := agg.add(rows[frameEnd]); err != nil {
+							return nil, err
+						}
+					}
+					value = agg.finish()
+				}
+			}
+			out[i][idx] = value
+		}
+	}
+	return out, nil
+}
+
+// peers calculates, for every row in the partition, the index of its first peer, and the index after its last peer.
+// When there is no ORDER BY, all rows in the partition are peers of each other.
+func (w *Window) peers(rows []sqltypes.Row) (peerStart, peerEnd []int) {
+	peerStart = make([]int, len(rows))
+	peerEnd = make([]int, len(rows))
+	start := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) && w.OrderBy.Compare(rows[start], rows[i]) == 0 {
+			continue
+		}
+		for j := start; j < i; j++ {
+			peerStart[j] = start
+			peerEnd[j] = i
+		}
+		start = i
+	}
+	return
+}
+
+// frame returns the half-open range of rows [start, end) that makes up the frame of the current row
+func (w *Window) frame(frame *WindowFrame, current, size int, peerStart, peerEnd []int) (start, end int) {
+	if frame == nil {
+		return 0, peerEnd[current]
+	}
+
+	bound := func(fb FrameBound, isEnd bool) int {
+		var pos int
+		switch fb.Type {
+		case UnboundedPreceding:
+			return 0
+		case UnboundedFollowing:
+			return size
+		case CurrentRow:
+			if frame.Unit == FrameRange {
+				if isEnd {
+					return peerEnd[current]
+				}
+				return peerStart[current]
+			}
+			pos = current
+		case Preceding:
+			pos = current - int(fb.Offset)
+		case Following:
+			pos = current + int(fb.Offset)
+		}
+		if isEnd {
+			pos++
+		}
+		return min(max(pos, 0), size)
+	}
+
+	start = bound(frame.Start, false)
+	end = bound(frame.End, true)
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+func valueAt(rows []sqltypes.Row, idx, start, end, col int) sqltypes.Value {
+	if idx < start || idx >= end {
+		return sqltypes.NULL
+	}
+	return rows[idx][col]
+}
+
+func denseRank(peerStart []int, current int) int {
+	rank := 0
+	for i := 0; i <= current; i++ {
+		if peerStart[i] == i {
+			rank++
+		}
+	}
+	return rank
+}
+
+// ntile calculates the bucket number of the row at the given position.
+// Like MySQL, if the rows can't be evenly distributed, the first buckets get one row extra.
+func ntile(row, size int, buckets int64) uint64 {
+	n := int(buckets)
+	if n >= size {
+		return uint64(row + 1)
+	}
+	perBucket := size / n
+	extra := size % n
+	bigBuckets := extra * (perBucket + 1)
+	if row < bigBuckets {
+		return uint64(row/(perBucket+1) + 1)
+	}
+	return uint64((row-bigBuckets)/perBucket + extra + 1)
+}
+
+func (wf *WindowFuncParams) isAggregate() bool {
+	switch wf.Opcode {
+	case WindowCount, WindowCountStar, WindowSum, WindowMin, WindowMax:
+		return true
+	default:
+		return false
+	}
+}
+
+func (wf *WindowFuncParams) newAggregator(fields []*querypb.Field) (aggregator, error) {
+	noDistinct := aggregatorDistinct{column: -1}
+	switch wf.Opcode {
+	case WindowCountStar:
+		return &aggregatorCountStar{}, nil
+	case WindowCount:
+		return &aggregatorCount{from: wf.Col, distinct: noDistinct}, nil
+	case WindowSum:
+		return &aggregatorSum{
+			from:     wf.Col,
+			sum:      evalengine.NewAggregationSum(fields[wf.Col].Type),
+			distinct: noDistinct,
+		}, nil
+	case WindowMin:
+		return &aggregatorMin{aggregatorMinMax{
+			from:   wf.Col,
+			minmax: evalengine.NewAggregationMinMax(fields[wf.Col].Type, wf.CollationEnv, wf.Type.Collation(), wf.Type.Values()),
+		}}, nil
+	case WindowMax:
+		return &aggregatorMax{aggregatorMinMax{
+			from:   wf.Col,
+			minmax: evalengine.NewAggregationMinMax(fields[wf.Col].Type, wf.CollationEnv, wf.Type.Collation(), wf.Type.Values()),
+		}}, nil
+	}
+	return nil, vterrors.VT13001(fmt.Sprintf("unexpected window aggregation: %s", wf.Opcode.String()))
+}
+
+func windowFuncParamsToString(in any) string {
+	return in.(*WindowFuncParams).String()
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{
+		"Functions": GenericJoin(w.Funcs, windowFuncParamsToString),
+	}
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, orderByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, orderByParamsToString)
+	}
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func windowInput() *fakePrimitive {
+	return &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"c1|c2|c3",
+				"varbinary|int64|int64",
+			),
+			"a|1|10",
+			"a|2|20",
+			"a|2|30",
+			"a|4|40",
+			"b|1|50",
+		)},
+	}
+}
+
+func newTestWindow(input Primitive, funcs ...*WindowFuncParams) *Window {
+	return &Window{
+		PartitionBy: evalengine.Comparison{{Col: 0, WeightStringCol: -1}},
+		OrderBy:     evalengine.Comparison{{Col: 1, WeightStringCol: -1}},
+		Funcs:       funcs,
+		Input:       input,
+	}
+}
+
+func TestWindowRanking(t *testing.T) {
+	w := newTestWindow(windowInput(),
+		&WindowFuncParams{Opcode: WindowRowNumber, Col: -1, DefaultCol: -1, Alias: "rn"},
+		&WindowFuncParams{Opcode: WindowRank, Col: -1, DefaultCol: -1, Alias: "rnk"},
+		&WindowFuncParams{Opcode: WindowDenseRank, Col: -1, DefaultCol: -1, Alias: "drnk"},
+		&WindowFuncParams{Opcode: WindowPercentRank, Col: -1, DefaultCol: -1, Alias: "prnk"},
+		&WindowFuncParams{Opcode: WindowCumeDist, Col: -1, DefaultCol: -1, Alias: "cd"},
+		&WindowFuncParams{Opcode: WindowNtile, Col: -1, DefaultCol: -1, N: 3, Alias: "nt"},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"rn|rnk|drnk|prnk|cd|nt|c1|c2|c3",
+			"uint64|uint64|uint64|float64|float64|uint64|varbinary|int64|int64",
+		),
+		"1|1|1|0|0.25|1|a|1|10",
+		"2|2|2|0.3333333333333333|0.75|1|a|2|20",
+		"3|2|2|0.3333333333333333|0.75|2|a|2|30",
+		"4|4|3|1|1|3|a|4|40",
+		"1|1|1|0|1|1|b|1|50",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+
+	w.Input = windowInput()
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+}
+
+func TestWindowValueFunctions(t *testing.T) {
+	w := newTestWindow(windowInput(),
+		&WindowFuncParams{Opcode: WindowLag, Col: 2, DefaultCol: -1, N: 1, Alias: "lag"},
+		&WindowFuncParams{Opcode: WindowLead, Col: 2, DefaultCol: 1, N: 1, Alias: "lead"},
+		&WindowFuncParams{Opcode: WindowFirstValue, Col: 2, DefaultCol: -1, Alias: "first"},
+		&WindowFuncParams{Opcode: WindowLastValue, Col: 2, DefaultCol: -1, Alias: "last"},
+		&WindowFuncParams{Opcode: WindowNthValue, Col: 2, DefaultCol: -1, N: 2, Alias: "second", Frame: &WindowFrame{
+			Unit:  FrameRows,
+			Start: FrameBound{Type: UnboundedPreceding},
+			End:   FrameBound{Type: UnboundedFollowing},
+		}},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"lag|lead|first|last|second|c1|c2|c3",
+			"int64|int64|int64|int64|int64|varbinary|int64|int64",
+		),
+		"null|20|10|10|20|a|1|10",
+		"10|30|10|30|20|a|2|20",
+		"20|40|10|30|20|a|2|30",
+		"30|4|10|40|20|a|4|40",
+		"null|1|50|50|null|b|1|50",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+}
+
+func TestWindowAggregations(t *testing.T) {
+	collationEnv := collations.MySQL8()
+	w := newTestWindow(windowInput(),
+		&WindowFuncParams{Opcode: WindowSum, Col: 2, DefaultCol: -1, Alias: "running_sum"},
+		&WindowFuncParams{Opcode: WindowSum, Col: 2, DefaultCol: -1, Alias: "moving_sum", Frame: &WindowFrame{
+			Unit:  FrameRows,
+			Start: FrameBound{Type: Preceding, Offset: 1},
+			End:   FrameBound{Type: Following, Offset: 1},
+		}},
+		&WindowFuncParams{Opcode: WindowCountStar, Col: -1, DefaultCol: -1, Alias: "cnt", Frame: &WindowFrame{
+			Unit:  FrameRows,
+			Start: FrameBound{Type: UnboundedPreceding},
+			End:   FrameBound{Type: UnboundedFollowing},
+		}},
+		&WindowFuncParams{Opcode: WindowMin, Col: 2, DefaultCol: -1, Alias: "min", CollationEnv: collationEnv, Frame: &WindowFrame{
+			Unit:  FrameRows,
+			Start: FrameBound{Type: Preceding, Offset: 1},
+			End:   FrameBound{Type: CurrentRow},
+		}},
+		&WindowFuncParams{Opcode: WindowMax, Col: 2, DefaultCol: -1, Alias: "max", CollationEnv: collationEnv, Frame: &WindowFrame{
+			Unit:  FrameRange,
+			Start: FrameBound{Type: CurrentRow},
+			End:   FrameBound{Type: CurrentRow},
+		}},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"running_sum|moving_sum|cnt|min|max|c1|c2|c3",
+			"decimal|decimal|int64|int64|int64|varbinary|int64|int64",
+		),
+		"10|30|4|10|10|a|1|10",
+		"60|60|4|10|30|a|2|20",
+		"60|90|4|20|30|a|2|30",
+		"100|70|4|30|40|a|4|40",
+		"50|50|1|50|50|b|1|50",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+
+	w.Input = windowInput()
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+}
+
+func TestWindowWithoutPartitionAndOrder(t *testing.T) {
+	w := &Window{
+		Funcs: []*WindowFuncParams{
+			{Opcode: WindowCount, Col: 2, DefaultCol: -1, Alias: "cnt"},
+			{Opcode: WindowRank, Col: -1, DefaultCol: -1, Alias: "rnk"},
+		},
+		Input: windowInput(),
+	}
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"cnt|rnk|c1|c2|c3",
+			"int64|uint64|varbinary|int64|int64",
+		),
+		"5|1|a|1|10",
+		"5|1|a|2|20",
+		"5|1|a|2|30",
+		"5|1|a|4|40",
+		"5|1|b|1|50",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, result)
+}
+
+func TestWindowGetFields(t *testing.T) {
+	w := newTestWindow(windowInput(),
+		&WindowFuncParams{Opcode: WindowRowNumber, Col: -1, DefaultCol: -1, Alias: "rn"},
+		&WindowFuncParams{Opcode: WindowMax, Col: 2, DefaultCol: -1, Alias: "max"},
+	)
+
+	result, err := w.GetFields(context.Background(), &noopVCursor{}, nil)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestFields(
+		"rn|max|c1|c2|c3",
+		"uint64|int64|varbinary|int64|int64",
+	), result.Fields)
+}
+
+func TestNtile(t *testing.T) {
+	tcases := []struct {
+		size    int
+		buckets int64
+		want    []uint64
+	}{
+		{size: 5, buckets: 2, want: []uint64{1, 1, 1, 2, 2}},
+		{size: 7, buckets: 3, want: []uint64{1, 1, 1, 2, 2, 3, 3}},
+		{size: 3, buckets: 5, want: []uint64{1, 2, 3}},
+		{size: 4, buckets: 4, want: []uint64{1, 2, 3, 4}},
+	}
+	for _, tc := range tcases {
+		var got []uint64
+		for row := 0; row < tc.size; row++ {
+			got = append(got, ntile(row, tc.size, tc.buckets))
+		}
+		require.Equal(t, tc.want, got, "ntile(%d) over %d rows", tc.buckets, tc.size)
+	}
+}
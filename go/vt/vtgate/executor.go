@@ -77,6 +77,8 @@ var (
 	queriesProcessedByTable = stats.NewCountersWithMultiLabels("QueriesProcessedByTable", "Queries processed at vtgate by plan type, keyspace and table", []string{"Plan", "Keyspace", "Table"})
 	queriesRoutedByTable    = stats.NewCountersWithMultiLabels("QueriesRoutedByTable", "Queries routed from vtgate to vttablet by plan type, keyspace and table", []string{"Plan", "Keyspace", "Table"})
 
+	spilledBytes = stats.NewCountersWithSingleLabel("SpilledBytes", "Bytes spilled to disk by vtgate primitives exceeding max_memory_rows, by plan type", "Plan")
+
 	exceedMemoryRowsLogger = logutil.NewThrottledLogger("ExceedMemoryRows", 1*time.Minute)
 
 	errorTransform errorTransformer = nullErrorTransformer{}
@@ -116,6 +118,15 @@ type Executor struct {
 	plans *PlanCache
 	epoch atomic.Uint32
 
+	// resultCache is nil unless the result cache is enabled.
+	resultCache *ResultCache
+
+	// queries are the queries being executed, and the memory they hold.
+	queries *runningQueries
+
+	// sequences are the blocks of values leased for the sequences with a backend.
+	sequences *engine.SequenceCache
+
 	normalize       bool
 	warnShardedOnly bool
 
@@ -178,7 +189,9 @@ func NewExecutor(
 		plans:               plans,
 		warmingReadsPercent: warmingReadsPercent,
 		warmingReadsChannel: make(chan bool, warmingReadsConcurrency),
+		queries:             newRunningQueries(maxQueryMemory, maxTotalQueryMemory),
 	}
+	e.sequences = engine.NewSequenceCache(e.leaseSequenceFromTopo)
 
 	vschemaacl.Init()
 	// we subscribe to update from the VSchemaManager
@@ -210,6 +223,9 @@ func NewExecutor(
 		stats.NewCounterFunc("QueryPlanCacheMisses", "Query plan cache misses", func() int64 {
 			return e.plans.Metrics.Misses()
 		})
+		stats.NewGaugeFunc("QueryMemoryBytes", "Bytes held in memory by the rows of the running queries", func() int64 {
+			return e.queries.memory.Used()
+		})
 		servenv.HTTPHandle(pathQueryPlans, e)
 		servenv.HTTPHandle(pathScatterStats, e)
 		servenv.HTTPHandle(pathVSchema, e)
@@ -339,6 +355,8 @@ func (e *Executor) StreamExecute(
 		err := vc.StreamExecutePrimitive(ctx, plan.Instructions, bindVars, true, func(qr *sqltypes.Result) error {
 			return srr.storeResultStats(plan.Type, qr)
 		})
+		recordSpilledBytes(plan, vc)
+		recordScatterQueueTime(plan, vc)
 
 		// Check if there was partial DML execution. If so, rollback the effect of the partially executed query.
 		if err != nil {
@@ -1057,6 +1075,9 @@ func (e *Executor) SaveVSchema(vschema *vindexes.VSchema, stats *VSchemaStats) {
 	}
 	e.vschemaStats = stats
 	e.ClearPlans()
+	if e.resultCache != nil {
+		e.resultCache.vschemaUpdated(e.vschema)
+	}
 
 	if vschemaCounters != nil {
 		vschemaCounters.Add("Reload", 1)
@@ -1071,6 +1092,14 @@ func (e *Executor) SaveVSchema(vschema *vindexes.VSchema, stats *VSchemaStats) {
 	}
 }
 
+// EnableResultCache makes the executor cache the results of read-only queries.
+func (e *Executor) EnableResultCache(rc *ResultCache) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resultCache = rc
+	rc.vschemaUpdated(e.vschema)
+}
+
 // ParseDestinationTarget parses destination target string and sets default keyspace if possible.
 func (e *Executor) ParseDestinationTarget(targetString string) (string, topodatapb.TabletType, key.Destination, error) {
 	destKeyspace, destTabletType, dest, err := topoproto.ParseDestination(targetString, defaultTabletType)
@@ -1105,7 +1134,11 @@ func (e *Executor) getPlan(
 		return nil, vterrors.VT13001("vschema not initialized")
 	}
 
-	qh, err := sqlparser.BuildQueryHints(stmt)
+	// Query attributes can be used instead of the directives in the comments.
+	attributes := queryAttributesFromContext(ctx)
+	logStats.QueryAttributes = attributes
+
+	qh, err := sqlparser.BuildQueryHintsWithDefaults(stmt, attributes)
 	if err != nil {
 		return nil, err
 	}
@@ -1115,6 +1148,8 @@ func (e *Executor) getPlan(
 	vcursor.UpdateForeignKeyChecksState(qh.ForeignKeyChecks)
 	vcursor.SetPriority(qh.Priority)
 	vcursor.SetExecQueryTimeout(qh.Timeout)
+	vcursor.resultCacheTTL = qh.ResultCacheTTL
+	vcursor.SetScatterConcurrency(qh.ScatterConcurrency)
 
 	setVarComment, err := prepareSetVarComment(vcursor, stmt)
 	if err != nil {
@@ -1300,6 +1335,16 @@ func (e *Executor) updateQueryCounts(planType, keyspace, tableName string, shard
 	}
 }
 
+// recordSpilledBytes adds the number of bytes the query spilled to disk to the plan and the global stats
+func recordSpilledBytes(plan *engine.Plan, vcursor *vcursorImpl) {
+	spilled := vcursor.spiller.SpilledBytes()
+	if spilled == 0 {
+		return
+	}
+	plan.AddSpilledBytes(uint64(spilled))
+	spilledBytes.Add(plan.Instructions.RouteType(), spilled)
+}
+
 // VSchemaStats returns the loaded vschema stats.
 func (e *Executor) VSchemaStats() *VSchemaStats {
 	e.mu.Lock()
@@ -24,6 +24,7 @@ import (
 
 	"github.com/google/safehtml/template"
 
+	"vitess.io/vitess/go/stats"
 	"vitess.io/vitess/go/vt/logz"
 
 	"vitess.io/vitess/go/vt/proto/vtrpc"
@@ -31,9 +32,23 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/engine"
 )
 
+var scatterQueueTime = stats.NewCountersWithSingleLabel("ScatterQueueTimeNs", "Time in nanoseconds shard queries waited for the scatter concurrency limit, by plan type", "Plan")
+
+// recordScatterQueueTime adds the time the shard queries of the query waited for the scatter concurrency
+// limit to the plan and the global stats
+func recordScatterQueueTime(plan *engine.Plan, vcursor *vcursorImpl) {
+	queueTime := vcursor.scatterLimiter.QueueTime()
+	if queueTime == 0 {
+		return
+	}
+	plan.AddQueueTime(queueTime)
+	scatterQueueTime.Add(plan.Instructions.RouteType(), int64(queueTime))
+}
+
 type statsResults struct {
 	TotalReadOnlyTime  time.Duration
 	TotalScatterTime   time.Duration
+	TotalQueueTime     time.Duration
 	PercentTimeScatter float64
 	Items              []*statsResultItem
 }
@@ -42,6 +57,7 @@ type statsResults struct {
 type statsResultItem struct {
 	Query                  string
 	AvgTimePerQuery        time.Duration
+	AvgQueueTimePerQuery   time.Duration
 	PercentTimeOfReads     float64
 	PercentTimeOfScatters  float64
 	PercentCountOfReads    float64
@@ -52,6 +68,7 @@ type statsResultItem struct {
 
 func (e *Executor) gatherScatterStats() (statsResults, error) {
 	scatterExecTime := time.Duration(0)
+	scatterQueueTime := time.Duration(0)
 	readOnlyTime := time.Duration(0)
 	scatterCount := uint64(0)
 	readOnlyCount := uint64(0)
@@ -76,6 +93,7 @@ func (e *Executor) gatherScatterStats() (statsResults, error) {
 			plans = append(plans, plan)
 			routes = append(routes, route)
 			scatterExecTime += time.Duration(atomic.LoadUint64(&plan.ExecTime))
+			scatterQueueTime += time.Duration(atomic.LoadUint64(&plan.QueueTime))
 			scatterCount += atomic.LoadUint64(&plan.ExecCount)
 		}
 		if readOnly {
@@ -97,14 +115,17 @@ func (e *Executor) gatherScatterStats() (statsResults, error) {
 		route := routes[i]
 		execCount := atomic.LoadUint64(&plan.ExecCount)
 		execTime := time.Duration(atomic.LoadUint64(&plan.ExecTime))
+		queueTime := time.Duration(atomic.LoadUint64(&plan.QueueTime))
 
-		var avgTimePerQuery int64
+		var avgTimePerQuery, avgQueueTimePerQuery int64
 		if execCount != 0 {
 			avgTimePerQuery = execTime.Nanoseconds() / int64(execCount)
+			avgQueueTimePerQuery = queueTime.Nanoseconds() / int64(execCount)
 		}
 		resultItems[i] = &statsResultItem{
 			Query:                  plan.Original,
 			AvgTimePerQuery:        time.Duration(avgTimePerQuery),
+			AvgQueueTimePerQuery:   time.Duration(avgQueueTimePerQuery),
 			PercentTimeOfReads:     100 * float64(execTime) / float64(readOnlyTime),
 			PercentTimeOfScatters:  100 * float64(execTime) / float64(scatterExecTime),
 			PercentCountOfReads:    100 * float64(execCount) / float64(readOnlyCount),
@@ -116,6 +137,7 @@ func (e *Executor) gatherScatterStats() (statsResults, error) {
 	result := statsResults{
 		TotalReadOnlyTime:  readOnlyTime,
 		TotalScatterTime:   scatterExecTime,
+		TotalQueueTime:     scatterQueueTime,
 		PercentTimeScatter: 100 * float64(scatterExecTime) / float64(readOnlyTime),
 		Items:              resultItems,
 	}
@@ -146,7 +168,11 @@ func (e *Executor) WriteScatterStats(w http.ResponseWriter) {
 	}
 
 	_, err = fmt.Fprintf(w, "Percentage of time spent on scatter queries: %2.2f%%", results.PercentTimeScatter)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+	}
 
+	_, err = fmt.Fprintf(w, "<br>Time shard queries waited for the scatter concurrency limit: %v", results.TotalQueueTime)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
 	}
@@ -159,6 +185,7 @@ const statsHTML = `
 		<th>Query</th>
 		<th># of executions</th>
 		<th>Avg time/query</th>
+		<th>Avg queue time/query</th>
 		<th>% time of reads</th>
 		<th>% time of scatters</th>
 		<th>% of reads</th>
@@ -170,6 +197,7 @@ const statsHTML = `
 	<td>{{.Query}}</td>
 	<td>{{.Count}}</td>
 	<td>{{.AvgTimePerQuery}}</td>
+	<td>{{.AvgQueueTimePerQuery}}</td>
 	<td>{{.PercentTimeOfReads}}</td>
 	<td>{{.PercentTimeOfScatters}}</td>
 	<td>{{.PercentCountOfReads}}</td>
@@ -78,3 +78,28 @@ func TestScatterStatsHttpWriting(t *testing.T) {
 	require.Contains(t, recorder.Body.String(), "select * from `user` as u1 join `user` as u2 on u1.Id = u2.Id")
 	require.NoError(t, err)
 }
+
+func TestScatterStatsQueueTime(t *testing.T) {
+	executor, _, _, _, ctx := createExecutorEnv(t)
+	session := NewSafeSession(&vtgatepb.Session{TargetString: "@primary"})
+
+	_, err := executor.Execute(ctx, nil, "TestScatterStatsQueueTime", session, "select * from user", nil)
+	require.NoError(t, err)
+
+	result, err := executor.gatherScatterStats()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Items))
+	require.Zero(t, result.TotalQueueTime, "the scatter concurrency is not limited by default")
+
+	_, err = executor.Execute(ctx, nil, "TestScatterStatsQueueTime", session, "select /*vt+ SCATTER_CONCURRENCY=1 */ * from music", nil)
+	require.NoError(t, err)
+
+	result, err = executor.gatherScatterStats()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(result.Items))
+	require.NotZero(t, result.TotalQueueTime)
+
+	recorder := httptest.NewRecorder()
+	executor.WriteScatterStats(recorder)
+	require.Contains(t, recorder.Body.String(), "Avg queue time/query")
+}
@@ -1373,6 +1373,26 @@ func TestSelectNormalize(t *testing.T) {
 	sbc2.Queries = nil
 }
 
+func TestSelectNormalizeWindowFunctions(t *testing.T) {
+	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
+	executor.normalize = true
+
+	// The counts of the window functions stay literals, so that
+	// vtgate can evaluate them over the rows of all the shards.
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("col", "int64"), "1", "2")
+	sbc1.SetResults([]*sqltypes.Result{result})
+	sbc2.SetResults([]*sqltypes.Result{result})
+	session := &vtgatepb.Session{TargetString: "@primary"}
+	qr, err := executorExec(ctx, executor, session, "select col, ntile(2) over (order by col), lag(col, 2) over (order by col) from user", nil)
+	require.NoError(t, err)
+	// Two rows from each of the two shards, and a row from each other shard.
+	require.Len(t, qr.Rows, 10)
+	assert.Equal(t, sqltypes.NewUint64(1), qr.Rows[0][1])
+	assert.Equal(t, sqltypes.NewUint64(2), qr.Rows[9][1])
+	require.NotEmpty(t, sbc1.Queries)
+	assert.NotContains(t, sbc1.Queries[0].Sql, "ntile")
+}
+
 func TestSelectCaseSensitivity(t *testing.T) {
 	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
 
@@ -1803,6 +1803,27 @@ func TestGetPlanPriority(t *testing.T) {
 
 }
 
+func TestGetPlanQueryAttributes(t *testing.T) {
+	r, _, _, _, _ := createExecutorEnv(t)
+	session := NewSafeSession(&vtgatepb.Session{TargetString: "@unknown", Options: &querypb.ExecuteOptions{}})
+
+	attributes := map[string]string{"workload_name": "reports", "priority": "20", "trace_id": "abc"}
+	ctx := withQueryAttributes(context.Background(), attributes)
+	logStats := logstats.NewLogStats(ctx, "Test", "", "", nil)
+	vCursor, err := newVCursorImpl(session, makeComments(""), r, nil, r.vm, r.VSchema(), r.resolver.resolver, nil, false, pv)
+	require.NoError(t, err)
+
+	sql := "select /*vt+ PRIORITY=33 */ * from music_user_map"
+	stmt, err := sqlparser.NewTestParser().Parse(sql)
+	require.NoError(t, err)
+	_, err = r.getPlan(ctx, vCursor, sql, stmt, makeComments(""), map[string]*querypb.BindVariable{}, nil, true, logStats)
+	require.NoError(t, err)
+
+	assert.Equal(t, "33", vCursor.safeSession.Options.Priority, "the directive should take precedence")
+	assert.Equal(t, "reports", vCursor.safeSession.Options.WorkloadName)
+	assert.Equal(t, attributes, logStats.QueryAttributes)
+}
+
 func TestPassthroughDDL(t *testing.T) {
 	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
 	session := &vtgatepb.Session{
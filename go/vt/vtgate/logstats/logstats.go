@@ -55,6 +55,7 @@ type LogStats struct {
 	MirrorSourceExecuteTime time.Duration
 	MirrorTargetExecuteTime time.Duration
 	MirrorTargetError       error
+	QueryAttributes         map[string]string // QueryAttributes are the attributes sent by the MySQL client with the query
 }
 
 // NewLogStats constructs a new LogStats with supplied Method and ctx
@@ -194,6 +195,8 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 	log.Duration(stats.MirrorTargetExecuteTime)
 	log.Key("MirrorTargetError")
 	log.String(stats.MirrorTargetErrorStr())
+	log.Key("QueryAttributes")
+	log.StringMap(stats.QueryAttributes)
 
 	return log.Flush(w)
 }
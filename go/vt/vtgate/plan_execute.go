@@ -84,6 +84,9 @@ func (e *Executor) newExecute(
 		return err
 	}
 
+	running := e.queries.start(ctx, sql)
+	defer e.queries.finish(running)
+
 	var (
 		vs                 = e.VSchema()
 		lastVSchemaCreated = vs.GetCreated()
@@ -115,6 +118,7 @@ func (e *Executor) newExecute(
 		if err != nil {
 			return err
 		}
+		vcursor.memory = running.memory
 
 		// 3: Create a plan for the query.
 		// If we are retrying, it is likely that the routing rules have changed and hence we need to
@@ -323,6 +327,16 @@ func (e *Executor) executePlan(
 	execStart time.Time,
 ) (*sqltypes.Result, error) {
 
+	var cacheLookup *resultCacheLookup
+	if e.resultCache != nil {
+		var qr *sqltypes.Result
+		qr, cacheLookup = e.resultCache.lookup(ctx, vcursor, plan, bindVars)
+		if qr != nil {
+			e.setLogStats(logStats, plan, vcursor, execStart, nil, qr)
+			return qr, nil
+		}
+	}
+
 	// 4: Execute!
 	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
 
@@ -333,6 +347,14 @@ func (e *Executor) executePlan(
 	if err != nil {
 		return nil, e.rollbackExecIfNeeded(ctx, safeSession, bindVars, logStats, err)
 	}
+
+	switch {
+	case cacheLookup != nil:
+		e.resultCache.store(cacheLookup, qr)
+	case e.resultCache != nil && plan.Type != sqlparser.StmtSelect:
+		// Don't wait for the row events to drop the results of the tables we wrote to.
+		e.resultCache.invalidateTables(plan.TablesUsed...)
+	}
 	return qr, nil
 }
 
@@ -390,6 +412,8 @@ func (e *Executor) setLogStats(logStats *logstats.LogStats, plan *engine.Plan, v
 	logStats.TabletType = vcursor.TabletType().String()
 	errCount := e.logExecutionEnd(logStats, execStart, plan, err, qr)
 	plan.AddStats(1, time.Since(logStats.StartTime), logStats.ShardQueries, logStats.RowsAffected, logStats.RowsReturned, errCount)
+	recordSpilledBytes(plan, vcursor)
+	recordScatterQueueTime(plan, vcursor)
 }
 
 func (e *Executor) logExecutionEnd(logStats *logstats.LogStats, execStart time.Time, plan *engine.Plan, err error, qr *sqltypes.Result) uint64 {
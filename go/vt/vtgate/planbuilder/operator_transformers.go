@@ -18,6 +18,7 @@ package planbuilder
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -81,6 +82,8 @@ func transformToPrimitive(ctx *plancontext.PlanningContext, op operators.Operato
 		return transformRecurseCTE(ctx, op)
 	case *operators.PercentBasedMirror:
 		return transformPercentBasedMirror(ctx, op)
+	case *operators.Window:
+		return transformWindow(ctx, op)
 	}
 
 	return nil, vterrors.VT13001(fmt.Sprintf("unknown type encountered: %T (transformToPrimitive)", op))
@@ -192,6 +195,7 @@ func transformInsertionSelection(ctx *plancontext.PlanningContext, op *operators
 			ForceNonStreaming: op.ForceNonStreaming,
 			Generate:          autoIncGenerate(ins.AutoIncrement),
 			ColVindexes:       ins.ColVindexes,
+			VindexExprs:       ins.VindexExprs,
 		},
 		VindexValueOffset: ins.VindexValueOffset,
 	}
@@ -258,6 +262,31 @@ func transformSubQuery(ctx *plancontext.PlanningContext, op *operators.SubQuery)
 		return nil, err
 	}
 
+	if op.PerRow {
+		cs := &engine.CorrelatedSubquery{
+			Opcode:         op.FilterType,
+			SubqueryResult: op.SubqueryValueName,
+			HasValues:      op.HasValuesName,
+			Vars:           op.Vars,
+			Predicate:      op.PredicateWithOffsets,
+			ASTPredicate:   op.CorrelatedPredicate,
+			Outer:          outer,
+			Subquery:       inner,
+		}
+		if batch := op.Batch(ctx); batch != nil {
+			// if the batched query can't be planned, the subquery is evaluated one combination of outer values at a time
+			plan, _, err := newBuildSelectPlan(batch.Statement, ctx.ReservedVars, ctx.VSchema, ctx.PlannerVersion)
+			if err == nil {
+				cs.Batch = plan
+				cs.BatchVar = batch.Arg
+				cs.BatchKeys = batch.Vars
+				cs.BatchTypes = batch.Types
+				cs.BatchFallback = batch.Fallback
+			}
+		}
+		return cs, nil
+	}
+
 	cols, err := op.GetJoinColumns(ctx, op.Outer)
 	if err != nil {
 		return nil, err
@@ -311,9 +340,6 @@ func transformFkVerify(ctx *plancontext.PlanningContext, fkv *operators.FkVerify
 }
 
 func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggregator) (engine.Primitive, error) {
-	if op.WithRollup {
-		return nil, vterrors.VT12001("GROUP BY WITH ROLLUP not supported for sharded queries")
-	}
 	src, err := transformToPrimitive(ctx, op.Source)
 	if err != nil {
 		return nil, err
@@ -330,6 +356,10 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 			message := fmt.Sprintf("Aggregate UDF '%s' must be pushed down to MySQL", sqlparser.String(aggr.Original.Expr))
 			return nil, vterrors.VT12001(message)
 		}
+		if op.WithRollup && (aggr.Distinct || aggr.OpCode.IsDistinct() || aggr.OriginalOpCode.IsDistinct()) {
+			// the distinct values seen by one group can't be merged into the super-aggregate rows
+			return nil, vterrors.VT12001(fmt.Sprintf("DISTINCT aggregation with ROLLUP on sharded keyspace: %s", sqlparser.String(aggr.Original.Expr)))
+		}
 
 		aggrParam := engine.NewAggregateParam(aggr.OpCode, aggr.ColOffset, aggr.Alias, ctx.VSchema.Environment().CollationEnv())
 		aggrParam.Func = aggr.Func
@@ -340,6 +370,15 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 		aggrParam.OrigOpcode = aggr.OriginalOpCode
 		aggrParam.WCol = aggr.WSOffset
 		aggrParam.Type = aggr.GetTypeCollation(ctx)
+		if aggr.OpCode == opcode.AggregateGrouping {
+			aggrParam.GroupingKeys, err = groupingKeys(ctx, op, aggr.Func.GetArgs())
+			if err != nil {
+				return nil, err
+			}
+		}
+		if gcFunc, isGc := aggr.Func.(*sqlparser.GroupConcatExpr); isGc && len(aggr.ArgOffsets) > 0 {
+			aggrParam.Args, aggrParam.OrderBy = groupConcatParams(ctx, gcFunc, aggr)
+		}
 		aggregates = append(aggregates, aggrParam)
 	}
 
@@ -366,10 +405,54 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 		Aggregates:          aggregates,
 		GroupByKeys:         groupByKeys,
 		TruncateColumnCount: op.ResultColumns,
+		WithRollup:          op.WithRollup,
 		Input:               src,
 	}, nil
 }
 
+// groupConcatParams returns the columns of the arguments and of the ORDER BY clause of a GROUP_CONCAT evaluated at the vtgate
+func groupConcatParams(ctx *plancontext.PlanningContext, gc *sqlparser.GroupConcatExpr, aggr operators.Aggr) (args, orderBy evalengine.Comparison) {
+	collationEnv := ctx.VSchema.Environment().CollationEnv()
+	for i, expr := range gc.Exprs {
+		typ, _ := ctx.TypeForExpr(expr)
+		args = append(args, evalengine.OrderByParams{
+			Col:             aggr.ArgOffsets[i],
+			WeightStringCol: aggr.ArgWSOffsets[i],
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	for i, order := range gc.OrderBy {
+		typ, _ := ctx.TypeForExpr(operators.GroupConcatOrderExpr(gc, order.Expr))
+		orderBy = append(orderBy, evalengine.OrderByParams{
+			Col:             aggr.OrderByOffsets[i],
+			WeightStringCol: aggr.OrderByWSOffsets[i],
+			Desc:            order.Direction == sqlparser.DescOrder,
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	return args, orderBy
+}
+
+// groupingKeys returns, for each argument of a GROUPING() call, the index of the matching grouping key
+func groupingKeys(ctx *plancontext.PlanningContext, op *operators.Aggregator, args sqlparser.Exprs) ([]int, error) {
+	if !op.WithRollup {
+		return nil, vterrors.VT03036()
+	}
+	var keys []int
+	for argIdx, arg := range args {
+		idx := slices.IndexFunc(op.Grouping, func(gb operators.GroupBy) bool {
+			return ctx.SemTable.EqualsExprWithDeps(gb.Inner, arg)
+		})
+		if idx < 0 {
+			return nil, vterrors.VT03035(argIdx + 1)
+		}
+		keys = append(keys, idx)
+	}
+	return keys, nil
+}
+
 func transformDistinct(ctx *plancontext.PlanningContext, op *operators.Distinct) (engine.Primitive, error) {
 	src, err := transformToPrimitive(ctx, op.Source)
 	if err != nil {
@@ -412,6 +495,53 @@ func createMemorySort(ctx *plancontext.PlanningContext, src engine.Primitive, or
 	return prim, nil
 }
 
+func transformWindow(ctx *plancontext.PlanningContext, op *operators.Window) (engine.Primitive, error) {
+	src, err := transformToPrimitive(ctx, op.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	collationEnv := ctx.VSchema.Environment().CollationEnv()
+	prim := &engine.Window{Input: src}
+	for idx, expr := range op.PartitionBy {
+		typ, _ := ctx.TypeForExpr(expr)
+		prim.PartitionBy = append(prim.PartitionBy, evalengine.OrderByParams{
+			Col:             op.PartitionOffsets[idx],
+			WeightStringCol: op.PartitionWSOffsets[idx],
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	for idx, order := range op.OrderBy {
+		typ, _ := ctx.TypeForExpr(order.SimplifiedExpr)
+		prim.OrderBy = append(prim.OrderBy, evalengine.OrderByParams{
+			Col:             op.OrderOffsets[idx],
+			WeightStringCol: op.OrderWSOffsets[idx],
+			Desc:            order.Inner.Direction == sqlparser.DescOrder,
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	for _, f := range op.Funcs {
+		var typ evalengine.Type
+		if f.Arg != nil {
+			typ, _ = ctx.TypeForExpr(f.Arg)
+		}
+		prim.Funcs = append(prim.Funcs, &engine.WindowFuncParams{
+			Opcode:       f.OpCode,
+			Col:          f.ArgOffset,
+			N:            f.N,
+			DefaultCol:   f.DefaultOffset,
+			Frame:        f.Frame,
+			Alias:        sqlparser.String(f.Func),
+			Type:         typ,
+			CollationEnv: collationEnv,
+		})
+	}
+
+	return prim, nil
+}
+
 func transformProjection(ctx *plancontext.PlanningContext, op *operators.Projection) (engine.Primitive, error) {
 	src, err := transformToPrimitive(ctx, op.Source)
 	if err != nil {
@@ -649,6 +779,7 @@ func buildInsertPrimitive(
 		Ignore:      ins.Ignore,
 		Generate:    autoIncGenerate(ins.AutoIncrement),
 		ColVindexes: ins.ColVindexes,
+		VindexExprs: ins.VindexExprs,
 	}
 	if hints != nil {
 		ic.MultiShardAutocommit = hints.multiShardAutocommit
@@ -691,6 +822,8 @@ func autoIncGenerate(gen *operators.Generate) *engine.Generate {
 	return &engine.Generate{
 		Keyspace: gen.Keyspace,
 		Query:    sqlparser.String(selNext),
+		Sequence: gen.TableName.Name.String(),
+		Backend:  gen.Backend,
 		Values:   gen.Values,
 		Offset:   gen.Offset,
 	}
@@ -922,8 +1055,8 @@ func transformHashJoin(ctx *plancontext.PlanningContext, op *operators.HashJoin)
 		return nil, err
 	}
 
-	if len(op.LHSKeys) != 1 {
-		return nil, vterrors.VT12001("hash joins must have exactly one join predicate")
+	if len(op.LHSKeys) == 0 {
+		return nil, vterrors.VT12001("hash joins must have at least one equality join predicate")
 	}
 
 	joinOp := engine.InnerJoin
@@ -932,14 +1065,25 @@ func transformHashJoin(ctx *plancontext.PlanningContext, op *operators.HashJoin)
 	}
 
 	var missingTypes []string
+	var comparisonTypes []evalengine.Type
+	for _, cmp := range op.JoinComparisons {
+		ltyp, found := ctx.TypeForExpr(cmp.LHS)
+		if !found {
+			missingTypes = append(missingTypes, sqlparser.String(cmp.LHS))
+		}
+		rtyp, found := ctx.TypeForExpr(cmp.RHS)
+		if !found {
+			missingTypes = append(missingTypes, sqlparser.String(cmp.RHS))
+		}
+		if len(missingTypes) > 0 {
+			continue
+		}
 
-	ltyp, found := ctx.TypeForExpr(op.JoinComparisons[0].LHS)
-	if !found {
-		missingTypes = append(missingTypes, sqlparser.String(op.JoinComparisons[0].LHS))
-	}
-	rtyp, found := ctx.TypeForExpr(op.JoinComparisons[0].RHS)
-	if !found {
-		missingTypes = append(missingTypes, sqlparser.String(op.JoinComparisons[0].RHS))
+		comparisonType, err := evalengine.CoerceTypes(ltyp, rtyp, ctx.VSchema.Environment().CollationEnv())
+		if err != nil {
+			return nil, err
+		}
+		comparisonTypes = append(comparisonTypes, comparisonType)
 	}
 
 	if len(missingTypes) > 0 {
@@ -947,23 +1091,18 @@ func transformHashJoin(ctx *plancontext.PlanningContext, op *operators.HashJoin)
 			fmt.Sprintf("missing type information for [%s]", strings.Join(missingTypes, ", ")))
 	}
 
-	comparisonType, err := evalengine.CoerceTypes(ltyp, rtyp, ctx.VSchema.Environment().CollationEnv())
-	if err != nil {
-		return nil, err
-	}
-
 	return &engine.HashJoin{
-		Left:           lhs,
-		Right:          rhs,
-		Opcode:         joinOp,
-		Cols:           op.ColumnOffsets,
-		LHSKey:         op.LHSKeys[0],
-		RHSKey:         op.RHSKeys[0],
-		ASTPred:        op.JoinPredicate(),
-		Collation:      comparisonType.Collation(),
-		ComparisonType: comparisonType.Type(),
-		CollationEnv:   ctx.VSchema.Environment().CollationEnv(),
-		Values:         comparisonType.Values(),
+		Left:            lhs,
+		Right:           rhs,
+		Opcode:          joinOp,
+		Cols:            op.ColumnOffsets,
+		LHSKeys:         op.LHSKeys,
+		RHSKeys:         op.RHSKeys,
+		ASTPred:         op.JoinPredicate(),
+		ComparisonTypes: comparisonTypes,
+		CollationEnv:    ctx.VSchema.Environment().CollationEnv(),
+		Residual:        op.ResidualWithOffsets,
+		ResidualCols:    op.ResidualColumnOffsets,
 	}, nil
 }
 
@@ -1008,10 +1147,22 @@ func transformRecurseCTE(ctx *plancontext.PlanningContext, op *operators.Recurse
 	if err != nil {
 		return nil, err
 	}
+	var checkCols []engine.CheckCol
+	if op.Distinct {
+		for idx, col := range op.Seed().GetColumns(ctx) {
+			typ, _ := ctx.TypeForExpr(col.Expr)
+			checkCols = append(checkCols, engine.CheckCol{
+				Col:          idx,
+				Type:         typ,
+				CollationEnv: ctx.VSchema.Environment().CollationEnv(),
+			})
+		}
+	}
 	return &engine.RecurseCTE{
-		Seed: seed,
-		Term: term,
-		Vars: op.Vars,
+		Seed:      seed,
+		Term:      term,
+		Vars:      op.Vars,
+		CheckCols: checkCols,
 	}, nil
 }
 
@@ -347,7 +347,7 @@ func (qb *queryBuilder) sortTables() {
 			sel: sel,
 			tbl: qb.ctx.SemTable,
 		}
-		sort.Sort(ts)
+		sort.Stable(ts)
 		return true, nil
 	}, qb.stmt)
 
@@ -376,9 +376,20 @@ func (ts *tableSorter) Less(i, j int) bool {
 		return i < j
 	}
 
+	// LATERAL derived tables use the tables before them, so they have to stay after them
+	leftLateral, rightLateral := isLateral(left), isLateral(right)
+	if leftLateral || rightLateral {
+		return !leftLateral
+	}
+
 	return ts.tbl.TableSetFor(left).TableOffset() < ts.tbl.TableSetFor(right).TableOffset()
 }
 
+func isLateral(tbl *sqlparser.AliasedTableExpr) bool {
+	dt, ok := tbl.Expr.(*sqlparser.DerivedTable)
+	return ok && dt.Lateral
+}
+
 // Swap implements the Sort interface
 func (ts *tableSorter) Swap(i, j int) {
 	ts.sel.From[i], ts.sel.From[j] = ts.sel.From[j], ts.sel.From[i]
@@ -613,6 +624,10 @@ func buildApplyJoin(op *ApplyJoin, qb *queryBuilder) {
 
 	qbR := &queryBuilder{ctx: qb.ctx}
 	buildQuery(op.RHS, qbR)
+	if len(op.ExtraLHSVars) > 0 && qbR.stmt != nil {
+		// both sides are sent in the same query, so the RHS can use the LHS columns directly
+		qbR.stmt = useLHSColumns(qbR.stmt, op.ExtraLHSVars)
+	}
 
 	switch {
 	// if we have a recursive cte, we might be missing a statement from one of the sides
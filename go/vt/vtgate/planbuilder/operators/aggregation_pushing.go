@@ -39,8 +39,9 @@ func tryPushAggregator(ctx *plancontext.PlanningContext, aggregator *Aggregator)
 		return aggregator, NoRewrite
 	}
 
-	// this rewrite is always valid, and we should do it whenever possible
-	if route, ok := aggregator.Source.(*Route); ok && (route.IsSingleShard() || overlappingUniqueVindex(ctx, aggregator.Grouping)) {
+	// this rewrite is always valid, and we should do it whenever possible.
+	// the super-aggregate rows of a ROLLUP span all groups, so a unique vindex in the grouping is not enough
+	if route, ok := aggregator.Source.(*Route); ok && (route.IsSingleShard() || (!aggregator.WithRollup && overlappingUniqueVindex(ctx, aggregator.Grouping))) {
 		return Swap(aggregator, route, "push down aggregation under route - remove original")
 	}
 
@@ -49,6 +50,11 @@ func tryPushAggregator(ctx *plancontext.PlanningContext, aggregator *Aggregator)
 		return aggregator, NoRewrite
 	}
 
+	// the shards can't produce partial results for these aggregations, so we aggregate all the rows at the vtgate
+	if slices.ContainsFunc(aggregator.Aggregations, Aggr.needsAllValues) {
+		return aggregator, NoRewrite
+	}
+
 	// if we have not yet been able to push this aggregation down,
 	// we need to turn AVG into SUM/COUNT to support this over a sharded keyspace
 	if needAvgBreaking(aggregator.Aggregations) {
@@ -174,6 +180,13 @@ func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator,
 	distinctAggrGroupByAdded := false
 
 	for i, aggr := range aggregator.Aggregations {
+		if aggr.OpCode == opcode.AggregateGrouping {
+			// GROUPING() depends on the rollup level of the row, which is only known at the vtgate,
+			// so we just need something to keep the column offset in the pushed down query
+			aggrBelowRoute.Columns[aggr.ColOffset] = aeWrap(aggr.Func.GetArg())
+			continue
+		}
+
 		if !aggr.Distinct || canPushDistinctAggr {
 			aggrBelowRoute.Aggregations = append(aggrBelowRoute.Aggregations, aggr)
 			aggregateTheAggregate(aggregator, i)
@@ -319,6 +332,9 @@ func exprHasVindex(ctx *plancontext.PlanningContext, expr sqlparser.Expr, hasToB
 	}
 	vschemaTable := tableInfo.GetVindexTable()
 	for _, vindex := range vschemaTable.ColumnVindexes {
+		if vindex.Expression != nil {
+			continue
+		}
 		// TODO: Support composite vindexes (multicol, etc).
 		if len(vindex.Columns) > 1 || hasToBeUnique && !vindex.IsUnique() {
 			return false
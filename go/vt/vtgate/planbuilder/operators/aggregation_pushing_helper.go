@@ -133,10 +133,6 @@ func (ab *aggBuilder) handleAggr(ctx *plancontext.PlanningContext, aggr Aggr) er
 	case opcode.AggregateMax, opcode.AggregateMin, opcode.AggregateAnyValue:
 		return ab.handlePushThroughAggregation(ctx, aggr)
 	case opcode.AggregateGroupConcat:
-		f := aggr.Func.(*sqlparser.GroupConcatExpr)
-		if f.Distinct || len(f.OrderBy) > 0 {
-			panic(vterrors.VT12001("cannot evaluate group concat with distinct or order by"))
-		}
 		// this needs special handling, currently aborting the push of function
 		// and later will try pushing the column instead.
 		// TODO: this should be handled better by pushing the function down.
@@ -149,6 +145,9 @@ func (ab *aggBuilder) handleAggr(ctx *plancontext.PlanningContext, aggr Aggr) er
 	case opcode.AggregateSumDistinct, opcode.AggregateCountDistinct:
 		// we are not going to see values multiple times, so we don't need to multiply with the count(*) from the other side
 		return ab.handlePushThroughAggregation(ctx, aggr)
+	case opcode.AggregateGrouping:
+		// GROUPING() is evaluated at the vtgate, so there is nothing to push down
+		return errAbortAggrPushing
 	default:
 		panic(vterrors.VT12001(fmt.Sprintf("aggregation not planned: %s", aggr.OpCode.String())))
 	}
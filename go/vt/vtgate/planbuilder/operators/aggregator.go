@@ -19,6 +19,7 @@ package operators
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"vitess.io/vitess/go/slice"
@@ -421,10 +422,11 @@ func (aggr Aggr) getPushColumn() sqlparser.Expr {
 		return aggr.Original.Expr
 	case opcode.AggregateCountStar:
 		return sqlparser.NewIntLiteral("1")
+	case opcode.AggregateGrouping:
+		// the value of GROUPING() is computed at the vtgate, the argument is only a placeholder column
+		return aggr.Func.GetArg()
 	case opcode.AggregateGroupConcat:
-		if len(aggr.Func.GetArgs()) > 1 {
-			panic(vterrors.VT12001("group_concat with more than 1 column"))
-		}
+		// the other arguments are added by planGroupConcatOffsets
 		return aggr.Func.GetArg()
 	default:
 		if len(aggr.Func.GetArgs()) > 1 {
@@ -465,6 +467,56 @@ func (a *Aggregator) planOffsetsNotPushed(ctx *plancontext.PlanningContext) {
 	}
 
 	a.pushRemainingGroupingColumnsAndWeightStrings(ctx)
+	a.planGroupConcatOffsets(ctx)
+}
+
+// planGroupConcatOffsets adds the columns needed to evaluate GROUP_CONCAT at the vtgate
+// when it has more than one argument, or uses DISTINCT or ORDER BY
+func (a *Aggregator) planGroupConcatOffsets(ctx *plancontext.PlanningContext) {
+	for idx, aggr := range a.Aggregations {
+		gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+		if !ok || (len(gc.Exprs) == 1 && !aggr.needsAllValues()) {
+			continue
+		}
+
+		for i, expr := range gc.Exprs {
+			offset := aggr.ColOffset
+			if i > 0 {
+				offset = a.internalAddColumn(ctx, aeWrap(expr), false)
+			}
+			wsOffset := -1
+			if gc.Distinct && ctx.NeedsWeightString(expr) {
+				wsOffset = a.internalAddWSColumn(ctx, offset, aeWrap(weightStringFor(expr)))
+			}
+			a.Aggregations[idx].ArgOffsets = append(a.Aggregations[idx].ArgOffsets, offset)
+			a.Aggregations[idx].ArgWSOffsets = append(a.Aggregations[idx].ArgWSOffsets, wsOffset)
+		}
+
+		for _, order := range gc.OrderBy {
+			expr := GroupConcatOrderExpr(gc, order.Expr)
+			offset := a.internalAddColumn(ctx, aeWrap(expr), false)
+			wsOffset := -1
+			if ctx.NeedsWeightString(expr) {
+				wsOffset = a.internalAddWSColumn(ctx, offset, aeWrap(weightStringFor(expr)))
+			}
+			a.Aggregations[idx].OrderByOffsets = append(a.Aggregations[idx].OrderByOffsets, offset)
+			a.Aggregations[idx].OrderByWSOffsets = append(a.Aggregations[idx].OrderByWSOffsets, wsOffset)
+		}
+	}
+}
+
+// GroupConcatOrderExpr returns the expression used for ordering inside a GROUP_CONCAT.
+// A number in the ORDER BY clause refers to one of the arguments of the call.
+func GroupConcatOrderExpr(gc *sqlparser.GroupConcatExpr, expr sqlparser.Expr) sqlparser.Expr {
+	lit, ok := expr.(*sqlparser.Literal)
+	if !ok || lit.Type != sqlparser.IntVal {
+		return expr
+	}
+	num, err := strconv.Atoi(lit.Val)
+	if err != nil || num < 1 || num > len(gc.Exprs) {
+		return expr
+	}
+	return gc.Exprs[num-1]
 }
 
 func (a *Aggregator) addIfAggregationColumn(ctx *plancontext.PlanningContext, colIdx int) int {
@@ -578,6 +630,7 @@ func (a *Aggregator) SplitAggregatorBelowOperators(ctx *plancontext.PlanningCont
 	newOp := a.Clone(input).(*Aggregator)
 	newOp.Pushed = false
 	newOp.Original = false
+	newOp.WithRollup = false
 	newOp.DT = nil
 
 	// We need to make sure that the columns are cloned so that the original operator is not affected
@@ -87,8 +87,9 @@ func addWherePredsToSubQueryBuilder(ctx *plancontext.PlanningContext, in sqlpars
 	for _, expr := range sqlparser.SplitAndExpression(nil, in) {
 		sqlparser.RemoveKeyspaceInCol(expr)
 		expr = simplifyPredicates(ctx, expr)
-		subq := sqc.handleSubquery(ctx, expr, outerID)
-		if subq != nil {
+		if newExpr := sqc.pullOutPredicateSubqueries(ctx, expr, outerID); newExpr != nil {
+			expr = newExpr
+		} else if subq := sqc.handleSubquery(ctx, expr, outerID); subq != nil {
 			continue
 		}
 		boolean := ctx.IsConstantBool(expr)
@@ -102,6 +103,7 @@ func addWherePredsToSubQueryBuilder(ctx *plancontext.PlanningContext, in sqlpars
 			expr = sqlparser.NewIntLiteral("0")
 		}
 
+		expr = breakRecursivePredicate(ctx, expr)
 		op = op.AddPredicate(ctx, expr)
 		addColumnEquality(ctx, expr)
 	}
@@ -251,7 +253,11 @@ func getOperatorFromTableExpr(ctx *plancontext.PlanningContext, tableExpr sqlpar
 
 func getOperatorFromJoinTableExpr(ctx *plancontext.PlanningContext, tableExpr *sqlparser.JoinTableExpr) Operator {
 	lhs := getOperatorFromTableExpr(ctx, tableExpr.LeftExpr, false)
+	lateralVars := extractLateralVars(ctx, tableExpr.RightExpr, TableID(lhs))
 	rhs := getOperatorFromTableExpr(ctx, tableExpr.RightExpr, false)
+	if len(lateralVars) > 0 {
+		return createLateralJoin(ctx, tableExpr, lhs, rhs, lateralVars)
+	}
 
 	switch tableExpr.Join {
 	case sqlparser.NormalJoinType:
@@ -388,10 +394,21 @@ func idForRecursiveTable(ctx *plancontext.PlanningContext, def *semantics.CTE) s
 func crossJoin(ctx *plancontext.PlanningContext, exprs sqlparser.TableExprs) Operator {
 	var output Operator
 	for _, tableExpr := range exprs {
+		var lateralVars []BindVarExpr
+		if output != nil {
+			lateralVars = extractLateralVars(ctx, tableExpr, TableID(output))
+		}
 		op := getOperatorFromTableExpr(ctx, tableExpr, len(exprs) == 1)
-		if output == nil {
+		switch {
+		case output == nil:
 			output = op
-		} else {
+		case len(lateralVars) > 0:
+			output = &Join{
+				binaryOperator: newBinaryOp(output, op),
+				JoinType:       sqlparser.NormalJoinType,
+				LateralVars:    lateralVars,
+			}
+		default:
 			output = createJoin(ctx, output, op)
 		}
 	}
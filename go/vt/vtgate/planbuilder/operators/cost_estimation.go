@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"io"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// The cost model used to order joins and pick join algorithms. Costs are expressed in rows:
+// every query sent to a shard costs as much as reading queryCost rows, and every row read
+// from the shards or kept in a hash table costs one.
+// The row estimates come from the table statistics the schema tracker loads from the tablets.
+// They are estimates of a single shard, which a route multiplies by the number of shards it
+// reaches. When a table has none, the cost of the plans using it is unknown.
+const (
+	queryCost = 100
+
+	// the selectivity of the predicates we know nothing about
+	equalitySelectivity   = 0.1
+	comparisonSelectivity = 1.0 / 3
+)
+
+// estimatedRows returns an estimation of the number of rows the operator produces, if one is known.
+// Without table statistics, we only know this for unique vindex lookups and literal limits
+func estimatedRows(ctx *plancontext.PlanningContext, op Operator) (float64, bool) {
+	switch op := op.(type) {
+	case *Route:
+		if routing, ok := op.Routing.(*ShardedRouting); ok && routing.RouteOpCode == engine.EqualUnique {
+			return 1, true
+		}
+		rows, ok := estimatedRows(ctx, op.Source)
+		if !ok {
+			return 0, false
+		}
+		return rows * shardsReached(op), true
+	case *Limit:
+		return limitedRows(ctx, op.Source, op.AST)
+	case *Horizon:
+		if limit := op.Query.GetLimit(); limit != nil {
+			return limitedRows(ctx, op.Source, limit)
+		}
+	case *Table:
+		if op.VTable == nil || op.VTable.Stats == nil {
+			return 0, false
+		}
+		return float64(op.VTable.Stats.Rows), true
+	case *Filter:
+		rows, ok := estimatedRows(ctx, op.Source)
+		if !ok {
+			return 0, false
+		}
+		for _, pred := range op.Predicates {
+			rows *= selectivity(ctx, op.Source, pred)
+		}
+		return max(rows, 1), true
+	case *Join:
+		lhs, lok := estimatedRows(ctx, op.LHS)
+		rhs, rok := estimatedRows(ctx, op.RHS)
+		if !lok || !rok {
+			return 0, false
+		}
+		rows := lhs * rhs
+		for _, pred := range sqlparser.SplitAndExpression(nil, op.Predicate) {
+			rows *= selectivity(ctx, op, pred)
+		}
+		return joinRows(lhs, rows, op.JoinType.IsInner()), true
+	case *ApplyJoin:
+		// the predicates of the join have been pushed to the RHS,
+		// so the RHS estimation is the number of rows for each LHS row
+		lhs, lok := estimatedRows(ctx, op.LHS)
+		rhs, rok := estimatedRows(ctx, op.RHS)
+		if !lok || !rok {
+			return 0, false
+		}
+		return joinRows(lhs, lhs*rhs, op.IsInner()), true
+	case *HashJoin:
+		lhs, lok := estimatedRows(ctx, op.LHS)
+		rhs, rok := estimatedRows(ctx, op.RHS)
+		if !lok || !rok {
+			return 0, false
+		}
+		rows := lhs * rhs
+		for _, cmp := range op.JoinComparisons {
+			rows /= max(columnCardinality(ctx, op, cmp.LHS), columnCardinality(ctx, op, cmp.RHS), 1/equalitySelectivity)
+		}
+		return joinRows(lhs, rows, !op.LeftJoin), true
+	}
+
+	inputs := op.Inputs()
+	if len(inputs) != 1 {
+		return 0, false
+	}
+	return estimatedRows(ctx, inputs[0])
+}
+
+// shardsReached returns the number of shards the route sends its query to. The size of
+// the keyspace is the number of shards that reported the statistics of its tables.
+func shardsReached(op *Route) float64 {
+	routing, ok := op.Routing.(*ShardedRouting)
+	if !ok {
+		return 1
+	}
+	shards := 1
+	_ = Visit(op.Source, func(op Operator) error {
+		tbl, ok := op.(*Table)
+		if !ok || tbl.VTable == nil || tbl.VTable.Stats == nil {
+			return nil
+		}
+		shards = max(tbl.VTable.Stats.Shards, 1)
+		return io.EOF
+	})
+
+	switch routing.RouteOpCode {
+	case engine.Equal:
+		return 1
+	case engine.IN:
+		if routing.Selected != nil && len(routing.Selected.ValueExprs) == 1 {
+			if values, ok := routing.Selected.ValueExprs[0].(sqlparser.ValTuple); ok {
+				return float64(min(len(values), shards))
+			}
+		}
+	}
+	return float64(shards)
+}
+
+// limitedRows returns the rows of an operator with a LIMIT, which are known
+// even when the rows of the operator under the limit are not
+func limitedRows(ctx *plancontext.PlanningContext, source Operator, limit *sqlparser.Limit) (float64, bool) {
+	limitRows, lok := limitRows(limit)
+	rows, ok := estimatedRows(ctx, source)
+	switch {
+	case lok && ok:
+		return min(float64(limitRows), rows), true
+	case lok:
+		return float64(limitRows), true
+	}
+	return rows, ok
+}
+
+// joinRows returns the rows of a join, an outer join returning at least every row of its LHS
+func joinRows(lhs, rows float64, inner bool) float64 {
+	if inner {
+		return rows
+	}
+	return max(lhs, rows)
+}
+
+// estimatedCost returns the cost of executing the operator, if it is known.
+func estimatedCost(ctx *plancontext.PlanningContext, op Operator) (float64, bool) {
+	switch op := op.(type) {
+	case *Route:
+		rows, ok := estimatedRows(ctx, op)
+		if !ok {
+			return 0, false
+		}
+		return float64(max(op.Cost(), 1)*queryCost) + rows, true
+	case *ApplyJoin:
+		// the RHS is executed once per row coming from the LHS
+		lhsCost, lok := estimatedCost(ctx, op.LHS)
+		lhsRows, rok := estimatedRows(ctx, op.LHS)
+		rhsCost, cok := estimatedCost(ctx, op.RHS)
+		if !lok || !rok || !cok {
+			return 0, false
+		}
+		return lhsCost + lhsRows*rhsCost, true
+	case *HashJoin:
+		return hashJoinCost(ctx, op.LHS, op.RHS)
+	}
+
+	inputs := op.Inputs()
+	if len(inputs) != 1 {
+		return 0, false
+	}
+	return estimatedCost(ctx, inputs[0])
+}
+
+// hashJoinCost returns the cost of a hash join between the two inputs. Both sides are
+// executed once, and the rows of the LHS are kept in memory, so the smaller side should build.
+func hashJoinCost(ctx *plancontext.PlanningContext, lhs, rhs Operator) (float64, bool) {
+	lhsCost, lok := estimatedCost(ctx, lhs)
+	lhsRows, rok := estimatedRows(ctx, lhs)
+	rhsCost, cok := estimatedCost(ctx, rhs)
+	if !lok || !rok || !cok {
+		return 0, false
+	}
+	return lhsCost + rhsCost + lhsRows, true
+}
+
+// cheaperPlan returns true if plan a is cheaper than plan b. When the table statistics
+// do not tell the plans apart, we compare the cost of their routes
+func cheaperPlan(ctx *plancontext.PlanningContext, a, b Operator) bool {
+	aCost, aok := estimatedCost(ctx, a)
+	bCost, bok := estimatedCost(ctx, b)
+	if aok && bok && aCost != bCost {
+		return aCost < bCost
+	}
+	return CostOf(a) < CostOf(b)
+}
+
+// selectivity returns the fraction of the rows of the operator that pass the predicate
+func selectivity(ctx *plancontext.PlanningContext, op Operator, pred sqlparser.Expr) float64 {
+	cmp, ok := pred.(*sqlparser.ComparisonExpr)
+	if !ok {
+		return comparisonSelectivity
+	}
+	switch cmp.Operator {
+	case sqlparser.EqualOp, sqlparser.NullSafeEqualOp:
+		cardinality := max(columnCardinality(ctx, op, cmp.Left), columnCardinality(ctx, op, cmp.Right))
+		if cardinality == 0 {
+			return equalitySelectivity
+		}
+		return 1 / cardinality
+	case sqlparser.InOp:
+		values, ok := cmp.Right.(sqlparser.ValTuple)
+		if !ok || len(values) == 0 {
+			return comparisonSelectivity
+		}
+		sel := float64(len(values)) * selectivity(ctx, op, &sqlparser.ComparisonExpr{Operator: sqlparser.EqualOp, Left: cmp.Left, Right: values[0]})
+		return min(sel, 1)
+	}
+	return comparisonSelectivity
+}
+
+// columnCardinality returns the estimated number of distinct values of a column
+// of one of the tables of the operator, or zero if it is not known
+func columnCardinality(ctx *plancontext.PlanningContext, op Operator, expr sqlparser.Expr) float64 {
+	col, ok := expr.(*sqlparser.ColName)
+	if !ok {
+		return 0
+	}
+	id := ctx.SemTable.DirectDeps(col)
+	var cardinality uint64
+	_ = Visit(op, func(op Operator) error {
+		tbl, ok := op.(*Table)
+		if !ok || tbl.QTable.ID != id {
+			return nil
+		}
+		if tbl.VTable != nil && tbl.VTable.Stats != nil {
+			cardinality = tbl.VTable.Stats.Cardinality[col.Name.Lowered()]
+		}
+		return io.EOF
+	})
+	return float64(cardinality)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// TestRouteEstimatedRows tests that the row estimates of a shard are scaled by the number of shards a route reaches
+func TestRouteEstimatedRows(t *testing.T) {
+	table := &Table{VTable: &vindexes.Table{Stats: &vindexes.TableStats{Rows: 100, Shards: 4}}}
+	tuple := func(n int) *VindexOption {
+		values := make(sqlparser.ValTuple, n)
+		for i := range values {
+			values[i] = sqlparser.NewIntLiteral("1")
+		}
+		return &VindexOption{ValueExprs: []sqlparser.Expr{values}}
+	}
+
+	tcases := []struct {
+		opcode   engine.Opcode
+		selected *VindexOption
+		expected float64
+	}{
+		{opcode: engine.Scatter, expected: 400},
+		{opcode: engine.KeyRange, expected: 400},
+		{opcode: engine.IN, selected: tuple(2), expected: 200},
+		{opcode: engine.IN, selected: tuple(10), expected: 400},
+		{opcode: engine.Equal, expected: 100},
+		{opcode: engine.EqualUnique, expected: 1},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.opcode.String(), func(t *testing.T) {
+			route := &Route{
+				unaryOperator: newUnaryOp(table),
+				Routing:       &ShardedRouting{RouteOpCode: tc.opcode, Selected: tc.selected},
+			}
+			rows, ok := estimatedRows(nil, route)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, rows)
+		})
+	}
+
+	// the estimates of an unsharded table are those of its only shard
+	route := &Route{unaryOperator: newUnaryOp(table), Routing: &AnyShardRouting{}}
+	rows, ok := estimatedRows(nil, route)
+	assert.True(t, ok)
+	assert.EqualValues(t, 100, rows)
+}
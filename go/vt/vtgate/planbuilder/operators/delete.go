@@ -255,11 +255,12 @@ func createDeleteOperator(ctx *plancontext.PlanningContext, del *sqlparser.Delet
 	}
 
 	if del.Limit != nil {
-		delOp.Source = newLimit(addOrdering(ctx, op, del.OrderBy), del.Limit, false)
-	} else {
-		delOp.Source = op
+		// the subqueries in the WHERE clause have to filter the rows before the LIMIT is applied
+		delOp.Source = newLimit(addOrdering(ctx, sqc.getRootOperator(op, nil), del.OrderBy), del.Limit, false)
+		return delOp, vTbl
 	}
 
+	delOp.Source = op
 	return sqc.getRootOperator(delOp, nil), vTbl
 }
 
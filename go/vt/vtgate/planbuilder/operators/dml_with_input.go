@@ -86,6 +86,11 @@ func (d *DMLWithInput) GetOrdering(ctx *plancontext.PlanningContext) []OrderBy {
 }
 
 func (d *DMLWithInput) planOffsets(ctx *plancontext.PlanningContext) Operator {
+	if d.cols == nil {
+		// the source was planned on its own, and the offsets are already known
+		return nil
+	}
+
 	// go through the primary key columns to get offset from the input
 	offsets := make([][]int, len(d.cols))
 	for idx, columns := range d.cols {
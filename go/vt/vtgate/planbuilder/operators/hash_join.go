@@ -19,11 +19,13 @@ package operators
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"vitess.io/vitess/go/slice"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
@@ -39,6 +41,10 @@ type (
 		// Before offset planning
 		JoinComparisons []Comparison
 
+		// Residuals are the join predicates that can't be solved by hashing,
+		// and are instead evaluated on the rows that matched by hash code
+		Residuals []sqlparser.Expr
+
 		// These columns are the output columns of the hash join. While in operator mode we keep track of complex expression,
 		// but once we move to the engine primitives, the hash join only passes through column from either left or right.
 		// anything more complex will be solved by a projection on top of the hash join
@@ -53,6 +59,11 @@ type (
 		// These are the values that will be hashed together
 		LHSKeys, RHSKeys []int
 
+		// ResidualWithOffsets is the residual predicate that will be evaluated on the row
+		// built from ResidualColumnOffsets, which uses the same notation as ColumnOffsets
+		ResidualWithOffsets   evalengine.Expr
+		ResidualColumnOffsets []int
+
 		offset bool
 	}
 
@@ -93,6 +104,8 @@ func (hj *HashJoin) Clone(inputs []Operator) Operator {
 	kopy.LHSKeys = slices.Clone(hj.LHSKeys)
 	kopy.RHSKeys = slices.Clone(hj.RHSKeys)
 	kopy.JoinComparisons = slices.Clone(hj.JoinComparisons)
+	kopy.Residuals = slices.Clone(hj.Residuals)
+	kopy.ResidualColumnOffsets = slices.Clone(hj.ResidualColumnOffsets)
 	return &kopy
 }
 
@@ -150,6 +163,11 @@ func (hj *HashJoin) planOffsets(ctx *plancontext.PlanningContext) Operator {
 		hj.RHSKeys = append(hj.RHSKeys, rOffset)
 	}
 
+	if len(hj.Residuals) > 0 {
+		residual := hj.rewriteToOffsets(ctx, sqlparser.AndExpressions(hj.Residuals...), &hj.ResidualColumnOffsets)
+		hj.ResidualWithOffsets = hj.translate(ctx, residual)
+	}
+
 	needsProj := false
 	lID := TableID(hj.LHS)
 	rID := TableID(hj.RHS)
@@ -204,6 +222,9 @@ func (hj *HashJoin) ShortDescription() string {
 	comparisons := slice.Map(hj.JoinComparisons, func(from Comparison) string {
 		return from.String()
 	})
+	for _, residual := range hj.Residuals {
+		comparisons = append(comparisons, sqlparser.String(residual))
+	}
 	cmp := strings.Join(comparisons, " AND ")
 
 	if len(hj.columns.columns) > 0 {
@@ -254,30 +275,141 @@ func (hj *HashJoin) IsInner() bool {
 }
 
 func (hj *HashJoin) AddJoinPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) {
+	lID := TableID(hj.LHS)
+	rID := TableID(hj.RHS)
+	if cmp, ok := hashJoinComparison(ctx, expr, lID, rID); ok {
+		hj.JoinComparisons = append(hj.JoinComparisons, cmp)
+		return
+	}
+
+	if !canBeResidual(ctx, expr, lID.Merge(rID)) {
+		panic(vterrors.VT12001(fmt.Sprintf("can't use [%s] with hash joins", sqlparser.String(expr))))
+	}
+	hj.Residuals = append(hj.Residuals, expr)
+}
+
+// hashJoinComparison checks if the predicate is an equality comparison between an expression
+// from the LHS and one from the RHS, which can be solved by hashing the values on both sides
+func hashJoinComparison(ctx *plancontext.PlanningContext, expr sqlparser.Expr, lID, rID semantics.TableSet) (Comparison, bool) {
 	cmp, ok := expr.(*sqlparser.ComparisonExpr)
 	if !ok || !canBeSolvedWithHashJoin(cmp.Operator) {
-		panic(vterrors.VT12001(fmt.Sprintf("can't use [%s] with hash joins", sqlparser.String(expr))))
+		return Comparison{}, false
 	}
 	lExpr := cmp.Left
 	lDeps := ctx.SemTable.RecursiveDeps(lExpr)
 	rExpr := cmp.Right
 	rDeps := ctx.SemTable.RecursiveDeps(rExpr)
-	lID := TableID(hj.LHS)
-	rID := TableID(hj.RHS)
 	if !lDeps.IsSolvedBy(lID) || !rDeps.IsSolvedBy(rID) {
 		// we'll switch and see if things work out then
 		lExpr, rExpr = rExpr, lExpr
 		lDeps, rDeps = rDeps, lDeps
 	}
 
-	if !lDeps.IsSolvedBy(lID) || !rDeps.IsSolvedBy(rID) {
-		panic(vterrors.VT12001(fmt.Sprintf("can't use [%s] with hash joins", sqlparser.String(expr))))
+	if lDeps.IsEmpty() || rDeps.IsEmpty() || !lDeps.IsSolvedBy(lID) || !rDeps.IsSolvedBy(rID) {
+		return Comparison{}, false
 	}
 
-	hj.JoinComparisons = append(hj.JoinComparisons, Comparison{
+	return Comparison{
 		LHS: lExpr,
 		RHS: rExpr,
-	})
+	}, true
+}
+
+// canBeResidual returns true if the predicate can be evaluated on the joined rows
+func canBeResidual(ctx *plancontext.PlanningContext, expr sqlparser.Expr, id semantics.TableSet) bool {
+	if !ctx.SemTable.RecursiveDeps(expr).IsSolvedBy(id) {
+		return false
+	}
+	hasSubquery := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		_, isSubQ := node.(*sqlparser.Subquery)
+		hasSubquery = hasSubquery || isSubQ
+		return !hasSubquery, nil
+	}, expr)
+	return !hasSubquery
+}
+
+// hashJoinRowThreshold is the estimated number of rows coming from the LHS of a join,
+// above which we prefer a hash join over a nested loop join that has to send one scatter query per row
+const hashJoinRowThreshold = 100
+
+// preferHashJoin returns true if the join between the two inputs should be planned as a hash join
+// instead of the given nested loop join. The ALLOW_HASH_JOIN comment directive makes the planner use
+// hash joins whenever the join predicates allow it. Without it, we compare the estimated cost of both
+// joins, and when the table statistics are missing, we only use hash joins when we expect the nested
+// loop join to be expensive.
+func preferHashJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredicates []sqlparser.Expr, applyJoin Operator) bool {
+	if !canUseHashJoin(ctx, lhs, rhs, joinPredicates) {
+		return false
+	}
+	if hashJoinDirective(ctx) {
+		return true
+	}
+
+	applyCost, aok := estimatedCost(ctx, applyJoin)
+	hashCost, hok := hashJoinCost(ctx, lhs, rhs)
+	if aok && hok {
+		return hashCost < applyCost
+	}
+
+	rows, known := estimatedRows(ctx, lhs)
+	return known && rows >= hashJoinRowThreshold && scatterPerRow(ctx, lhs, rhs, joinPredicates)
+}
+
+// canUseHashJoin returns true if at least one of the join predicates can be used as a hash key,
+// and the rest can be evaluated as residual predicates
+func canUseHashJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredicates []sqlparser.Expr) bool {
+	lID, rID := TableID(lhs), TableID(rhs)
+	hasKey := false
+	for _, pred := range joinPredicates {
+		if _, ok := hashJoinComparison(ctx, pred, lID, rID); ok {
+			hasKey = true
+			continue
+		}
+		if !canBeResidual(ctx, pred, lID.Merge(rID)) {
+			return false
+		}
+	}
+	return hasKey
+}
+
+func hashJoinDirective(ctx *plancontext.PlanningContext) bool {
+	cmt, ok := ctx.Statement.(sqlparser.Commented)
+	return ok && cmt.GetParsedComments().Directives().IsSet(sqlparser.DirectiveAllowHashJoin)
+}
+
+// scatterPerRow returns true if a nested loop join would have to send a scatter query
+// to the RHS for every row coming from the LHS
+func scatterPerRow(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredicates []sqlparser.Expr) bool {
+	route, ok := rhs.(*Route)
+	if !ok {
+		return false
+	}
+	routing, ok := route.Routing.(*ShardedRouting)
+	if !ok || routing.RouteOpCode != engine.Scatter {
+		return false
+	}
+	lID, rID := TableID(lhs), TableID(rhs)
+	for _, pred := range joinPredicates {
+		cmp, ok := hashJoinComparison(ctx, pred, lID, rID)
+		if ok && findColumnVindex(ctx, rhs, cmp.RHS) != nil {
+			// the value coming from the LHS can be used to route the query to a single shard
+			return false
+		}
+	}
+	return true
+}
+
+func limitRows(limit *sqlparser.Limit) (int, bool) {
+	if limit == nil {
+		return 0, false
+	}
+	lit, ok := limit.Rowcount.(*sqlparser.Literal)
+	if !ok || lit.Type != sqlparser.IntVal {
+		return 0, false
+	}
+	rows, err := strconv.Atoi(lit.Val)
+	return rows, err == nil
 }
 
 func canBeSolvedWithHashJoin(op sqlparser.ComparisonExprOperator) bool {
@@ -295,6 +427,22 @@ func (c Comparison) String() string {
 func lhsOffset(i int) int { return (i * -1) - 1 }
 func rhsOffset(i int) int { return i + 1 }
 func (hj *HashJoin) addColumn(ctx *plancontext.PlanningContext, in sqlparser.Expr) (*ProjExpr, bool) {
+	rewrittenExpr := hj.rewriteToOffsets(ctx, in, &hj.ColumnOffsets)
+	eexpr := hj.translate(ctx, rewrittenExpr)
+
+	_, isPureOffset := rewrittenExpr.(*sqlparser.Offset)
+
+	return &ProjExpr{
+		Original: aeWrap(in),
+		EvalExpr: rewrittenExpr,
+		ColExpr:  rewrittenExpr,
+		Info:     &EvalEngine{EExpr: eexpr},
+	}, isPureOffset
+}
+
+// rewriteToOffsets replaces the parts of the expression that come from the inputs with offsets.
+// The offsets point to the columns added to the given slice, which uses the same notation as ColumnOffsets
+func (hj *HashJoin) rewriteToOffsets(ctx *plancontext.PlanningContext, in sqlparser.Expr, offsets *[]int) sqlparser.Expr {
 	lId, rId := TableID(hj.LHS), TableID(hj.RHS)
 	r := new(replacer) // this is the expression we will put in instead of whatever we find there
 	pre := func(node, parent sqlparser.SQLNode) bool {
@@ -319,8 +467,8 @@ func (hj *HashJoin) addColumn(ctx *plancontext.PlanningContext, in sqlparser.Exp
 
 			// we have to turn the incoming offset to an outgoing offset of the columns this operator is exposing
 			internalOffset := offsetter(inOffset)
-			hj.ColumnOffsets = append(hj.ColumnOffsets, internalOffset)
-			return len(hj.ColumnOffsets) - 1
+			*offsets = append(*offsets, internalOffset)
+			return len(*offsets) - 1
 		}
 
 		if lOffset := check(lId, hj.LHS, lhsOffset); lOffset >= 0 {
@@ -336,25 +484,20 @@ func (hj *HashJoin) addColumn(ctx *plancontext.PlanningContext, in sqlparser.Exp
 		return true
 	}
 
-	rewrittenExpr := sqlparser.CopyOnRewrite(in, pre, r.post, ctx.SemTable.CopySemanticInfo).(sqlparser.Expr)
+	return sqlparser.CopyOnRewrite(in, pre, r.post, ctx.SemTable.CopySemanticInfo).(sqlparser.Expr)
+}
+
+func (hj *HashJoin) translate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) evalengine.Expr {
 	cfg := &evalengine.Config{
 		ResolveType: ctx.TypeForExpr,
 		Collation:   ctx.SemTable.Collation,
 		Environment: ctx.VSchema.Environment(),
 	}
-	eexpr, err := evalengine.Translate(rewrittenExpr, cfg)
+	eexpr, err := evalengine.Translate(expr, cfg)
 	if err != nil {
 		panic(err)
 	}
-
-	_, isPureOffset := rewrittenExpr.(*sqlparser.Offset)
-
-	return &ProjExpr{
-		Original: aeWrap(in),
-		EvalExpr: rewrittenExpr,
-		ColExpr:  rewrittenExpr,
-		Info:     &EvalEngine{EExpr: eexpr},
-	}, isPureOffset
+	return eexpr
 }
 
 // JoinPredicate produces an AST representation of the join condition this join has
@@ -365,7 +508,7 @@ func (hj *HashJoin) JoinPredicate() sqlparser.Expr {
 			Right: from.RHS,
 		}
 	})
-	return sqlparser.AndExpressions(exprs...)
+	return sqlparser.AndExpressions(append(exprs, hj.Residuals...)...)
 }
 
 type replacer struct {
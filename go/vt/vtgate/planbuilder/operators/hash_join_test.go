@@ -58,6 +58,34 @@ func TestJoinPredicates(t *testing.T) {
 	require.Len(t, hj.RHSKeys, 1)
 }
 
+func TestJoinPredicatesWithResidual(t *testing.T) {
+	lcol1, lcol2 := sqlparser.NewColName("lhs1"), sqlparser.NewColName("lhs2")
+	rcol1, rcol2 := sqlparser.NewColName("rhs1"), sqlparser.NewColName("rhs2")
+	ctx := &plancontext.PlanningContext{SemTable: semantics.EmptySemTable()}
+	lid := semantics.SingleTableSet(0)
+	rid := semantics.SingleTableSet(1)
+	ctx.SemTable.Recursive[lcol1] = lid
+	ctx.SemTable.Recursive[lcol2] = lid
+	ctx.SemTable.Recursive[rcol1] = rid
+	ctx.SemTable.Recursive[rcol2] = rid
+	lhs := &fakeOp{id: lid}
+	rhs := &fakeOp{id: rid}
+
+	equal := &sqlparser.ComparisonExpr{Operator: sqlparser.EqualOp, Left: rcol1, Right: lcol1}
+	lessThan := &sqlparser.ComparisonExpr{Operator: sqlparser.LessThanOp, Left: lcol2, Right: rcol2}
+	preds := []sqlparser.Expr{equal, lessThan}
+	require.True(t, canUseHashJoin(ctx, lhs, rhs, preds))
+	require.False(t, canUseHashJoin(ctx, lhs, rhs, []sqlparser.Expr{lessThan}))
+
+	hj := NewHashJoin(lhs, rhs, false)
+	for _, pred := range preds {
+		hj.AddJoinPredicate(ctx, pred)
+	}
+	require.Equal(t, []Comparison{{LHS: lcol1, RHS: rcol1}}, hj.JoinComparisons)
+	require.Equal(t, []sqlparser.Expr{lessThan}, hj.Residuals)
+	assert.Equal(t, "lhs1 = rhs1 and lhs2 < rhs2", sqlparser.String(hj.JoinPredicate()))
+}
+
 func TestOffsetPlanning(t *testing.T) {
 	lcol1, lcol2 := sqlparser.NewColName("lhs1"), sqlparser.NewColName("lhs2")
 	rcol1, rcol2 := sqlparser.NewColName("rhs1"), sqlparser.NewColName("rhs2")
@@ -87,6 +87,16 @@ func expandSelectHorizon(ctx *plancontext.PlanningContext, horizon *Horizon, sel
 		extracted = append(extracted, "Projection")
 	}
 
+	if windowFuncs := qp.windowFuncs(); len(windowFuncs) > 0 && !canPushWindowFuncs(ctx, horizon.src(), sel, windowFuncs) {
+		proj, ok := op.(*Projection)
+		if !ok || qp.NeedsAggregation() {
+			ctx.SemTable.NotSingleRouteErr = vterrors.VT12001("window functions with aggregation on sharded keyspace")
+		} else {
+			addWindowOperators(ctx, proj, qp, sel)
+			extracted = append(extracted, "Window")
+		}
+	}
+
 	if qp.NeedsDistinct() {
 		op = newDistinct(op, qp, true)
 		extracted = append(extracted, "Distinct")
@@ -113,7 +123,7 @@ func expandSelectHorizon(ctx *plancontext.PlanningContext, horizon *Horizon, sel
 func expandOrderBy(ctx *plancontext.PlanningContext, op Operator, qp *QueryProjection, derived string) Operator {
 	var newOrder []OrderBy
 	sqc := &SubQueryBuilder{}
-	proj, ok := op.(*Projection)
+	proj, _ := op.(*Projection)
 
 	for _, expr := range qp.OrderExprs {
 		// Attempt to extract any subqueries within the expression
@@ -127,13 +137,16 @@ func expandOrderBy(ctx *plancontext.PlanningContext, op Operator, qp *QueryProje
 			continue
 		}
 
-		// If the operator is not a projection, we cannot handle subqueries with aggregation if we are unable to push everything into a single route.
-		if !ok {
-			ctx.SemTable.NotSingleRouteErr = vterrors.VT12001("subquery with aggregation in order by")
-			return newOrdering(op, qp.OrderExprs)
-		} else {
-			// Add the new subquery expression to the projection
-			proj.addSubqueryExpr(ctx, aeWrap(newExpr), newExpr, subqs...)
+		// If the operator is not a projection, like when we are sorting the output of an aggregation,
+		// we add a projection on top of it that passes its columns through and evaluates the subqueries
+		if proj == nil {
+			proj = createPassThroughProjection(ctx, op)
+			op = proj
+		}
+		// Add the new subquery expression to the projection
+		if !proj.addSubqueryExpr(ctx, aeWrap(newExpr), newExpr, subqs...) {
+			// the projection already has a column using the same subqueries, so there is no need to evaluate them again
+			sqc.Inner = sqc.Inner[:len(sqc.Inner)-len(subqs)]
 		}
 
 		// Replace the original order expression with the new expression containing subqueries
@@ -155,6 +168,23 @@ func expandOrderBy(ctx *plancontext.PlanningContext, op Operator, qp *QueryProje
 	return newOrdering(op, newOrder)
 }
 
+// createPassThroughProjection creates a projection returning all the columns of the given operator.
+// A derived table on an aggregator is moved to the projection, so the outer query can still find its columns.
+func createPassThroughProjection(ctx *plancontext.PlanningContext, op Operator) *Projection {
+	var dt *DerivedTable
+	if aggr, ok := op.(*Aggregator); ok {
+		dt, aggr.DT = aggr.DT, nil
+	}
+	proj := newAliasedProjection(op)
+	proj.DT = dt
+	for idx, ae := range op.GetColumns(ctx) {
+		pe := newProjExpr(sqlparser.Clone(ae))
+		pe.Info = Offset(idx)
+		proj.addProjExpr(pe)
+	}
+	return proj
+}
+
 // exposeOrderingColumn will expose the ordering column to the outer query
 func exposeOrderingColumn(ctx *plancontext.PlanningContext, qp *QueryProjection, orderBy OrderBy, derived string) OrderBy {
 	for _, se := range qp.SelectExprs {
@@ -214,7 +244,7 @@ func createProjectionWithAggr(ctx *plancontext.PlanningContext, qp *QueryProject
 	for idx, aggr := range aggregations {
 		aggregations[idx] = pullOutValueSubqueries(ctx, aggr, sqc, TableID(src))
 	}
-	aggrOp.Source = sqc.getRootOperator(src, nil)
+	aggrOp.Source = projectGroupingSubqueries(ctx, sqc.getRootOperator(src, nil), aggrOp.Grouping)
 
 	// create the projection columns from aggregator.
 	if complexAggr {
@@ -246,6 +276,31 @@ func pullOutValueSubqueries(ctx *plancontext.PlanningContext, aggr Aggr, sqc *Su
 	return aggr
 }
 
+// projectGroupingSubqueries adds a projection under the aggregator when grouping on expressions using subqueries.
+// The subqueries are evaluated before the aggregation, and the aggregator groups on the columns they are projected to.
+func projectGroupingSubqueries(ctx *plancontext.PlanningContext, src Operator, grouping []GroupBy) Operator {
+	var proj *Projection
+	sqc := &SubQueryBuilder{}
+	for idx, gb := range grouping {
+		// pulling out the subqueries changes them, so the grouping keeps a copy of the original expression
+		org := ctx.SemTable.Clone(gb.Inner).(sqlparser.Expr)
+		newExpr, subqs := sqc.pullOutValueSubqueries(ctx, gb.Inner, TableID(src), false)
+		if newExpr == nil {
+			continue
+		}
+		if proj == nil {
+			proj = newAliasedProjection(nil)
+		}
+		grouping[idx].Inner = org
+		proj.addSubqueryExpr(ctx, aeWrap(org), newExpr, subqs...)
+	}
+	if proj == nil {
+		return src
+	}
+	proj.Source = sqc.getRootOperator(src, nil)
+	return proj
+}
+
 func addAllColumnsToAggregator(ctx *plancontext.PlanningContext, a *Aggregator, qp *QueryProjection) {
 outer:
 	for colIdx, expr := range qp.SelectExprs {
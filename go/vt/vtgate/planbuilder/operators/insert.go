@@ -43,6 +43,10 @@ type Insert struct {
 	// ColVindexes are the vindexes that will use the VindexValues or VindexValueOffset
 	ColVindexes []*vindexes.ColumnVindex
 
+	// VindexExprs are the expressions of the ColVindexes declared over an expression,
+	// translated to be evaluated over the values of the columns of the vindex.
+	VindexExprs []evalengine.Expr
+
 	// VindexValues specifies values for all the vindex columns.
 	VindexValues [][][]evalengine.Expr
 
@@ -61,6 +65,8 @@ type Generate struct {
 	Keyspace *vindexes.Keyspace
 	// TableName represents the name of the table.
 	TableName sqlparser.TableName
+	// Backend is the SequenceBackend of the sequence table, if any.
+	Backend *vindexes.SequenceBackend
 
 	// Values are the supplied values for the column, which
 	// will be stored as a list within the expression. New
@@ -91,6 +97,7 @@ func (i *Insert) Clone([]Operator) Operator {
 		AutoIncrement:     i.AutoIncrement,
 		Ignore:            i.Ignore,
 		ColVindexes:       i.ColVindexes,
+		VindexExprs:       i.VindexExprs,
 		VindexValues:      i.VindexValues,
 		VindexValueOffset: i.VindexValueOffset,
 	}
@@ -113,37 +120,176 @@ func createOperatorFromInsert(ctx *plancontext.PlanningContext, ins *sqlparser.I
 
 	vTbl, routing := buildVindexTableForDML(ctx, tableInfo, qt, ins, "insert")
 
-	deleteBeforeInsert := false
-	if ins.Action == sqlparser.ReplaceAct &&
-		(ctx.SemTable.ForeignKeysPresent() || vTbl.Keyspace.Sharded) &&
-		(len(vTbl.PrimaryKey) > 0 || len(vTbl.UniqueKeys) > 0) {
-		// this needs a delete before insert as there can be row clash which needs to be deleted first.
-		ins.Action = sqlparser.InsertAct
-		deleteBeforeInsert = true
+	var delOp Operator
+	if ins.Action == sqlparser.ReplaceAct && (ctx.SemTable.ForeignKeysPresent() || vTbl.Keyspace.Sharded) {
+		if len(vTbl.PrimaryKey) > 0 || len(vTbl.UniqueKeys) > 0 {
+			// this needs a delete before insert as there can be row clash which needs to be deleted first.
+			// The delete is planned first, since planning the insert replaces the values with bind variables.
+			ins.Action = sqlparser.InsertAct
+			delOp = createDeleteBeforeInsert(ctx, ins, vTbl)
+		} else if vTbl.Keyspace.Sharded {
+			// without the keys we can't find the rows that clash, and they can be on any shard.
+			panic(vterrors.VT12001("REPLACE INTO on a sharded table without primary or unique key information"))
+		}
 	}
 
 	insOp := checkAndCreateInsertOperator(ctx, ins, vTbl, routing)
 
-	if !deleteBeforeInsert {
+	if delOp == nil {
 		return insOp
 	}
+	return &Sequential{Sources: []Operator{delOp, insOp}}
+}
 
-	rows, isRows := ins.Rows.(sqlparser.Values)
-	if !isRows {
-		panic(vterrors.VT12001("REPLACE INTO using select statement"))
+// createDeleteBeforeInsert creates the delete that removes the rows clashing with the rows of a REPLACE INTO.
+// Planning it as a normal delete takes care of the owned vindexes of the table.
+func createDeleteBeforeInsert(ctx *plancontext.PlanningContext, ins *sqlparser.Insert, vTbl *vindexes.Table) Operator {
+	if ins.Columns == nil && valuesProvided(ins.Rows) {
+		if !vTbl.ColumnListAuthoritative {
+			panic(vterrors.VT09004())
+		}
+		ins = populateInsertColumnlist(ins, vTbl)
 	}
 
-	pkCompExpr := pkCompExpression(vTbl, ins, rows)
-	uniqKeyCompExprs := uniqKeyCompExpressions(vTbl, ins, rows)
-	whereExpr := getWhereCondExpr(append(uniqKeyCompExprs, pkCompExpr))
+	var whereExpr sqlparser.Expr
+	switch rows := ins.Rows.(type) {
+	case sqlparser.Values:
+		rows = sqlparser.Clone(rows)
+		pkCompExpr := pkCompExpression(vTbl, ins, rows)
+		uniqKeyCompExprs := uniqKeyCompExpressions(vTbl, ins, rows)
+		whereExpr = getWhereCondExpr(append(uniqKeyCompExprs, pkCompExpr))
+	case sqlparser.SelectStatement:
+		if selectReadsTable(ctx, rows, vTbl) {
+			// the select would not see the rows removed by the delete
+			panic(vterrors.VT12001("REPLACE INTO using select statement reading from the target table"))
+		}
+		return createDeleteWithSelectInput(ctx, ins, vTbl, rows)
+	}
+	if whereExpr == nil {
+		// none of the keys can clash with the inserted rows
+		return nil
+	}
 
 	delStmt := &sqlparser.Delete{
 		Comments:   ins.Comments,
 		TableExprs: sqlparser.TableExprs{sqlparser.Clone(ins.Table)},
 		Where:      sqlparser.NewWhere(sqlparser.WhereClause, whereExpr),
 	}
-	delOp := createOpFromStmt(ctx, delStmt, false, "")
-	return &Sequential{Sources: []Operator{delOp, insOp}}
+	return createOpFromStmt(ctx, delStmt, false, "")
+}
+
+func selectReadsTable(ctx *plancontext.PlanningContext, sel sqlparser.SelectStatement, vTbl *vindexes.Table) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		ate, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return !found, nil
+		}
+		ti, err := ctx.SemTable.TableInfoFor(ctx.SemTable.TableSetFor(ate))
+		if err != nil {
+			return true, nil
+		}
+		if tbl := ti.GetVindexTable(); tbl != nil && tbl.Keyspace.Name == vTbl.Keyspace.Name && tbl.Name.String() == vTbl.Name.String() {
+			found = true
+		}
+		return !found, nil
+	}, sel)
+	return found
+}
+
+// createDeleteWithSelectInput creates the delete for a REPLACE INTO ... SELECT.
+// The keys of the selected rows are read first, and each key of the table is used to delete the rows clashing with them.
+func createDeleteWithSelectInput(ctx *plancontext.PlanningContext, ins *sqlparser.Insert, vTbl *vindexes.Table, sel sqlparser.SelectStatement) Operator {
+	keys := make([]sqlparser.Exprs, 0, len(vTbl.UniqueKeys)+1)
+	if len(vTbl.PrimaryKey) > 0 {
+		var pk sqlparser.Exprs
+		for _, col := range vTbl.PrimaryKey {
+			pk = append(pk, sqlparser.NewColName(col.String()))
+		}
+		keys = append(keys, pk)
+	}
+	keys = append(keys, vTbl.UniqueKeys...)
+
+	const dtName = "dt"
+	var selExprs sqlparser.SelectExprs
+	var dmls []Operator
+	var offsets [][]int
+	for _, key := range keys {
+		keyExprs := selectKeyExpressions(vTbl, ins, key, dtName)
+		if keyExprs == nil {
+			continue
+		}
+
+		var colTuple sqlparser.ValTuple
+		var keyOffsets []int
+		for idx, expr := range key {
+			keyOffsets = append(keyOffsets, len(selExprs))
+			selExprs = append(selExprs, sqlparser.NewAliasedExpr(keyExprs[idx], ""))
+			colTuple = append(colTuple, sqlparser.Clone(expr))
+		}
+		// optimize for case when there is only single column on left hand side.
+		var lhs sqlparser.Expr = colTuple
+		if len(colTuple) == 1 {
+			lhs = colTuple[0]
+		}
+
+		delStmt := &sqlparser.Delete{
+			Comments:   ins.Comments,
+			TableExprs: sqlparser.TableExprs{sqlparser.Clone(ins.Table)},
+			Where:      sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.NewComparisonExpr(sqlparser.InOp, lhs, sqlparser.ListArg(engine.DmlVals), nil)),
+		}
+		dmls = append(dmls, createOpFromStmt(ctx, delStmt, false, ""))
+		offsets = append(offsets, keyOffsets)
+	}
+	if len(dmls) == 0 {
+		// none of the keys can clash with the inserted rows
+		return nil
+	}
+
+	keySel := &sqlparser.Select{
+		SelectExprs: selExprs,
+		From: sqlparser.TableExprs{&sqlparser.AliasedTableExpr{
+			Expr:    &sqlparser.DerivedTable{Select: sqlparser.Clone(sel)},
+			As:      sqlparser.NewIdentifierCS(dtName),
+			Columns: sqlparser.Clone(ins.Columns),
+		}},
+	}
+	return &DMLWithInput{
+		Source:  createOpFromStmt(ctx, keySel, false, ""),
+		DML:     dmls,
+		Offsets: offsets,
+	}
+}
+
+// selectKeyExpressions returns the expressions calculating the key from the columns of the derived table
+// holding the selected rows. It returns nil if the key can't clash with the inserted rows.
+func selectKeyExpressions(vTbl *vindexes.Table, ins *sqlparser.Insert, key sqlparser.Exprs, dtName string) sqlparser.Exprs {
+	var keyExprs sqlparser.Exprs
+	for _, expr := range key {
+		skipKey := false
+		keyExpr := sqlparser.CopyOnRewrite(expr, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+			col, isCol := cursor.Node().(*sqlparser.ColName)
+			if !isCol {
+				return
+			}
+			if ins.Columns.FindColumn(col.Name) >= 0 {
+				cursor.Replace(sqlparser.NewColNameWithQualifier(col.Name.String(), sqlparser.NewTableName(dtName)))
+				return
+			}
+			def := findDefault(vTbl, col.Name)
+			if def == nil {
+				// default value is empty, nothing to compare as it will always be false.
+				skipKey = true
+				return
+			}
+			cursor.Replace(sqlparser.Clone(def))
+		}, nil).(sqlparser.Expr)
+		if skipKey {
+			return nil
+		}
+		keyExprs = append(keyExprs, keyExpr)
+	}
+	return keyExprs
 }
 
 func checkAndCreateInsertOperator(ctx *plancontext.PlanningContext, ins *sqlparser.Insert, vTbl *vindexes.Table, routing Routing) Operator {
@@ -205,6 +351,10 @@ func pkCompExpression(vTbl *vindexes.Table, ins *sqlparser.Insert, rows sqlparse
 		return nil
 	}
 	pIndexes, pColTuple := findPKIndexes(vTbl, ins)
+	if len(pIndexes) == 0 {
+		// the primary key can't clash with the inserted rows
+		return nil
+	}
 
 	var pValTuple sqlparser.ValTuple
 	for _, row := range rows {
@@ -245,6 +395,10 @@ func findPKIndexes(vTbl *vindexes.Table, ins *sqlparser.Insert) (pIndexes []pCom
 }
 
 func findDefault(vTbl *vindexes.Table, pCol sqlparser.IdentifierCI) sqlparser.Expr {
+	if vTbl.AutoIncrement != nil && vTbl.AutoIncrement.Column.Equal(pCol) {
+		// a generated value does not have a default to compare with
+		return nil
+	}
 	for _, column := range vTbl.Columns {
 		if column.Name.Equal(pCol) {
 			return column.Default
@@ -390,6 +544,7 @@ func createInsertOperator(ctx *plancontext.PlanningContext, insStmt *sqlparser.I
 	insOp.Ignore = bool(insStmt.Ignore) || insStmt.OnDup != nil
 
 	insOp.ColVindexes = getColVindexes(insOp)
+	insOp.VindexExprs = translateVindexExprs(ctx, insOp.ColVindexes)
 	switch rows := insStmt.Rows.(type) {
 	case sqlparser.Values:
 		op = route
@@ -553,6 +708,37 @@ func getColVindexes(insOp *Insert) (colVindexes []*vindexes.ColumnVindex) {
 	return
 }
 
+// translateVindexExprs translates the expressions of the vindexes declared over an expression,
+// the columns of the expression being the values of the columns of the vindex.
+func translateVindexExprs(ctx *plancontext.PlanningContext, colVindexes []*vindexes.ColumnVindex) []evalengine.Expr {
+	var exprs []evalengine.Expr
+	for idx, colVindex := range colVindexes {
+		if colVindex.Expression == nil {
+			continue
+		}
+		if exprs == nil {
+			exprs = make([]evalengine.Expr, len(colVindexes))
+		}
+		expr, err := evalengine.Translate(colVindex.Expression, &evalengine.Config{
+			ResolveColumn: func(col *sqlparser.ColName) (int, error) {
+				for offset, vcol := range colVindex.Columns {
+					if col.Name.Equal(vcol) {
+						return offset, nil
+					}
+				}
+				return 0, vterrors.VT03019(sqlparser.String(col))
+			},
+			Collation:   ctx.SemTable.Collation,
+			Environment: ctx.VSchema.Environment(),
+		})
+		if err != nil {
+			panic(err)
+		}
+		exprs[idx] = expr
+	}
+	return exprs
+}
+
 func checkAndErrIfVindexChanging(setClauses sqlparser.UpdateExprs, col sqlparser.IdentifierCI) {
 	for _, assignment := range setClauses {
 		if col.Equal(assignment.Name.Name) {
@@ -613,6 +799,7 @@ func modifyForAutoinc(ctx *plancontext.PlanningContext, ins *sqlparser.Insert, v
 	gen := &Generate{
 		Keyspace:  vTable.AutoIncrement.Sequence.Keyspace,
 		TableName: sqlparser.TableName{Name: vTable.AutoIncrement.Sequence.Name},
+		Backend:   vTable.AutoIncrement.Sequence.SequenceBackend,
 	}
 	colNum, newColAdded := findOrAddColumn(ins, vTable.AutoIncrement.Column)
 	switch rows := ins.Rows.(type) {
@@ -17,9 +17,13 @@ limitations under the License.
 package operators
 
 import (
+	"slices"
+	"strings"
+
 	"vitess.io/vitess/go/slice"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
@@ -32,6 +36,10 @@ type Join struct {
 	// NormalJoinType, StraightJoinType and LeftJoinType.
 	JoinType sqlparser.JoinType
 
+	// LateralVars are the LHS expressions used by a LATERAL derived table on the RHS.
+	// The derived table reads them from these bind variables.
+	LateralVars []BindVarExpr
+
 	noColumns
 }
 
@@ -42,6 +50,7 @@ func (j *Join) Clone(inputs []Operator) Operator {
 	clone := *j
 	clone.LHS = inputs[0]
 	clone.RHS = inputs[1]
+	clone.LateralVars = slices.Clone(j.LateralVars)
 	return &clone
 }
 
@@ -102,17 +111,62 @@ func createLeftOuterJoin(ctx *plancontext.PlanningContext, join *sqlparser.JoinT
 	ctx.OuterTables = ctx.OuterTables.Merge(TableID(rhs))
 
 	// for outer joins we have to be careful with the predicates we use
-	var op Operator
-	subq, _ := getSubQuery(join.Condition.On)
-	if subq != nil {
-		panic(vterrors.VT12001("subquery in outer join predicate"))
-	}
-	predicate := join.Condition.On
+	return addOuterJoinPredicate(ctx, join.Condition.On, joinOp)
+}
+
+// addOuterJoinPredicate sets the ON condition of an outer join. The condition can't be turned into
+// filters, so uncorrelated subqueries in it are evaluated before the join and their results are
+// used through bind variables.
+func addOuterJoinPredicate(ctx *plancontext.PlanningContext, predicate sqlparser.Expr, joinOp *Join) Operator {
 	sqlparser.RemoveKeyspaceInCol(predicate)
-	joinOp.Predicate = predicate
-	op = joinOp
+	if subq, _ := getSubQuery(predicate); subq == nil {
+		joinOp.Predicate = predicate
+		return joinOp
+	}
 
-	return op
+	sqc := &SubQueryBuilder{}
+	newPred, subqs := sqc.pullOutValueSubqueries(ctx, predicate, TableID(joinOp), true)
+	for _, sq := range subqs {
+		if sq.correlated {
+			panic(vterrors.VT12001("correlated subquery in outer join predicate"))
+		}
+	}
+	joinOp.Predicate = addHasValuesChecks(ctx, newPred, subqs)
+	return sqc.getRootOperator(joinOp, nil)
+}
+
+// addHasValuesChecks makes the arguments of pulled out subqueries usable in a predicate.
+// IN and NOT IN comparisons need to know if the subquery returned any rows, and EXISTS reads that directly.
+func addHasValuesChecks(ctx *plancontext.PlanningContext, expr sqlparser.Expr, subqs []*SubQuery) sqlparser.Expr {
+	for _, sq := range subqs {
+		if sq.FilterType == opcode.PulloutExists {
+			sq.HasValuesName = sq.ArgName
+		}
+	}
+	return sqlparser.Rewrite(expr, nil, func(cursor *sqlparser.Cursor) bool {
+		cmp, ok := cursor.Node().(*sqlparser.ComparisonExpr)
+		if !ok {
+			return true
+		}
+		listArg, ok := cmp.Right.(sqlparser.ListArg)
+		if !ok {
+			return true
+		}
+		for _, sq := range subqs {
+			if sq.ArgName != string(listArg) {
+				continue
+			}
+			hasValues := sqlparser.NewArgument(sq.hasValuesArg(ctx))
+			switch sq.FilterType {
+			case opcode.PulloutIn:
+				cursor.Replace(sqlparser.AndExpressions(hasValues, cmp))
+			case opcode.PulloutNotIn:
+				cursor.Replace(&sqlparser.OrExpr{Left: sqlparser.NewNotExpr(hasValues), Right: cmp})
+			}
+			break
+		}
+		return true
+	}).(sqlparser.Expr)
 }
 
 func createInnerJoin(ctx *plancontext.PlanningContext, tableExpr *sqlparser.JoinTableExpr, lhs, rhs Operator) Operator {
@@ -130,22 +184,31 @@ func addJoinPredicates(
 	sqlparser.RemoveKeyspaceInCol(joinPredicate)
 	exprs := sqlparser.SplitAndExpression(nil, joinPredicate)
 	for _, pred := range exprs {
-		subq := sqc.handleSubquery(ctx, pred, outerID)
-		if subq != nil {
+		if newPred := sqc.pullOutPredicateSubqueries(ctx, pred, outerID); newPred != nil {
+			pred = newPred
+		} else if subq := sqc.handleSubquery(ctx, pred, outerID); subq != nil {
 			continue
 		}
 
-		// if we are inside a CTE, we need to check if we depend on the recursion table
-		if cte := ctx.ActiveCTE(); cte != nil && ctx.SemTable.DirectDeps(pred).IsOverlapping(cte.Id) {
-			original := pred
-			pred = addCTEPredicate(ctx, pred, cte)
-			ctx.AddJoinPredicates(original, pred)
-		}
+		pred = breakRecursivePredicate(ctx, pred)
 		op = op.AddPredicate(ctx, pred)
 	}
 	return sqc.getRootOperator(op, nil)
 }
 
+// breakRecursivePredicate checks if we are inside a CTE and the predicate depends on the recursion table.
+// If so, the columns coming from the recursion table are replaced with bind variables.
+func breakRecursivePredicate(ctx *plancontext.PlanningContext, pred sqlparser.Expr) sqlparser.Expr {
+	cte := ctx.ActiveCTE()
+	if cte == nil || !ctx.SemTable.DirectDeps(pred).IsOverlapping(cte.Id) {
+		return pred
+	}
+	original := pred
+	pred = addCTEPredicate(ctx, pred, cte)
+	ctx.AddJoinPredicates(original, pred)
+	return pred
+}
+
 // addCTEPredicate breaks the expression into LHS and RHS
 func addCTEPredicate(
 	ctx *plancontext.PlanningContext,
@@ -231,5 +294,9 @@ func (j *Join) AddJoinPredicate(ctx *plancontext.PlanningContext, expr sqlparser
 }
 
 func (j *Join) ShortDescription() string {
-	return sqlparser.String(j.Predicate)
+	if len(j.LateralVars) == 0 {
+		return sqlparser.String(j.Predicate)
+	}
+	lateralVars := slice.Map(j.LateralVars, func(s BindVarExpr) string { return s.String() })
+	return sqlparser.String(j.Predicate) + " lateral: " + strings.Join(lateralVars, ", ")
 }
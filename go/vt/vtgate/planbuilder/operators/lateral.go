@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"io"
+	"slices"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+var lateralOuterColumnsErr = vterrors.VT12001("LATERAL derived table using outer columns outside of its WHERE clause")
+
+// extractLateralVars rewrites the WHERE clause of a LATERAL derived table, so that the columns it uses
+// from the tables before it in the FROM clause are read from bind variables instead.
+// It returns the bind variables together with the LHS expressions that produce them.
+// Derived tables that are not LATERAL, or that do not use any LHS columns, are left untouched.
+func extractLateralVars(ctx *plancontext.PlanningContext, tableExpr sqlparser.TableExpr, lhs semantics.TableSet) []BindVarExpr {
+	aliased, ok := tableExpr.(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return nil
+	}
+	dt, ok := aliased.Expr.(*sqlparser.DerivedTable)
+	if !ok || !dt.Lateral {
+		return nil
+	}
+
+	sel, ok := dt.Select.(*sqlparser.Select)
+	if !ok {
+		if usesTables(ctx, dt.Select, nil, lhs) {
+			panic(lateralOuterColumnsErr)
+		}
+		return nil
+	}
+	if usesTables(ctx, sel, sel.Where, lhs) {
+		panic(lateralOuterColumnsErr)
+	}
+	if sel.Where == nil {
+		return nil
+	}
+
+	col := breakExpressionInLHSandRHS(ctx, sel.Where.Expr, lhs)
+	sel.Where.Expr = col.RHSExpr
+
+	var vars []BindVarExpr
+	for _, bve := range col.LHSExprs {
+		if !slices.ContainsFunc(vars, func(other BindVarExpr) bool { return other.Name == bve.Name }) {
+			vars = append(vars, bve)
+		}
+	}
+	return vars
+}
+
+// usesTables returns true if any of the columns in the given node, outside the skipped WHERE clause,
+// come from the given tables
+func usesTables(ctx *plancontext.PlanningContext, node sqlparser.SQLNode, skip *sqlparser.Where, tables semantics.TableSet) bool {
+	err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Where:
+			return skip == nil || node != skip, nil
+		case *sqlparser.ColName:
+			if ctx.SemTable.RecursiveDeps(node).IsOverlapping(tables) {
+				return false, io.EOF
+			}
+		}
+		return true, nil
+	}, node)
+	return err == io.EOF
+}
+
+// createLateralJoin creates the join between the LHS and a LATERAL derived table using columns from it
+func createLateralJoin(ctx *plancontext.PlanningContext, join *sqlparser.JoinTableExpr, lhs, rhs Operator, lateralVars []BindVarExpr) Operator {
+	joinOp := &Join{
+		binaryOperator: newBinaryOp(lhs, rhs),
+		JoinType:       join.Join,
+		LateralVars:    lateralVars,
+	}
+
+	switch join.Join {
+	case sqlparser.NormalJoinType, sqlparser.StraightJoinType:
+		return addJoinPredicates(ctx, join.Condition.On, joinOp)
+	case sqlparser.LeftJoinType:
+		// mark the RHS as outer tables so we know which columns are nullable
+		ctx.OuterTables = ctx.OuterTables.Merge(TableID(rhs))
+
+		return addOuterJoinPredicate(ctx, join.Condition.On, joinOp)
+	default:
+		panic(vterrors.VT12001(join.Join.ToString() + " with a LATERAL derived table"))
+	}
+}
+
+// optimizeLateralJoin plans a join with a LATERAL derived table on the RHS.
+// The RHS can't be evaluated without the LHS row, so we never switch sides or use a hash join.
+// Unless both sides can be merged into a single route, we use an ApplyJoin that
+// sends the LHS columns the derived table uses to the RHS as bind variables.
+func optimizeLateralJoin(ctx *plancontext.PlanningContext, op *Join) (Operator, *ApplyResult) {
+	joinPredicates := sqlparser.SplitAndExpression(nil, op.Predicate)
+	jm := newJoinMerge(joinPredicates, op.JoinType)
+	jm.lateralVars = op.LateralVars
+	if newPlan := jm.mergeLateralJoinInputs(ctx, op.LHS, op.RHS); newPlan != nil {
+		return newPlan, Rewrote("merge lateral join routes into single operator")
+	}
+
+	join := NewApplyJoin(ctx, Clone(op.LHS), Clone(op.RHS), nil, op.JoinType)
+	join.ExtraLHSVars = slices.Clone(op.LateralVars)
+	for _, pred := range joinPredicates {
+		join.AddJoinPredicate(ctx, pred)
+	}
+	return join, Rewrote("lateral join to applyJoin")
+}
+
+// mergeLateralJoinInputs checks whether the two sides of a lateral join can be merged into a single route.
+// The merged route uses the routing of the LHS, since the RHS is only able to
+// go to the right shards once it knows the values coming from the LHS.
+func (jm *joinMerger) mergeLateralJoinInputs(ctx *plancontext.PlanningContext, lhs, rhs Operator) *Route {
+	lhsRoute, rhsRoute, routingA, routingB, a, b, sameKeyspace := prepareInputRoutes(lhs, rhs)
+	if lhsRoute == nil {
+		return nil
+	}
+
+	switch b {
+	case sharded:
+		if a != sharded || !sameKeyspace {
+			return nil
+		}
+		// the RHS has to be on the same shard as the LHS row it was sent for
+		predicates := append(lateralMergePredicates(routingB.(*ShardedRouting), jm.lateralVars), jm.predicates...)
+		if !canMergeOnFilters(ctx, lhsRoute, rhsRoute, predicates) {
+			return nil
+		}
+		return jm.merge(ctx, lhsRoute, rhsRoute, routingA)
+	case infoSchema:
+		// the information_schema routing can depend on the bind variables coming from the LHS
+		return nil
+	default:
+		return jm.mergeJoinInputs(ctx, lhs, rhs, jm.predicates)
+	}
+}
+
+// lateralMergePredicates finds the equality predicates that the RHS routing has seen between one of its columns
+// and a lateral bind variable, and turns them into predicates comparing the column with the LHS expression
+func lateralMergePredicates(routing *ShardedRouting, lateralVars []BindVarExpr) (predicates []sqlparser.Expr) {
+	for _, pred := range routing.SeenPredicates {
+		cmp, ok := pred.(*sqlparser.ComparisonExpr)
+		if !ok || cmp.Operator != sqlparser.EqualOp {
+			continue
+		}
+		for _, bve := range lateralVars {
+			switch {
+			case isArgumentNamed(cmp.Right, bve.Name):
+				predicates = append(predicates, sqlparser.NewComparisonExpr(sqlparser.EqualOp, cmp.Left, bve.Expr, nil))
+			case isArgumentNamed(cmp.Left, bve.Name):
+				predicates = append(predicates, sqlparser.NewComparisonExpr(sqlparser.EqualOp, bve.Expr, cmp.Right, nil))
+			}
+		}
+	}
+	return predicates
+}
+
+func isArgumentNamed(expr sqlparser.Expr, name string) bool {
+	arg, ok := expr.(*sqlparser.Argument)
+	return ok && arg.Name == name
+}
+
+// useLHSColumns replaces the bind variables that the RHS of a merged lateral join reads from the LHS
+// with the expressions they come from, and marks the derived tables using them as LATERAL
+func useLHSColumns(stmt sqlparser.Statement, lateralVars []BindVarExpr) sqlparser.Statement {
+	var replaced int
+	var before []int
+	return sqlparser.CopyOnRewrite(stmt, func(node, _ sqlparser.SQLNode) bool {
+		if _, ok := node.(*sqlparser.DerivedTable); ok {
+			before = append(before, replaced)
+		}
+		return true
+	}, func(cursor *sqlparser.CopyOnWriteCursor) {
+		switch node := cursor.Node().(type) {
+		case *sqlparser.Argument:
+			idx := slices.IndexFunc(lateralVars, func(bve BindVarExpr) bool { return bve.Name == node.Name })
+			if idx >= 0 {
+				cursor.Replace(lateralVars[idx].Expr)
+				replaced++
+			}
+		case *sqlparser.DerivedTable:
+			start := before[len(before)-1]
+			before = before[:len(before)-1]
+			if replaced > start && !node.Lateral {
+				cursor.Replace(sqlparser.NewDerivedTable(true, node.Select))
+			}
+		}
+	}, nil).(sqlparser.Statement)
+}
@@ -63,8 +63,13 @@ func planOffsets(ctx *plancontext.PlanningContext, root Operator) Operator {
 // mustFetchFromInput returns true for expressions that have to be fetched from the input and cannot be evaluated
 func mustFetchFromInput(ctx *plancontext.PlanningContext, e sqlparser.SQLNode) bool {
 	switch fun := e.(type) {
+	case *sqlparser.Avg:
+		// AVG used as a window function is calculated from SUM and COUNT over the same window
+		return fun.OverClause == nil
 	case *sqlparser.ColName, sqlparser.AggrFunc:
 		return true
+	case *sqlparser.ArgumentLessWindowExpr, *sqlparser.FirstOrLastValueExpr, *sqlparser.NtileExpr, *sqlparser.NTHValueExpr, *sqlparser.LagLeadExpr:
+		return true
 	case *sqlparser.FuncExpr:
 		return fun.Name.EqualsAnyString(ctx.VSchema.GetAggregateUDFs())
 	default:
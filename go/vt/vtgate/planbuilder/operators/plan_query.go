@@ -158,6 +158,10 @@ func tryTruncateColumnsAt(op Operator, truncateAt int) bool {
 	case *Limit:
 		return tryTruncateColumnsAt(op.Source, truncateAt)
 	case *SubQuery:
+		if op.PerRow {
+			// the predicate or the subquery value need columns that are not part of the output
+			return false
+		}
 		for _, offset := range op.Vars {
 			if offset >= truncateAt {
 				return false
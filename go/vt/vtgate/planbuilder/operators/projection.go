@@ -286,7 +286,9 @@ func (p *Projection) addUnexploredExpr(ae *sqlparser.AliasedExpr, e sqlparser.Ex
 	return p.addProjExpr(newProjExprWithInner(ae, e))
 }
 
-func (p *Projection) addSubqueryExpr(ctx *plancontext.PlanningContext, ae *sqlparser.AliasedExpr, expr sqlparser.Expr, sqs ...*SubQuery) {
+// addSubqueryExpr adds a column using the given subqueries to the projection.
+// It returns false if the projection already had the column.
+func (p *Projection) addSubqueryExpr(ctx *plancontext.PlanningContext, ae *sqlparser.AliasedExpr, expr sqlparser.Expr, sqs ...*SubQuery) bool {
 	ap, err := p.GetAliasedProjections()
 	if err != nil {
 		panic(err)
@@ -294,7 +296,7 @@ func (p *Projection) addSubqueryExpr(ctx *plancontext.PlanningContext, ae *sqlpa
 	for _, projExpr := range ap {
 		if ctx.SemTable.EqualsExprWithDeps(projExpr.EvalExpr, expr) {
 			// if we already have this column, we can just return the offset
-			return
+			return false
 		}
 	}
 
@@ -302,6 +304,7 @@ func (p *Projection) addSubqueryExpr(ctx *plancontext.PlanningContext, ae *sqlpa
 	pe.Info = SubQueryExpression(sqs)
 
 	_ = p.addProjExpr(pe)
+	return true
 }
 
 func (p *Projection) addColumnWithoutPushing(ctx *plancontext.PlanningContext, expr *sqlparser.AliasedExpr, _ bool) int {
@@ -333,7 +336,7 @@ func (p *Projection) AddWSColumn(ctx *plancontext.PlanningContext, offset int, u
 
 	// we need to push down this column to our input
 	offsetOnInput := p.Source.FindCol(ctx, expr, false)
-	if offsetOnInput >= 0 {
+	if offsetOnInput >= 0 && !isPerRowSubqueryValue(p.Source, offsetOnInput) {
 		// if we are not getting this from the source, we can solve this at offset planning time
 		inputOffset := p.Source.AddWSColumn(ctx, offsetOnInput, false)
 		pe.Info = Offset(inputOffset)
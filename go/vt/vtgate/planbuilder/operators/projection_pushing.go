@@ -126,7 +126,8 @@ func pushProjectionToOuter(ctx *plancontext.PlanningContext, p *Projection, sq *
 		return p, NoRewrite
 	}
 
-	if !reachedPhase(ctx, subquerySettling) {
+	if !reachedPhase(ctx, subquerySettling) || sq.projectsValue() {
+		// when the subquery result is returned as a column, the projection needs to stay above the subquery
 		return p, NoRewrite
 	}
 
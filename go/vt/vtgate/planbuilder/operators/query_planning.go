@@ -208,7 +208,8 @@ func pushOrExpandHorizon(ctx *plancontext.PlanningContext, in *Horizon) (Operato
 		!needsOrdering &&
 		!qp.NeedsAggregation() &&
 		!in.selectStatement().IsDistinct() &&
-		in.selectStatement().GetLimit() == nil
+		in.selectStatement().GetLimit() == nil &&
+		(!isSel || canPushWindowFuncs(ctx, rb, sel, qp.windowFuncs()))
 
 	if canPush {
 		return Swap(in, rb, "push horizon into route")
@@ -485,6 +486,9 @@ func setUpperLimit(in *Limit) (Operator, *ApplyResult) {
 		case *Join, *ApplyJoin, *SubQueryContainer, *SubQuery:
 			// we can't push limits down on either side
 			return SkipChildren
+		case *Window:
+			// window functions need to see all the rows of a partition
+			return SkipChildren
 		case *Aggregator:
 			if len(op.Grouping) > 0 {
 				// we can't push limits down if we have a group by
@@ -521,6 +525,10 @@ func tryPushOrdering(ctx *plancontext.PlanningContext, in *Ordering) (Operator,
 	case *Projection:
 		return pushOrderingUnderProjection(ctx, in, src)
 	case *Aggregator:
+		if src.WithRollup {
+			// the order of the grouping columns decides which super-aggregate rows are produced
+			return in, NoRewrite
+		}
 		if !src.QP.AlignGroupByAndOrderBy(ctx) && !overlaps(ctx, in.Order, src.Grouping) {
 			return in, NoRewrite
 		}
@@ -693,7 +701,7 @@ func tryPushFilter(ctx *plancontext.PlanningContext, in *Filter) (Operator, *App
 		outerTableID := TableID(src.Outer)
 		for _, pred := range in.Predicates {
 			deps := ctx.SemTable.RecursiveDeps(pred)
-			if !deps.IsSolvedBy(outerTableID) {
+			if !deps.IsSolvedBy(outerTableID) || src.projectsValue() && src.usesValue(pred) {
 				return in, NoRewrite
 			}
 		}
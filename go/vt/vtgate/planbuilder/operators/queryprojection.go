@@ -82,6 +82,11 @@ type (
 		ColOffset int // Offset for the column being aggregated
 		WSOffset  int // Offset for the weight string of the column
 
+		// Offsets used by GROUP_CONCAT when it is evaluated at the vtgate.
+		// They point to the arguments and the ORDER BY expressions of the call, and to their weight strings
+		ArgOffsets, ArgWSOffsets         []int
+		OrderByOffsets, OrderByWSOffsets []int
+
 		SubQueryExpression []*SubQuery // Subqueries associated with this aggregation
 
 		PushedDown bool // Whether the aggregation has been pushed down to the next layer
@@ -92,6 +97,13 @@ func (aggr Aggr) NeedsWeightString(ctx *plancontext.PlanningContext) bool {
 	return aggr.OpCode.NeedsComparableValues() && ctx.NeedsWeightString(aggr.Func.GetArg())
 }
 
+// needsAllValues returns true when the aggregation can't be built from the partial results of the shards,
+// so all the rows have to be sent to the vtgate
+func (aggr Aggr) needsAllValues() bool {
+	gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+	return ok && (gc.Distinct || len(gc.OrderBy) > 0)
+}
+
 func (aggr Aggr) GetTypeCollation(ctx *plancontext.PlanningContext) evalengine.Type {
 	if aggr.Func == nil {
 		return evalengine.NewUnknownType()
@@ -370,6 +382,20 @@ func (qp *QueryProjection) NeedsAggregation() bool {
 	return qp.HasAggr || len(qp.groupByExprs) > 0
 }
 
+// windowFuncs returns the window functions used in the SELECT list and the ORDER BY
+func (qp *QueryProjection) windowFuncs() []sqlparser.Expr {
+	var exprs []sqlparser.Expr
+	for _, expr := range qp.SelectExprs {
+		if ae, ok := expr.Col.(*sqlparser.AliasedExpr); ok {
+			exprs = append(exprs, ae.Expr)
+		}
+	}
+	for _, order := range qp.OrderExprs {
+		exprs = append(exprs, order.SimplifiedExpr)
+	}
+	return findWindowFuncs(exprs...)
+}
+
 func (qp *QueryProjection) onlyAggr() bool {
 	if !qp.HasAggr {
 		return false
@@ -474,6 +500,10 @@ orderBy:
 	// We need to return all columns that are being used for ordering
 	for _, orderExpr := range qp.OrderExprs {
 		orderExpr := orderExpr.SimplifiedExpr
+		if subq, _ := getSubQuery(orderExpr); subq != nil {
+			// subqueries are evaluated on the output of the aggregation when expanding the ORDER BY
+			continue
+		}
 		for _, expr := range qp.SelectExprs {
 			col, ok := expr.Col.(*sqlparser.AliasedExpr)
 			if !ok {
@@ -684,12 +714,15 @@ func (qp *QueryProjection) addDerivedColumn(ctx *plancontext.PlanningContext, ex
 
 func checkForInvalidGroupingExpressions(ctx *plancontext.PlanningContext, expr sqlparser.Expr) {
 	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, isSubQ := node.(*sqlparser.Subquery); isSubQ {
+			// aggregations inside a subquery are evaluated by the subquery
+			return false, nil
+		}
 		if ctx.IsAggr(node) {
 			panic(vterrors.VT03005(sqlparser.String(expr)))
 		}
-		_, isSubQ := node.(*sqlparser.Subquery)
 		arg, isArg := node.(*sqlparser.Argument)
-		if isSubQ || (isArg && strings.HasPrefix(arg.Name, "__sq")) {
+		if isArg && strings.HasPrefix(arg.Name, "__sq") {
 			panic(vterrors.VT12001("subqueries in GROUP BY"))
 		}
 		return true, nil
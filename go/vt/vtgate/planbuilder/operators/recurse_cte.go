@@ -176,7 +176,13 @@ func (r *RecurseCTE) expressions() []*plancontext.RecurseExpression {
 
 func (r *RecurseCTE) planOffsets(ctx *plancontext.PlanningContext) Operator {
 	r.Vars = make(map[string]int)
-	columns := r.Seed().GetColumns(ctx)
+	columns := slice.Map(r.Seed().GetColumns(ctx), (*sqlparser.AliasedExpr).ColumnName)
+	for i, col := range r.Def.Columns {
+		// the column aliases of the CTE replace the names of the seed columns
+		if i < len(columns) {
+			columns[i] = col.String()
+		}
+	}
 	for _, expr := range r.expressions() {
 	outer:
 		for _, lhsExpr := range expr.LeftExprs {
@@ -186,7 +192,7 @@ func (r *RecurseCTE) planOffsets(ctx *plancontext.PlanningContext) Operator {
 			}
 
 			for offset, column := range columns {
-				if lhsExpr.Expr.Name.EqualString(column.ColumnName()) {
+				if lhsExpr.Expr.Name.EqualString(column) {
 					r.Vars[lhsExpr.Name] = offset
 					continue outer
 				}
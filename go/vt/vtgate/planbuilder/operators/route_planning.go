@@ -53,6 +53,9 @@ func pushDerived(ctx *plancontext.PlanningContext, op *Horizon) (Operator, *Appl
 }
 
 func optimizeJoin(ctx *plancontext.PlanningContext, op *Join) (Operator, *ApplyResult) {
+	if len(op.LateralVars) > 0 {
+		return optimizeLateralJoin(ctx, op)
+	}
 	return mergeOrJoin(ctx, op.LHS, op.RHS, sqlparser.SplitAndExpression(nil, op.Predicate), op.JoinType)
 }
 
@@ -248,7 +251,7 @@ func findBestJoin(
 				continue
 			}
 			plan := getJoinFor(ctx, planCache, lhs, rhs, joinPredicates)
-			if bestPlan == nil || CostOf(plan) < CostOf(bestPlan) {
+			if bestPlan == nil || cheaperPlan(ctx, plan, bestPlan) {
 				bestPlan = plan
 				// remember which plans we based on, so we can remove them later
 				lIdx = i
@@ -317,6 +320,15 @@ func mergeOrJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredic
 		join.AddJoinPredicate(ctx, pred)
 	}
 
+	if preferHashJoin(ctx, lhs, rhs, joinPredicates, join) {
+		hashJoin := NewHashJoin(Clone(lhs), Clone(rhs), !joinType.IsInner())
+		for _, pred := range joinPredicates {
+			hashJoin.AddJoinPredicate(ctx, pred)
+		}
+		ctx.SemTable.QuerySignature.HashJoin = true
+		return hashJoin, Rewrote("use a hash join instead of a nested loop join")
+	}
+
 	return join, Rewrote("logical join to applyJoin ")
 }
 
@@ -399,7 +411,7 @@ func findColumnVindex(ctx *plancontext.PlanningContext, a Operator, exp sqlparse
 				if vtable != nil {
 					for _, vindex := range vtable.ColumnVindexes {
 						sC, isSingle := vindex.Vindex.(vindexes.SingleColumn)
-						if isSingle && vindex.Columns[0].Equal(col.Name) {
+						if isSingle && vindex.Expression == nil && vindex.Columns[0].Equal(col.Name) {
 							singCol = sC
 							return io.EOF
 						}
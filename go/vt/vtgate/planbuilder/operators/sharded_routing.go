@@ -229,6 +229,12 @@ func (tr *ShardedRouting) searchForNewVindexes(ctx *plancontext.PlanningContext,
 	case *sqlparser.IsExpr:
 		found := tr.planIsExpr(ctx, node)
 		newVindexFound = newVindexFound || found
+
+	case *sqlparser.BetweenExpr:
+		column, ok := node.Left.(*sqlparser.ColName)
+		if node.IsBetween && ok {
+			newVindexFound = tr.planRangeOp(ctx, node, column, node.From, node.To)
+		}
 	}
 
 	return nil, newVindexFound
@@ -245,11 +251,105 @@ func (tr *ShardedRouting) planComparison(ctx *plancontext.PlanningContext, cmp *
 	case sqlparser.LikeOp:
 		found := tr.planLikeOp(ctx, cmp)
 		return nil, found
-
+	case sqlparser.LessThanOp, sqlparser.LessEqualOp, sqlparser.GreaterThanOp, sqlparser.GreaterEqualOp:
+		found := tr.planInequalityOp(ctx, cmp)
+		return nil, found
 	}
 	return nil, false
 }
 
+// planInequalityOp plans a comparison between a column and a value like `col > 10`,
+// which bounds the range of the values of the column on one side
+func (tr *ShardedRouting) planInequalityOp(ctx *plancontext.PlanningContext, cmp *sqlparser.ComparisonExpr) bool {
+	op := cmp.Operator
+	column, ok := cmp.Left.(*sqlparser.ColName)
+	value := cmp.Right
+	if !ok {
+		column, ok = cmp.Right.(*sqlparser.ColName)
+		if !ok {
+			return false
+		}
+		value = cmp.Left
+		op, _ = op.SwitchSides()
+	}
+
+	switch op {
+	case sqlparser.GreaterThanOp, sqlparser.GreaterEqualOp:
+		return tr.planRangeOp(ctx, cmp, column, value, nil)
+	default:
+		return tr.planRangeOp(ctx, cmp, column, nil, value)
+	}
+}
+
+// hasBinaryCollation returns true if the column is known to compare its values by their bytes
+func hasBinaryCollation(ctx *plancontext.PlanningContext, column *sqlparser.ColName) bool {
+	typ, found := ctx.TypeForExpr(column)
+	return found && typ.Collation() == collations.CollationBinaryID
+}
+
+// planRangeOp adds the bounds of the values of a column to the range option of the vindexes
+// of the column that can map ranges, so that predicates like `col >= 10 and col < 20` are
+// combined into a single range. The bounds are included, a superset of the rows of the
+// range being fine for routing.
+func (tr *ShardedRouting) planRangeOp(ctx *plancontext.PlanningContext, node sqlparser.Expr, column *sqlparser.ColName, from, to sqlparser.Expr) bool {
+	newVindexFound := false
+	for _, v := range tr.VindexPreds {
+		if !ctx.SemTable.DirectDeps(column).IsSolvedBy(v.TableID) {
+			continue
+		}
+		rm, ok := v.ColVindex.Vindex.(vindexes.RangeMapper)
+		if !ok || v.ColVindex.Expression != nil || !column.Name.Equal(v.ColVindex.Columns[0]) {
+			continue
+		}
+		if vindexes.MapsRangeOfBytes(rm) && !hasBinaryCollation(ctx, column) {
+			// the range of bytes would miss the values the column's collation considers in the range
+			continue
+		}
+
+		// we replace the range option we already have instead of modifying it, since it can be shared with clones.
+		// A missing bound is NULL.
+		null := makeEvalEngineExpr(ctx, &sqlparser.NullVal{})
+		option := &VindexOption{
+			Values:      []evalengine.Expr{null, null},
+			ValueExprs:  []sqlparser.Expr{&sqlparser.NullVal{}, &sqlparser.NullVal{}},
+			OpCode:      engine.KeyRange,
+			FoundVindex: v.ColVindex.Vindex,
+			Cost:        costFor(v.ColVindex, engine.KeyRange),
+			Ready:       true,
+		}
+		options := v.Options
+		if i := slices.IndexFunc(v.Options, func(o *VindexOption) bool { return o.OpCode == engine.KeyRange }); i >= 0 {
+			prev := v.Options[i]
+			copy(option.Values, prev.Values)
+			copy(option.ValueExprs, prev.ValueExprs)
+			option.Predicates = slices.Clone(prev.Predicates)
+			options = slices.Delete(slices.Clone(v.Options), i, i+1)
+		}
+
+		updated := false
+		for side, bound := range []sqlparser.Expr{from, to} {
+			if _, missing := option.ValueExprs[side].(*sqlparser.NullVal); bound == nil || !missing {
+				continue
+			}
+			value := makeEvalEngineExpr(ctx, bound)
+			if value == nil {
+				continue
+			}
+			option.Values[side] = value
+			option.ValueExprs[side] = bound
+			updated = true
+		}
+		if !updated {
+			continue
+		}
+
+		option.Predicates = append(option.Predicates, node)
+		v.Options = append(options, option)
+		newVindexFound = true
+	}
+	return newVindexFound
+}
+
 func (tr *ShardedRouting) planIsExpr(ctx *plancontext.PlanningContext, node *sqlparser.IsExpr) bool {
 	// we only handle IS NULL correct. IsExpr can contain other expressions as well
 	if node.Right != sqlparser.IsNullOp {
@@ -297,6 +397,9 @@ func (tr *ShardedRouting) planInOp(ctx *plancontext.PlanningContext, cmp *sqlpar
 		case sqlparser.ListArg:
 			return tr.planCompositeInOpArg(ctx, cmp, left, right)
 		}
+	default:
+		opcode := func(*vindexes.ColumnVindex) engine.Opcode { return engine.IN }
+		return tr.planExpressionOp(ctx, cmp, opcode)
 	}
 	return false
 }
@@ -334,6 +437,8 @@ func (tr *ShardedRouting) Cost() int {
 		return 10
 	case engine.MultiEqual:
 		return 10
+	case engine.KeyRange:
+		return 15
 	case engine.Scatter:
 		return 20
 	default:
@@ -399,14 +504,26 @@ func (tr *ShardedRouting) processSingleColumnVindex(
 	newVindexFound bool,
 ) bool {
 	col := vindexPlusPredicates.ColVindex.Columns[0]
-	if !column.Name.Equal(col) {
+	if vindexPlusPredicates.ColVindex.Expression != nil || !column.Name.Equal(col) {
 		return newVindexFound
 	}
+	return tr.addSingleValueOption(node, valueExpr, value, opcode, vfunc, vindexPlusPredicates) || newVindexFound
+}
 
+// addSingleValueOption adds the option of routing with the value of the only column
+// or the expression of a vindex.
+func (tr *ShardedRouting) addSingleValueOption(
+	node sqlparser.Expr,
+	valueExpr sqlparser.Expr,
+	value evalengine.Expr,
+	opcode func(*vindexes.ColumnVindex) engine.Opcode,
+	vfunc func(*vindexes.ColumnVindex) vindexes.Vindex,
+	vindexPlusPredicates *VindexPlusPredicates,
+) bool {
 	routeOpcode := opcode(vindexPlusPredicates.ColVindex)
 	vindex := vfunc(vindexPlusPredicates.ColVindex)
 	if vindex == nil || routeOpcode == engine.Scatter {
-		return newVindexFound
+		return false
 	}
 
 	vo := &VindexOption{
@@ -490,8 +607,9 @@ func (tr *ShardedRouting) planEqualOp(ctx *plancontext.PlanningContext, node *sq
 	if !ok {
 		column, ok = node.Right.(*sqlparser.ColName)
 		if !ok {
-			// either the LHS or RHS have to be a column to be useful for the vindex
-			return false
+			// either the LHS or RHS have to be a column to be useful for the vindex,
+			// or an expression that a vindex is declared over
+			return tr.planExpressionOp(ctx, node, equalOrEqualUnique)
 		}
 		vdValue = node.Left
 	}
@@ -503,6 +621,39 @@ func (tr *ShardedRouting) planEqualOp(ctx *plancontext.PlanningContext, node *sq
 	return tr.haveMatchingVindex(ctx, node, vdValue, column, val, equalOrEqualUnique, justTheVindex)
 }
 
+// planExpressionOp plans a comparison between a value and an expression that vindexes
+// are declared over, like `json_unquote(doc->'$.tenant') = 'acme'`.
+func (tr *ShardedRouting) planExpressionOp(
+	ctx *plancontext.PlanningContext,
+	cmp *sqlparser.ComparisonExpr,
+	opcode func(*vindexes.ColumnVindex) engine.Opcode,
+) bool {
+	newVindexFound := false
+	for _, v := range tr.VindexPreds {
+		expr, vdValue := cmp.Left, cmp.Right
+		if !v.ColVindex.MatchesExpression(expr) {
+			if cmp.Operator != sqlparser.EqualOp {
+				continue
+			}
+			expr, vdValue = cmp.Right, cmp.Left
+			if !v.ColVindex.MatchesExpression(expr) {
+				continue
+			}
+		}
+		if !ctx.SemTable.DirectDeps(expr).IsSolvedBy(v.TableID) {
+			continue
+		}
+		value := makeEvalEngineExpr(ctx, vdValue)
+		if value == nil {
+			continue
+		}
+		if tr.addSingleValueOption(cmp, vdValue, value, opcode, justTheVindex, v) {
+			newVindexFound = true
+		}
+	}
+	return newVindexFound
+}
+
 func (tr *ShardedRouting) planCompositeInOpRecursive(
 	ctx *plancontext.PlanningContext,
 	cmp *sqlparser.ComparisonExpr,
@@ -586,6 +737,9 @@ func (tr *ShardedRouting) planCompositeInOpArg(
 
 func (tr *ShardedRouting) hasVindex(column *sqlparser.ColName) bool {
 	for _, v := range tr.VindexPreds {
+		if v.ColVindex.Expression != nil {
+			continue
+		}
 		for _, col := range v.ColVindex.Columns {
 			if column.Name.Equal(col) {
 				return true
@@ -662,7 +816,7 @@ func tryMergeShardedRouting(
 		// can merge via join predicates instead.
 		fallthrough
 
-	case engine.Scatter, engine.IN, engine.None:
+	case engine.Scatter, engine.IN, engine.KeyRange, engine.None:
 		if len(joinPredicates) == 0 {
 			// If we are doing two Scatters, we have to make sure that the
 			// joins are on the correct vindex to allow them to be merged
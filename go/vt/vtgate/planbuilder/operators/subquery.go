@@ -18,13 +18,17 @@ package operators
 
 import (
 	"fmt"
+	"io"
 	"maps"
 	"slices"
+	"strings"
 
 	"vitess.io/vitess/go/slice"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
@@ -52,9 +56,17 @@ type SubQuery struct {
 	// correlated stores whether this subquery is correlated or not.
 	// We use this information to fail the planning if we are unable to merge the subquery with a route.
 	correlated bool
+	// outerOutsidePredicates stores whether the subquery uses columns from the outer query
+	// in places other than the predicates that were extracted into Predicates.
+	outerOutsidePredicates bool
 
 	// IsArgument is set to true if the subquery puts the
 	IsArgument bool
+
+	// Fields related to subqueries that are evaluated once per row of the outer query:
+	PerRow               bool            // set when the correlated subquery could not be turned into a semi-join
+	CorrelatedPredicate  sqlparser.Expr  // predicate using the subquery result. Nil when the result is projected as a column
+	PredicateWithOffsets evalengine.Expr // the predicate, translated to use offsets into the outer rows
 }
 
 func (sq *SubQuery) planOffsets(ctx *plancontext.PlanningContext) Operator {
@@ -69,6 +81,24 @@ func (sq *SubQuery) planOffsets(ctx *plancontext.PlanningContext) Operator {
 			sq.Vars[lhsExpr.Name] = offset
 		}
 	}
+	if sq.CorrelatedPredicate == nil {
+		return nil
+	}
+
+	cfg := &evalengine.Config{
+		ResolveType: ctx.TypeForExpr,
+		Collation:   ctx.SemTable.Collation,
+		Environment: ctx.VSchema.Environment(),
+	}
+	rewritten := useOffsets(ctx, sq.CorrelatedPredicate, sq)
+	eexpr, err := evalengine.Translate(rewritten, cfg)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), evalengine.ErrTranslateExprNotSupported) {
+			panic(vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "%s: %s", evalengine.ErrTranslateExprNotSupported, sqlparser.String(sq.CorrelatedPredicate)))
+		}
+		panic(err)
+	}
+	sq.PredicateWithOffsets = eexpr
 	return nil
 }
 
@@ -172,11 +202,23 @@ func (sq *SubQuery) ShortDescription() string {
 }
 
 func (sq *SubQuery) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	if sq.projectsValue() && sq.usesValue(expr) {
+		return newFilter(sq, expr)
+	}
 	sq.Outer = sq.Outer.AddPredicate(ctx, expr)
 	return sq
 }
 
 func (sq *SubQuery) AddColumn(ctx *plancontext.PlanningContext, reuseExisting bool, addToGroupBy bool, ae *sqlparser.AliasedExpr) int {
+	if sq.projectsValue() {
+		if sq.isValue(ae.Expr) {
+			return 0
+		}
+		if sq.usesValue(ae.Expr) {
+			panic(vterrors.VT12001(fmt.Sprintf("correlated subquery in expression: %s", sqlparser.String(ae.Expr))))
+		}
+		return 1 + sq.Outer.AddColumn(ctx, reuseExisting, addToGroupBy, ae)
+	}
 	ae = sqlparser.Clone(ae)
 	// we need to rewrite the column name to an argument if it's the same as the subquery column name
 	ae.Expr = rewriteColNameToArgument(ctx, ae.Expr, []*SubQuery{sq}, sq)
@@ -184,21 +226,91 @@ func (sq *SubQuery) AddColumn(ctx *plancontext.PlanningContext, reuseExisting bo
 }
 
 func (sq *SubQuery) AddWSColumn(ctx *plancontext.PlanningContext, offset int, underRoute bool) int {
+	if sq.projectsValue() {
+		if offset == 0 {
+			panic(vterrors.VT12001("weight_string of correlated subquery"))
+		}
+		return 1 + sq.Outer.AddWSColumn(ctx, offset-1, underRoute)
+	}
 	return sq.Outer.AddWSColumn(ctx, offset, underRoute)
 }
 
 func (sq *SubQuery) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, underRoute bool) int {
-	return sq.Outer.FindCol(ctx, expr, underRoute)
+	if !sq.projectsValue() {
+		return sq.Outer.FindCol(ctx, expr, underRoute)
+	}
+	if sq.isValue(expr) {
+		return 0
+	}
+	offset := sq.Outer.FindCol(ctx, expr, underRoute)
+	if offset < 0 {
+		return offset
+	}
+	return 1 + offset
 }
 
 func (sq *SubQuery) GetColumns(ctx *plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	if sq.projectsValue() {
+		return append([]*sqlparser.AliasedExpr{aeWrap(sqlparser.NewColName(sq.ArgName))}, sq.Outer.GetColumns(ctx)...)
+	}
 	return sq.Outer.GetColumns(ctx)
 }
 
 func (sq *SubQuery) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	if sq.projectsValue() {
+		return transformColumnsToSelectExprs(ctx, sq)
+	}
 	return sq.Outer.GetSelectExprs(ctx)
 }
 
+// projectsValue returns true when the subquery is evaluated per outer row
+// and its result is returned as the first column of this operator
+func (sq *SubQuery) projectsValue() bool {
+	return sq.PerRow && sq.CorrelatedPredicate == nil
+}
+
+// isPerRowSubqueryValue returns true if the column at the offset is the result of a subquery evaluated per row.
+// These values are produced by the vtgate, so anything using them, like their weight_string, has to be evaluated there too.
+func isPerRowSubqueryValue(op Operator, offset int) bool {
+	for {
+		sq, ok := op.(*SubQuery)
+		if !ok {
+			return false
+		}
+		if sq.projectsValue() {
+			if offset == 0 {
+				return true
+			}
+			offset--
+		}
+		op = sq.Outer
+	}
+}
+
+// isValue returns true if the expression is the column representing the subquery result
+func (sq *SubQuery) isValue(expr sqlparser.Expr) bool {
+	switch expr := expr.(type) {
+	case *sqlparser.ColName:
+		return expr.Qualifier.IsEmpty() && expr.Name.String() == sq.ArgName
+	case *sqlparser.Argument:
+		return expr.Name == sq.ArgName
+	}
+	return false
+}
+
+// usesValue returns true if the expression references the subquery result anywhere
+func (sq *SubQuery) usesValue(expr sqlparser.Expr) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if e, ok := node.(sqlparser.Expr); ok && sq.isValue(e) {
+			found = true
+			return false, io.EOF
+		}
+		return true, nil
+	}, expr)
+	return found
+}
+
 // GetMergePredicates returns the predicates that we can use to try to merge this subquery with the outer query.
 func (sq *SubQuery) GetMergePredicates() []sqlparser.Expr {
 	if sq.OuterPredicate != nil {
@@ -210,84 +322,74 @@ func (sq *SubQuery) GetMergePredicates() []sqlparser.Expr {
 func (sq *SubQuery) settle(ctx *plancontext.PlanningContext, outer Operator) Operator {
 	// We can allow uncorrelated queries even when subquery isn't the top level construct,
 	// like if its underneath an Aggregator, because they will be pulled out and run separately.
-	if !sq.TopLevel && sq.correlated {
-		panic(subqueryNotAtTopErr)
-	}
-	if sq.correlated && sq.FilterType != opcode.PulloutExists {
-		panic(correlatedSubqueryErr)
+	// Correlated subqueries that can't be turned into a semi-join are evaluated once per outer row.
+	if sq.correlated && (sq.IsArgument || !sq.TopLevel || sq.FilterType != opcode.PulloutExists) {
+		return sq.settleCorrelated(ctx, outer)
 	}
 	if sq.IsArgument {
-		if len(sq.GetMergePredicates()) > 0 {
-			// this means that we have a correlated subquery on our hands
-			panic(correlatedSubqueryErr)
-		}
 		sq.SubqueryValueName = sq.ArgName
 		return outer
 	}
 	return sq.settleFilter(ctx, outer)
 }
 
-var correlatedSubqueryErr = vterrors.VT12001("correlated subquery is only supported for EXISTS")
-var subqueryNotAtTopErr = vterrors.VT12001("unmergable subquery can not be inside complex expression")
+var correlatedOuterColumnsErr = vterrors.VT12001("correlated subquery using outer columns outside of its predicates")
 
 func (sq *SubQuery) addLimit() {
 	// for a correlated subquery, we can add a limit 1 to the subquery
 	sq.Subquery = newLimit(sq.Subquery, &sqlparser.Limit{Rowcount: sqlparser.NewIntLiteral("1")}, true)
 }
 
-func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operator) Operator {
-	if len(sq.Predicates) > 0 {
-		if sq.FilterType != opcode.PulloutExists {
-			panic(correlatedSubqueryErr)
+// settleCorrelated plans a correlated subquery that will be executed once for every
+// distinct combination of the outer values it uses. The subquery result is either used
+// to evaluate the predicate on vtgate, or returned as a column for the operators above.
+func (sq *SubQuery) settleCorrelated(ctx *plancontext.PlanningContext, outer Operator) Operator {
+	if sq.outerOutsidePredicates {
+		panic(correlatedOuterColumnsErr)
+	}
+	sq.PerRow = true
+
+	if sq.IsArgument {
+		switch sq.FilterType {
+		case opcode.PulloutIn, opcode.PulloutNotIn:
+			panic(vterrors.VT12001("correlated IN subquery outside of a predicate"))
+		case opcode.PulloutExists:
+			sq.addLimit()
+		default:
+			sq.SubqueryValueName = sq.ArgName
 		}
-		sq.addLimit()
 		return outer
 	}
 
-	hasValuesArg := func() string {
-		s := ctx.ReservedVars.ReserveVariable(string(sqlparser.HasValueSubQueryBaseName))
-		sq.HasValuesName = s
-		return s
+	sq.CorrelatedPredicate = sq.rewriteOriginal(ctx)
+	switch sq.FilterType {
+	case opcode.PulloutExists, opcode.PulloutNotExists:
+		sq.addLimit()
+		sq.FilterType = opcode.PulloutExists // the NOT, if any, is part of the predicate
+	default:
+		sq.SubqueryValueName = sq.ArgName
 	}
-	post := func(cursor *sqlparser.CopyOnWriteCursor) {
-		node := cursor.Node()
-		// For IN and NOT IN type filters, we have to add a Expression that checks if we got any rows back or not
-		// for correctness. That expression should be ANDed with the expression that has the IN/NOT IN comparison.
-		if compExpr, isCompExpr := node.(*sqlparser.ComparisonExpr); sq.FilterType.NeedsListArg() && isCompExpr {
-			if listArg, isListArg := compExpr.Right.(sqlparser.ListArg); isListArg && listArg.String() == sq.ArgName {
-				if sq.FilterType == opcode.PulloutIn {
-					cursor.Replace(sqlparser.AndExpressions(sqlparser.NewArgument(hasValuesArg()), compExpr))
-				} else {
-					cursor.Replace(&sqlparser.OrExpr{
-						Left:  sqlparser.NewNotExpr(sqlparser.NewArgument(hasValuesArg())),
-						Right: compExpr,
-					})
-				}
-			}
-		}
-		if _, ok := node.(*sqlparser.Subquery); !ok {
-			return
-		}
+	return outer
+}
 
-		var arg sqlparser.Expr
-		if sq.FilterType.NeedsListArg() {
-			arg = sqlparser.NewListArg(sq.ArgName)
-		} else {
-			arg = sqlparser.NewArgument(sq.ArgName)
-		}
-		cursor.Replace(arg)
+func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operator) Operator {
+	if len(sq.Predicates) > 0 {
+		// only top level EXISTS subqueries end up here, and they are planned as semi-joins
+		sq.addLimit()
+		return outer
 	}
-	rhsPred := sqlparser.CopyOnRewrite(sq.Original, dontEnterSubqueries, post, ctx.SemTable.CopySemanticInfo).(sqlparser.Expr)
+
+	rhsPred := sq.rewriteOriginal(ctx)
 
 	var predicates []sqlparser.Expr
 	switch sq.FilterType {
 	case opcode.PulloutExists:
 		sq.addLimit()
-		predicates = append(predicates, sqlparser.NewArgument(hasValuesArg()))
+		predicates = append(predicates, sqlparser.NewArgument(sq.hasValuesArg(ctx)))
 	case opcode.PulloutNotExists:
 		sq.addLimit()
 		sq.FilterType = opcode.PulloutExists // it's the same pullout as EXISTS, just with a NOT in front of the predicate
-		predicates = append(predicates, sqlparser.NewNotExpr(sqlparser.NewArgument(hasValuesArg())))
+		predicates = append(predicates, sqlparser.NewNotExpr(sqlparser.NewArgument(sq.hasValuesArg(ctx))))
 	case opcode.PulloutIn:
 		// Because we replace the comparison expression with an AND expression, it might be the top level construct there.
 		// In this case, it is better to send the two sides of the AND expression separately in the predicates because it can
@@ -309,6 +411,56 @@ func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operato
 	return newFilter(outer, predicates...)
 }
 
+func (sq *SubQuery) hasValuesArg(ctx *plancontext.PlanningContext) string {
+	if sq.HasValuesName == "" {
+		sq.HasValuesName = ctx.ReservedVars.ReserveVariable(string(sqlparser.HasValueSubQueryBaseName))
+	}
+	return sq.HasValuesName
+}
+
+// rewriteOriginal returns the original expression with the subquery replaced by the arguments
+// that will hold the subquery result
+func (sq *SubQuery) rewriteOriginal(ctx *plancontext.PlanningContext) sqlparser.Expr {
+	// correlated EXISTS subqueries are not turned into semi-joins when they are part of a larger expression
+	replaceExists := sq.correlated && (sq.FilterType == opcode.PulloutExists || sq.FilterType == opcode.PulloutNotExists)
+	post := func(cursor *sqlparser.CopyOnWriteCursor) {
+		node := cursor.Node()
+		// For IN and NOT IN type filters, we have to add a Expression that checks if we got any rows back or not
+		// for correctness. That expression should be ANDed with the expression that has the IN/NOT IN comparison.
+		if compExpr, isCompExpr := node.(*sqlparser.ComparisonExpr); sq.FilterType.NeedsListArg() && isCompExpr {
+			if listArg, isListArg := compExpr.Right.(sqlparser.ListArg); isListArg && listArg.String() == sq.ArgName {
+				if sq.FilterType == opcode.PulloutIn {
+					cursor.Replace(sqlparser.AndExpressions(sqlparser.NewArgument(sq.hasValuesArg(ctx)), compExpr))
+				} else {
+					cursor.Replace(&sqlparser.OrExpr{
+						Left:  sqlparser.NewNotExpr(sqlparser.NewArgument(sq.hasValuesArg(ctx))),
+						Right: compExpr,
+					})
+				}
+			}
+		}
+		if _, ok := node.(*sqlparser.ExistsExpr); ok && replaceExists {
+			cursor.Replace(sqlparser.NewArgument(sq.hasValuesArg(ctx)))
+			return
+		}
+		if _, ok := node.(*sqlparser.Subquery); !ok {
+			return
+		}
+		if _, ok := cursor.Parent().(*sqlparser.ExistsExpr); ok && replaceExists {
+			return
+		}
+
+		var arg sqlparser.Expr
+		if sq.FilterType.NeedsListArg() {
+			arg = sqlparser.NewListArg(sq.ArgName)
+		} else {
+			arg = sqlparser.NewArgument(sq.ArgName)
+		}
+		cursor.Replace(arg)
+	}
+	return sqlparser.CopyOnRewrite(sq.Original, dontEnterSubqueries, post, ctx.SemTable.CopySemanticInfo).(sqlparser.Expr)
+}
+
 func dontEnterSubqueries(node, _ sqlparser.SQLNode) bool {
 	if _, ok := node.(*sqlparser.Subquery); ok {
 		return false
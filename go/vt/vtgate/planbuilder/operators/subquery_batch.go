@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"slices"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// CorrelatedBatch describes how a subquery that is evaluated per outer row can be
+// evaluated for many combinations of outer values using a single query.
+type CorrelatedBatch struct {
+	// Statement returns the inner side of the correlation predicates as its first columns,
+	// followed by the columns of the subquery. The outer values are sent in the Arg list argument.
+	Statement *sqlparser.Select
+	Arg       string
+
+	// Vars are the outer bind variables, in the order of the leading columns of Statement,
+	// and Types are the types used to compare them with these columns.
+	Vars  []string
+	Types []evalengine.Type
+
+	// Fallback is set when the subquery returns a row even if no inner row matches the outer values,
+	// like an aggregation without GROUP BY. Such outer values have to be evaluated one at a time.
+	Fallback bool
+}
+
+// Batch returns how to evaluate the subquery for many outer values at once. This is only possible
+// when all the correlation predicates are equality comparisons in the WHERE clause, and the rows
+// of the subquery can be told apart by the inner side of these comparisons.
+// It returns nil when the subquery has to be evaluated one combination of outer values at a time.
+func (sq *SubQuery) Batch(ctx *plancontext.PlanningContext) *CorrelatedBatch {
+	sel, ok := sq.originalSubquery.Select.(*sqlparser.Select)
+	if !ok || sel.Where == nil || sel.Limit != nil || sel.Into != nil || len(sel.Windows) > 0 ||
+		sqlparser.ContainsWindowFunction(sel.SelectExprs) {
+		return nil
+	}
+	if sel.GroupBy != nil {
+		if sel.GroupBy.WithRollup {
+			return nil
+		}
+		for _, expr := range sel.GroupBy.Exprs {
+			if _, isLiteral := expr.(*sqlparser.Literal); isLiteral {
+				// the columns are shifted by the ones we add, so positional grouping breaks
+				return nil
+			}
+		}
+	}
+
+	subqID := TableID(sq.Subquery)
+	batch := &CorrelatedBatch{}
+	var remaining, inner []sqlparser.Expr
+	for _, pred := range sqlparser.SplitAndExpression(nil, sel.Where.Expr) {
+		if ctx.SemTable.RecursiveDeps(pred).IsSolvedBy(subqID) {
+			remaining = append(remaining, pred)
+			continue
+		}
+		cmp, ok := pred.(*sqlparser.ComparisonExpr)
+		if !ok || cmp.Operator != sqlparser.EqualOp {
+			return nil
+		}
+		innerExpr, outerExpr := cmp.Left, cmp.Right
+		if !ctx.SemTable.RecursiveDeps(innerExpr).IsSolvedBy(subqID) {
+			innerExpr, outerExpr = outerExpr, innerExpr
+		}
+		if !ctx.SemTable.RecursiveDeps(innerExpr).IsSolvedBy(subqID) ||
+			ctx.SemTable.RecursiveDeps(outerExpr).IsOverlapping(subqID) {
+			return nil
+		}
+		name := sq.varFor(ctx, outerExpr)
+		if name == "" {
+			return nil
+		}
+		innerType, found := ctx.TypeForExpr(innerExpr)
+		if !found {
+			return nil
+		}
+		outerType, found := ctx.TypeForExpr(outerExpr)
+		if !found {
+			return nil
+		}
+		typ, err := evalengine.CoerceTypes(innerType, outerType, ctx.VSchema.Environment().CollationEnv())
+		if err != nil {
+			return nil
+		}
+		inner = append(inner, innerExpr)
+		batch.Vars = append(batch.Vars, name)
+		batch.Types = append(batch.Types, typ)
+	}
+	if len(inner) == 0 {
+		return nil
+	}
+	for name := range sq.Vars {
+		if !slices.Contains(batch.Vars, name) {
+			// the outer value is used outside the WHERE clause
+			return nil
+		}
+	}
+
+	aggregated := sel.GroupBy != nil || sqlparser.ContainsAggregation(sel.SelectExprs) ||
+		(sel.Having != nil && sqlparser.ContainsAggregation(sel.Having))
+	stmt := sqlparser.Clone(sel)
+	stmt.OrderBy = nil
+	stmt.Where = nil
+	if len(remaining) > 0 {
+		stmt.AddWhere(sqlparser.Clone(sqlparser.AndExpressions(remaining...)))
+	}
+	batch.Arg = ctx.ReservedVars.ReserveVariable("sq_batch")
+	in := &sqlparser.ComparisonExpr{Operator: sqlparser.InOp, Right: sqlparser.NewListArg(batch.Arg)}
+	var keys sqlparser.SelectExprs
+	for _, expr := range inner {
+		keys = append(keys, &sqlparser.AliasedExpr{Expr: sqlparser.Clone(expr)})
+	}
+	if len(inner) == 1 {
+		in.Left = sqlparser.Clone(inner[0])
+	} else {
+		in.Left = sqlparser.Clone(sqlparser.ValTuple(inner))
+	}
+	stmt.AddWhere(in)
+
+	switch {
+	case sq.FilterType == opcode.PulloutExists && !aggregated:
+		// only the existence of rows matters, so the keys are all we need
+		stmt.SelectExprs = keys
+		stmt.Distinct = true
+	default:
+		for _, expr := range stmt.SelectExprs {
+			if _, isStar := expr.(*sqlparser.StarExpr); isStar {
+				return nil
+			}
+		}
+		stmt.SelectExprs = append(keys, stmt.SelectExprs...)
+	}
+	if aggregated {
+		batch.Fallback = sel.GroupBy == nil
+		for _, expr := range inner {
+			stmt.AddGroupBy(sqlparser.Clone(expr))
+		}
+	}
+	batch.Statement = stmt
+	return batch
+}
+
+// varFor returns the name of the bind variable the outer expression is sent to the subquery in
+func (sq *SubQuery) varFor(ctx *plancontext.PlanningContext, expr sqlparser.Expr) string {
+	if _, isCol := expr.(*sqlparser.ColName); !isCol {
+		return ""
+	}
+	for _, jc := range sq.JoinColumns {
+		for _, lhs := range jc.LHSExprs {
+			if _, found := sq.Vars[lhs.Name]; found && ctx.SemTable.EqualsExprWithDeps(lhs.Expr, expr) {
+				return lhs.Name
+			}
+		}
+	}
+	return ""
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package operators
 
 import (
+	"io"
+	"slices"
+
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
@@ -64,6 +67,37 @@ func (sqb *SubQueryBuilder) handleSubquery(
 	return sqInner
 }
 
+// pullOutPredicateSubqueries handles predicates using more than one subquery, like the ones ANY/ALL comparisons
+// are rewritten to. When all the subqueries are uncorrelated and return a single value, they are pulled out
+// as arguments, and the rewritten predicate is returned so it can be used like any other predicate.
+// Otherwise, nil is returned and the predicate is left to handleSubquery.
+func (sqb *SubQueryBuilder) pullOutPredicateSubqueries(
+	ctx *plancontext.PlanningContext,
+	expr sqlparser.Expr,
+	outerID semantics.TableSet,
+) sqlparser.Expr {
+	count := 0
+	values := true
+	_ = sqlparser.Rewrite(expr, func(cursor *sqlparser.Cursor) bool {
+		subq, ok := cursor.Node().(*sqlparser.Subquery)
+		if !ok {
+			return true
+		}
+		count++
+		code := getOpCodeFromParent(cursor.Parent())
+		if code == nil || *code != opcode.PulloutValue || !ctx.SemTable.RecursiveDeps(subq).IsEmpty() {
+			values = false
+		}
+		return false
+	}, nil)
+	if count < 2 || !values {
+		return nil
+	}
+
+	newExpr, _ := sqb.pullOutValueSubqueries(ctx, expr, outerID, true)
+	return newExpr
+}
+
 func getSubQuery(expr sqlparser.Expr) (subqueryExprExists *sqlparser.Subquery, parentExpr sqlparser.Expr) {
 	flipped := false
 	_ = sqlparser.Rewrite(expr, func(cursor *sqlparser.Cursor) bool {
@@ -165,11 +199,15 @@ func createSubquery(
 	original = cloneASTAndSemState(ctx, original)
 	originalSq := cloneASTAndSemState(ctx, subq)
 	subqID := findTablesContained(ctx, subq.Select)
+	// the tables of a nested subquery are part of the outer tables we were given,
+	// but they are not outer tables from the point of view of the nested subquery
+	outerID = outerID.Remove(subqID)
 	totalID := subqID.Merge(outerID)
 	sqc := &SubQueryBuilder{totalID: totalID, subqID: subqID, outerID: outerID}
 
 	predicates, joinCols := sqc.inspectStatement(ctx, subq.Select)
 	correlated := !ctx.SemTable.RecursiveDeps(subq).IsEmpty()
+	outerOutsidePredicates := correlated && usesOuterOutsidePredicates(ctx, subq.Select, predicates, subqID)
 
 	opInner := translateQueryToOp(ctx, subq.Select)
 
@@ -185,7 +223,41 @@ func createSubquery(
 		TopLevel:         topLevel,
 		JoinColumns:      joinCols,
 		correlated:       correlated,
+
+		outerOutsidePredicates: outerOutsidePredicates,
+	}
+}
+
+// usesOuterOutsidePredicates returns true if the subquery uses columns from the outer query in any other
+// way than through the extracted predicates, which can be evaluated using bind variables.
+// Aggregations over outer columns belong to the outer query, so they can't be sent as bind variables either.
+func usesOuterOutsidePredicates(ctx *plancontext.PlanningContext, stmt sqlparser.SelectStatement, predicates sqlparser.Exprs, subqID semantics.TableSet) bool {
+	found := false
+	visit := func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ColName:
+			found = !ctx.SemTable.RecursiveDeps(node).IsSolvedBy(subqID)
+		case sqlparser.AggrFunc:
+			found = !ctx.SemTable.RecursiveDeps(node).IsSolvedBy(subqID)
+		}
+		if found {
+			return false, io.EOF
+		}
+		return true, nil
+	}
+	_ = sqlparser.Walk(visit, stmt)
+	for _, pred := range predicates {
+		if found {
+			break
+		}
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if _, isAggr := node.(sqlparser.AggrFunc); !isAggr {
+				return true, nil
+			}
+			return visit(node)
+		}, pred)
 	}
+	return found
 }
 
 func (sqb *SubQueryBuilder) inspectWhere(
@@ -202,8 +274,9 @@ func (sqb *SubQueryBuilder) inspectWhere(
 	}
 	for _, predicate := range sqlparser.SplitAndExpression(nil, in.Expr) {
 		sqlparser.RemoveKeyspaceInCol(predicate)
-		subq := sqb.handleSubquery(ctx, predicate, sqb.totalID)
-		if subq != nil {
+		if newPred := sqb.pullOutPredicateSubqueries(ctx, predicate, sqb.totalID); newPred != nil {
+			predicate = newPred
+		} else if subq := sqb.handleSubquery(ctx, predicate, sqb.totalID); subq != nil {
 			continue
 		}
 		jpc.inspectPredicate(ctx, predicate)
@@ -236,8 +309,9 @@ func (sqb *SubQueryBuilder) inspectOnExpr(
 			}
 
 			for _, pred := range sqlparser.SplitAndExpression(nil, cond.On) {
-				subq := sqb.handleSubquery(ctx, pred, sqb.totalID)
-				if subq != nil {
+				if newPred := sqb.pullOutPredicateSubqueries(ctx, pred, sqb.totalID); newPred != nil {
+					pred = newPred
+				} else if subq := sqb.handleSubquery(ctx, pred, sqb.totalID); subq != nil {
 					continue
 				}
 				jpc.inspectPredicate(ctx, pred)
@@ -340,8 +414,19 @@ func getOpCodeFromParent(parent sqlparser.SQLNode) *opcode.PulloutOpcode {
 func extractSubQueries(ctx *plancontext.PlanningContext, expr sqlparser.Expr, isDML bool) *subqueryExtraction {
 	sqe := &subqueryExtraction{}
 	replaceWithArg := func(cursor *sqlparser.Cursor, sq *sqlparser.Subquery, t opcode.PulloutOpcode) {
-		sqName := ctx.GetReservedArgumentFor(sq)
-		sqe.cols = append(sqe.cols, sqName)
+		// the same uncorrelated subquery used more than once only has to be evaluated once
+		idx := slices.IndexFunc(sqe.subq, func(other *sqlparser.Subquery) bool {
+			return sqlparser.Equals.RefOfSubquery(sq, other) && ctx.SemTable.RecursiveDeps(sq).IsEmpty()
+		})
+		var sqName string
+		if idx >= 0 && sqe.pullOutCode[idx] == t {
+			sqName = sqe.cols[idx]
+		} else {
+			sqName = ctx.GetReservedArgumentFor(sq)
+			sqe.cols = append(sqe.cols, sqName)
+			sqe.subq = append(sqe.subq, sq)
+			sqe.pullOutCode = append(sqe.pullOutCode, t)
+		}
 		if isDML {
 			if t.NeedsListArg() {
 				cursor.Replace(sqlparser.NewListArg(sqName))
@@ -351,7 +436,6 @@ func extractSubQueries(ctx *plancontext.PlanningContext, expr sqlparser.Expr, is
 		} else {
 			cursor.Replace(sqlparser.NewColName(sqName))
 		}
-		sqe.subq = append(sqe.subq, sq)
 	}
 
 	expr = sqlparser.Rewrite(expr, nil, func(cursor *sqlparser.Cursor) bool {
@@ -362,10 +446,8 @@ func extractSubQueries(ctx *plancontext.PlanningContext, expr sqlparser.Expr, is
 				return true
 			}
 			replaceWithArg(cursor, node, *t)
-			sqe.pullOutCode = append(sqe.pullOutCode, *t)
 		case *sqlparser.ExistsExpr:
 			replaceWithArg(cursor, node.Subquery, opcode.PulloutExists)
-			sqe.pullOutCode = append(sqe.pullOutCode, opcode.PulloutExists)
 		}
 		return true
 	}).(sqlparser.Expr)
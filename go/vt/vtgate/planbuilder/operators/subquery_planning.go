@@ -90,6 +90,8 @@ func settleSubqueries(ctx *plancontext.PlanningContext, op Operator) Operator {
 
 			for _, pe := range ap {
 				mergeSubqueryExpr(ctx, pe)
+				// columns passed through from other operators can use the arguments of merged subqueries as well
+				pe.EvalExpr = replaceMergedSubqueryArguments(ctx, pe.EvalExpr)
 			}
 		case *Update:
 			for _, setExpr := range op.Assignments {
@@ -105,6 +107,12 @@ func settleSubqueries(ctx *plancontext.PlanningContext, op Operator) Operator {
 			}
 		case *Ordering:
 			op.settleOrderingExpressions(ctx)
+		case *Filter:
+			op.settleFilterExpressions(ctx)
+		case *Table:
+			for idx, pred := range op.QTable.Predicates {
+				op.QTable.Predicates[idx] = replaceMergedSubqueryArguments(ctx, pred)
+			}
 		}
 		return op, NoRewrite
 	}
@@ -114,25 +122,79 @@ func settleSubqueries(ctx *plancontext.PlanningContext, op Operator) Operator {
 
 func (o *Ordering) settleOrderingExpressions(ctx *plancontext.PlanningContext) {
 	for idx, order := range o.Order {
-		for _, sq := range ctx.MergedSubqueries {
-			arg := ctx.GetReservedArgumentFor(sq)
-			expr := sqlparser.Rewrite(order.SimplifiedExpr, nil, func(cursor *sqlparser.Cursor) bool {
-				switch expr := cursor.Node().(type) {
-				case *sqlparser.ColName:
-					if expr.Name.String() == arg {
-						cursor.Replace(sq)
-					}
-				case *sqlparser.Argument:
-					if expr.Name == arg {
-						cursor.Replace(sq)
-					}
+		o.Order[idx].SimplifiedExpr = replaceMergedSubqueryArguments(ctx, order.SimplifiedExpr)
+	}
+}
+
+// settleFilterExpressions puts back the subqueries that were pulled out of the predicates
+// as arguments, and later merged into the same route as the filter.
+// The predicates pushed to a Table are settled the same way.
+func (f *Filter) settleFilterExpressions(ctx *plancontext.PlanningContext) {
+	for idx, pred := range f.Predicates {
+		f.Predicates[idx] = replaceMergedSubqueryArguments(ctx, pred)
+	}
+}
+
+func replaceMergedSubqueryArguments(ctx *plancontext.PlanningContext, expr sqlparser.Expr) sqlparser.Expr {
+	for _, sq := range ctx.MergedSubqueries {
+		arg := ctx.GetReservedArgumentFor(sq)
+		expr = sqlparser.Rewrite(expr, nil, func(cursor *sqlparser.Cursor) bool {
+			switch expr := cursor.Node().(type) {
+			case *sqlparser.ColName:
+				if expr.Name.String() == arg {
+					cursor.Replace(sq)
+				}
+			case *sqlparser.Argument:
+				if expr.Name == arg {
+					cursor.Replace(sq)
 				}
+			}
 
-				return true
-			})
-			o.Order[idx].SimplifiedExpr = expr.(sqlparser.Expr)
-		}
+			return true
+		}).(sqlparser.Expr)
 	}
+	return restoreComparisonModifiers(ctx, expr)
+}
+
+// restoreComparisonModifiers puts back the ANY/ALL/SOME comparisons whose rewritten form ended up
+// in a single route together with all of its subqueries, so the route sends the original comparison.
+func restoreComparisonModifiers(ctx *plancontext.PlanningContext, expr sqlparser.Expr) sqlparser.Expr {
+	if len(ctx.SemTable.ComparisonModifiers) == 0 {
+		return expr
+	}
+	return sqlparser.Rewrite(expr, func(cursor *sqlparser.Cursor) bool {
+		node, ok := cursor.Node().(sqlparser.Expr)
+		if !ok {
+			return true
+		}
+		for rewritten, original := range ctx.SemTable.ComparisonModifiers {
+			if sqlparser.Equals.Expr(node, rewritten) && allSubqueriesMerged(ctx, node) {
+				cursor.Replace(sqlparser.Clone(original))
+				return false
+			}
+		}
+		return true
+	}, nil).(sqlparser.Expr)
+}
+
+// allSubqueriesMerged returns true if all the subqueries used by the expression were merged into the outer route
+func allSubqueriesMerged(ctx *plancontext.PlanningContext, expr sqlparser.Expr) bool {
+	found, merged := false, true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		subq, ok := node.(*sqlparser.Subquery)
+		if !ok {
+			return true, nil
+		}
+		found = true
+		merged = slices.ContainsFunc(ctx.MergedSubqueries, func(other *sqlparser.Subquery) bool {
+			return sqlparser.Equals.RefOfSubquery(subq, other)
+		})
+		if !merged {
+			return false, io.EOF
+		}
+		return false, nil
+	}, expr)
+	return found && merged
 }
 
 func mergeSubqueryExpr(ctx *plancontext.PlanningContext, pe *ProjExpr) {
@@ -142,7 +204,7 @@ func mergeSubqueryExpr(ctx *plancontext.PlanningContext, pe *ProjExpr) {
 	}
 	newExpr, rewritten := rewriteMergedSubqueryExpr(ctx, se, pe.EvalExpr)
 	if rewritten {
-		pe.EvalExpr = newExpr
+		pe.EvalExpr = restoreComparisonModifiers(ctx, newExpr)
 	}
 }
 
@@ -214,6 +276,26 @@ coming from the LHS. The join predicate is not affected, but we are adding
 any new columns needed by the inner subquery to the JoinVars that the join
 will handle.
 */
+// predicatesUseArgument returns true if any of the join predicates uses one of the given arguments
+func (aj *ApplyJoin) predicatesUseArgument(names ...string) bool {
+	for _, col := range aj.JoinPredicates.columns {
+		found := false
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch node := node.(type) {
+			case *sqlparser.Argument:
+				found = found || slices.Contains(names, node.Name)
+			case sqlparser.ListArg:
+				found = found || slices.Contains(names, string(node))
+			}
+			return !found, nil
+		}, col.Original)
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
 func tryPushSubQueryInJoin(
 	ctx *plancontext.PlanningContext,
 	inner *SubQuery,
@@ -242,6 +324,12 @@ func tryPushSubQueryInJoin(
 		return merged, result
 	}
 
+	if !outer.IsInner() && outer.predicatesUseArgument(inner.ArgName, inner.HasValuesName) {
+		// the RHS of an outer join reads the subquery result in the join predicate,
+		// so the subquery has to be evaluated before the whole join
+		return nil, NoRewrite
+	}
+
 	_, ok := inner.Subquery.(*Projection)
 	if ok {
 		// This is a little hacky, but I could not find a better solution for it.
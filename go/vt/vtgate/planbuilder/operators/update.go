@@ -129,6 +129,11 @@ func updateWithInputPlanningRequired(
 	if isMultiTargetUpdate(ctx, updateStmt) {
 		return true
 	}
+	// Without an ORDER BY, the LIMIT can pick different rows for the owned vindex query and the update,
+	// so the rows to update are selected first, and updated using their primary key.
+	if updateStmt.Limit != nil && len(updateStmt.OrderBy) == 0 && updatesVindexColumn(ctx, updateStmt) {
+		return true
+	}
 	// If there are no foreign keys, we don't need to use delete with input.
 	if len(childFks) == 0 && len(parentFks) == 0 {
 		return false
@@ -140,6 +145,26 @@ func updateWithInputPlanningRequired(
 	return false
 }
 
+// updatesVindexColumn returns true if any of the update expressions changes a vindex column of a sharded table
+func updatesVindexColumn(ctx *plancontext.PlanningContext, updateStmt *sqlparser.Update) bool {
+	for _, ue := range updateStmt.Exprs {
+		tblInfo, err := ctx.SemTable.TableInfoForExpr(ue.Name)
+		if err != nil {
+			continue
+		}
+		vTbl := tblInfo.GetVindexTable()
+		if vTbl == nil || !vTbl.Keyspace.Sharded {
+			continue
+		}
+		for _, cv := range vTbl.ColumnVindexes {
+			if slices.ContainsFunc(cv.Columns, ue.Name.Name.Equal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isMultiTargetUpdate(ctx *plancontext.PlanningContext, updateStmt *sqlparser.Update) bool {
 	var targetTS semantics.TableSet
 	for _, ue := range updateStmt.Exprs {
@@ -249,6 +274,9 @@ func createUpdateOpWithTarget(ctx *plancontext.PlanningContext, updStmt *sqlpars
 		panic(vterrors.VT13001(err.Error()))
 	}
 	vTbl := ti.GetVindexTable()
+	if len(vTbl.PrimaryKey) == 0 {
+		panic(vterrors.VT09015())
+	}
 	tblName, err := ti.Name()
 	if err != nil {
 		panic(err)
@@ -319,8 +347,13 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 	op := crossJoin(ctx, updStmt.TableExprs)
 
 	sqc := &SubQueryBuilder{}
+	whereSqc := sqc
+	if updStmt.Limit != nil {
+		// the subqueries in the WHERE clause have to filter the rows before the LIMIT is applied
+		whereSqc = &SubQueryBuilder{}
+	}
 	if updStmt.Where != nil {
-		op = addWherePredsToSubQueryBuilder(ctx, updStmt.Where.Expr, op, sqc)
+		op = addWherePredsToSubQueryBuilder(ctx, updStmt.Where.Expr, op, whereSqc)
 	}
 
 	outerID := TableID(op)
@@ -385,15 +418,17 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 		VerifyAll:                    ctx.VerifyAllFKs,
 	}
 
+	src := op
+	if updStmt.Limit != nil {
+		src = whereSqc.getRootOperator(src, nil)
+	}
 	if len(updStmt.OrderBy) > 0 {
-		updOp.Source = addOrdering(ctx, op, updStmt.OrderBy)
-	} else {
-		updOp.Source = op
+		src = addOrdering(ctx, src, updStmt.OrderBy)
 	}
-
 	if updStmt.Limit != nil {
-		updOp.Source = newLimit(updOp.Source, updStmt.Limit, false)
+		src = newLimit(src, updStmt.Limit, false)
 	}
+	updOp.Source = src
 
 	return sqc.getRootOperator(updOp, nil), targetTbl, updClone
 }
@@ -0,0 +1,493 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+type (
+	// Window evaluates window functions on the vtgate. All the window functions
+	// handled by a single Window share the same PARTITION BY and ORDER BY, and the
+	// input is expected to be sorted by the partition keys followed by the ordering keys.
+	// The window function results are placed before the columns of the input.
+	Window struct {
+		unaryOperator
+
+		PartitionBy []sqlparser.Expr
+		OrderBy     []OrderBy
+		Funcs       []*WindowFunc
+
+		// These are only filled in during offset planning
+		PartitionOffsets, PartitionWSOffsets []int
+		OrderOffsets, OrderWSOffsets         []int
+	}
+
+	// WindowFunc is a single window function evaluated by a Window operator
+	WindowFunc struct {
+		// Func is the window function expression, including the OVER clause
+		Func   sqlparser.Expr
+		OpCode opcode.WindowOpcode
+
+		// Arg is the argument of the function, if it has one
+		Arg sqlparser.Expr
+		// Default is the default value used by LAG and LEAD
+		Default sqlparser.Expr
+		// N is the static argument of NTILE, NTH_VALUE, LAG and LEAD
+		N int64
+
+		// Frame is nil when the default frame should be used
+		Frame *engine.WindowFrame
+
+		// These are only filled in during offset planning
+		ArgOffset, DefaultOffset int
+	}
+)
+
+func (w *Window) Clone(inputs []Operator) Operator {
+	kopy := *w
+	kopy.Source = inputs[0]
+	kopy.PartitionBy = slices.Clone(w.PartitionBy)
+	kopy.OrderBy = slices.Clone(w.OrderBy)
+	kopy.Funcs = slice.Map(w.Funcs, func(f *WindowFunc) *WindowFunc {
+		fKopy := *f
+		return &fKopy
+	})
+	kopy.PartitionOffsets = slices.Clone(w.PartitionOffsets)
+	kopy.PartitionWSOffsets = slices.Clone(w.PartitionWSOffsets)
+	kopy.OrderOffsets = slices.Clone(w.OrderOffsets)
+	kopy.OrderWSOffsets = slices.Clone(w.OrderWSOffsets)
+	return &kopy
+}
+
+func (w *Window) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	if sqlparser.ContainsWindowFunction(expr) {
+		return newFilter(w, expr)
+	}
+	// window functions are evaluated after the WHERE clause,
+	// so predicates that don't use them can be evaluated before
+	w.Source = w.Source.AddPredicate(ctx, expr)
+	return w
+}
+
+func (w *Window) AddColumn(ctx *plancontext.PlanningContext, reuse bool, gb bool, ae *sqlparser.AliasedExpr) int {
+	if offset := w.findFunc(ctx, ae.Expr); offset >= 0 {
+		return offset
+	}
+	if sqlparser.ContainsWindowFunction(ae.Expr) {
+		panic(vterrors.VT12001(fmt.Sprintf("window function not in the SELECT list: %s", sqlparser.String(ae.Expr))))
+	}
+	return len(w.Funcs) + w.Source.AddColumn(ctx, reuse, gb, ae)
+}
+
+func (w *Window) AddWSColumn(ctx *plancontext.PlanningContext, offset int, underRoute bool) int {
+	if offset < len(w.Funcs) {
+		panic(vterrors.VT12001(fmt.Sprintf("weight_string of window function: %s", sqlparser.String(w.Funcs[offset].Func))))
+	}
+	return len(w.Funcs) + w.Source.AddWSColumn(ctx, offset-len(w.Funcs), underRoute)
+}
+
+func (w *Window) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, underRoute bool) int {
+	if offset := w.findFunc(ctx, expr); offset >= 0 {
+		return offset
+	}
+	offset := w.Source.FindCol(ctx, expr, underRoute)
+	if offset < 0 {
+		return offset
+	}
+	return len(w.Funcs) + offset
+}
+
+func (w *Window) findFunc(ctx *plancontext.PlanningContext, expr sqlparser.Expr) int {
+	for idx, f := range w.Funcs {
+		if ctx.SemTable.EqualsExprWithDeps(f.Func, expr) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (w *Window) GetColumns(ctx *plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	cols := slice.Map(w.Funcs, func(f *WindowFunc) *sqlparser.AliasedExpr {
+		return aeWrap(f.Func)
+	})
+	return append(cols, w.Source.GetColumns(ctx)...)
+}
+
+func (w *Window) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	return transformColumnsToSelectExprs(ctx, w)
+}
+
+func (w *Window) GetOrdering(ctx *plancontext.PlanningContext) []OrderBy {
+	return w.Source.GetOrdering(ctx)
+}
+
+func (w *Window) planOffsets(ctx *plancontext.PlanningContext) Operator {
+	for _, expr := range w.PartitionBy {
+		offset := w.Source.AddColumn(ctx, true, false, aeWrap(expr))
+		w.PartitionOffsets = append(w.PartitionOffsets, offset)
+	}
+	for _, order := range w.OrderBy {
+		offset := w.Source.AddColumn(ctx, true, false, aeWrap(order.SimplifiedExpr))
+		w.OrderOffsets = append(w.OrderOffsets, offset)
+	}
+	for _, f := range w.Funcs {
+		f.ArgOffset, f.DefaultOffset = -1, -1
+		if f.Arg != nil {
+			f.ArgOffset = w.Source.AddColumn(ctx, true, false, aeWrap(f.Arg))
+		}
+		if f.Default != nil {
+			f.DefaultOffset = w.Source.AddColumn(ctx, true, false, aeWrap(f.Default))
+		}
+	}
+
+	// weight strings are added after all columns have been added to the input
+	wsOffset := func(e sqlparser.Expr, offset int) int {
+		if !ctx.NeedsWeightString(e) {
+			return -1
+		}
+		return w.Source.AddWSColumn(ctx, offset, false)
+	}
+	for idx, expr := range w.PartitionBy {
+		w.PartitionWSOffsets = append(w.PartitionWSOffsets, wsOffset(expr, w.PartitionOffsets[idx]))
+	}
+	for idx, order := range w.OrderBy {
+		w.OrderWSOffsets = append(w.OrderWSOffsets, wsOffset(order.SimplifiedExpr, w.OrderOffsets[idx]))
+	}
+	return nil
+}
+
+func (w *Window) ShortDescription() string {
+	funcs := slice.Map(w.Funcs, func(f *WindowFunc) string {
+		return sqlparser.String(f.Func)
+	})
+	return strings.Join(funcs, ", ")
+}
+
+// windowSpecFor returns the window specification used by the window function,
+// resolving references to named windows declared in the WINDOW clause
+func windowSpecFor(sel *sqlparser.Select, over *sqlparser.OverClause) *sqlparser.WindowSpecification {
+	if !over.WindowName.IsEmpty() {
+		return namedWindowSpec(sel, over.WindowName)
+	}
+	spec := over.WindowSpec
+	if spec == nil || spec.Name.IsEmpty() {
+		return spec
+	}
+
+	// the window specification is based on a named window, so we need to merge the two
+	base := namedWindowSpec(sel, spec.Name)
+	merged := &sqlparser.WindowSpecification{
+		PartitionClause: base.PartitionClause,
+		OrderClause:     base.OrderClause,
+		FrameClause:     base.FrameClause,
+	}
+	if len(spec.OrderClause) > 0 {
+		merged.OrderClause = spec.OrderClause
+	}
+	if spec.FrameClause != nil {
+		merged.FrameClause = spec.FrameClause
+	}
+	return merged
+}
+
+func namedWindowSpec(sel *sqlparser.Select, name sqlparser.IdentifierCI) *sqlparser.WindowSpecification {
+	for _, namedWindow := range sel.Windows {
+		for _, def := range namedWindow.Windows {
+			if !def.Name.Equal(name) {
+				continue
+			}
+			if def.WindowSpec != nil && !def.WindowSpec.Name.IsEmpty() {
+				return windowSpecFor(sel, &sqlparser.OverClause{WindowSpec: def.WindowSpec})
+			}
+			return def.WindowSpec
+		}
+	}
+	panic(vterrors.VT03034(name.String()))
+}
+
+// usesNamedWindows returns true if any of the window functions references a window declared in the WINDOW clause
+func usesNamedWindows(funcs []sqlparser.Expr) bool {
+	for _, f := range funcs {
+		over := sqlparser.GetOverClause(f)
+		if !over.WindowName.IsEmpty() || (over.WindowSpec != nil && !over.WindowSpec.Name.IsEmpty()) {
+			return true
+		}
+	}
+	return false
+}
+
+// findWindowFuncs returns all window functions used by the given expressions
+func findWindowFuncs(exprs ...sqlparser.Expr) (funcs []sqlparser.Expr) {
+	for _, expr := range exprs {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (kontinue bool, err error) {
+			switch node := node.(type) {
+			case *sqlparser.Subquery:
+				return false, nil
+			case sqlparser.Expr:
+				if sqlparser.GetOverClause(node) != nil {
+					funcs = append(funcs, node)
+					return false, nil
+				}
+			}
+			return true, nil
+		}, expr)
+	}
+	return
+}
+
+// canPushWindowFuncs returns true if the window functions can be evaluated by MySQL.
+// This is the case when the input is a route to a single shard, or when all rows of
+// each partition are guaranteed to live on the same shard, because the PARTITION BY
+// contains a column with a unique vindex.
+func canPushWindowFuncs(ctx *plancontext.PlanningContext, src Operator, sel *sqlparser.Select, funcs []sqlparser.Expr) bool {
+	route, ok := src.(*Route)
+	if !ok {
+		return false
+	}
+	if route.IsSingleShard() {
+		return true
+	}
+	if usesNamedWindows(funcs) {
+		// we don't produce the WINDOW clause when generating the SQL sent to MySQL
+		return false
+	}
+	for _, f := range funcs {
+		spec := windowSpecFor(sel, sqlparser.GetOverClause(f))
+		if spec == nil || !slices.ContainsFunc(spec.PartitionClause, func(e sqlparser.Expr) bool {
+			return exprHasUniqueVindex(ctx, e)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// addWindowOperators plans the window functions used by the projection on the vtgate.
+// A Window operator is created for each distinct combination of PARTITION BY and ORDER BY,
+// each with an Ordering underneath it that sorts the rows by the partition and ordering keys.
+func addWindowOperators(ctx *plancontext.PlanningContext, proj *Projection, qp *QueryProjection, sel *sqlparser.Select) {
+	ap, err := proj.GetAliasedProjections()
+	if err != nil {
+		panic(err)
+	}
+
+	var exprs []sqlparser.Expr
+	for _, pe := range ap {
+		pe.EvalExpr = splitWindowAvg(ctx, pe.EvalExpr)
+		exprs = append(exprs, pe.EvalExpr)
+	}
+	for _, order := range qp.OrderExprs {
+		exprs = append(exprs, order.SimplifiedExpr)
+	}
+
+	var windows []*Window
+	var keys []string
+	for _, expr := range findWindowFuncs(exprs...) {
+		spec := windowSpecFor(sel, sqlparser.GetOverClause(expr))
+		var partitionBy sqlparser.Exprs
+		var orderBy sqlparser.OrderBy
+		var frame *sqlparser.FrameClause
+		if spec != nil {
+			partitionBy, orderBy, frame = spec.PartitionClause, spec.OrderClause, spec.FrameClause
+		}
+
+		f := newWindowFunc(expr, frame)
+		key := sqlparser.String(partitionBy) + "|" + sqlparser.String(orderBy)
+		idx := slices.Index(keys, key)
+		if idx < 0 {
+			idx = len(windows)
+			keys = append(keys, key)
+			windows = append(windows, &Window{
+				PartitionBy: partitionBy,
+				OrderBy: slice.Map(orderBy, func(o *sqlparser.Order) OrderBy {
+					return OrderBy{Inner: o, SimplifiedExpr: o.Expr}
+				}),
+			})
+		}
+		if windows[idx].findFunc(ctx, expr) < 0 {
+			windows[idx].Funcs = append(windows[idx].Funcs, f)
+		}
+	}
+
+	src := proj.Source
+	for _, w := range windows {
+		var order []OrderBy
+		for _, expr := range w.PartitionBy {
+			order = append(order, OrderBy{
+				Inner:          &sqlparser.Order{Expr: expr, Direction: sqlparser.AscOrder},
+				SimplifiedExpr: expr,
+			})
+		}
+		order = append(order, w.OrderBy...)
+		if len(order) > 0 {
+			src = newOrdering(src, order)
+		}
+		w.Source = src
+		src = w
+	}
+	proj.Source = src
+}
+
+// splitWindowAvg rewrites AVG() window functions into SUM() / COUNT() over the same window,
+// since AVG is calculated from these two on the vtgate
+func splitWindowAvg(ctx *plancontext.PlanningContext, expr sqlparser.Expr) sqlparser.Expr {
+	return sqlparser.CopyOnRewrite(expr, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		avg, ok := cursor.Node().(*sqlparser.Avg)
+		if !ok || avg.OverClause == nil {
+			return
+		}
+		if avg.Distinct {
+			panic(vterrors.VT12001("AVG(distinct <>) as window function"))
+		}
+		cursor.Replace(&sqlparser.BinaryExpr{
+			Operator: sqlparser.DivOp,
+			Left:     &sqlparser.Sum{Arg: avg.Arg, OverClause: avg.OverClause},
+			Right:    &sqlparser.Count{Args: sqlparser.Exprs{avg.Arg}, OverClause: avg.OverClause},
+		})
+	}, ctx.SemTable.CopySemanticInfo).(sqlparser.Expr)
+}
+
+func newWindowFunc(expr sqlparser.Expr, frame *sqlparser.FrameClause) *WindowFunc {
+	f := &WindowFunc{Func: expr}
+	switch node := expr.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		switch node.Type {
+		case sqlparser.RowNumberExprType:
+			f.OpCode = opcode.WindowRowNumber
+		case sqlparser.RankExprType:
+			f.OpCode = opcode.WindowRank
+		case sqlparser.DenseRankExprType:
+			f.OpCode = opcode.WindowDenseRank
+		case sqlparser.PercentRankExprType:
+			f.OpCode = opcode.WindowPercentRank
+		case sqlparser.CumeDistExprType:
+			f.OpCode = opcode.WindowCumeDist
+		}
+	case *sqlparser.NtileExpr:
+		f.OpCode = opcode.WindowNtile
+		f.N = windowFuncIntArg(node.N, "ntile")
+	case *sqlparser.LagLeadExpr:
+		f.OpCode = opcode.WindowLag
+		if node.Type == sqlparser.LeadExprType {
+			f.OpCode = opcode.WindowLead
+		}
+		f.Arg, f.Default = node.Expr, node.Default
+		f.N = 1
+		if node.N != nil {
+			f.N = windowFuncIntArg(node.N, strings.ToLower(node.Type.ToString()))
+		}
+	case *sqlparser.FirstOrLastValueExpr:
+		f.OpCode = opcode.WindowFirstValue
+		if node.Type == sqlparser.LastValueExprType {
+			f.OpCode = opcode.WindowLastValue
+		}
+		f.Arg = node.Expr
+	case *sqlparser.NTHValueExpr:
+		f.OpCode = opcode.WindowNthValue
+		f.Arg = node.Expr
+		f.N = windowFuncIntArg(node.N, "nth_value")
+	case *sqlparser.CountStar:
+		f.OpCode = opcode.WindowCountStar
+	case *sqlparser.Count:
+		if node.Distinct || len(node.Args) != 1 {
+			panic(vterrors.VT12001(fmt.Sprintf("window function on sharded keyspace: %s", sqlparser.String(expr))))
+		}
+		f.OpCode = opcode.WindowCount
+		f.Arg = node.Args[0]
+	case *sqlparser.Sum:
+		f.OpCode = opcode.WindowSum
+		f.Arg = node.Arg
+	case *sqlparser.Min:
+		f.OpCode = opcode.WindowMin
+		f.Arg = node.Arg
+	case *sqlparser.Max:
+		f.OpCode = opcode.WindowMax
+		f.Arg = node.Arg
+	default:
+		panic(vterrors.VT12001(fmt.Sprintf("window function on sharded keyspace: %s", sqlparser.String(expr))))
+	}
+
+	if aggr, ok := expr.(sqlparser.DistinctableAggr); ok && aggr.IsDistinct() {
+		panic(vterrors.VT12001(fmt.Sprintf("DISTINCT in window function: %s", sqlparser.String(expr))))
+	}
+
+	if frame != nil && f.OpCode.UsesFrame() {
+		f.Frame = newWindowFrame(frame)
+	}
+	return f
+}
+
+func newWindowFrame(frame *sqlparser.FrameClause) *engine.WindowFrame {
+	wf := &engine.WindowFrame{Unit: engine.FrameRows}
+	if frame.Unit == sqlparser.FrameRangeType {
+		wf.Unit = engine.FrameRange
+	}
+	wf.Start = newFrameBound(frame, frame.Start)
+	if frame.End == nil {
+		wf.End = engine.FrameBound{Type: engine.CurrentRow}
+	} else {
+		wf.End = newFrameBound(frame, frame.End)
+	}
+	return wf
+}
+
+func newFrameBound(frame *sqlparser.FrameClause, point *sqlparser.FramePoint) engine.FrameBound {
+	switch point.Type {
+	case sqlparser.CurrentRowType:
+		return engine.FrameBound{Type: engine.CurrentRow}
+	case sqlparser.UnboundedPrecedingType:
+		return engine.FrameBound{Type: engine.UnboundedPreceding}
+	case sqlparser.UnboundedFollowingType:
+		return engine.FrameBound{Type: engine.UnboundedFollowing}
+	}
+
+	if frame.Unit == sqlparser.FrameRangeType {
+		panic(vterrors.VT12001("RANGE frame with offset in window function on sharded keyspace"))
+	}
+	typ := engine.Preceding
+	if point.Type == sqlparser.ExprFollowingType {
+		typ = engine.Following
+	}
+	return engine.FrameBound{
+		Type:   typ,
+		Offset: windowFuncIntArg(point.Expr, "window frame"),
+	}
+}
+
+// windowFuncIntArg returns the value of an integer argument to a window function, which has to be a literal
+func windowFuncIntArg(expr sqlparser.Expr, name string) int64 {
+	lit, ok := expr.(*sqlparser.Literal)
+	if !ok || lit.Type != sqlparser.IntVal {
+		panic(vterrors.VT12001(fmt.Sprintf("non-literal argument to %s on sharded keyspace", name)))
+	}
+	n, err := strconv.ParseInt(lit.Val, 10, 64)
+	if err != nil || n < 0 || (n == 0 && name != "lag" && name != "lead" && name != "window frame") {
+		panic(vterrors.VT03025(name))
+	}
+	return n
+}
@@ -293,6 +293,22 @@ func (s *planTestSuite) TestViews() {
 	s.testFile("view_cases.json", vschemaWrapper, false)
 }
 
+// TestTableStatsPlanning tests the join order and join algorithms chosen using the table statistics.
+func (s *planTestSuite) TestTableStatsPlanning() {
+	vschema := loadSchema(s.T(), "vschemas/schema.json", true)
+	tables := vschema.Keyspaces["user"].Tables
+	tables["user"].Stats = &vindexes.TableStats{Rows: 100000, Cardinality: map[string]uint64{"id": 100000, "col": 1000}, Shards: 4}
+	tables["user_extra"].Stats = &vindexes.TableStats{Rows: 10, Cardinality: map[string]uint64{"user_id": 10, "col": 10}, Shards: 4}
+	tables["music"].Stats = &vindexes.TableStats{Rows: 50000, Cardinality: map[string]uint64{"user_id": 5000, "intcol": 10}, Shards: 4}
+	vschemaWrapper := &vschemawrapper.VSchemaWrapper{
+		V:           vschema,
+		TestBuilder: TestBuilder,
+		Env:         vtenv.NewTestEnv(),
+	}
+
+	s.testFile("table_stats_cases.json", vschemaWrapper, false)
+}
+
 func (s *planTestSuite) TestOne() {
 	reset := operators.EnableDebugPrinting()
 	defer reset()
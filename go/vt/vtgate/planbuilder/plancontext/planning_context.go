@@ -358,7 +358,8 @@ func (ctx *PlanningContext) NeedsWeightString(e sqlparser.Expr) bool {
 func (ctx *PlanningContext) IsAggr(e sqlparser.SQLNode) bool {
 	switch node := e.(type) {
 	case sqlparser.AggrFunc:
-		return true
+		// aggregations used as window functions are evaluated per row, and do not group the input
+		return sqlparser.GetOverClause(node) == nil
 	case *sqlparser.FuncExpr:
 		return node.Name.EqualsAnyString(ctx.VSchema.GetAggregateUDFs())
 	}
@@ -368,12 +369,15 @@ func (ctx *PlanningContext) IsAggr(e sqlparser.SQLNode) bool {
 
 func (ctx *PlanningContext) ContainsAggr(e sqlparser.SQLNode) (hasAggr bool) {
 	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (kontinue bool, err error) {
-		switch node.(type) {
+		switch node := node.(type) {
 		case *sqlparser.Offset:
 			// offsets here indicate that a possible aggregation has already been handled by an input,
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case sqlparser.AggrFunc:
+			if sqlparser.GetOverClause(node) != nil {
+				return true, nil
+			}
 			hasAggr = true
 			return false, io.EOF
 		case *sqlparser.Subquery:
@@ -118,7 +118,7 @@ func buildShowBasicPlan(show *sqlparser.ShowBasic, vschema plancontext.VSchema)
 		return buildPluginsPlan()
 	case sqlparser.Engines:
 		return buildEnginesPlan()
-	case sqlparser.VitessReplicationStatus, sqlparser.VitessShards, sqlparser.VitessTablets, sqlparser.VitessVariables:
+	case sqlparser.VitessQueries, sqlparser.VitessReplicationStatus, sqlparser.VitessSequences, sqlparser.VitessShards, sqlparser.VitessTablets, sqlparser.VitessVariables:
 		return &engine.ShowExec{
 			Command:    show.Command,
 			ShowFilter: show.Filter,
@@ -725,7 +725,9 @@ func buildVschemaVindexesPlan(show *sqlparser.ShowBasic, vschema plancontext.VSc
 		for _, colVindex := range tbl.ColumnVindexes {
 			vindex, ok := schemaKs.Vindexes[colVindex.GetName()]
 			columns := colVindex.GetColumns()
-			if len(columns) == 0 {
+			if colVindex.GetExpression() != "" {
+				columns = []string{colVindex.GetExpression()}
+			} else if len(columns) == 0 {
 				columns = []string{colVindex.GetColumn()}
 			}
 			if ok {
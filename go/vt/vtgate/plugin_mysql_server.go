@@ -73,6 +73,10 @@ var (
 	mysqlSlowConnectWarnThreshold time.Duration
 	mysqlConnBufferPooling        bool
 
+	mysqlServerCompressionAlgorithms string
+
+	mysqlServerMaxCursorBufferedRows = mysql.DefaultMaxCursorBufferedRows
+
 	mysqlDefaultWorkloadName = "OLTP"
 	mysqlDefaultWorkload     int32
 	mysqlDrainOnTerm         bool
@@ -104,6 +108,8 @@ func registerPluginFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&mysqlServerFlushDelay, "mysql_server_flush_delay", mysqlServerFlushDelay, "Delay after which buffered response will be flushed to the client.")
 	fs.StringVar(&mysqlDefaultWorkloadName, "mysql_default_workload", mysqlDefaultWorkloadName, "Default session workload (OLTP, OLAP, DBA)")
 	fs.BoolVar(&mysqlDrainOnTerm, "mysql-server-drain-onterm", mysqlDrainOnTerm, "If set, the server waits for --onterm_timeout for already connected clients to complete their in flight work")
+	fs.StringVar(&mysqlServerCompressionAlgorithms, "mysql-server-compression-algorithms", mysqlServerCompressionAlgorithms, "Comma separated list of protocol compression algorithms that clients can use on the tcp listener. Options: zlib, zstd. Compression is disabled if empty.")
+	fs.IntVar(&mysqlServerMaxCursorBufferedRows, "mysql-server-max-cursor-buffered-rows", mysqlServerMaxCursorBufferedRows, "Maximum number of rows of a read-only cursor kept in memory when the client runs another command before fetching them all, as JDBC does with useCursorFetch. The cursor is aborted if it has more rows. 0 means no limit.")
 }
 
 // vtgateHandler implements the Listener interface.
@@ -149,6 +155,14 @@ func (vh *vtgateHandler) ComResetConnection(c *mysql.Conn) {
 	}
 }
 
+// ComChangeUser releases the reserved connections and transaction of the
+// previous user, and starts a new session for the new one.
+func (vh *vtgateHandler) ComChangeUser(c *mysql.Conn) {
+	vh.ComResetConnection(c)
+	c.ClientData = nil
+	fillInTxStatusFlags(c, vh.session(c))
+}
+
 func (vh *vtgateHandler) ConnectionClosed(c *mysql.Conn) {
 	// Rollback if there is an ongoing transaction. Ignore error.
 	defer func() {
@@ -228,6 +242,8 @@ func (vh *vtgateHandler) ComQuery(c *mysql.Conn, query string, callback func(*sq
 	defer span.Finish()
 
 	ctx = callinfo.MysqlCallInfo(ctx, c)
+	ctx = withQueryAttributes(ctx, c.QueryAttributes)
+	ctx = withConnectionID(ctx, c.ConnectionID)
 
 	// Fill in the ImmediateCallerID with the UserData returned by
 	// the AuthServer plugin for that user. If nothing was
@@ -333,6 +349,8 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 	}
 
 	ctx = callinfo.MysqlCallInfo(ctx, c)
+	ctx = withQueryAttributes(ctx, c.QueryAttributes)
+	ctx = withConnectionID(ctx, c.ConnectionID)
 
 	// Fill in the ImmediateCallerID with the UserData returned by
 	// the AuthServer plugin for that user. If nothing was
@@ -356,7 +374,9 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 		}
 	}()
 
-	if session.Options.Workload == querypb.ExecuteOptions_OLAP {
+	// Rows of read-only cursors are fetched by the client a few at a time,
+	// so they're streamed to keep the memory usage bounded.
+	if session.Options.Workload == querypb.ExecuteOptions_OLAP || prepare.CursorType&mysql.CursorTypeReadOnly != 0 {
 		_, err := vh.vtg.StreamExecute(ctx, vh, session, prepare.PrepareStmt, prepare.BindVars, callback)
 		if err != nil {
 			return sqlerror.NewSQLErrorFromError(err)
@@ -550,6 +570,11 @@ func initMySQLProtocol(vtgate *VTGate) *mysqlServer {
 			_ = initTLSConfig(context.Background(), srv, mysqlSslCert, mysqlSslKey, mysqlSslCa, mysqlSslCrl, mysqlSslServerCA, mysqlServerRequireSecureTransport, tlsVersion)
 		}
 		srv.tcpListener.AllowClearTextWithoutTLS.Store(mysqlAllowClearTextWithoutTLS)
+		srv.tcpListener.MaxCursorBufferedRows = mysqlServerMaxCursorBufferedRows
+		srv.tcpListener.CompressionAlgorithms, err = mysql.ParseCompressionAlgorithms(mysqlServerCompressionAlgorithms)
+		if err != nil {
+			log.Exitf("-mysql-server-compression-algorithms: %v", err)
+		}
 		// Check for the connection threshold
 		if mysqlSlowConnectWarnThreshold != 0 {
 			log.Infof("setting mysql slow connection threshold to %v", mysqlSlowConnectWarnThreshold)
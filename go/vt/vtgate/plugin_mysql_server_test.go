@@ -346,6 +346,39 @@ func TestKillMethods(t *testing.T) {
 	require.True(t, mysqlConn.IsMarkedForClose())
 }
 
+func TestComChangeUser(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+
+	vh := newVtgateHandler(&VTGate{executor: executor, timings: timings, rowsReturned: rowsReturned, rowsAffected: rowsAffected, queryTextCharsProcessed: queryTextCharsProcessed})
+	th := &testHandler{}
+	listener, err := mysql.NewListener("tcp", "127.0.0.1:", mysql.NewAuthServerNone(), th, 0, 0, false, false, 0, 0)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	mysqlConn := mysql.GetTestServerConn(listener)
+	mysqlConn.ConnectionID = 1
+	mysqlConn.UserData = &mysql.StaticUserData{}
+	vh.connections[1] = mysqlConn
+
+	err = vh.ComQuery(mysqlConn, "BEGIN", func(result *sqltypes.Result) error {
+		return nil
+	})
+	require.NoError(t, err)
+	oldSession := vh.session(mysqlConn)
+	require.True(t, oldSession.InTransaction)
+	require.EqualValues(t, 1, vh.busyConnections.Load())
+
+	// The transaction of the previous user is gone, along with its session.
+	vh.ComChangeUser(mysqlConn)
+	newSession := vh.session(mysqlConn)
+	assert.NotSame(t, oldSession, newSession)
+	assert.NotEqual(t, oldSession.SessionUUID, newSession.SessionUUID)
+	assert.False(t, newSession.InTransaction)
+	assert.EqualValues(t, 0, vh.busyConnections.Load())
+	assert.Zero(t, mysqlConn.StatusFlags&mysql.ServerStatusInTrans)
+	assert.NotZero(t, mysqlConn.StatusFlags&mysql.ServerStatusAutocommit)
+}
+
 func TestGracefulShutdown(t *testing.T) {
 	executor, _, _, _, _ := createExecutorEnv(t)
 
@@ -34,6 +34,7 @@ func setupUnixSocket(srv *mysqlServer, authServer mysql.AuthServer, path string)
 	if err != nil {
 		return err
 	}
+	srv.unixListener.MaxCursorBufferedRows = mysqlServerMaxCursorBufferedRows
 	// Listen for unix socket
 	go srv.unixListener.Accept()
 	return nil
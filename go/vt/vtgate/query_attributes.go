@@ -0,0 +1,39 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+)
+
+type queryAttributesKey struct{}
+
+// withQueryAttributes returns a context carrying the query attributes sent by
+// the MySQL client. They are logged, and used as defaults for the query
+// directives, so clients can set e.g. WORKLOAD_NAME without changing the query.
+func withQueryAttributes(ctx context.Context, attributes map[string]string) context.Context {
+	if len(attributes) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, queryAttributesKey{}, attributes)
+}
+
+// queryAttributesFromContext returns the query attributes of the context, if any.
+func queryAttributesFromContext(ctx context.Context) map[string]string {
+	attributes, _ := ctx.Value(queryAttributesKey{}).(map[string]string)
+	return attributes
+}
@@ -0,0 +1,377 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/binary"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/cache/theine"
+	"vitess.io/vitess/go/hack"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/log"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vthash"
+)
+
+var (
+	resultCacheHits          = stats.NewCounter("ResultCacheHits", "Result cache hits")
+	resultCacheMisses        = stats.NewCounter("ResultCacheMisses", "Result cache misses")
+	resultCacheInvalidations = stats.NewCountersWithSingleLabel("ResultCacheInvalidations", "Result cache invalidations by table", "Table")
+
+	// resultCacheStreamRetryDelay is how long we wait before restarting
+	// a row event stream that ended.
+	resultCacheStreamRetryDelay = 5 * time.Second
+)
+
+// resultCacheStreamer streams the row events of the given tables of a keyspace,
+// until the context is done.
+type resultCacheStreamer func(ctx context.Context, keyspace string, tables []string, send func([]*binlogdatapb.VEvent) error) error
+
+// ResultCache caches the results of read-only queries in vtgate.
+//
+// A select is cached if it has the RESULT_CACHE_TTL directive, or if all
+// the tables it uses have a result cache TTL in the VSchema, in which case
+// the smallest one is used. Queries in a transaction or on a reserved
+// connection are never cached.
+//
+// Results are invalidated before their TTL when the tables they use change:
+// the writes that go through this vtgate, the row events of the tables that
+// have a TTL in the VSchema, and the schema changes reported by the schema
+// tracker, which rebuild the VSchema.
+type ResultCache struct {
+	results *theine.Store[PlanCacheKey, *cachedResult]
+	// epoch is increased to invalidate all the results at once.
+	epoch atomic.Uint32
+
+	mu sync.Mutex
+	// versions is increased every time a table, qualified by its keyspace,
+	// changes. A result is stale if the version of any of its tables changed.
+	versions map[string]uint64
+	// streams are the running row event streams, by keyspace.
+	streams map[string]*resultCacheStream
+
+	streamer resultCacheStreamer
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+type cachedResult struct {
+	result *sqltypes.Result
+	// versions are the ones of the plan's tables when the query ran.
+	versions []uint64
+	expires  time.Time
+}
+
+// CachedSize is the cost of the result in the cache.
+func (cr *cachedResult) CachedSize(alloc bool) int64 {
+	size := cr.result.CachedSize(true) + hack.RuntimeAllocSize(int64(cap(cr.versions))*8)
+	if alloc {
+		size += hack.RuntimeAllocSize(int64(80))
+	}
+	return size
+}
+
+type resultCacheStream struct {
+	tables []string
+	cancel context.CancelFunc
+}
+
+// resultCacheLookup is used to store the result of a query that
+// could be cached, but wasn't found in the cache.
+type resultCacheLookup struct {
+	key      PlanCacheKey
+	tables   []string
+	versions []uint64
+	ttl      time.Duration
+}
+
+// NewResultCache creates a result cache that uses up to maxMemory bytes.
+// If streamer is not nil, it is used to invalidate the results of the
+// tables that have a result cache TTL in the VSchema when they change.
+func NewResultCache(maxMemory int64, doorkeeper bool, streamer resultCacheStreamer) *ResultCache {
+	rc := &ResultCache{
+		results:  theine.NewStore[PlanCacheKey, *cachedResult](maxMemory, doorkeeper),
+		versions: make(map[string]uint64),
+		streams:  make(map[string]*resultCacheStream),
+		streamer: streamer,
+	}
+	rc.ctx, rc.cancel = context.WithCancel(context.Background())
+	return rc
+}
+
+// vstreamResultCacheEvents returns a streamer that gets the row events from the primaries.
+func vstreamResultCacheEvents(vsm *vstreamManager) resultCacheStreamer {
+	return func(ctx context.Context, keyspace string, tables []string, send func([]*binlogdatapb.VEvent) error) error {
+		vgtid := &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: keyspace, Gtid: "current"}},
+		}
+		filter := &binlogdatapb.Filter{}
+		for _, table := range tables {
+			filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: table})
+		}
+		return vsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, filter, &vtgatepb.VStreamFlags{}, send)
+	}
+}
+
+// Close stops the row event streams.
+func (rc *ResultCache) Close() {
+	rc.cancel()
+	rc.results.Close()
+}
+
+// lookup returns the cached result of the plan, if there is one. When there
+// is none but the result can be cached, it returns what store needs.
+func (rc *ResultCache) lookup(ctx context.Context, vcursor *vcursorImpl, plan *engine.Plan, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, *resultCacheLookup) {
+	if plan.Type != sqlparser.StmtSelect || vcursor.safeSession.InTransaction() || vcursor.safeSession.InReservedConn() {
+		return nil, nil
+	}
+	ttl := resultCacheTTL(vcursor, plan)
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	l := &resultCacheLookup{
+		key:    rc.hashResult(ctx, vcursor, plan, bindVars),
+		tables: plan.TablesUsed,
+		ttl:    ttl,
+	}
+	// The versions are taken before running the query, so that any
+	// change while it runs makes its result stale.
+	l.versions = rc.tableVersions(l.tables)
+
+	cached, ok := rc.results.Get(l.key, rc.epoch.Load())
+	if ok && time.Now().Before(cached.expires) && slices.Equal(cached.versions, l.versions) {
+		resultCacheHits.Add(1)
+		return cached.result.ShallowCopy(), nil
+	}
+	resultCacheMisses.Add(1)
+	return nil, l
+}
+
+// store caches the result of a query that lookup didn't find.
+func (rc *ResultCache) store(l *resultCacheLookup, qr *sqltypes.Result) {
+	rc.results.Set(l.key, &cachedResult{
+		result:   qr.Copy(),
+		versions: l.versions,
+		expires:  time.Now().Add(l.ttl),
+	}, 0, rc.epoch.Load())
+}
+
+// resultCacheTTL returns how long the result of the plan can be cached, or
+// zero if it can't be.
+func resultCacheTTL(vcursor *vcursorImpl, plan *engine.Plan) time.Duration {
+	if vcursor.resultCacheTTL > 0 {
+		return vcursor.resultCacheTTL
+	}
+	if len(plan.TablesUsed) == 0 {
+		return 0
+	}
+	var ttl time.Duration
+	for _, name := range plan.TablesUsed {
+		tableTTL := tableResultCacheTTL(vcursor.vschema, name)
+		if tableTTL <= 0 {
+			return 0
+		}
+		if ttl == 0 || tableTTL < ttl {
+			ttl = tableTTL
+		}
+	}
+	return ttl
+}
+
+// tableResultCacheTTL returns the result cache TTL in the VSchema of a table qualified by its keyspace.
+func tableResultCacheTTL(vschema *vindexes.VSchema, name string) time.Duration {
+	ksName, tableName, ok := strings.Cut(name, ".")
+	if !ok || vschema == nil {
+		return 0
+	}
+	ks := vschema.Keyspaces[ksName]
+	if ks == nil {
+		return 0
+	}
+	table := ks.Tables[tableName]
+	if table == nil {
+		return 0
+	}
+	return table.ResultCacheTTL
+}
+
+// hashResult extends the plan cache key with the user and the bind variables.
+// The user is part of the key as the tablets may check its access to the tables.
+func (rc *ResultCache) hashResult(ctx context.Context, vcursor *vcursorImpl, plan *engine.Plan, bindVars map[string]*querypb.BindVariable) PlanCacheKey {
+	hasher := vthash.New256()
+	vcursor.keyForPlan(ctx, plan.Original, hasher)
+
+	_, _ = hasher.WriteString("+User:")
+	_, _ = hasher.WriteString(callerid.ImmediateCallerIDFromContext(ctx).GetUsername())
+
+	names := make([]string, 0, len(bindVars))
+	for name := range bindVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	for _, name := range names {
+		_, _ = hasher.WriteString("+BindVar:")
+		_, _ = hasher.WriteString(name)
+		// The length prefix keeps values from running into each other.
+		buf, _ = bindVars[name].MarshalVT()
+		_, _ = hasher.Write(binary.BigEndian.AppendUint64(nil, uint64(len(buf))))
+		_, _ = hasher.Write(buf)
+	}
+
+	var key PlanCacheKey
+	hasher.Sum(key[:0])
+	return key
+}
+
+func (rc *ResultCache) tableVersions(tables []string) []uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	versions := make([]uint64, len(tables))
+	for i, table := range tables {
+		version, ok := rc.versions[table]
+		if !ok {
+			// Remember the table, so that invalidating its keyspace bumps it.
+			rc.versions[table] = 0
+		}
+		versions[i] = version
+	}
+	return versions
+}
+
+// invalidateTables invalidates the results that use any of the given
+// tables, qualified by their keyspace.
+func (rc *ResultCache) invalidateTables(tables ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, table := range tables {
+		rc.versions[table]++
+		resultCacheInvalidations.Add(table, 1)
+	}
+}
+
+// invalidateKeyspace invalidates the results that use any table of the keyspace.
+func (rc *ResultCache) invalidateKeyspace(keyspace string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	prefix := keyspace + "."
+	for table := range rc.versions {
+		if strings.HasPrefix(table, prefix) {
+			rc.versions[table]++
+			resultCacheInvalidations.Add(table, 1)
+		}
+	}
+}
+
+// vschemaUpdated drops all the results, as the VSchema changes when the
+// schema tracker sees schema changes, or when the TTLs change. It then makes
+// sure there is a row event stream for the tables that have a TTL.
+func (rc *ResultCache) vschemaUpdated(vschema *vindexes.VSchema) {
+	rc.epoch.Add(1)
+	if rc.streamer == nil || vschema == nil {
+		return
+	}
+
+	cached := make(map[string][]string)
+	for ksName, ks := range vschema.Keyspaces {
+		for tableName, table := range ks.Tables {
+			if table.ResultCacheTTL > 0 {
+				cached[ksName] = append(cached[ksName], tableName)
+			}
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for ksName, stream := range rc.streams {
+		tables := cached[ksName]
+		sort.Strings(tables)
+		if !slices.Equal(tables, stream.tables) {
+			stream.cancel()
+			delete(rc.streams, ksName)
+		}
+	}
+	for ksName, tables := range cached {
+		if _, ok := rc.streams[ksName]; ok {
+			continue
+		}
+		sort.Strings(tables)
+		ctx, cancel := context.WithCancel(rc.ctx)
+		rc.streams[ksName] = &resultCacheStream{tables: tables, cancel: cancel}
+		go rc.runStream(ctx, ksName, tables)
+	}
+}
+
+// streamedKeyspaces returns the keyspaces that have a row event stream, and their tables.
+func (rc *ResultCache) streamedKeyspaces() map[string][]string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	keyspaces := make(map[string][]string, len(rc.streams))
+	for ksName, stream := range rc.streams {
+		keyspaces[ksName] = stream.tables
+	}
+	return keyspaces
+}
+
+// runStream invalidates the results of the tables as their rows change,
+// restarting the stream of row events until the context is done.
+func (rc *ResultCache) runStream(ctx context.Context, keyspace string, tables []string) {
+	for {
+		err := rc.streamer(ctx, keyspace, tables, func(events []*binlogdatapb.VEvent) error {
+			for _, event := range events {
+				switch event.Type {
+				case binlogdatapb.VEventType_ROW:
+					rc.invalidateTables(event.RowEvent.TableName)
+				case binlogdatapb.VEventType_FIELD:
+					rc.invalidateTables(event.FieldEvent.TableName)
+				case binlogdatapb.VEventType_DDL:
+					rc.invalidateKeyspace(keyspace)
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		// We may have missed events while the stream was down.
+		rc.invalidateKeyspace(keyspace)
+		log.Warningf("Result cache row event stream for keyspace %s ended, restarting it: %v", keyspace, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resultCacheStreamRetryDelay):
+		}
+	}
+}
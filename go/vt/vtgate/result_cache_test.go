@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+func TestResultCacheDirective(t *testing.T) {
+	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
+	rc := NewResultCache(10*1024*1024, false, nil)
+	defer rc.Close()
+	executor.EnableResultCache(rc)
+
+	start := sbc1.ExecCount.Load() + sbc2.ExecCount.Load()
+	execCount := func() int64 {
+		return sbc1.ExecCount.Load() + sbc2.ExecCount.Load() - start
+	}
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	query := "select /*vt+ RESULT_CACHE_TTL=60 */ id from user where id = 1"
+
+	want, err := executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, execCount())
+
+	// The same query is served from the cache.
+	got, err := executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, execCount())
+	assert.Equal(t, want, got)
+
+	// Other values are other results.
+	_, err = executorExec(ctx, executor, session, "select /*vt+ RESULT_CACHE_TTL=60 */ id from user where id = 2", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, execCount())
+
+	// Queries without the directive are not cached.
+	for range 2 {
+		_, err = executorExec(ctx, executor, session, "select id from user where id = 1", nil)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 4, execCount())
+
+	// A write through the executor invalidates the results of its tables.
+	_, err = executorExec(ctx, executor, session, "update user set a = 2 where id = 1", nil)
+	require.NoError(t, err)
+	before := execCount()
+	_, err = executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, before+1, execCount())
+
+	// Queries in a transaction are never cached.
+	_, err = executorExec(ctx, executor, session, "begin", nil)
+	require.NoError(t, err)
+	before = execCount()
+	_, err = executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, before+1, execCount())
+	_, err = executorExec(ctx, executor, session, "rollback", nil)
+	require.NoError(t, err)
+}
+
+func TestResultCacheVSchemaTTL(t *testing.T) {
+	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
+	rc := NewResultCache(10*1024*1024, false, nil)
+	defer rc.Close()
+	executor.EnableResultCache(rc)
+	executor.VSchema().Keyspaces[KsTestSharded].Tables["user"].ResultCacheTTL = time.Minute
+
+	start := sbc1.ExecCount.Load() + sbc2.ExecCount.Load()
+	execCount := func() int64 {
+		return sbc1.ExecCount.Load() + sbc2.ExecCount.Load() - start
+	}
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	for range 2 {
+		_, err := executorExec(ctx, executor, session, "select id from user where id = 1", nil)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, execCount())
+
+	// music has no result cache TTL, so the join is not cached.
+	before := execCount()
+	for range 2 {
+		_, err := executorExec(ctx, executor, session, "select user.id from user join music on user.id = music.user_id where user.id = 1", nil)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, before+2, execCount())
+}
+
+func TestResultCacheRowEvents(t *testing.T) {
+	type streamRequest struct {
+		keyspace string
+		tables   []string
+		send     func([]*binlogdatapb.VEvent) error
+	}
+	requests := make(chan streamRequest, 1)
+	streamer := func(ctx context.Context, keyspace string, tables []string, send func([]*binlogdatapb.VEvent) error) error {
+		requests <- streamRequest{keyspace: keyspace, tables: tables, send: send}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	rc := NewResultCache(10*1024*1024, false, streamer)
+	defer rc.Close()
+
+	vschema := &vindexes.VSchema{
+		Keyspaces: map[string]*vindexes.KeyspaceSchema{
+			"ks": {
+				Tables: map[string]*vindexes.Table{
+					"t1": {ResultCacheTTL: time.Minute},
+					"t2": {},
+				},
+			},
+		},
+	}
+	rc.vschemaUpdated(vschema)
+	assert.Equal(t, map[string][]string{"ks": {"t1"}}, rc.streamedKeyspaces())
+
+	var req streamRequest
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the row event stream did not start")
+	}
+	assert.Equal(t, "ks", req.keyspace)
+	assert.Equal(t, []string{"t1"}, req.tables)
+
+	versions := rc.tableVersions([]string{"ks.t1", "ks.t2"})
+	err := req.send([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_ROW,
+		RowEvent: &binlogdatapb.RowEvent{TableName: "ks.t1"},
+	}})
+	require.NoError(t, err)
+	newVersions := rc.tableVersions([]string{"ks.t1", "ks.t2"})
+	assert.NotEqual(t, versions[0], newVersions[0])
+	assert.Equal(t, versions[1], newVersions[1])
+
+	// The stream stops once no table of the keyspace has a TTL.
+	vschema.Keyspaces["ks"].Tables["t1"].ResultCacheTTL = 0
+	rc.vschemaUpdated(vschema)
+	assert.Empty(t, rc.streamedKeyspaces())
+}
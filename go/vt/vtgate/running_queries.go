@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/callerid"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+)
+
+type connectionIDKey struct{}
+
+// withConnectionID returns a context carrying the ID of the MySQL connection
+// running the query, so that SHOW VITESS_QUERIES can tell which connection
+// to KILL.
+func withConnectionID(ctx context.Context, connectionID uint32) context.Context {
+	return context.WithValue(ctx, connectionIDKey{}, connectionID)
+}
+
+// connectionIDFromContext returns the MySQL connection ID of the context,
+// or zero for the queries that do not come from a MySQL connection.
+func connectionIDFromContext(ctx context.Context) uint32 {
+	connectionID, _ := ctx.Value(connectionIDKey{}).(uint32)
+	return connectionID
+}
+
+// runningQuery is a query being executed by this vtgate.
+type runningQuery struct {
+	connectionID uint32
+	user         string
+	sql          string
+	start        time.Time
+	memory       *engine.MemoryTracker
+}
+
+// runningQueries keeps track of the queries being executed, and of the
+// memory held by their rows, per query and in total.
+type runningQueries struct {
+	queryMemoryLimit int64
+	memory           *engine.MemoryTracker
+
+	mu      sync.Mutex
+	queries map[*runningQuery]struct{}
+}
+
+func newRunningQueries(queryMemoryLimit, totalMemoryLimit int64) *runningQueries {
+	return &runningQueries{
+		queryMemoryLimit: queryMemoryLimit,
+		memory:           engine.NewMemoryTracker("vtgate", totalMemoryLimit, nil),
+		queries:          make(map[*runningQuery]struct{}),
+	}
+}
+
+// start registers a query that is about to be executed.
+func (rq *runningQueries) start(ctx context.Context, sql string) *runningQuery {
+	q := &runningQuery{
+		connectionID: connectionIDFromContext(ctx),
+		user:         callerid.ImmediateCallerIDFromContext(ctx).GetUsername(),
+		sql:          sql,
+		start:        time.Now(),
+		memory:       engine.NewMemoryTracker("query", rq.queryMemoryLimit, rq.memory),
+	}
+	rq.mu.Lock()
+	rq.queries[q] = struct{}{}
+	rq.mu.Unlock()
+	return q
+}
+
+// finish unregisters a query, and releases the memory it still accounts for.
+func (rq *runningQueries) finish(q *runningQuery) {
+	rq.mu.Lock()
+	delete(rq.queries, q)
+	rq.mu.Unlock()
+	q.memory.Close()
+}
+
+// list returns the running queries, the ones holding the most memory first.
+func (rq *runningQueries) list() []*runningQuery {
+	rq.mu.Lock()
+	queries := make([]*runningQuery, 0, len(rq.queries))
+	for q := range rq.queries {
+		queries = append(queries, q)
+	}
+	rq.mu.Unlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		if used, other := queries[i].memory.Used(), queries[j].memory.Used(); used != other {
+			return used > other
+		}
+		return queries[i].start.Before(queries[j].start)
+	})
+	return queries
+}
+
+// showVitessQueries lists the queries running in this vtgate, the ones
+// holding the most memory first. A query can be stopped with KILL QUERY
+// and the ID of its connection.
+func (e *Executor) showVitessQueries() (*sqltypes.Result, error) {
+	fields := []*querypb.Field{
+		{Name: "Id", Type: sqltypes.Uint32},
+		{Name: "User", Type: sqltypes.VarChar},
+		{Name: "Time", Type: sqltypes.Int64},
+		{Name: "Memory", Type: sqltypes.Int64},
+		{Name: "Peak_memory", Type: sqltypes.Int64},
+		{Name: "Info", Type: sqltypes.VarChar},
+	}
+	now := time.Now()
+	var rows [][]sqltypes.Value
+	for _, q := range e.queries.list() {
+		rows = append(rows, []sqltypes.Value{
+			sqltypes.NewUint32(q.connectionID),
+			sqltypes.NewVarChar(q.user),
+			sqltypes.NewInt64(int64(now.Sub(q.start).Seconds())),
+			sqltypes.NewInt64(q.memory.Used()),
+			sqltypes.NewInt64(q.memory.Peak()),
+			sqltypes.NewVarChar(e.env.Parser().TruncateForUI(q.sql)),
+		})
+	}
+	return &sqltypes.Result{Fields: fields, Rows: rows}, nil
+}
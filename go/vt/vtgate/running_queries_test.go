@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestShowVitessQueries(t *testing.T) {
+	executor, _, _, _, ctx := createExecutorEnv(t)
+	session := &vtgatepb.Session{TargetString: "@primary"}
+
+	qr, err := executorExec(withConnectionID(ctx, 7), executor, session, "show vitess_queries", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Fields, 6)
+	require.Len(t, qr.Rows, 1)
+	assert.Equal(t, sqltypes.NewUint32(7), qr.Rows[0][0])
+	assert.Equal(t, sqltypes.NewVarChar("show vitess_queries"), qr.Rows[0][5])
+
+	// the finished queries are not listed anymore
+	assert.Empty(t, executor.queries.list())
+}
+
+func TestQueryMemoryLimit(t *testing.T) {
+	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
+	session := &vtgatepb.Session{TargetString: "@primary"}
+
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2", "3")
+	sbc1.SetResults([]*sqltypes.Result{result})
+	sbc2.SetResults([]*sqltypes.Result{result})
+	executor.queries = newRunningQueries(100, 0)
+	_, err := executorExec(ctx, executor, session, "select id from user union select id from music", nil)
+	require.EqualError(t, err, "query memory exceeded allowed limit of 100 bytes")
+	assert.Zero(t, executor.queries.memory.Used())
+
+	sbc1.SetResults([]*sqltypes.Result{result})
+	sbc2.SetResults([]*sqltypes.Result{result})
+	executor.queries = newRunningQueries(0, 100)
+	_, err = executorExec(ctx, executor, session, "select id from user union select id from music", nil)
+	require.EqualError(t, err, "vtgate memory exceeded allowed limit of 100 bytes")
+	assert.Zero(t, executor.queries.memory.Used())
+
+	sbc1.SetResults([]*sqltypes.Result{result})
+	sbc2.SetResults([]*sqltypes.Result{result})
+	executor.queries = newRunningQueries(1000, 1000)
+	qr, err := executorExec(ctx, executor, session, "select id from user union select id from music", nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, qr.Rows)
+	assert.Zero(t, executor.queries.memory.Used())
+	assert.NotZero(t, executor.queries.memory.Peak())
+}
@@ -626,6 +626,23 @@ func (stc *ScatterConn) multiGo(
 	return allErrors
 }
 
+type scatterLimiterKey struct{}
+
+// withScatterLimiter returns a context carrying the limiter of the number of
+// shards the queries of a scatter are sent to at once.
+func withScatterLimiter(ctx context.Context, limiter *engine.ScatterLimiter) context.Context {
+	return context.WithValue(ctx, scatterLimiterKey{}, limiter)
+}
+
+// scatterLimiterFromContext returns the scatter limiter of the context, or one
+// using the default scatter concurrency if the context has none.
+func scatterLimiterFromContext(ctx context.Context) *engine.ScatterLimiter {
+	if limiter, ok := ctx.Value(scatterLimiterKey{}).(*engine.ScatterLimiter); ok {
+		return limiter
+	}
+	return engine.NewScatterLimiter(scatterConcurrency)
+}
+
 // panicData is used to capture panics during parallel execution.
 type panicData struct {
 	p     any
@@ -690,23 +707,17 @@ func (stc *ScatterConn) multiGoTransaction(
 		}
 	} else {
 		var panicRecord atomic.Value
-		var wg sync.WaitGroup
-		for i, rs := range rss {
-			wg.Add(1)
-			go func(rs *srvtopo.ResolvedShard, i int) {
-				defer wg.Done()
-				defer func() {
-					if r := recover(); r != nil {
-						panicRecord.Store(&panicData{
-							p:     r,
-							trace: debug.Stack(),
-						})
-					}
-				}()
-				oneShard(rs, i)
-			}(rs, i)
-		}
-		wg.Wait()
+		scatterLimiterFromContext(ctx).Go(numShards, func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					panicRecord.Store(&panicData{
+						p:     r,
+						trace: debug.Stack(),
+					})
+				}
+			}()
+			oneShard(rss[i], i)
+		})
 		if pr, ok := panicRecord.Load().(*panicData); ok {
 			log.Errorf("caught a panic during parallel execution:\n%s", string(pr.trace))
 			panic(pr.p) // rethrow the captured panic in the main thread
@@ -35,6 +35,7 @@ import (
 
 type (
 	keyspaceStr  = string
+	shardStr     = string
 	tableNameStr = string
 	viewNameStr  = string
 
@@ -47,6 +48,7 @@ type (
 		tables *tableMap
 		views  *viewMap
 		udfs   map[keyspaceStr][]string
+		stats  map[keyspaceStr]map[shardStr]map[tableNameStr]*vindexes.TableStats
 		ctx    context.Context
 		signal func() // a function that we'll call whenever we have new schema data
 
@@ -54,6 +56,9 @@ type (
 		tracked      map[keyspaceStr]*updateController
 		consumeDelay time.Duration
 
+		// statsInterval is how often the table statistics are reloaded, zero disables them
+		statsInterval time.Duration
+
 		parser *sqlparser.Parser
 	}
 )
@@ -61,12 +66,20 @@ type (
 // defaultConsumeDelay is the default time, the updateController will wait before checking the schema fetch request queue.
 const defaultConsumeDelay = 1 * time.Second
 
+const (
+	// tableRowsQuery returns the estimated number of rows of the tables of the keyspace
+	tableRowsQuery = "select table_name, table_rows from information_schema.tables where table_schema = database() and table_type = 'BASE TABLE'"
+	// indexCardinalityQuery returns the estimated number of distinct values of the columns starting an index
+	indexCardinalityQuery = "select table_name, column_name, max(cardinality) from information_schema.statistics where table_schema = database() and seq_in_index = 1 group by table_name, column_name"
+)
+
 // NewTracker creates the tracker object.
 func NewTracker(ch chan *discovery.TabletHealth, enableViews, enableUDFs bool, parser *sqlparser.Parser) *Tracker {
 	t := &Tracker{
 		ctx:          context.Background(),
 		ch:           ch,
 		tables:       &tableMap{m: make(map[keyspaceStr]map[tableNameStr]*vindexes.TableInfo)},
+		stats:        map[keyspaceStr]map[shardStr]map[tableNameStr]*vindexes.TableStats{},
 		tracked:      map[keyspaceStr]*updateController{},
 		consumeDelay: defaultConsumeDelay,
 		parser:       parser,
@@ -81,6 +94,13 @@ func NewTracker(ch chan *discovery.TabletHealth, enableViews, enableUDFs bool, p
 	return t
 }
 
+// SetTableStatsInterval enables loading the table statistics of the primary
+// tablets, which the planner uses to order joins, and sets how often they are
+// reloaded. It must be called before the tracker is started.
+func (t *Tracker) SetTableStatsInterval(interval time.Duration) {
+	t.statsInterval = interval
+}
+
 // LoadKeyspace loads the keyspace schema.
 func (t *Tracker) LoadKeyspace(conn queryservice.QueryService, target *querypb.Target) error {
 	err := t.loadTables(conn, target)
@@ -95,6 +115,8 @@ func (t *Tracker) LoadKeyspace(conn queryservice.QueryService, target *querypb.T
 	if err != nil {
 		return err
 	}
+	// the statistics only make plans better, so the keyspace is usable without them
+	_, _ = t.loadTableStats(conn, target)
 
 	t.tracked[target.Keyspace].setLoaded(true)
 	return nil
@@ -183,6 +205,96 @@ func (t *Tracker) loadUDFs(conn queryservice.QueryService, target *querypb.Targe
 	return nil
 }
 
+// loadTableStats loads the table statistics of the shard of the target, and returns whether they changed.
+func (t *Tracker) loadTableStats(conn queryservice.QueryService, target *querypb.Target) (bool, error) {
+	if t.statsInterval <= 0 {
+		// This happens only when table statistics are not enabled.
+		return false, nil
+	}
+
+	rows, err := conn.Execute(t.ctx, target, tableRowsQuery, nil, 0, 0, nil)
+	if err != nil {
+		log.Warningf("error fetching table statistics for keyspace %s: %v", target.Keyspace, err)
+		return false, err
+	}
+	cardinalities, err := conn.Execute(t.ctx, target, indexCardinalityQuery, nil, 0, 0, nil)
+	if err != nil {
+		log.Warningf("error fetching index statistics for keyspace %s: %v", target.Keyspace, err)
+		return false, err
+	}
+
+	stats := make(map[tableNameStr]*vindexes.TableStats, len(rows.Rows))
+	for _, row := range rows.Rows {
+		// table_rows is NULL for the tables MySQL has no estimate for
+		tableRows, err := row[1].ToUint64()
+		if err != nil {
+			continue
+		}
+		stats[row[0].ToString()] = &vindexes.TableStats{Rows: tableRows}
+	}
+	for _, row := range cardinalities.Rows {
+		tblStats := stats[row[0].ToString()]
+		cardinality, err := row[2].ToUint64()
+		if tblStats == nil || err != nil {
+			continue
+		}
+		if tblStats.Cardinality == nil {
+			tblStats.Cardinality = map[string]uint64{}
+		}
+		tblStats.Cardinality[strings.ToLower(row[1].ToString())] = cardinality
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	shards := t.stats[target.Keyspace]
+	if shards == nil {
+		shards = map[shardStr]map[tableNameStr]*vindexes.TableStats{}
+		t.stats[target.Keyspace] = shards
+	}
+	previous, found := shards[target.Shard]
+	changed := !found || !maps.EqualFunc(previous, stats, func(a, b *vindexes.TableStats) bool {
+		return a.Rows == b.Rows && maps.Equal(a.Cardinality, b.Cardinality)
+	})
+	shards[target.Shard] = stats
+	if controller := t.tracked[target.Keyspace]; controller != nil {
+		controller.setStatsLoaded(target.Shard, time.Now())
+	}
+	log.Infof("finished loading table statistics for shard %s/%s. Found %d tables", target.Keyspace, target.Shard, len(stats))
+	return changed, nil
+}
+
+// aggregateTableStats combines the table statistics of the shards of a keyspace. The row
+// estimates and cardinalities are averaged over the shards that reported them, so that
+// the planner can scale them by the number of shards its routes reach.
+func aggregateTableStats(shards map[shardStr]map[tableNameStr]*vindexes.TableStats) map[tableNameStr]*vindexes.TableStats {
+	aggregated := map[tableNameStr]*vindexes.TableStats{}
+	for _, tables := range shards {
+		for name, stats := range tables {
+			agg := aggregated[name]
+			if agg == nil {
+				agg = &vindexes.TableStats{}
+				aggregated[name] = agg
+			}
+			agg.Rows += stats.Rows
+			agg.Shards++
+			for col, cardinality := range stats.Cardinality {
+				if agg.Cardinality == nil {
+					agg.Cardinality = map[string]uint64{}
+				}
+				agg.Cardinality[col] += cardinality
+			}
+		}
+	}
+	for _, agg := range aggregated {
+		shards := uint64(agg.Shards)
+		agg.Rows /= shards
+		for col := range agg.Cardinality {
+			agg.Cardinality[col] /= shards
+		}
+	}
+	return aggregated
+}
+
 // Start starts the schema tracking.
 func (t *Tracker) Start() {
 	log.Info("Starting schema tracking")
@@ -221,7 +333,7 @@ func (t *Tracker) getKeyspaceUpdateController(th *discovery.TabletHealth) *updat
 }
 
 func (t *Tracker) newUpdateController() *updateController {
-	return &updateController{update: t.updateSchema, reloadKeyspace: t.initKeyspace, signal: t.signal, consumeDelay: t.consumeDelay}
+	return &updateController{update: t.updateSchema, reloadKeyspace: t.initKeyspace, signal: t.signal, consumeDelay: t.consumeDelay, statsInterval: t.statsInterval}
 }
 
 func (t *Tracker) initKeyspace(th *discovery.TabletHealth) error {
@@ -269,7 +381,7 @@ func (t *Tracker) GetIndexes(ks string, tbl string) []*sqlparser.IndexDefinition
 	return tblInfo.Indexes
 }
 
-// Tables returns a map with the columns for all known tables in the keyspace
+// Tables returns a map with the columns and statistics for all known tables in the keyspace
 func (t *Tracker) Tables(ks string) map[string]*vindexes.TableInfo {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -279,7 +391,17 @@ func (t *Tracker) Tables(ks string) map[string]*vindexes.TableInfo {
 		return map[string]*vindexes.TableInfo{} // we know nothing about this KS, so that is the info we can give out
 	}
 
-	return maps.Clone(m)
+	if len(t.stats[ks]) == 0 {
+		return maps.Clone(m)
+	}
+	stats := aggregateTableStats(t.stats[ks])
+	tables := make(map[string]*vindexes.TableInfo, len(m))
+	for name, tblInfo := range m {
+		info := *tblInfo
+		info.Stats = stats[name]
+		tables[name] = &info
+	}
+	return tables
 }
 
 // Views returns all known views in the keyspace with their definition.
@@ -315,18 +437,35 @@ func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 		return false
 	}
 
+	statsChanged := false
+	if t.tableStatsDue(th.Target.Keyspace, th.Target.Shard) {
+		// a failure keeps the previous statistics, and is retried on the next health check
+		statsChanged, _ = t.loadTableStats(th.Conn, th.Target)
+	}
+
 	// there is view definition change in the tablet
 	if th.Stats.ViewSchemaChanged != nil {
 		success = t.updatedViewSchema(th)
 	}
 
-	if !success || !th.Stats.UdfsChanged {
-		return success
+	if !success {
+		return false
+	}
+	if !th.Stats.UdfsChanged {
+		// the VSchema is not rebuilt for statistics that didn't change, as that clears the plan cache
+		return statsChanged || th.Stats.TableSchemaChanged != nil || th.Stats.ViewSchemaChanged != nil
 	}
 
 	return t.loadUDFs(th.Conn, th.Target) == nil
 }
 
+func (t *Tracker) tableStatsDue(ks, shard string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	controller := t.tracked[ks]
+	return controller != nil && controller.statsDue(shard)
+}
+
 func (t *Tracker) updatedTableSchema(th *discovery.TabletHealth) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -403,6 +403,86 @@ func TestIndexInfoRetrieval(t *testing.T) {
 	testTracker(t, false, schemaDefResult, testcases)
 }
 
+// TestTableStatsRetrieval tests that the tracker loads the row estimates and index cardinalities of the tables.
+func TestTableStatsRetrieval(t *testing.T) {
+	target := &querypb.Target{Cell: cell, Keyspace: keyspace, Shard: "-80", TabletType: topodatapb.TabletType_PRIMARY}
+	tablet := &topodatapb.Tablet{Keyspace: target.Keyspace, Shard: target.Shard, Type: target.TabletType}
+
+	sbc := sandboxconn.NewSandboxConn(tablet)
+	sbc.SetSchemaResult([]sandboxconn.SchemaResult{
+		tables(
+			tbl("big", "CREATE TABLE `big` (`id` bigint, `kind` int, PRIMARY KEY (`id`), KEY `kind` (`kind`))"),
+			tbl("small", "CREATE TABLE `small` (`id` bigint, PRIMARY KEY (`id`))"),
+			tbl("unknown", "CREATE TABLE `unknown` (`id` bigint)"),
+		),
+	})
+	sbc.SetResults([]*sqltypes.Result{
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name|table_rows", "varchar|uint64"),
+			"big|100000",
+			"small|10",
+			"unknown|null",
+		),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name|column_name|max(cardinality)", "varchar|varchar|int64"),
+			"big|id|100000",
+			"big|Kind|5",
+			"small|id|10",
+		),
+	})
+
+	tracker := NewTracker(nil, false, false, sqlparser.NewTestParser())
+	tracker.SetTableStatsInterval(time.Hour)
+	require.NoError(t, tracker.AddNewKeyspace(sbc, target))
+
+	tbls := tracker.Tables(keyspace)
+	require.Len(t, tbls, 3)
+	assert.Equal(t, &vindexes.TableStats{Rows: 100000, Cardinality: map[string]uint64{"id": 100000, "kind": 5}, Shards: 1}, tbls["big"].Stats)
+	assert.Equal(t, &vindexes.TableStats{Rows: 10, Cardinality: map[string]uint64{"id": 10}, Shards: 1}, tbls["small"].Stats)
+	assert.Nil(t, tbls["unknown"].Stats)
+	assert.Nil(t, tracker.tables.get(keyspace, "big").Stats, "the tracked table info must not be modified")
+
+	// the statistics are reloaded once the interval went by
+	controller := tracker.tracked[keyspace]
+	assert.False(t, controller.statsDue(target.Shard))
+	controller.setStatsLoaded(target.Shard, time.Now().Add(-time.Hour))
+	assert.True(t, controller.statsDue(target.Shard))
+
+	// the VSchema is only rebuilt when the reloaded statistics changed
+	statsResults := func(bigRows string) []*sqltypes.Result {
+		return []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name|table_rows", "varchar|uint64"),
+				"big|"+bigRows,
+				"small|10",
+			),
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name|column_name|max(cardinality)", "varchar|varchar|int64"),
+				"big|id|100000",
+				"big|Kind|5",
+				"small|id|10",
+			),
+		}
+	}
+	th := &discovery.TabletHealth{Conn: sbc, Tablet: tablet, Target: target, Stats: &querypb.RealtimeStats{}}
+	sbc.SetResults(statsResults("100000"))
+	assert.False(t, tracker.updateSchema(th))
+	assert.False(t, controller.statsDue(target.Shard))
+
+	controller.setStatsLoaded(target.Shard, time.Now().Add(-time.Hour))
+	sbc.SetResults(statsResults("200000"))
+	assert.True(t, tracker.updateSchema(th))
+	assert.EqualValues(t, 200000, tracker.Tables(keyspace)["big"].Stats.Rows)
+
+	// the statistics of the other shards are loaded on their own schedule,
+	// and combined with the ones of the first shard
+	target2 := &querypb.Target{Cell: cell, Keyspace: keyspace, Shard: "80-", TabletType: topodatapb.TabletType_PRIMARY}
+	tablet2 := &topodatapb.Tablet{Keyspace: target2.Keyspace, Shard: target2.Shard, Type: target2.TabletType}
+	sbc2 := sandboxconn.NewSandboxConn(tablet2)
+	assert.True(t, controller.statsDue(target2.Shard))
+	sbc2.SetResults(statsResults("100000"))
+	assert.True(t, tracker.updateSchema(&discovery.TabletHealth{Conn: sbc2, Tablet: tablet2, Target: target2, Stats: &querypb.RealtimeStats{}}))
+	assert.False(t, controller.statsDue(target2.Shard))
+	assert.Equal(t, &vindexes.TableStats{Rows: 150000, Cardinality: map[string]uint64{"id": 100000, "kind": 5}, Shards: 2}, tracker.Tables(keyspace)["big"].Stats)
+	assert.Equal(t, &vindexes.TableStats{Rows: 10, Cardinality: map[string]uint64{"id": 10}, Shards: 2}, tracker.Tables(keyspace)["small"].Stats)
+}
+
 func empty() sandboxconn.SchemaResult {
 	return sandboxconn.SchemaResult{TablesAndViews: map[string]string{}}
 }
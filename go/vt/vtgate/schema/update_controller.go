@@ -41,6 +41,10 @@ type (
 		signal         func()
 		loaded         bool
 
+		// the table statistics of each shard are reloaded every statsInterval, if it is not zero
+		statsInterval time.Duration
+		statsLoaded   map[string]time.Time
+
 		// we'll only log a failed keyspace loading once
 		ignore bool
 	}
@@ -147,8 +151,9 @@ func (u *updateController) add(th *discovery.TabletHealth) {
 		return
 	}
 
-	// If the keyspace schema is loaded and there is no schema change detected. Then there is nothing to process.
-	if len(th.Stats.TableSchemaChanged) == 0 && len(th.Stats.ViewSchemaChanged) == 0 && !th.Stats.UdfsChanged && u.loaded {
+	// If the keyspace schema is loaded and there is no schema change detected,
+	// nor table statistics to reload. Then there is nothing to process.
+	if len(th.Stats.TableSchemaChanged) == 0 && len(th.Stats.ViewSchemaChanged) == 0 && !th.Stats.UdfsChanged && u.loaded && !u.statsDueLocked(th.Target.Shard) {
 		return
 	}
 
@@ -176,6 +181,26 @@ func (u *updateController) setLoaded(loaded bool) {
 	u.loaded = loaded
 }
 
+func (u *updateController) setStatsLoaded(shard string, at time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.statsLoaded == nil {
+		u.statsLoaded = map[string]time.Time{}
+	}
+	u.statsLoaded[shard] = at
+}
+
+// statsDue tells whether the table statistics of the shard must be reloaded
+func (u *updateController) statsDue(shard string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.statsDueLocked(shard)
+}
+
+func (u *updateController) statsDueLocked(shard string) bool {
+	return u.statsInterval > 0 && time.Since(u.statsLoaded[shard]) >= u.statsInterval
+}
+
 func (u *updateController) setIgnore(i bool) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
@@ -72,14 +72,15 @@ func (a *analyzer) lateInit() {
 	a.binder = newBinder(a.scoper, a, a.tables, a.typer)
 	a.scoper.binder = a.binder
 	a.rewriter = &earlyRewriter{
-		binder:          a.binder,
-		scoper:          a.scoper,
-		expandedColumns: map[sqlparser.TableName][]*sqlparser.ColName{},
-		env:             a.si.Environment(),
-		aliasMapCache:   map[*sqlparser.Select]map[string]exprContainer{},
-		reAnalyze:       a.reAnalyze,
-		tables:          a.tables,
-		aggrUDFs:        a.si.GetAggregateUDFs(),
+		binder:              a.binder,
+		scoper:              a.scoper,
+		expandedColumns:     map[sqlparser.TableName][]*sqlparser.ColName{},
+		comparisonModifiers: map[sqlparser.Expr]sqlparser.Expr{},
+		env:                 a.si.Environment(),
+		aliasMapCache:       map[*sqlparser.Select]map[string]exprContainer{},
+		reAnalyze:           a.reAnalyze,
+		tables:              a.tables,
+		aggrUDFs:            a.si.GetAggregateUDFs(),
 	}
 	a.fk = &fkManager{
 		binder:   a.binder,
@@ -182,6 +183,7 @@ func (a *analyzer) newSemTable(
 		ColumnEqualities:          map[columnName][]sqlparser.Expr{},
 		Collation:                 coll,
 		ExpandedColumns:           a.rewriter.expandedColumns,
+		ComparisonModifiers:       a.rewriter.comparisonModifiers,
 		columns:                   columns,
 		StatementIDs:              a.scoper.statementIDs,
 		QuerySignature:            a.sig,
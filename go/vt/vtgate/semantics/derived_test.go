@@ -234,6 +234,49 @@ func TestScopingWComplexDerivedTables(t *testing.T) {
 	}
 }
 
+func TestScopingWLateralDerivedTables(t *testing.T) {
+	queries := []struct {
+		query         string
+		errorMessage  string
+		recursiveDeps TableSet
+	}{{
+		query:         "select 1 from user as u, lateral (select * from t1 where t1.id = u.id) as t",
+		recursiveDeps: MergeTableSets(TS0, TS1),
+	}, {
+		query:         "select 1 from user as u join lateral (select * from t1 where t1.id = u.id) as t",
+		recursiveDeps: MergeTableSets(TS0, TS1),
+	}, {
+		query:         "select 1 from user as u left join lateral (select * from t1 where t1.id = u.id limit 1) as t on true",
+		recursiveDeps: MergeTableSets(TS0, TS1),
+	}, {
+		query:        "select 1 from user as u, (select * from t1 where t1.id = u.id) as t",
+		errorMessage: "column 'u.id' not found",
+	}}
+	for _, query := range queries {
+		t.Run(query.query, func(t *testing.T) {
+			parse, err := sqlparser.NewTestParser().Parse(query.query)
+			require.NoError(t, err)
+			st, err := Analyze(parse, "user", fakeSchemaInfo())
+			require.NoError(t, err)
+			if query.errorMessage != "" {
+				require.EqualError(t, st.NotUnshardedErr, query.errorMessage)
+				return
+			}
+
+			sel := parse.(*sqlparser.Select)
+			var dt *sqlparser.DerivedTable
+			switch from := sel.From[len(sel.From)-1].(type) {
+			case *sqlparser.AliasedTableExpr:
+				dt = from.Expr.(*sqlparser.DerivedTable)
+			case *sqlparser.JoinTableExpr:
+				dt = from.RightExpr.(*sqlparser.AliasedTableExpr).Expr.(*sqlparser.DerivedTable)
+			}
+			predicate := dt.Select.(*sqlparser.Select).Where.Expr
+			assert.Equal(t, query.recursiveDeps, st.RecursiveDeps(predicate))
+		})
+	}
+}
+
 func BenchmarkAnalyzeDerivedTableQueries(b *testing.B) {
 	queries := []string{
 		"select id from (select x as id from user) as t",
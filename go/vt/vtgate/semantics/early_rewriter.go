@@ -32,9 +32,11 @@ type earlyRewriter struct {
 	clause          string
 	warning         string
 	expandedColumns map[sqlparser.TableName][]*sqlparser.ColName
-	env             *vtenv.Environment
-	aliasMapCache   map[*sqlparser.Select]map[string]exprContainer
-	tables          *tableCollector
+	// comparisonModifiers maps the rewritten ANY/ALL/SOME comparisons to the original ones
+	comparisonModifiers map[sqlparser.Expr]sqlparser.Expr
+	env                 *vtenv.Environment
+	aliasMapCache       map[*sqlparser.Select]map[string]exprContainer
+	tables              *tableCollector
 
 	// reAnalyze is used when we are running in the late stage, after the other parts of semantic analysis
 	// have happened, and we are introducing or changing the AST. We invoke it so all parts of the query have been
@@ -52,9 +54,7 @@ func (r *earlyRewriter) down(cursor *sqlparser.Cursor) error {
 	case *sqlparser.ComparisonExpr:
 		return handleComparisonExpr(cursor, node)
 	case *sqlparser.With:
-		if !node.Recursive {
-			return r.handleWith(node)
-		}
+		return r.handleWith(node)
 	case *sqlparser.AliasedTableExpr:
 		return r.handleAliasedTable(node)
 	case *sqlparser.Delete:
@@ -104,7 +104,9 @@ func (r *earlyRewriter) up(cursor *sqlparser.Cursor) error {
 		if node.Type == sqlparser.HavingClause {
 			return r.handleHavingClause(node, cursor.Parent())
 		}
-
+	case *sqlparser.ComparisonExpr:
+		// this rewriting is done in the `up` phase, because we need to know if the subquery is correlated or not
+		return r.rewriteComparisonModifier(cursor, node)
 	}
 	return nil
 }
@@ -151,13 +153,19 @@ func (r *earlyRewriter) handleAliasedTable(node *sqlparser.AliasedTableExpr) err
 
 func (r *earlyRewriter) handleWith(node *sqlparser.With) error {
 	scope := r.scoper.currentScope()
+	var recursive []*sqlparser.CommonTableExpr
 	for _, cte := range node.CTEs {
+		if node.Recursive && isRecursiveCTE(cte) {
+			// recursive CTEs are kept in the query and planned by the operators
+			recursive = append(recursive, cte)
+			continue
+		}
 		err := scope.addCTE(cte)
 		if err != nil {
 			return err
 		}
 	}
-	node.CTEs = nil
+	node.CTEs = recursive
 	return nil
 }
 
@@ -173,6 +181,7 @@ func rewriteNotExpr(cursor *sqlparser.Cursor, node *sqlparser.NotExpr) {
 		return
 	}
 	cmp.Operator = cmp.Operator.Inverse()
+	cmp.Modifier = cmp.Modifier.Inverse()
 	cursor.Replace(cmp)
 }
 
@@ -870,6 +879,169 @@ func handleComparisonExpr(cursor *sqlparser.Cursor, node *sqlparser.ComparisonEx
 	return nil
 }
 
+var errComparisonModifier = NotSingleRouteErr{Inner: &UnsupportedConstruct{errString: "ANY/ALL/SOME comparison operator"}}
+
+// rewriteComparisonModifier rewrites comparisons using ANY, SOME or ALL with an uncorrelated subquery,
+// so they can be planned without sending the whole query to a single route.
+// `x = ANY` and `x <> ALL` are the same as `x IN` and `x NOT IN`. The other comparisons are rewritten to compare
+// with the MIN or MAX value of the subquery. The number of NULL values returned by the subquery is fetched as well,
+// since a NULL makes the comparison NULL instead of TRUE for ALL, and NULL instead of FALSE for ANY.
+// The original comparison is remembered, so it can be sent as is when it ends up in a single route.
+func (r *earlyRewriter) rewriteComparisonModifier(cursor *sqlparser.Cursor, cmp *sqlparser.ComparisonExpr) error {
+	if cmp.Modifier == sqlparser.Missing {
+		return nil
+	}
+	subq, ok := cmp.Right.(*sqlparser.Subquery)
+	if !ok || !r.binder.recursive.dependencies(subq).IsEmpty() {
+		return errComparisonModifier
+	}
+	if ae, ok := cursor.Parent().(*sqlparser.AliasedExpr); ok && ae.As.IsEmpty() {
+		// the column keeps the name it would have had without the rewriting
+		ae.As = sqlparser.NewIdentifierCI(ae.ColumnName())
+	}
+
+	original := sqlparser.Clone(cmp)
+	switch {
+	case cmp.Operator == sqlparser.EqualOp && cmp.Modifier == sqlparser.Any:
+		cmp.Operator, cmp.Modifier = sqlparser.InOp, sqlparser.Missing
+		r.comparisonModifiers[cmp] = original
+		return nil
+	case cmp.Operator == sqlparser.NotEqualOp && cmp.Modifier == sqlparser.All:
+		cmp.Operator, cmp.Modifier = sqlparser.NotInOp, sqlparser.Missing
+		r.comparisonModifiers[cmp] = original
+		return nil
+	}
+	if _, isTuple := cmp.Left.(sqlparser.ValTuple); isTuple {
+		return errComparisonModifier
+	}
+	if _, ok := sqlparser.GetFirstSelect(subq.Select).SelectExprs[0].(*sqlparser.AliasedExpr); !ok {
+		// without knowing the column of the subquery, we can't aggregate it
+		return errComparisonModifier
+	}
+
+	var newExpr sqlparser.Expr
+	switch cmp.Operator {
+	case sqlparser.EqualOp, sqlparser.NotEqualOp:
+		// x = ALL (subquery) is only true if the subquery returns a single distinct value
+		minVal := aggregateSubquery(subq, func(col sqlparser.Expr) sqlparser.Expr { return &sqlparser.Min{Arg: col} })
+		maxVal := aggregateSubquery(subq, func(col sqlparser.Expr) sqlparser.Expr { return &sqlparser.Max{Arg: col} })
+		newExpr = compareWithAll(sqlparser.AndExpressions(
+			sqlparser.NewComparisonExpr(sqlparser.EqualOp, cmp.Left, minVal, nil),
+			sqlparser.NewComparisonExpr(sqlparser.EqualOp, sqlparser.Clone(cmp.Left), maxVal, nil),
+		), minVal, subq)
+		if cmp.Operator == sqlparser.NotEqualOp {
+			// x <> ANY (subquery) is the same as NOT (x = ALL (subquery))
+			newExpr = sqlparser.NewNotExpr(newExpr)
+		}
+	case sqlparser.LessThanOp, sqlparser.LessEqualOp, sqlparser.GreaterThanOp, sqlparser.GreaterEqualOp:
+		// x > ALL and x < ANY compare with the largest value, and x < ALL and x > ANY with the smallest one
+		useMax := cmp.Operator == sqlparser.GreaterThanOp || cmp.Operator == sqlparser.GreaterEqualOp
+		if cmp.Modifier == sqlparser.Any {
+			useMax = !useMax
+		}
+		val := aggregateSubquery(subq, func(col sqlparser.Expr) sqlparser.Expr {
+			if useMax {
+				return &sqlparser.Max{Arg: col}
+			}
+			return &sqlparser.Min{Arg: col}
+		})
+		comparison := sqlparser.NewComparisonExpr(cmp.Operator, cmp.Left, val, nil)
+		if cmp.Modifier == sqlparser.All {
+			newExpr = compareWithAll(comparison, val, subq)
+		} else {
+			newExpr = compareWithAny(comparison, val, subq)
+		}
+	default:
+		return errComparisonModifier
+	}
+
+	r.comparisonModifiers[newExpr] = original
+	cursor.Replace(newExpr)
+	return r.reAnalyze(newExpr)
+}
+
+// compareWithAll returns the expression used for ALL comparisons. It is true if the subquery returns no rows,
+// otherwise it is the comparison with the aggregated value, turned from TRUE into NULL if the subquery returned NULLs:
+// (val is null and nulls = 0) or (comparison and (nulls = 0 or null))
+func compareWithAll(comparison sqlparser.Expr, val, subq *sqlparser.Subquery) sqlparser.Expr {
+	empty := sqlparser.AndExpressions(
+		&sqlparser.IsExpr{Left: cloneWithColNames(val), Right: sqlparser.IsNullOp},
+		sqlparser.NewComparisonExpr(sqlparser.EqualOp, countNulls(subq), sqlparser.NewIntLiteral("0"), nil),
+	)
+	noNulls := &sqlparser.OrExpr{
+		Left:  sqlparser.NewComparisonExpr(sqlparser.EqualOp, countNulls(subq), sqlparser.NewIntLiteral("0"), nil),
+		Right: &sqlparser.NullVal{},
+	}
+	return &sqlparser.OrExpr{Left: empty, Right: sqlparser.AndExpressions(comparison, noNulls)}
+}
+
+// compareWithAny returns the expression used for ANY comparisons. It is false if the subquery returns no rows,
+// otherwise it is the comparison with the aggregated value, turned from FALSE into NULL if the subquery returned NULLs:
+// (val is not null and comparison) or (nulls > 0 and null)
+func compareWithAny(comparison sqlparser.Expr, val, subq *sqlparser.Subquery) sqlparser.Expr {
+	hasNulls := sqlparser.AndExpressions(
+		sqlparser.NewComparisonExpr(sqlparser.GreaterThanOp, countNulls(subq), sqlparser.NewIntLiteral("0"), nil),
+		&sqlparser.NullVal{},
+	)
+	return &sqlparser.OrExpr{
+		Left:  sqlparser.AndExpressions(&sqlparser.IsExpr{Left: cloneWithColNames(val), Right: sqlparser.IsNotNullOp}, comparison),
+		Right: hasNulls,
+	}
+}
+
+// countNulls returns a subquery counting the NULL values returned by the given subquery
+func countNulls(subq *sqlparser.Subquery) *sqlparser.Subquery {
+	return aggregateSubquery(subq, func(col sqlparser.Expr) sqlparser.Expr {
+		return &sqlparser.BinaryExpr{
+			Operator: sqlparser.MinusOp,
+			Left:     &sqlparser.CountStar{},
+			Right:    &sqlparser.Count{Args: sqlparser.Exprs{col}},
+		}
+	})
+}
+
+// aggregateSubquery returns a new subquery that aggregates the single column of the given subquery.
+// Simple queries get their column replaced by the aggregation, and other queries are used as a derived table.
+func aggregateSubquery(subq *sqlparser.Subquery, aggr func(col sqlparser.Expr) sqlparser.Expr) *sqlparser.Subquery {
+	stmt := cloneWithColNames(subq.Select)
+	ae := sqlparser.GetFirstSelect(stmt).SelectExprs[0].(*sqlparser.AliasedExpr)
+	if sel, ok := stmt.(*sqlparser.Select); ok && canAggregateInPlace(sel, ae) {
+		sel.SelectExprs = sqlparser.SelectExprs{sqlparser.NewAliasedExpr(aggr(ae.Expr), "")}
+		// neither the order nor the duplicates of the rows change the result of MIN, MAX, or the number of NULLs
+		sel.Distinct = false
+		sel.OrderBy = nil
+		return sqlparser.NewSubquery(sel)
+	}
+
+	col := sqlparser.NewColNameWithQualifier(ae.ColumnName(), sqlparser.NewTableName("dt"))
+	return sqlparser.NewSubquery(&sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{sqlparser.NewAliasedExpr(aggr(col), "")},
+		From:        []sqlparser.TableExpr{sqlparser.NewAliasedTableExpr(sqlparser.NewDerivedTable(false, stmt), "dt")},
+	})
+}
+
+// cloneWithColNames returns a deep copy of the node. sqlparser.Clone keeps the ColNames,
+// so they keep pointing to the semantic information, but here we want a copy that can be analyzed on its own.
+func cloneWithColNames[T sqlparser.SQLNode](node T) T {
+	return sqlparser.Rewrite(sqlparser.Clone(node), nil, func(cursor *sqlparser.Cursor) bool {
+		if col, ok := cursor.Node().(*sqlparser.ColName); ok {
+			newCol := *col
+			cursor.Replace(&newCol)
+		}
+		return true
+	}).(T)
+}
+
+func canAggregateInPlace(sel *sqlparser.Select, ae *sqlparser.AliasedExpr) bool {
+	return len(sel.SelectExprs) == 1 &&
+		sel.GroupBy == nil &&
+		sel.Having == nil &&
+		sel.Limit == nil &&
+		sel.With == nil &&
+		len(sel.Windows) == 0 &&
+		!sqlparser.ContainsAggregation(ae.Expr)
+}
+
 func (r *earlyRewriter) expandStar(cursor *sqlparser.Cursor, node sqlparser.SelectExprs) error {
 	currentScope := r.scoper.currentScope()
 	var selExprs sqlparser.SelectExprs
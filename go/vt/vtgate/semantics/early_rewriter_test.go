@@ -857,6 +857,76 @@ func TestRewriteNot(t *testing.T) {
 	}
 }
 
+func TestRewriteComparisonModifier(t *testing.T) {
+	schemaInfo := &FakeSI{
+		Tables: map[string]*vindexes.Table{
+			"t1": {
+				Keyspace: &vindexes.Keyspace{Name: "main", Sharded: true},
+				Name:     sqlparser.NewIdentifierCS("t1"),
+				Columns: []vindexes.Column{{
+					Name: sqlparser.NewIdentifierCI("a"),
+					Type: sqltypes.Int64,
+				}, {
+					Name: sqlparser.NewIdentifierCI("b"),
+					Type: sqltypes.Int64,
+				}},
+				ColumnListAuthoritative: true,
+			},
+		},
+	}
+	tcases := []struct {
+		sql      string
+		expected string
+		err      string
+	}{{
+		sql:      "select a from t1 where a = any (select b from t1)",
+		expected: "select a from t1 where a in (select b from t1)",
+	}, {
+		sql:      "select a from t1 where a <> all (select b from t1)",
+		expected: "select a from t1 where a not in (select b from t1)",
+	}, {
+		sql:      "select a from t1 where a > all (select b from t1)",
+		expected: "select a from t1 where (select max(b) from t1) is null and (select count(*) - count(b) from t1) = 0 or a > (select max(b) from t1) and ((select count(*) - count(b) from t1) = 0 or null)",
+	}, {
+		sql:      "select a from t1 where a <= some (select b from t1)",
+		expected: "select a from t1 where (select max(b) from t1) is not null and a <= (select max(b) from t1) or (select count(*) - count(b) from t1) > 0 and null",
+	}, {
+		sql:      "select a from t1 where a = all (select b from t1)",
+		expected: "select a from t1 where (select min(b) from t1) is null and (select count(*) - count(b) from t1) = 0 or a = (select min(b) from t1) and a = (select max(b) from t1) and ((select count(*) - count(b) from t1) = 0 or null)",
+	}, {
+		sql:      "select a from t1 where a < all (select b from t1 limit 10)",
+		expected: "select a from t1 where (select min(dt.b) from (select b from t1 limit 10) as dt) is null and (select count(*) - count(dt.b) from (select b from t1 limit 10) as dt) = 0 or a < (select min(dt.b) from (select b from t1 limit 10) as dt) and ((select count(*) - count(dt.b) from (select b from t1 limit 10) as dt) = 0 or null)",
+	}, {
+		sql:      "select a < all (select b from t1) from t1",
+		expected: "select (select min(b) from t1) is null and (select count(*) - count(b) from t1) = 0 or a < (select min(b) from t1) and ((select count(*) - count(b) from t1) = 0 or null) as `a < all (select b from t1)` from t1",
+	}, {
+		sql: "select a from t1 as x where a > all (select b from t1 where t1.a = x.b)",
+		err: "VT12001: unsupported: ANY/ALL/SOME comparison operator",
+	}}
+	for _, tcase := range tcases {
+		t.Run(tcase.sql, func(t *testing.T) {
+			ast, err := sqlparser.NewTestParser().Parse(tcase.sql)
+			require.NoError(t, err)
+			st, err := Analyze(ast, "db", schemaInfo)
+			require.NoError(t, err)
+			if tcase.err != "" {
+				require.EqualError(t, st.NotSingleRouteErr, tcase.err)
+				return
+			}
+			require.NoError(t, st.NotSingleRouteErr)
+			assert.Equal(t, tcase.expected, sqlparser.String(ast))
+
+			// the original comparison is kept, to be sent as is to a single route
+			stmt, err := sqlparser.NewTestParser().Parse(tcase.sql)
+			require.NoError(t, err)
+			require.Len(t, st.ComparisonModifiers, 1)
+			for _, original := range st.ComparisonModifiers {
+				assert.Contains(t, sqlparser.String(stmt), sqlparser.String(original))
+			}
+		})
+	}
+}
+
 func TestOrderByDerivedTable(t *testing.T) {
 	ks := &vindexes.Keyspace{
 		Name:    "main",
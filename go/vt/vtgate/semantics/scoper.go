@@ -175,6 +175,10 @@ func (s *scoper) enterJoinScope(cursor *sqlparser.Cursor) {
 		// To create this special context, we will find the parent scope of the select statement involved.
 		currScope := s.currentScope()
 		stmtScope := currScope.findParentScopeOfStatement()
+		if containsLateral(cursor.Node().(sqlparser.TableExpr)) {
+			// a LATERAL derived table can also see the tables that come before it in the FROM clause
+			stmtScope = currScope
+		}
 		nScope := newScope(stmtScope)
 		if stmtScope == nil {
 			// TODO: this feels hacky. revisit with a better plan
@@ -185,6 +189,23 @@ func (s *scoper) enterJoinScope(cursor *sqlparser.Cursor) {
 	}
 }
 
+func containsLateral(node sqlparser.TableExpr) bool {
+	switch node := node.(type) {
+	case *sqlparser.AliasedTableExpr:
+		dt, ok := node.Expr.(*sqlparser.DerivedTable)
+		return ok && dt.Lateral
+	case *sqlparser.JoinTableExpr:
+		return containsLateral(node.LeftExpr) || containsLateral(node.RightExpr)
+	case *sqlparser.ParenTableExpr:
+		for _, expr := range node.Exprs {
+			if containsLateral(expr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *scoper) pushSelectScope(node *sqlparser.Select) {
 	currScope := newScope(s.currentScope())
 	currScope.stmtScope = true
@@ -363,20 +384,24 @@ func (s *scope) addCTE(cte *sqlparser.CommonTableExpr) error {
 	return nil
 }
 
-func checkForInvalidAliasUse(cte *sqlparser.CommonTableExpr, name string) (err error) {
+func checkForInvalidAliasUse(cte *sqlparser.CommonTableExpr, name string) error {
 	// TODO I'm sure there is a better. way, but we need to do this to stop infinite loops from occurring
-	down := func(node sqlparser.SQLNode, parent sqlparser.SQLNode) bool {
+	if usesCTEName(cte.Subquery, name) {
+		return vterrors.VT12001("do not support CTE that use the CTE alias inside the CTE query")
+	}
+	return nil
+}
+
+// usesCTEName returns true if the query uses an unqualified table with the given name
+func usesCTEName(query sqlparser.SQLNode, name string) (found bool) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
 		tbl, ok := node.(sqlparser.TableName)
-		if !ok || tbl.Qualifier.NotEmpty() {
-			return err == nil
-		}
-		if tbl.Name.String() == name {
-			err = vterrors.VT12001("do not support CTE that use the CTE alias inside the CTE query")
+		if ok && tbl.Qualifier.IsEmpty() && tbl.Name.String() == name {
+			found = true
 		}
-		return err == nil
-	}
-	_ = sqlparser.CopyOnRewrite(cte.Subquery, down, nil, nil)
-	return err
+		return !found, nil
+	}, query)
+	return found
 }
 
 func (s *scope) addTable(info TableInfo) error {
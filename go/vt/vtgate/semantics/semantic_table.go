@@ -139,6 +139,10 @@ type (
 		// The columns were added because of the use of `*` in the query
 		ExpandedColumns map[sqlparser.TableName][]*sqlparser.ColName
 
+		// ComparisonModifiers maps the expressions that ANY/ALL/SOME comparisons were rewritten to, to the
+		// original comparisons. These are put back when the whole comparison is sent to a single route.
+		ComparisonModifiers map[sqlparser.Expr]sqlparser.Expr
+
 		columns map[*sqlparser.Union]sqlparser.SelectExprs
 
 		comparator *sqlparser.Comparator
@@ -448,6 +452,10 @@ func isShardScoped(pTable *vindexes.Table, cTable *vindexes.Table, pCols sqlpars
 	if cPrimaryVdx.Vindex != pPrimaryVdx.Vindex {
 		return false
 	}
+	// Equal columns are not mapped to the same shard by vindexes declared over different expressions.
+	if !sqlparser.Equals.Expr(cPrimaryVdx.Expression, pPrimaryVdx.Expression) {
+		return false
+	}
 
 	childFkContatined, childFkIndexes := cCols.Indexes(cPrimaryVdx.Columns)
 	if !childFkContatined {
@@ -68,7 +68,7 @@ func (etc *earlyTableCollector) down(cursor *sqlparser.Cursor) bool {
 			Name:      cte.ID.String(),
 			Query:     cte.Subquery,
 			Columns:   cte.Columns,
-			Recursive: with.Recursive,
+			Recursive: with.Recursive && isRecursiveCTE(cte),
 		}
 	}
 	return true
@@ -395,6 +395,12 @@ func (etc *earlyTableCollector) buildRecursiveCTE(node *sqlparser.AliasedTableEx
 	}, nil
 }
 
+// isRecursiveCTE returns true if the CTE uses itself. In a WITH RECURSIVE clause,
+// the CTEs that don't are handled like the CTEs of a regular WITH clause.
+func isRecursiveCTE(cte *sqlparser.CommonTableExpr) bool {
+	return usesCTEName(cte.Subquery, cte.ID.String())
+}
+
 func checkValidRecursiveCTE(cteDef *CTE) error {
 	if cteDef.IDForRecurse != nil {
 		return vterrors.VT09029(cteDef.Name)
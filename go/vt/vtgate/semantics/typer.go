@@ -18,6 +18,7 @@ package semantics
 
 import (
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
@@ -61,10 +62,34 @@ func (t *typer) up(cursor *sqlparser.Cursor) error {
 			}
 		}
 		t.m[node] = code.ResolveType(inputType, t.collationEnv)
+	case *sqlparser.ArgumentLessWindowExpr:
+		typ := sqltypes.Uint64
+		if node.Type == sqlparser.PercentRankExprType || node.Type == sqlparser.CumeDistExprType {
+			typ = sqltypes.Float64
+		}
+		t.m[node] = evalengine.NewType(typ, collations.CollationBinaryID)
+	case *sqlparser.NtileExpr:
+		t.m[node] = evalengine.NewType(sqltypes.Uint64, collations.CollationBinaryID)
+	case *sqlparser.FirstOrLastValueExpr:
+		t.setNullableTypeFrom(node, node.Expr)
+	case *sqlparser.NTHValueExpr:
+		t.setNullableTypeFrom(node, node.Expr)
+	case *sqlparser.LagLeadExpr:
+		t.setNullableTypeFrom(node, node.Expr)
 	}
 	return nil
 }
 
+// setNullableTypeFrom sets the type of a window function returning values of its argument, which can also return NULL
+func (t *typer) setNullableTypeFrom(node, arg sqlparser.Expr) {
+	typ, ok := t.m[arg]
+	if !ok {
+		return
+	}
+	typ.SetNullability(true)
+	t.m[node] = typ
+}
+
 func (t *typer) setTypeFor(node *sqlparser.ColName, typ evalengine.Type) {
 	t.m[node] = typ
 }
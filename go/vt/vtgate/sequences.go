@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// leaseSequenceFromTopo leases a block of values of a sequence whose
+// backend is the topo.
+func (e *Executor) leaseSequenceFromTopo(ctx context.Context, keyspace, sequence string, start, count int64) (int64, error) {
+	ts, err := e.serv.GetTopoServer()
+	if err != nil {
+		return 0, err
+	}
+	return ts.LeaseSequenceBlock(ctx, keyspace, sequence, start, count)
+}
+
+// showVitessSequences lists the blocks of values this vtgate cached for
+// the sequences with a backend, and how fast they are used up.
+func (e *Executor) showVitessSequences() (*sqltypes.Result, error) {
+	fields := []*querypb.Field{
+		{Name: "Keyspace", Type: sqltypes.VarChar},
+		{Name: "Sequence", Type: sqltypes.VarChar},
+		{Name: "Backend", Type: sqltypes.VarChar},
+		{Name: "Block_size", Type: sqltypes.Int64},
+		{Name: "Block_start", Type: sqltypes.Int64},
+		{Name: "Block_end", Type: sqltypes.Int64},
+		{Name: "Next_value", Type: sqltypes.Int64},
+		{Name: "Cached", Type: sqltypes.Int64},
+		{Name: "Leases", Type: sqltypes.Int64},
+		{Name: "Leased_from", Type: sqltypes.VarChar},
+		{Name: "Last_lease", Type: sqltypes.VarChar},
+		{Name: "Exhaustion_rate", Type: sqltypes.Float64},
+	}
+	var rows [][]sqltypes.Value
+	for _, s := range e.sequences.Stats() {
+		lastLease := ""
+		if !s.LastLease.IsZero() {
+			lastLease = s.LastLease.UTC().Format(time.RFC3339)
+		}
+		rows = append(rows, []sqltypes.Value{
+			sqltypes.NewVarChar(s.Keyspace),
+			sqltypes.NewVarChar(s.Sequence),
+			sqltypes.NewVarChar(s.Backend),
+			sqltypes.NewInt64(s.BlockSize),
+			sqltypes.NewInt64(s.BlockStart),
+			sqltypes.NewInt64(s.BlockEnd),
+			sqltypes.NewInt64(s.NextValue),
+			sqltypes.NewInt64(s.Cached),
+			sqltypes.NewInt64(s.Leases),
+			sqltypes.NewVarChar(s.LeasedFrom),
+			sqltypes.NewVarChar(lastLease),
+			sqltypes.NewFloat64(roundRate(s.ExhaustionRate)),
+		})
+	}
+	return &sqltypes.Result{Fields: fields, Rows: rows}, nil
+}
+
+// roundRate keeps two decimals of an exhaustion rate, in values per second.
+func roundRate(rate float64) float64 {
+	return math.Round(rate*100) / 100
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/config"
+	"vitess.io/vitess/go/sqltypes"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestShowVitessSequences(t *testing.T) {
+	vschema := `
+{
+	"sharded": true,
+	"vindexes": {
+		"hash_index": {
+			"type": "hash"
+		}
+	},
+	"tables": {
+		"topo_seq": {
+			"type": "sequence",
+			"sequence_backend": {
+				"type": "topo",
+				"block_size": 10,
+				"start": 1
+			}
+		},
+		"topo_auto": {
+			"column_vindexes": [
+				{
+					"column": "id",
+					"name": "hash_index"
+				}
+			],
+			"auto_increment": {
+				"column": "id",
+				"sequence": "topo_seq"
+			}
+		}
+	}
+}
+`
+	executor, _, _, sbclookup, ctx := createCustomExecutor(t, vschema, config.DefaultMySQLVersion)
+	session := &vtgatepb.Session{TargetString: "@primary"}
+
+	qr, err := executorExec(ctx, executor, session, "show vitess_sequences", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Fields, 12)
+	assert.Empty(t, qr.Rows)
+
+	qr, err = executorExec(ctx, executor, session, "insert into topo_auto(v) values (1), (2)", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, qr.InsertID)
+	// The values are leased from the topo, not from a sequence table.
+	assert.Empty(t, sbclookup.Queries)
+
+	qr, err = executorExec(ctx, executor, session, "show vitess_sequences", nil)
+	require.NoError(t, err)
+	require.Len(t, qr.Rows, 1)
+	row := qr.Rows[0]
+	assert.Equal(t, []sqltypes.Value{
+		sqltypes.NewVarChar(KsTestSharded),
+		sqltypes.NewVarChar("topo_seq"),
+		sqltypes.NewVarChar("topo"),
+		sqltypes.NewInt64(10),
+		sqltypes.NewInt64(1),
+		sqltypes.NewInt64(10),
+		sqltypes.NewInt64(3),
+		sqltypes.NewInt64(8),
+		sqltypes.NewInt64(1),
+		sqltypes.NewVarChar("topo"),
+	}, row[:10])
+	assert.NotEmpty(t, row[10].ToString())
+
+	// Another vtgate leases the next block.
+	ts, err := executor.serv.GetTopoServer()
+	require.NoError(t, err)
+	first, err := ts.LeaseSequenceBlock(ctx, KsTestSharded, "topo_seq", 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, first)
+}
@@ -81,6 +81,8 @@ type (
 		showShards(ctx context.Context, filter *sqlparser.ShowFilter, destTabletType topodatapb.TabletType) (*sqltypes.Result, error)
 		showTablets(filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
 		showVitessMetadata(ctx context.Context, filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
+		showVitessQueries() (*sqltypes.Result, error)
+		showVitessSequences() (*sqltypes.Result, error)
 		setVitessMetadata(ctx context.Context, name, value string) error
 
 		// TODO: remove when resolver is gone
@@ -119,11 +121,16 @@ type (
 		// A nil value represents that no foreign_key_checks value was provided.
 		fkChecksState       *bool
 		ignoreMaxMemoryRows bool
+		spiller             *engine.Spiller
+		memory              *engine.MemoryTracker
+		scatterLimiter      *engine.ScatterLimiter
+		sequences           *engine.SequenceCache
 		vschema             *vindexes.VSchema
 		vm                  VSchemaOperator
 		semTable            *semantics.SemTable
 		warnShardedOnly     bool // when using sharded only features, a warning will be warnings field
 		queryTimeout        time.Duration
+		resultCacheTTL      time.Duration // set with the RESULT_CACHE_TTL directive
 
 		warnings []*querypb.QueryWarning // any warnings that are accumulated during the planning phase are stored here
 		pv       plancontext.PlannerVersion
@@ -184,9 +191,11 @@ func newVCursorImpl(
 
 	warmingReadsPct := 0
 	var warmingReadsChan chan bool
+	var sequences *engine.SequenceCache
 	if executor != nil {
 		warmingReadsPct = executor.warmingReadsPercent
 		warmingReadsChan = executor.warmingReadsChannel
+		sequences = executor.sequences
 	}
 	return &vcursorImpl{
 		safeSession:         safeSession,
@@ -206,6 +215,9 @@ func newVCursorImpl(
 		warmingReadsPercent: warmingReadsPct,
 		warmingReadsChannel: warmingReadsChan,
 		resultsObserver:     nullResultsObserver{},
+		spiller:             newSpiller(),
+		scatterLimiter:      engine.NewScatterLimiter(scatterConcurrency),
+		sequences:           sequences,
 	}, nil
 }
 
@@ -250,6 +262,40 @@ func (vc *vcursorImpl) ExceedsMaxMemoryRows(numRows int) bool {
 	return !vc.ignoreMaxMemoryRows && numRows > maxMemoryRows
 }
 
+// Spiller returns the Spiller used to move intermediate rows to disk, or nil if spilling is disabled.
+func (vc *vcursorImpl) Spiller() *engine.Spiller {
+	return vc.spiller
+}
+
+// MemoryTracker returns the tracker of the memory held by the rows of the query, or nil if it is not tracked.
+func (vc *vcursorImpl) MemoryTracker() *engine.MemoryTracker {
+	return vc.memory
+}
+
+// ScatterLimiter returns the limiter of the number of shards the query runs on at once.
+func (vc *vcursorImpl) ScatterLimiter() *engine.ScatterLimiter {
+	return vc.scatterLimiter
+}
+
+// SequenceCache returns the cache of the values of the sequences with a backend, or nil if they are not cached.
+func (vc *vcursorImpl) SequenceCache() *engine.SequenceCache {
+	return vc.sequences
+}
+
+// SetScatterConcurrency overrides the number of shards the query runs on at once, if concurrency is not zero.
+func (vc *vcursorImpl) SetScatterConcurrency(concurrency int) {
+	if concurrency > 0 {
+		vc.scatterLimiter = engine.NewScatterLimiter(concurrency)
+	}
+}
+
+func newSpiller() *engine.Spiller {
+	if spillToDiskDir == "" {
+		return nil
+	}
+	return engine.NewSpiller(spillToDiskDir, spillToDiskMaxBytes)
+}
+
 // SetIgnoreMaxMemoryRows sets the ignoreMaxMemoryRows value.
 func (vc *vcursorImpl) SetIgnoreMaxMemoryRows(ignoreMaxMemoryRows bool) {
 	vc.ignoreMaxMemoryRows = ignoreMaxMemoryRows
@@ -325,6 +371,11 @@ func (vc *vcursorImpl) FindView(name sqlparser.TableName) sqlparser.SelectStatem
 	return vc.vschema.FindView(ks, name.Name.String())
 }
 
+// IsVindexExpression returns true if a vindex is declared over the expression
+func (vc *vcursorImpl) IsVindexExpression(expr sqlparser.Expr) bool {
+	return vc.vschema.IsVindexExpression(expr)
+}
+
 func (vc *vcursorImpl) FindRoutedTable(name sqlparser.TableName) (*vindexes.Table, error) {
 	destKeyspace, destTabletType, _, err := vc.executor.ParseDestinationTarget(name.Qualifier.String())
 	if err != nil {
@@ -659,6 +710,7 @@ func (vc *vcursorImpl) ExecuteMultiShard(ctx context.Context, primitive engine.P
 		return nil, []error{err}
 	}
 
+	ctx = withScatterLimiter(ctx, vc.scatterLimiter)
 	qr, errs := vc.executor.ExecuteMultiShard(ctx, primitive, rss, commentedShardQueries(queries, vc.marginComments), vc.safeSession, canAutocommit, vc.ignoreMaxMemoryRows, vc.resultsObserver)
 	vc.setRollbackOnPartialExecIfRequired(len(errs) != len(rss), rollbackOnError)
 	vc.logShardsQueried(primitive, len(rss))
@@ -676,6 +728,7 @@ func (vc *vcursorImpl) StreamExecuteMulti(ctx context.Context, primitive engine.
 		return []error{err}
 	}
 
+	ctx = withScatterLimiter(ctx, vc.scatterLimiter)
 	errs := vc.executor.StreamExecuteMulti(ctx, primitive, vc.marginComments.Leading+query+vc.marginComments.Trailing, rss, bindVars, vc.safeSession, autocommit, callback, vc.resultsObserver)
 	vc.setRollbackOnPartialExecIfRequired(len(errs) != len(rss), rollbackOnError)
 
@@ -1303,6 +1356,10 @@ func (vc *vcursorImpl) ShowExec(ctx context.Context, command sqlparser.ShowComma
 		return vc.executor.showTablets(filter)
 	case sqlparser.VitessVariables:
 		return vc.executor.showVitessMetadata(ctx, filter)
+	case sqlparser.VitessQueries:
+		return vc.executor.showVitessQueries()
+	case sqlparser.VitessSequences:
+		return vc.executor.showVitessSequences()
 	default:
 		return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "bug: unexpected show command: %v", command)
 	}
@@ -1405,6 +1462,10 @@ func (vc *vcursorImpl) cloneWithAutocommitSession() *vcursorImpl {
 		warnShardedOnly: vc.warnShardedOnly,
 		pv:              vc.pv,
 		resultsObserver: vc.resultsObserver,
+		spiller:         vc.spiller,
+		memory:          vc.memory,
+		scatterLimiter:  vc.scatterLimiter,
+		sequences:       vc.sequences,
 	}
 }
 
@@ -1478,6 +1539,9 @@ func (vc *vcursorImpl) CloneForReplicaWarming(ctx context.Context) engine.VCurso
 		warnings:            vc.warnings,
 		pv:                  vc.pv,
 		resultsObserver:     nullResultsObserver{},
+		spiller:             newSpiller(),
+		scatterLimiter:      engine.NewScatterLimiter(vc.scatterLimiter.Concurrency()),
+		sequences:           vc.sequences,
 	}
 
 	v.marginComments.Trailing += "/* warming read */"
@@ -1510,6 +1574,9 @@ func (vc *vcursorImpl) CloneForMirroring(ctx context.Context) engine.VCursor {
 		warnings:            vc.warnings,
 		pv:                  vc.pv,
 		resultsObserver:     nullResultsObserver{},
+		spiller:             newSpiller(),
+		scatterLimiter:      engine.NewScatterLimiter(vc.scatterLimiter.Concurrency()),
+		sequences:           vc.sequences,
 	}
 
 	v.marginComments.Trailing += "/* mirror query */"
@@ -29,6 +29,7 @@ var (
 	_ SingleColumn    = (*Binary)(nil)
 	_ Reversible      = (*Binary)(nil)
 	_ Hashing         = (*Binary)(nil)
+	_ RangeMapper     = (*Binary)(nil)
 	_ ParamValidating = (*Binary)(nil)
 )
 
@@ -92,6 +93,11 @@ func (vind *Binary) Map(ctx context.Context, vcursor VCursor, ids []sqltypes.Val
 	return out, nil
 }
 
+// MapRange returns the key range holding all the ids between from and to, both included.
+func (*Binary) MapRange(from, to sqltypes.Value) (key.Destination, error) {
+	return mapBytesRange(from, to)
+}
+
 func (vind *Binary) Hash(id sqltypes.Value) ([]byte, error) {
 	return id.ToBytes()
 }
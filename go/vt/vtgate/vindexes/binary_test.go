@@ -130,6 +130,20 @@ func TestBinaryVerify(t *testing.T) {
 	}
 }
 
+func TestBinaryMapRange(t *testing.T) {
+	dest, err := binOnlyVindex.(RangeMapper).MapRange(sqltypes.NewVarChar("abc"), sqltypes.NewVarBinary("abd"))
+	require.NoError(t, err)
+	require.Equal(t, "DestinationKeyRange(616263-61626400)", dest.String())
+
+	dest, err = binOnlyVindex.(RangeMapper).MapRange(sqltypes.NewVarChar("abc"), sqltypes.NULL)
+	require.NoError(t, err)
+	require.Equal(t, "DestinationKeyRange(616263-)", dest.String())
+
+	// numbers are not compared byte by byte
+	_, err = binOnlyVindex.(RangeMapper).MapRange(sqltypes.NewInt64(1), sqltypes.NULL)
+	require.Error(t, err)
+}
+
 func TestBinaryReverseMap(t *testing.T) {
 	got, err := binOnlyVindex.(Reversible).ReverseMap(nil, [][]byte{[]byte("\x00\x00\x00\x00\x00\x00\x00\x01")})
 	require.NoError(t, err)
@@ -87,7 +87,7 @@ func (cached *ColumnVindex) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(112)
+		size += int64(128)
 	}
 	// field Columns []vitess.io/vitess/go/vt/sqlparser.IdentifierCI
 	{
@@ -104,6 +104,10 @@ func (cached *ColumnVindex) CachedSize(alloc bool) int64 {
 	if cc, ok := cached.Vindex.(cachedObject); ok {
 		size += cc.CachedSize(true)
 	}
+	// field Expression vitess.io/vitess/go/vt/sqlparser.Expr
+	if cc, ok := cached.Expression.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
 	return size
 }
 func (cached *ConsistentLookup) CachedSize(alloc bool) int64 {
@@ -416,6 +420,34 @@ func (cached *NumericStaticMap) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *RangeMap) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(80)
+	}
+	// field name string
+	size += hack.RuntimeAllocSize(int64(len(cached.name)))
+	// field topoPath string
+	size += hack.RuntimeAllocSize(int64(len(cached.topoPath)))
+	// field ranges []vitess.io/vitess/go/vt/vtgate/vindexes.rangeMapEntry
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ranges)) * int64(32))
+		for _, elem := range cached.ranges {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field unknownParams []string
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.unknownParams)) * int64(16))
+		for _, elem := range cached.unknownParams {
+			size += hack.RuntimeAllocSize(int64(len(elem)))
+		}
+	}
+	return size
+}
 func (cached *RegionExperimental) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -489,6 +521,37 @@ func (cached *ReverseBits) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *SequenceBackend) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(64)
+	}
+	// field Type string
+	size += hack.RuntimeAllocSize(int64(len(cached.Type)))
+	// field ShardRanges []vitess.io/vitess/go/vt/vtgate/vindexes.SequenceShardRange
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ShardRanges)) * int64(32))
+		for _, elem := range cached.ShardRanges {
+			size += elem.CachedSize(false)
+		}
+	}
+	return size
+}
+func (cached *SequenceShardRange) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(32)
+	}
+	// field Shard string
+	size += hack.RuntimeAllocSize(int64(len(cached.Shard)))
+	return size
+}
 func (cached *UnicodeLooseMD5) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -645,3 +708,17 @@ func (cached *prefixCFC) CachedSize(alloc bool) int64 {
 	size += cached.cfcCommon.CachedSize(true)
 	return size
 }
+func (cached *rangeMapEntry) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(32)
+	}
+	// field ksid []byte
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ksid)))
+	}
+	return size
+}
@@ -35,6 +35,7 @@ const (
 
 var (
 	_ ParamValidating = (*CFC)(nil)
+	_ RangeMapper     = (*CFC)(nil)
 
 	cfcParams = []string{
 		cfcParamHash,
@@ -53,8 +54,9 @@ var (
 // this vindex maps the full key, i.e. (s1, s2, ... sN) to a
 // `key.DestinationKeyspaceID` and the prefix of it, i.e. (s1, s2, ... sj)(j<N)
 // to a `key.DestinationKeyRange`. Note that the prefix to key range mapping is
-// only active in 'LIKE' expression. When a column with CFC defined appears in
-// other expressions, e.g. =, !=, IN etc, it behaves exactly as other
+// only active in 'LIKE' expression, and in range predicates like 'BETWEEN'
+// for the components shared by both bounds. When a column with CFC defined
+// appears in other expressions, e.g. =, !=, IN etc, it behaves exactly as other
 // functional unique vindexes.
 //
 // This provides the capability to model hierarchical data models. If we
@@ -279,6 +281,47 @@ func (vind *CFC) PrefixVindex() SingleColumn {
 	return vind.prefixCFC
 }
 
+// MapRange returns the key range holding all the ids between from and to, both included.
+// Without hash, the keyspace ids are the ids themselves, so they keep their order. With a
+// hash, all the ids of the range share the components that are the same in both bounds,
+// and the range maps to the key range of this prefix.
+func (vind *CFC) MapRange(from, to sqltypes.Value) (key.Destination, error) {
+	if vind.hash == nil {
+		return mapBytesRange(from, to)
+	}
+	if from.IsNull() || to.IsNull() {
+		return key.DestinationAllShards{}, nil
+	}
+	start, err := rangeBoundBytes(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := rangeBoundBytes(to)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Compare(start, end) > 0 {
+		return key.DestinationNone{}, nil
+	}
+
+	common := 0
+	for common < len(start) && common < len(end) && start[common] == end[common] {
+		common++
+	}
+	prefix := 0
+	for _, offset := range vind.offsets {
+		if offset > common {
+			break
+		}
+		prefix = offset
+	}
+	ksid, err := vind.computeKsid(start[:prefix], true)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyRangeFromPrefix(ksid), nil
+}
+
 // NewKeyRangeFromPrefix creates a keyspace range from a prefix of keyspace id.
 func NewKeyRangeFromPrefix(begin []byte) key.Destination {
 	if len(begin) == 0 {
@@ -432,6 +432,77 @@ func TestCFCPrefixMap(t *testing.T) {
 	}
 }
 
+func TestCFCMapRange(t *testing.T) {
+	var cfc, noHash RangeMapper = makeCFC(t, map[string]string{"hash": "md5", "offsets": "[3,5]"}), makeCFC(t, nil)
+
+	cases := []struct {
+		testName string
+		vindex   RangeMapper
+		from, to sqltypes.Value
+		dest     key.Destination
+	}{
+		{
+			testName: "no hash",
+			vindex:   noHash,
+			from:     sqltypes.NewVarBinary("abc"),
+			to:       sqltypes.NewVarBinary("abd"),
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte("abc"), End: []byte("abd\x00")}},
+		},
+		{
+			testName: "no hash, unbounded",
+			vindex:   noHash,
+			from:     sqltypes.NULL,
+			to:       sqltypes.NewVarChar("abd"),
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{End: []byte("abd\x00")}},
+		},
+		{
+			testName: "common components",
+			vindex:   cfc,
+			from:     sqltypes.NewVarBinary("abcdef"),
+			to:       sqltypes.NewVarBinary("abcdez"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}, {'d', 'e'}})),
+		},
+		{
+			testName: "common first component",
+			vindex:   cfc,
+			from:     sqltypes.NewVarBinary("abcdef"),
+			to:       sqltypes.NewVarBinary("abcz"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}})),
+		},
+		{
+			testName: "no common component",
+			vindex:   cfc,
+			from:     sqltypes.NewVarBinary("abcdef"),
+			to:       sqltypes.NewVarBinary("abz"),
+			dest:     key.DestinationAllShards{},
+		},
+		{
+			testName: "unbounded",
+			vindex:   cfc,
+			from:     sqltypes.NewVarBinary("abcdef"),
+			to:       sqltypes.NULL,
+			dest:     key.DestinationAllShards{},
+		},
+		{
+			testName: "empty range",
+			vindex:   cfc,
+			from:     sqltypes.NewVarBinary("abz"),
+			to:       sqltypes.NewVarBinary("abc"),
+			dest:     key.DestinationNone{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.testName, func(t *testing.T) {
+			dest, err := tc.vindex.MapRange(tc.from, tc.to)
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.dest, dest)
+		})
+	}
+
+	_, err := cfc.MapRange(sqltypes.NewInt64(1), sqltypes.NewInt64(2))
+	require.Error(t, err)
+}
+
 func TestCFCPrefixQueryMapNoHash(t *testing.T) {
 	cfc := makeCFC(t, nil)
 	prefixcfc := cfc.PrefixVindex()
@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
@@ -30,6 +31,7 @@ var (
 	_ SingleColumn    = (*Numeric)(nil)
 	_ Reversible      = (*Numeric)(nil)
 	_ Hashing         = (*Numeric)(nil)
+	_ RangeMapper     = (*Numeric)(nil)
 	_ ParamValidating = (*Numeric)(nil)
 )
 
@@ -113,6 +115,27 @@ func (vind *Numeric) UnknownParams() []string {
 	return vind.unknownParams
 }
 
+// MapRange returns the key range holding all the ids between from and to, both included.
+func (vind *Numeric) MapRange(from, to sqltypes.Value) (key.Destination, error) {
+	var start, end []byte
+	var err error
+	if !from.IsNull() {
+		if start, err = vind.Hash(from); err != nil {
+			return nil, err
+		}
+	}
+	if !to.IsNull() {
+		num, err := to.ToCastUint64()
+		if err != nil {
+			return nil, err
+		}
+		if num < math.MaxUint64 {
+			end = binary.BigEndian.AppendUint64(nil, num+1)
+		}
+	}
+	return keyRangeBetween(start, end), nil
+}
+
 func (*Numeric) Hash(id sqltypes.Value) ([]byte, error) {
 	num, err := id.ToCastUint64()
 	if err != nil {
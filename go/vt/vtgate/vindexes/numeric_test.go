@@ -25,6 +25,7 @@ import (
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 )
 
 var numeric SingleColumn
@@ -130,6 +131,40 @@ func TestNumericVerify(t *testing.T) {
 	require.EqualError(t, err, "cannot parse uint64 from \"aa\"")
 }
 
+func TestNumericMapRange(t *testing.T) {
+	keyRange := func(start, end string) key.Destination {
+		return key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte(start), End: []byte(end)}}
+	}
+	tests := []struct {
+		from, to sqltypes.Value
+		dest     key.Destination
+	}{{
+		from: sqltypes.NewInt64(1),
+		to:   sqltypes.NewInt64(2),
+		dest: keyRange("\x00\x00\x00\x00\x00\x00\x00\x01", "\x00\x00\x00\x00\x00\x00\x00\x03"),
+	}, {
+		from: sqltypes.NULL,
+		to:   sqltypes.NewVarChar("255"),
+		dest: keyRange("", "\x00\x00\x00\x00\x00\x00\x01\x00"),
+	}, {
+		from: sqltypes.NewInt64(256),
+		to:   sqltypes.NewUint64(18446744073709551615),
+		dest: keyRange("\x00\x00\x00\x00\x00\x00\x01\x00", ""),
+	}, {
+		from: sqltypes.NewInt64(2),
+		to:   sqltypes.NewInt64(1),
+		dest: key.DestinationNone{},
+	}}
+	for _, tt := range tests {
+		dest, err := numeric.(RangeMapper).MapRange(tt.from, tt.to)
+		require.NoError(t, err)
+		require.Equal(t, tt.dest.String(), dest.String())
+	}
+
+	_, err := numeric.(RangeMapper).MapRange(sqltypes.NewInt64(-1), sqltypes.NewInt64(2))
+	require.Error(t, err)
+}
+
 func TestNumericReverseMap(t *testing.T) {
 	got, err := numeric.(Reversible).ReverseMap(nil, [][]byte{[]byte("\x00\x00\x00\x00\x00\x00\x00\x01")})
 	require.NoError(t, err)
@@ -0,0 +1,260 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+const (
+	rangeMapParamJSON     = "json"
+	rangeMapParamTopoPath = "topo_path"
+)
+
+var (
+	_ SingleColumn    = (*RangeMap)(nil)
+	_ Hashing         = (*RangeMap)(nil)
+	_ RangeMapper     = (*RangeMap)(nil)
+	_ ParamValidating = (*RangeMap)(nil)
+
+	rangeMapParams = []string{
+		rangeMapParamJSON,
+		rangeMapParamTopoPath,
+	}
+)
+
+// RangeMapEntry is an entry of the split table of a RangeMap vindex:
+// all the ids from From up to the From of the next entry map to KeyspaceID.
+type RangeMapEntry struct {
+	From       uint64 `json:"from"`
+	KeyspaceID string `json:"keyspace_id"`
+}
+
+type rangeMapEntry struct {
+	from uint64
+	ksid []byte
+}
+
+// RangeMap is a unique vindex that maps ranges of numeric ids to keyspace ids,
+// so that sequential ids stay together instead of being scattered by a hash.
+// The split table is a JSON list of RangeMapEntry, sorted by id, and the
+// keyspace ids must be increasing too, which keeps the order of the ids
+// and lets a range of ids be mapped to a key range.
+//
+// The split table is either given in the `json` param, or stored in the
+// global topo at `topo_path`, in which case vtgate loads it when building
+// the VSchema and reloads it when the file changes.
+type RangeMap struct {
+	name          string
+	topoPath      string
+	ranges        []rangeMapEntry
+	unknownParams []string
+}
+
+func init() {
+	Register("range_map", newRangeMap)
+}
+
+// newRangeMap creates a RangeMap vindex.
+func newRangeMap(name string, params map[string]string) (Vindex, error) {
+	jsonStr, jsok := params[rangeMapParamJSON]
+	topoPath, tpok := params[rangeMapParamTopoPath]
+
+	if !jsok && !tpok {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: Could not find either `json` or `topo_path` params in vschema")
+	}
+	if jsok && tpok {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: Found both `json` and `topo_path` params in vschema")
+	}
+
+	rm := &RangeMap{
+		name:          name,
+		topoPath:      topoPath,
+		unknownParams: FindUnknownParams(params, rangeMapParams),
+	}
+	if jsok {
+		if err := rm.LoadRanges([]byte(jsonStr)); err != nil {
+			return nil, err
+		}
+	}
+	return rm, nil
+}
+
+// String returns the name of the vindex.
+func (rm *RangeMap) String() string {
+	return rm.name
+}
+
+// Cost returns the cost of this vindex as 1.
+func (*RangeMap) Cost() int {
+	return 1
+}
+
+// IsUnique returns true since the Vindex is unique.
+func (*RangeMap) IsUnique() bool {
+	return true
+}
+
+// NeedsVCursor satisfies the Vindex interface.
+func (*RangeMap) NeedsVCursor() bool {
+	return false
+}
+
+// TopoPath returns the path of the split table in the global topo,
+// or an empty string if the split table is in the VSchema.
+func (rm *RangeMap) TopoPath() string {
+	return rm.topoPath
+}
+
+// LoadRanges parses the split table and sets it as the ranges of the vindex.
+// It must be called before the vindex is used, since the vindex is not locked.
+func (rm *RangeMap) LoadRanges(data []byte) error {
+	var entries []RangeMapEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: invalid split table: %v", err)
+	}
+	if len(entries) == 0 {
+		return vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: the split table is empty")
+	}
+	ranges := make([]rangeMapEntry, 0, len(entries))
+	for i, entry := range entries {
+		ksid, err := hex.DecodeString(entry.KeyspaceID)
+		if err != nil {
+			return vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: invalid keyspace id %q: %v", entry.KeyspaceID, err)
+		}
+		if i > 0 {
+			prev := ranges[i-1]
+			if entry.From <= prev.from {
+				return vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: the ranges must be sorted by id, %d comes after %d", entry.From, prev.from)
+			}
+			if bytes.Compare(ksid, prev.ksid) <= 0 {
+				return vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: the keyspace ids must be increasing, %q comes after %q", entry.KeyspaceID, hex.EncodeToString(prev.ksid))
+			}
+		}
+		ranges = append(ranges, rangeMapEntry{from: entry.From, ksid: ksid})
+	}
+	rm.ranges = ranges
+	return nil
+}
+
+// Verify returns true if ids and ksids match.
+func (rm *RangeMap) Verify(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, ksids [][]byte) ([]bool, error) {
+	out := make([]bool, 0, len(ids))
+	for i, id := range ids {
+		ksid, err := rm.Hash(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, bytes.Equal(ksid, ksids[i]))
+	}
+	return out, nil
+}
+
+// Map can map ids to key.Destination objects.
+func (rm *RangeMap) Map(ctx context.Context, vcursor VCursor, ids []sqltypes.Value) ([]key.Destination, error) {
+	if err := rm.checkLoaded(); err != nil {
+		return nil, err
+	}
+	out := make([]key.Destination, 0, len(ids))
+	for _, id := range ids {
+		ksid, err := rm.Hash(id)
+		if err != nil || ksid == nil {
+			out = append(out, key.DestinationNone{})
+			continue
+		}
+		out = append(out, key.DestinationKeyspaceID(ksid))
+	}
+	return out, nil
+}
+
+// MapRange returns the key range holding all the ids between from and to, both included.
+// A NULL bound means that the range is not bounded on that side.
+func (rm *RangeMap) MapRange(from, to sqltypes.Value) (key.Destination, error) {
+	if err := rm.checkLoaded(); err != nil {
+		return nil, err
+	}
+	kr := &topodatapb.KeyRange{}
+	first := 0
+	if !from.IsNull() {
+		num, err := from.ToCastUint64()
+		if err != nil {
+			return nil, err
+		}
+		first = max(rm.find(num), 0)
+		kr.Start = rm.ranges[first].ksid
+	}
+	if !to.IsNull() {
+		num, err := to.ToCastUint64()
+		if err != nil {
+			return nil, err
+		}
+		last := rm.find(num)
+		if last < first {
+			return key.DestinationNone{}, nil
+		}
+		if last+1 < len(rm.ranges) {
+			kr.End = rm.ranges[last+1].ksid
+		}
+	}
+	return key.DestinationKeyRange{KeyRange: kr}, nil
+}
+
+// Hash returns the keyspace id of the range holding the id,
+// or nil if the id is before the first range.
+func (rm *RangeMap) Hash(id sqltypes.Value) ([]byte, error) {
+	if err := rm.checkLoaded(); err != nil {
+		return nil, err
+	}
+	num, err := id.ToCastUint64()
+	if err != nil {
+		return nil, err
+	}
+	i := rm.find(num)
+	if i < 0 {
+		return nil, nil
+	}
+	return rm.ranges[i].ksid, nil
+}
+
+// UnknownParams implements the ParamValidating interface.
+func (rm *RangeMap) UnknownParams() []string {
+	return rm.unknownParams
+}
+
+// find returns the index of the range holding num, or -1 if num is before the first range.
+func (rm *RangeMap) find(num uint64) int {
+	return sort.Search(len(rm.ranges), func(i int) bool {
+		return rm.ranges[i].from > num
+	}) - 1
+}
+
+func (rm *RangeMap) checkLoaded() error {
+	if rm.ranges == nil {
+		return vterrors.Errorf(vtrpc.Code_UNAVAILABLE, "RangeMap %s: the split table at %s has not been loaded from the topo", rm.name, rm.topoPath)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+const rangeMapTestSplits = `[
+	{"from": 1, "keyspace_id": "10"},
+	{"from": 1000, "keyspace_id": "40"},
+	{"from": 2000, "keyspace_id": "80"},
+	{"from": 5000, "keyspace_id": "c0"}
+]`
+
+func createRangeMap(t *testing.T) *RangeMap {
+	vindex, err := CreateVindex("range_map", "range_map", map[string]string{"json": rangeMapTestSplits})
+	require.NoError(t, err)
+	return vindex.(*RangeMap)
+}
+
+func rangeMapCreateVindexTestCase(
+	testName string,
+	vindexParams map[string]string,
+	expectErr error,
+	expectUnknownParams []string,
+) createVindexTestCase {
+	return createVindexTestCase{
+		testName: testName,
+
+		vindexType:   "range_map",
+		vindexName:   "range_map",
+		vindexParams: vindexParams,
+
+		expectCost:          1,
+		expectErr:           expectErr,
+		expectIsUnique:      true,
+		expectNeedsVCursor:  false,
+		expectString:        "range_map",
+		expectUnknownParams: expectUnknownParams,
+	}
+}
+
+func TestRangeMapCreateVindex(t *testing.T) {
+	cases := []createVindexTestCase{
+		rangeMapCreateVindexTestCase(
+			"no params invalid, require either json or topo_path",
+			nil,
+			vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: Could not find either `json` or `topo_path` params in vschema"),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"json and topo_path mutually exclusive",
+			map[string]string{
+				"json":      rangeMapTestSplits,
+				"topo_path": "range_maps/tenants",
+			},
+			vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "RangeMap: Found both `json` and `topo_path` params in vschema"),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"json ok",
+			map[string]string{
+				"json": rangeMapTestSplits,
+			},
+			nil,
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"topo_path ok",
+			map[string]string{
+				"topo_path": "range_maps/tenants",
+			},
+			nil,
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"json must not be empty",
+			map[string]string{
+				"json": "[]",
+			},
+			errors.New("RangeMap: the split table is empty"),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"ids must be sorted",
+			map[string]string{
+				"json": `[{"from": 10, "keyspace_id": "10"}, {"from": 5, "keyspace_id": "20"}]`,
+			},
+			errors.New("RangeMap: the ranges must be sorted by id, 5 comes after 10"),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"keyspace ids must be increasing",
+			map[string]string{
+				"json": `[{"from": 10, "keyspace_id": "20"}, {"from": 20, "keyspace_id": "10"}]`,
+			},
+			errors.New(`RangeMap: the keyspace ids must be increasing, "10" comes after "20"`),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"keyspace ids must be hex",
+			map[string]string{
+				"json": `[{"from": 10, "keyspace_id": "zz"}]`,
+			},
+			errors.New(`RangeMap: invalid keyspace id "zz": encoding/hex: invalid byte: U+007A 'z'`),
+			nil,
+		),
+		rangeMapCreateVindexTestCase(
+			"unknown params",
+			map[string]string{
+				"json":  rangeMapTestSplits,
+				"hello": "world",
+			},
+			nil,
+			[]string{"hello"},
+		),
+	}
+
+	testCreateVindexes(t, cases)
+}
+
+func TestRangeMapMap(t *testing.T) {
+	rm := createRangeMap(t)
+	got, err := rm.Map(context.Background(), nil, []sqltypes.Value{
+		sqltypes.NewInt64(0),
+		sqltypes.NewInt64(1),
+		sqltypes.NewInt64(999),
+		sqltypes.NewInt64(1000),
+		sqltypes.NewUint64(1 << 40),
+		sqltypes.NewVarChar("2500"),
+		sqltypes.NewVarChar("abcd"),
+	})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationNone{},
+		key.DestinationKeyspaceID([]byte{0x10}),
+		key.DestinationKeyspaceID([]byte{0x10}),
+		key.DestinationKeyspaceID([]byte{0x40}),
+		key.DestinationKeyspaceID([]byte{0xc0}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationNone{},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestRangeMapVerify(t *testing.T) {
+	rm := createRangeMap(t)
+	got, err := rm.Verify(context.Background(), nil,
+		[]sqltypes.Value{sqltypes.NewInt64(1500), sqltypes.NewInt64(1500), sqltypes.NewInt64(0)},
+		[][]byte{{0x40}, {0x80}, {0x10}})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false}, got)
+
+	_, err = rm.Verify(context.Background(), nil, []sqltypes.Value{sqltypes.NewVarChar("abcd")}, [][]byte{{0x10}})
+	require.Error(t, err)
+}
+
+func TestRangeMapMapRange(t *testing.T) {
+	rm := createRangeMap(t)
+	keyRange := func(start, end []byte) key.Destination {
+		return key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: start, End: end}}
+	}
+	tests := []struct {
+		from, to sqltypes.Value
+		want     key.Destination
+	}{{
+		from: sqltypes.NewInt64(10),
+		to:   sqltypes.NewInt64(20),
+		want: keyRange([]byte{0x10}, []byte{0x40}),
+	}, {
+		from: sqltypes.NewInt64(500),
+		to:   sqltypes.NewInt64(2000),
+		want: keyRange([]byte{0x10}, []byte{0xc0}),
+	}, {
+		from: sqltypes.NewInt64(0),
+		to:   sqltypes.NewInt64(1500),
+		want: keyRange([]byte{0x10}, []byte{0x80}),
+	}, {
+		from: sqltypes.NewInt64(3000),
+		to:   sqltypes.NULL,
+		want: keyRange([]byte{0x80}, nil),
+	}, {
+		from: sqltypes.NULL,
+		to:   sqltypes.NewInt64(1000),
+		want: keyRange(nil, []byte{0x80}),
+	}, {
+		from: sqltypes.NewInt64(6000),
+		to:   sqltypes.NewInt64(9000),
+		want: keyRange([]byte{0xc0}, nil),
+	}, {
+		from: sqltypes.NewInt64(0),
+		to:   sqltypes.NewInt64(0),
+		want: key.DestinationNone{},
+	}, {
+		from: sqltypes.NewInt64(3000),
+		to:   sqltypes.NewInt64(1000),
+		want: key.DestinationNone{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.from.String()+"-"+tt.to.String(), func(t *testing.T) {
+			got, err := rm.MapRange(tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRangeMapNotLoaded(t *testing.T) {
+	vindex, err := CreateVindex("range_map", "range_map", map[string]string{"topo_path": "range_maps/tenants"})
+	require.NoError(t, err)
+	rm := vindex.(*RangeMap)
+	assert.Equal(t, "range_maps/tenants", rm.TopoPath())
+
+	_, err = rm.Map(context.Background(), nil, []sqltypes.Value{sqltypes.NewInt64(1)})
+	require.EqualError(t, err, "RangeMap range_map: the split table at range_maps/tenants has not been loaded from the topo")
+
+	require.NoError(t, rm.LoadRanges([]byte(rangeMapTestSplits)))
+	got, err := rm.Map(context.Background(), nil, []sqltypes.Value{sqltypes.NewInt64(1)})
+	require.NoError(t, err)
+	assert.Equal(t, []key.Destination{key.DestinationKeyspaceID([]byte{0x10})}, got)
+}
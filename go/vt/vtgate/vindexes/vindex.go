@@ -17,6 +17,7 @@ limitations under the License.
 package vindexes
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sort"
@@ -29,6 +30,7 @@ import (
 	"vitess.io/vitess/go/vt/vterrors"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
@@ -138,6 +140,19 @@ type (
 		PrefixVindex() SingleColumn
 	}
 
+	// A RangeMapper vindex is one that can map a range of ids to the key range
+	// holding all of them, usually because it keeps the order of the ids in the
+	// keyspace ids. It's being used to reduce the fan out for range predicates
+	// like 'BETWEEN', '<' and '>'.
+	RangeMapper interface {
+		SingleColumn
+		// MapRange returns the key range holding all the ids between from and to,
+		// both included. A NULL bound means that the range is not bounded on that side.
+		// An error means that the range cannot be mapped, and the query has to be sent
+		// to all the shards.
+		MapRange(from, to sqltypes.Value) (key.Destination, error)
+	}
+
 	// A Lookup vindex is one that needs to lookup
 	// a previously stored map to compute the keyspace
 	// id from an id. This means that the creation of
@@ -238,6 +253,54 @@ func firstColsOnly(rowsColValues [][]sqltypes.Value) []sqltypes.Value {
 	return firstCols
 }
 
+// keyRangeBetween returns the destination of the keyspace ids from start included to end excluded,
+// a nil bound meaning that the range is not bounded on that side.
+func keyRangeBetween(start, end []byte) key.Destination {
+	if start != nil && end != nil && bytes.Compare(start, end) >= 0 {
+		return key.DestinationNone{}
+	}
+	return key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: start, End: end}}
+}
+
+// MapsRangeOfBytes returns true if the vindex maps a range of ids by comparing their bytes.
+// This only holds for the ids of a column using the binary collation, since other
+// collations can order and compare the strings differently, e.g. without case or trailing spaces.
+func MapsRangeOfBytes(v RangeMapper) bool {
+	switch v.(type) {
+	case *Binary, *CFC:
+		return true
+	}
+	return false
+}
+
+// mapBytesRange maps a range of ids to a key range for the vindexes using the bytes of the ids as
+// keyspace ids. Since MySQL compares strings to numbers as numbers, only strings are mapped.
+func mapBytesRange(from, to sqltypes.Value) (key.Destination, error) {
+	var start, end []byte
+	var err error
+	if !from.IsNull() {
+		if start, err = rangeBoundBytes(from); err != nil {
+			return nil, err
+		}
+	}
+	if !to.IsNull() {
+		if end, err = rangeBoundBytes(to); err != nil {
+			return nil, err
+		}
+		// the first keyspace id after the bytes of the id
+		end = append(bytes.Clone(end), 0)
+	}
+	return keyRangeBetween(start, end), nil
+}
+
+// rangeBoundBytes returns the bytes of a bound of a range of string ids
+func rangeBoundBytes(v sqltypes.Value) ([]byte, error) {
+	if !v.IsText() && !v.IsBinary() {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "cannot map a range of strings bounded by a %s", v.Type())
+	}
+	return v.Raw(), nil
+}
+
 // FindUnknownParams a sorted slice of keys in params that are not present in knownParams.
 func FindUnknownParams(params map[string]string, knownParams []string) []string {
 	var unknownParams []string
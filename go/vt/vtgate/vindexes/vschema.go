@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -60,6 +61,17 @@ const (
 	TypeReference = "reference"
 )
 
+// The following constants represent the backends of the sequences
+// whose values vtgate leases in blocks and caches.
+const (
+	SequenceBackendTopo   = "topo"
+	SequenceBackendShards = "shards"
+
+	// DefaultSequenceBlockSize is the number of values of a sequence
+	// leased at once when its backend doesn't tell.
+	DefaultSequenceBlockSize = 1000
+)
+
 // VSchema represents the denormalized version of SrvVSchema,
 // used for building routing plans.
 type VSchema struct {
@@ -75,6 +87,8 @@ type VSchema struct {
 	Keyspaces            map[string]*KeyspaceSchema `json:"keyspaces"`
 	ShardRoutingRules    map[string]string          `json:"shard_routing_rules"`
 	KeyspaceRoutingRules map[string]string          `json:"keyspace_routing_rules"`
+	// vindexExpressions are the vindexes of all the keyspaces that are declared over an expression.
+	vindexExpressions []*ColumnVindex
 	// created is the time when the VSchema object was created. Used to detect if a cached
 	// copy of the vschema is stale.
 	created time.Time
@@ -141,6 +155,14 @@ type Table struct {
 	// Source is a keyspace-qualified table name that points to the source of a
 	// reference table. Only applicable for tables with Type set to "reference".
 	Source *Source `json:"source,omitempty"`
+	// ResultCacheTTL is how long vtgate can cache the results of read-only
+	// queries that only reference cached tables. Zero disables caching.
+	ResultCacheTTL time.Duration `json:"result_cache_ttl,omitempty"`
+	// Stats are the row estimates reported by the tablets, used by the planner to order joins.
+	Stats *TableStats `json:"stats,omitempty"`
+	// SequenceBackend is set for the sequences whose values vtgate leases
+	// in blocks and caches. Only applicable for tables with Type set to "sequence".
+	SequenceBackend *SequenceBackend `json:"sequence_backend,omitempty"`
 
 	ChildForeignKeys  []ChildFKInfo  `json:"child_foreign_keys,omitempty"`
 	ParentForeignKeys []ParentFKInfo `json:"parent_foreign_keys,omitempty"`
@@ -174,6 +196,10 @@ type ColumnVindex struct {
 	cost     int
 	partial  bool
 	backfill bool
+
+	// Expression is set when the vindex is declared over an expression of the
+	// Columns instead of a column, in which case it maps the value of the expression.
+	Expression sqlparser.Expr `json:"-"`
 }
 
 // TableInfo contains column and foreign key info for a table.
@@ -181,6 +207,21 @@ type TableInfo struct {
 	Columns     []Column
 	ForeignKeys []*sqlparser.ForeignKeyDefinition
 	Indexes     []*sqlparser.IndexDefinition
+	Stats       *TableStats
+}
+
+// TableStats are the estimates MySQL keeps in information_schema for a table,
+// as reported by the primary tablets of the shards of its keyspace.
+type TableStats struct {
+	// Rows is the estimated number of rows of the table in a shard,
+	// averaged over the shards that reported it.
+	Rows uint64 `json:"rows"`
+	// Cardinality is the estimated number of distinct values in a shard of the
+	// columns that are the first column of an index, by lowercase column name,
+	// averaged over the shards that reported it.
+	Cardinality map[string]uint64 `json:"cardinality,omitempty"`
+	// Shards is the number of shards that reported the table.
+	Shards int `json:"shards,omitempty"`
 }
 
 // IsUnique is used to tell whether the ColumnVindex
@@ -206,6 +247,33 @@ func (c *ColumnVindex) IsBackfilling() bool {
 	return c.backfill
 }
 
+// MatchesExpression returns true if the vindex is declared over an expression
+// equal to expr, the qualifiers of the columns of expr being ignored.
+func (c *ColumnVindex) MatchesExpression(expr sqlparser.Expr) bool {
+	return c.Expression != nil && unqualifiedComparator.Expr(c.Expression, expr)
+}
+
+// MarshalJSON returns a JSON representation of ColumnVindex.
+func (c *ColumnVindex) MarshalJSON() ([]byte, error) {
+	type columnVindex ColumnVindex
+	cj := struct {
+		*columnVindex
+		Expression string `json:"expression,omitempty"`
+	}{
+		columnVindex: (*columnVindex)(c),
+	}
+	if c.Expression != nil {
+		cj.Expression = sqlparser.String(c.Expression)
+	}
+	return json.Marshal(cj)
+}
+
+var unqualifiedComparator = &sqlparser.Comparator{
+	RefOfColName_: func(a, b *sqlparser.ColName) bool {
+		return a.Name.Equal(b.Name)
+	},
+}
+
 // Column describes a column.
 type Column struct {
 	Name          sqlparser.IdentifierCI `json:"name"`
@@ -333,6 +401,34 @@ type AutoIncrement struct {
 	Sequence *Table                 `json:"sequence"`
 }
 
+// SequenceBackend tells where vtgate leases the blocks of values of a sequence from.
+type SequenceBackend struct {
+	Type      string `json:"type"`
+	BlockSize int64  `json:"block_size"`
+	Start     int64  `json:"start"`
+	// ShardRanges are the values handed out by the sequence table of
+	// each shard with the shards backend, sorted by their start.
+	ShardRanges []SequenceShardRange `json:"shard_ranges,omitempty"`
+}
+
+// SequenceShardRange is the range of values of the sequence table of a shard,
+// from Start to End excluded.
+type SequenceShardRange struct {
+	Shard string `json:"shard"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+}
+
+// ShardRange returns the range of values of the sequence table of a shard.
+func (sb *SequenceBackend) ShardRange(shard string) (SequenceShardRange, bool) {
+	for _, r := range sb.ShardRanges {
+		if r.Shard == shard {
+			return r, true
+		}
+	}
+	return SequenceShardRange{}, false
+}
+
 type Source struct {
 	sqlparser.TableName
 }
@@ -354,6 +450,7 @@ func BuildVSchema(source *vschemapb.SrvVSchema, parser *sqlparser.Parser) (vsche
 		created:        time.Now(),
 	}
 	buildKeyspaces(source, vschema, parser)
+	buildVindexExpressions(vschema)
 	// buildGlobalTables before buildReferences so that buildReferences can
 	// resolve sources which reference global tables.
 	buildGlobalTables(source, vschema)
@@ -461,6 +558,18 @@ func (vschema *VSchema) AddUDF(ksname, udfName string) error {
 	return nil
 }
 
+func buildVindexExpressions(vschema *VSchema) {
+	for _, ks := range vschema.Keyspaces {
+		for _, t := range ks.Tables {
+			for _, cv := range t.ColumnVindexes {
+				if cv.Expression != nil {
+					vschema.vindexExpressions = append(vschema.vindexExpressions, cv)
+				}
+			}
+		}
+	}
+}
+
 func buildGlobalTables(source *vschemapb.SrvVSchema, vschema *VSchema) {
 	for ksname, ks := range source.Keyspaces {
 		ksvschema := vschema.Keyspaces[ksname]
@@ -632,6 +741,14 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			Name:                    sqlparser.NewIdentifierCS(tname),
 			Keyspace:                keyspace,
 			ColumnListAuthoritative: table.ColumnListAuthoritative,
+			ResultCacheTTL:          time.Duration(table.ResultCacheTtlSeconds) * time.Second,
+		}
+		if table.SequenceBackend != nil && table.Type != TypeSequence {
+			return vterrors.Errorf(
+				vtrpcpb.Code_INVALID_ARGUMENT,
+				"sequence backend is only allowed for sequence tables: %s",
+				tname,
+			)
 		}
 		switch table.Type {
 		case "":
@@ -652,7 +769,13 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			}
 			t.Type = table.Type
 		case TypeSequence:
-			if keyspace.Sharded && table.Pinned == "" {
+			if table.SequenceBackend != nil {
+				backend, err := buildSequenceBackend(table.SequenceBackend, tname)
+				if err != nil {
+					return err
+				}
+				t.SequenceBackend = backend
+			} else if keyspace.Sharded && table.Pinned == "" {
 				return vterrors.Errorf(
 					vtrpcpb.Code_FAILED_PRECONDITION,
 					"sequence table has to be in an unsharded keyspace or must be pinned: %s",
@@ -679,8 +802,9 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			t.Pinned = decoded
 		}
 
-		// If keyspace is sharded, then any table that's not a reference or pinned must have vindexes.
-		if keyspace.Sharded && t.Type != TypeReference && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
+		// If keyspace is sharded, then any table that's not a reference, a sequence with a backend or
+		// pinned must have vindexes.
+		if keyspace.Sharded && t.Type != TypeReference && t.SequenceBackend == nil && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
 			return vterrors.Errorf(
 				vtrpcpb.Code_NOT_FOUND,
 				"missing primary col vindex for table: %s",
@@ -744,7 +868,46 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 				owned = true
 			}
 			var columns []sqlparser.IdentifierCI
-			if ind.Column != "" {
+			var expression sqlparser.Expr
+			if ind.Expression != "" {
+				if ind.Column != "" || len(ind.Columns) > 0 {
+					return vterrors.Errorf(
+						vtrpcpb.Code_INVALID_ARGUMENT,
+						"can't use an expression and columns at the same time in vindex (%s) and table (%s)",
+						ind.Name,
+						tname,
+					)
+				}
+				if _, ok := vindex.(SingleColumn); !ok || vindex.NeedsVCursor() {
+					return vterrors.Errorf(
+						vtrpcpb.Code_INVALID_ARGUMENT,
+						"only functional single column vindexes can be declared over an expression, vindex (%s) of table (%s) is not",
+						ind.Name,
+						tname,
+					)
+				}
+				var err error
+				expression, err = parser.ParseExpr(ind.Expression)
+				if err != nil {
+					return vterrors.Errorf(
+						vtrpcpb.Code_INVALID_ARGUMENT,
+						"could not parse the expression '%s' of vindex (%s) for table (%s)",
+						ind.Expression,
+						ind.Name,
+						tname,
+					)
+				}
+				columns = expressionColumns(expression)
+				if len(columns) == 0 {
+					return vterrors.Errorf(
+						vtrpcpb.Code_INVALID_ARGUMENT,
+						"the expression '%s' of vindex (%s) for table (%s) does not use any column",
+						ind.Expression,
+						ind.Name,
+						tname,
+					)
+				}
+			} else if ind.Column != "" {
 				if len(ind.Columns) > 0 {
 					return vterrors.Errorf(
 						vtrpcpb.Code_INVALID_ARGUMENT,
@@ -772,14 +935,15 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 				backfill = lkpBackfill.IsBackfilling()
 			}
 			columnVindex := &ColumnVindex{
-				Columns:  columns,
-				Type:     vindexInfo.Type,
-				Name:     ind.Name,
-				Owned:    owned,
-				Vindex:   vindex,
-				isUnique: vindex.IsUnique(),
-				cost:     vindex.Cost(),
-				backfill: backfill,
+				Columns:    columns,
+				Type:       vindexInfo.Type,
+				Name:       ind.Name,
+				Owned:      owned,
+				Vindex:     vindex,
+				Expression: expression,
+				isUnique:   vindex.IsUnique(),
+				cost:       vindex.Cost(),
+				backfill:   backfill,
 			}
 			if i == 0 {
 				// Perform Primary vindex check.
@@ -844,6 +1008,16 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 				t.ColumnVindexes = append(t.ColumnVindexes, columnVindex)
 			}
 		}
+		if len(t.ColumnVindexes) > 0 && t.ColumnVindexes[0].Expression != nil && len(t.Owned) > 0 {
+			// The keyspace ids of the rows are computed from the values of the primary vindex
+			// columns when the owned vindexes are updated, which doesn't work for an expression.
+			return vterrors.Errorf(
+				vtrpcpb.Code_INVALID_ARGUMENT,
+				"primary vindex %s is declared over an expression, table %s can't own vindexes",
+				t.ColumnVindexes[0].Name,
+				tname,
+			)
+		}
 		t.Ordered = colVindexSorted(t.ColumnVindexes)
 
 		// Add the table to the map entries.
@@ -862,6 +1036,92 @@ func (vschema *VSchema) addTableName(t *Table) {
 	}
 }
 
+// buildSequenceBackend validates the backend of a sequence and fills in its defaults.
+// The sequences leased from shards can be in a sharded keyspace, as every shard hands
+// out its own range of values, and the ones leased from the topo don't need a table at all.
+// The ranges of the shards can't overlap, so that no value is handed out twice.
+func buildSequenceBackend(backend *vschemapb.SequenceBackend, tname string) (*SequenceBackend, error) {
+	switch backend.Type {
+	case SequenceBackendTopo, SequenceBackendShards:
+	default:
+		return nil, vterrors.Errorf(
+			vtrpcpb.Code_INVALID_ARGUMENT,
+			"unknown backend %s for sequence table: %s",
+			backend.Type,
+			tname,
+		)
+	}
+	if backend.BlockSize < 0 {
+		return nil, vterrors.Errorf(
+			vtrpcpb.Code_INVALID_ARGUMENT,
+			"invalid block size %d for sequence table: %s",
+			backend.BlockSize,
+			tname,
+		)
+	}
+	switch {
+	case backend.Type == SequenceBackendShards && len(backend.ShardRanges) == 0:
+		return nil, vterrors.Errorf(
+			vtrpcpb.Code_INVALID_ARGUMENT,
+			"missing shard ranges for sequence table: %s",
+			tname,
+		)
+	case backend.Type != SequenceBackendShards && len(backend.ShardRanges) > 0:
+		return nil, vterrors.Errorf(
+			vtrpcpb.Code_INVALID_ARGUMENT,
+			"shard ranges are only allowed with the shards backend for sequence table: %s",
+			tname,
+		)
+	}
+	sb := &SequenceBackend{
+		Type:      backend.Type,
+		BlockSize: backend.BlockSize,
+		Start:     backend.Start,
+	}
+	for _, r := range backend.ShardRanges {
+		if r.Start >= r.End {
+			return nil, vterrors.Errorf(
+				vtrpcpb.Code_INVALID_ARGUMENT,
+				"empty range [%d, %d) of shard %s for sequence table: %s",
+				r.Start,
+				r.End,
+				r.Shard,
+				tname,
+			)
+		}
+		if _, ok := sb.ShardRange(r.Shard); ok {
+			return nil, vterrors.Errorf(
+				vtrpcpb.Code_INVALID_ARGUMENT,
+				"duplicate range of shard %s for sequence table: %s",
+				r.Shard,
+				tname,
+			)
+		}
+		sb.ShardRanges = append(sb.ShardRanges, SequenceShardRange{Shard: r.Shard, Start: r.Start, End: r.End})
+	}
+	sort.Slice(sb.ShardRanges, func(i, j int) bool {
+		return sb.ShardRanges[i].Start < sb.ShardRanges[j].Start
+	})
+	for i := 1; i < len(sb.ShardRanges); i++ {
+		if prev, cur := sb.ShardRanges[i-1], sb.ShardRanges[i]; cur.Start < prev.End {
+			return nil, vterrors.Errorf(
+				vtrpcpb.Code_INVALID_ARGUMENT,
+				"overlapping ranges of shards %s and %s for sequence table: %s",
+				prev.Shard,
+				cur.Shard,
+				tname,
+			)
+		}
+	}
+	if sb.BlockSize == 0 {
+		sb.BlockSize = DefaultSequenceBlockSize
+	}
+	if sb.Start == 0 {
+		sb.Start = 1
+	}
+	return sb, nil
+}
+
 func resolveAutoIncrement(source *vschemapb.SrvVSchema, vschema *VSchema, parser *sqlparser.Parser) {
 	for ksname, ks := range source.Keyspaces {
 		ksvschema := vschema.Keyspaces[ksname]
@@ -1443,6 +1703,17 @@ func (vschema *VSchema) FindTableOrVindex(keyspace, name string, tabletType topo
 	return nil, nil, NotFoundError{TableName: name}
 }
 
+// IsVindexExpression returns true if a vindex of any keyspace is declared over an expression
+// equal to expr, the qualifiers of the columns of expr being ignored.
+func (vschema *VSchema) IsVindexExpression(expr sqlparser.Expr) bool {
+	for _, cv := range vschema.vindexExpressions {
+		if cv.MatchesExpression(expr) {
+			return true
+		}
+	}
+	return false
+}
+
 func (vschema *VSchema) FindView(keyspace, name string) sqlparser.SelectStatement {
 	if keyspace == "" {
 		switch {
@@ -1644,6 +1915,18 @@ func ChooseVindexForType(typ querypb.Type) (string, error) {
 	)
 }
 
+// expressionColumns returns the columns used by an expression, in order of appearance.
+func expressionColumns(expr sqlparser.Expr) []sqlparser.IdentifierCI {
+	var columns []sqlparser.IdentifierCI
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if col, ok := node.(*sqlparser.ColName); ok && !slices.ContainsFunc(columns, col.Name.Equal) {
+			columns = append(columns, col.Name)
+		}
+		return true, nil
+	}, expr)
+	return columns
+}
+
 // FindBestColVindex finds the best ColumnVindex for VReplication.
 func FindBestColVindex(table *Table) (*ColumnVindex, error) {
 	if len(table.ColumnVindexes) == 0 {
@@ -1658,6 +1941,10 @@ func FindBestColVindex(table *Table) (*ColumnVindex, error) {
 		if cv.Vindex.NeedsVCursor() {
 			continue
 		}
+		// VReplication computes the keyspace ids from the values of columns.
+		if cv.Expression != nil {
+			continue
+		}
 		if !cv.IsUnique() {
 			continue
 		}
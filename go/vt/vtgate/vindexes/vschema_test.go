@@ -2032,6 +2032,128 @@ func TestBuildVSchemaNoColumnsFail(t *testing.T) {
 	}
 }
 
+func TestBuildVSchemaExpressionVindex(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"stfu": {
+						Type: "stfu",
+					},
+					"stfn": {
+						Type: "stfn",
+					},
+				},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Expression: "json_unquote(doc->'$.tenant')",
+							Name:       "stfu",
+						}, {
+							Expression: "concat(a, ':', b, ':', a)",
+							Name:       "stfn",
+						}},
+					},
+				},
+			},
+		},
+	}
+	vschema := BuildVSchema(&good, sqlparser.NewTestParser())
+	require.NoError(t, vschema.Keyspaces["sharded"].Error)
+	t1 := vschema.Keyspaces["sharded"].Tables["t1"]
+
+	primary := t1.ColumnVindexes[0]
+	assert.Equal(t, []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("doc")}, primary.Columns)
+	assert.Equal(t, "json_unquote(doc -> '$.tenant')", sqlparser.String(primary.Expression))
+	assert.Equal(t, []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("a"), sqlparser.NewIdentifierCI("b")}, t1.ColumnVindexes[1].Columns)
+
+	parser := sqlparser.NewTestParser()
+	for expr, want := range map[string]bool{
+		"json_unquote(doc->'$.tenant')":    true,
+		"json_unquote(t1.doc->'$.tenant')": true,
+		"json_unquote(doc->'$.owner')":     false,
+		"doc->'$.tenant'":                  false,
+		"doc":                              false,
+	} {
+		parsed, err := parser.ParseExpr(expr)
+		require.NoError(t, err)
+		assert.Equal(t, want, primary.MatchesExpression(parsed), expr)
+		assert.Equal(t, want, vschema.IsVindexExpression(parsed), expr)
+	}
+
+	out, err := json.Marshal(primary)
+	require.NoError(t, err)
+	assert.Equal(t, `{"columns":["doc"],"type":"stfu","name":"stfu","vindex":{},"expression":"json_unquote(doc -\u003e '$.tenant')"}`, string(out))
+
+	best, err := FindBestColVindex(t1)
+	assert.Nil(t, best)
+	assert.EqualError(t, err, "could not find a vindex to compute keyspace id for table t1")
+}
+
+func TestBuildVSchemaExpressionVindexFail(t *testing.T) {
+	tests := []struct {
+		name          string
+		columnVindex  *vschemapb.ColumnVindex
+		ownedByLookup bool
+		want          string
+	}{{
+		name:         "expression and column",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower(c1)", Column: "c1", Name: "stfu"},
+		want:         "can't use an expression and columns at the same time in vindex (stfu) and table (t1)",
+	}, {
+		name:         "expression and columns",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower(c1)", Columns: []string{"c1"}, Name: "stfu"},
+		want:         "can't use an expression and columns at the same time in vindex (stfu) and table (t1)",
+	}, {
+		name:         "lookup vindex",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower(c1)", Name: "stlu"},
+		want:         "only functional single column vindexes can be declared over an expression, vindex (stlu) of table (t1) is not",
+	}, {
+		name:         "multi column vindex",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower(c1)", Name: "mcfu"},
+		want:         "only functional single column vindexes can be declared over an expression, vindex (mcfu) of table (t1) is not",
+	}, {
+		name:         "invalid expression",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower(c1", Name: "stfu"},
+		want:         "could not parse the expression 'lower(c1' of vindex (stfu) for table (t1)",
+	}, {
+		name:         "no column",
+		columnVindex: &vschemapb.ColumnVindex{Expression: "lower('a')", Name: "stfu"},
+		want:         "the expression 'lower('a')' of vindex (stfu) for table (t1) does not use any column",
+	}, {
+		name:          "owned vindexes",
+		columnVindex:  &vschemapb.ColumnVindex{Expression: "lower(c1)", Name: "stfu"},
+		ownedByLookup: true,
+		want:          "primary vindex stfu is declared over an expression, table t1 can't own vindexes",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &vschemapb.Table{ColumnVindexes: []*vschemapb.ColumnVindex{tt.columnVindex}}
+			lookup := &vschemapb.Vindex{Type: "stlu"}
+			if tt.ownedByLookup {
+				lookup.Owner = "t1"
+				table.ColumnVindexes = append(table.ColumnVindexes, &vschemapb.ColumnVindex{Column: "c2", Name: "stlu"})
+			}
+			bad := vschemapb.SrvVSchema{
+				Keyspaces: map[string]*vschemapb.Keyspace{
+					"sharded": {
+						Sharded: true,
+						Vindexes: map[string]*vschemapb.Vindex{
+							"stfu": {Type: "stfu"},
+							"stlu": lookup,
+							"mcfu": {Type: "mcfu"},
+						},
+						Tables: map[string]*vschemapb.Table{"t1": table},
+					},
+				},
+			}
+			got := BuildVSchema(&bad, sqlparser.NewTestParser())
+			assert.EqualError(t, got.Keyspaces["sharded"].Error, tt.want)
+		})
+	}
+}
+
 func TestBuildVSchemaNotUniqueFail(t *testing.T) {
 	bad := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -2653,6 +2775,114 @@ func TestBadShardedSequence(t *testing.T) {
 	}
 }
 
+func TestSequenceBackend(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Tables: map[string]*vschemapb.Table{
+					"seq_shards": {
+						Type: "sequence",
+						SequenceBackend: &vschemapb.SequenceBackend{
+							Type: "shards",
+							ShardRanges: []*vschemapb.SequenceShardRange{
+								{Shard: "80-", Start: 1000, End: 2000},
+								{Shard: "-80", Start: 1, End: 1000},
+							},
+						},
+					},
+					"seq_topo": {
+						Type:            "sequence",
+						SequenceBackend: &vschemapb.SequenceBackend{Type: "topo", BlockSize: 50, Start: 1000},
+					},
+				},
+			},
+		},
+	}
+	got := BuildVSchema(&good, sqlparser.NewTestParser())
+	ks := got.Keyspaces["sharded"]
+	require.NoError(t, ks.Error)
+	assert.Equal(t, &SequenceBackend{
+		Type:      "shards",
+		BlockSize: 1000,
+		Start:     1,
+		ShardRanges: []SequenceShardRange{
+			{Shard: "-80", Start: 1, End: 1000},
+			{Shard: "80-", Start: 1000, End: 2000},
+		},
+	}, ks.Tables["seq_shards"].SequenceBackend)
+	assert.Equal(t, &SequenceBackend{Type: "topo", BlockSize: 50, Start: 1000}, ks.Tables["seq_topo"].SequenceBackend)
+
+	testcases := []struct {
+		name  string
+		table *vschemapb.Table
+		err   string
+	}{{
+		name:  "not a sequence",
+		table: &vschemapb.Table{SequenceBackend: &vschemapb.SequenceBackend{Type: "topo"}},
+		err:   "sequence backend is only allowed for sequence tables: t1",
+	}, {
+		name:  "unknown backend",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{Type: "redis"}},
+		err:   "unknown backend redis for sequence table: t1",
+	}, {
+		name:  "negative block size",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{Type: "topo", BlockSize: -1}},
+		err:   "invalid block size -1 for sequence table: t1",
+	}, {
+		name:  "shards without ranges",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{Type: "shards"}},
+		err:   "missing shard ranges for sequence table: t1",
+	}, {
+		name: "topo with ranges",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{
+			Type:        "topo",
+			ShardRanges: []*vschemapb.SequenceShardRange{{Shard: "0", Start: 1, End: 10}},
+		}},
+		err: "shard ranges are only allowed with the shards backend for sequence table: t1",
+	}, {
+		name: "empty range",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{
+			Type:        "shards",
+			ShardRanges: []*vschemapb.SequenceShardRange{{Shard: "0", Start: 10, End: 10}},
+		}},
+		err: "empty range [10, 10) of shard 0 for sequence table: t1",
+	}, {
+		name: "duplicate shard",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{
+			Type: "shards",
+			ShardRanges: []*vschemapb.SequenceShardRange{
+				{Shard: "-80", Start: 1, End: 10},
+				{Shard: "-80", Start: 10, End: 20},
+			},
+		}},
+		err: "duplicate range of shard -80 for sequence table: t1",
+	}, {
+		name: "overlapping ranges",
+		table: &vschemapb.Table{Type: "sequence", SequenceBackend: &vschemapb.SequenceBackend{
+			Type: "shards",
+			ShardRanges: []*vschemapb.SequenceShardRange{
+				{Shard: "80-", Start: 100, End: 200},
+				{Shard: "-80", Start: 1, End: 101},
+			},
+		}},
+		err: "overlapping ranges of shards -80 and 80- for sequence table: t1",
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			bad := vschemapb.SrvVSchema{
+				Keyspaces: map[string]*vschemapb.Keyspace{
+					"unsharded": {
+						Tables: map[string]*vschemapb.Table{"t1": tc.table},
+					},
+				},
+			}
+			got := BuildVSchema(&bad, sqlparser.NewTestParser())
+			require.EqualError(t, got.Keyspaces["unsharded"].Error, tc.err)
+		})
+	}
+}
+
 func TestFindTable(t *testing.T) {
 	input := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
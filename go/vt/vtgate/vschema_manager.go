@@ -17,12 +17,15 @@ limitations under the License.
 package vtgate
 
 import (
+	"bytes"
 	"context"
 	"sync"
+	"time"
 
 	"vitess.io/vitess/go/vt/graph"
 	"vitess.io/vitess/go/vt/log"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/schema"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/srvtopo"
@@ -46,6 +49,24 @@ type VSchemaManager struct {
 	subscriber        func(vschema *vindexes.VSchema, stats *VSchemaStats)
 	schema            SchemaInfo
 	parser            *sqlparser.Parser
+
+	// rangeMaps holds the watches on the split tables of the
+	// range_map vindexes stored in the topo, by path
+	rangeMapsMu sync.Mutex
+	rangeMaps   map[string]*rangeMapWatch
+}
+
+// rangeMapWatchRetryDelay is the time to wait before watching
+// the split table of a range_map vindex again after an error
+var rangeMapWatchRetryDelay = 5 * time.Second
+
+// rangeMapWatch keeps the latest content of the split table of range_map vindexes stored in the topo
+type rangeMapWatch struct {
+	path     string
+	cancel   context.CancelFunc
+	contents []byte
+	// err is set while the split table cannot be watched, like when it doesn't exist yet
+	err error
 }
 
 // SchemaInfo is an interface to schema tracker.
@@ -191,6 +212,7 @@ func (vm *VSchemaManager) Rebuild() {
 // buildAndEnhanceVSchema builds a new VSchema and uses information from the schema tracker to update it
 func (vm *VSchemaManager) buildAndEnhanceVSchema(v *vschemapb.SrvVSchema) *vindexes.VSchema {
 	vschema := vindexes.BuildVSchema(v, vm.parser)
+	vm.loadRangeMaps(vschema)
 	if vm.schema != nil {
 		vm.updateFromSchema(vschema)
 		// We mark the keyspaces that have foreign key management in Vitess and have cyclic foreign keys
@@ -200,6 +222,129 @@ func (vm *VSchemaManager) buildAndEnhanceVSchema(v *vschemapb.SrvVSchema) *vinde
 	return vschema
 }
 
+// loadRangeMaps loads the split tables of the range_map vindexes stored in the topo.
+// The split tables are watched, and the VSchema is rebuilt when one of them changes.
+func (vm *VSchemaManager) loadRangeMaps(vschema *vindexes.VSchema) {
+	vm.rangeMapsMu.Lock()
+	defer vm.rangeMapsMu.Unlock()
+
+	used := make(map[string]bool)
+	for ksName, ks := range vschema.Keyspaces {
+		for vdxName, vdx := range ks.Vindexes {
+			rm, ok := vdx.(*vindexes.RangeMap)
+			if !ok || rm.TopoPath() == "" {
+				continue
+			}
+			used[rm.TopoPath()] = true
+			contents, err := vm.rangeMapContentsLocked(rm.TopoPath())
+			if err == nil {
+				err = rm.LoadRanges(contents)
+			}
+			if err != nil {
+				log.Errorf("unable to load the split table of vindex %s.%s from %s: %v", ksName, vdxName, rm.TopoPath(), err)
+			}
+		}
+	}
+
+	for path, w := range vm.rangeMaps {
+		if !used[path] {
+			w.cancel()
+			delete(vm.rangeMaps, path)
+		}
+	}
+}
+
+// rangeMapContentsLocked returns the content of a split table stored in the topo,
+// and starts watching it the first time it is requested.
+func (vm *VSchemaManager) rangeMapContentsLocked(path string) ([]byte, error) {
+	if w, ok := vm.rangeMaps[path]; ok {
+		return w.contents, w.err
+	}
+	if vm.serv == nil {
+		return nil, vterrors.New(vtrpcpb.Code_UNAVAILABLE, "no topo server to load the split table from")
+	}
+	ts, err := vm.serv.GetTopoServer()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := ts.ConnForCell(ctx, topo.GlobalCell)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// When the split table cannot be watched yet, it is watched again later on,
+	// and the VSchema is rebuilt once it can be loaded.
+	w := &rangeMapWatch{path: path, cancel: cancel}
+	current, changes, err := conn.Watch(ctx, path)
+	if err != nil {
+		w.err = err
+	} else {
+		w.contents = current.Contents
+	}
+	if vm.rangeMaps == nil {
+		vm.rangeMaps = make(map[string]*rangeMapWatch)
+	}
+	vm.rangeMaps[path] = w
+	go vm.watchRangeMap(ctx, conn, w, changes)
+	return w.contents, w.err
+}
+
+// watchRangeMap follows the changes of a split table until its watch is canceled.
+// When the topo watch fails, or could not be started, the last known content is kept
+// and the file is watched again.
+func (vm *VSchemaManager) watchRangeMap(ctx context.Context, conn topo.Conn, w *rangeMapWatch, changes <-chan *topo.WatchData) {
+	for {
+		for changes != nil {
+			wd, ok := <-changes
+			if !ok {
+				changes = nil
+				break
+			}
+			if wd.Err != nil {
+				if ctx.Err() == nil {
+					log.Errorf("error watching the split table at %s: %v", w.path, wd.Err)
+				}
+				continue
+			}
+			vm.updateRangeMap(w, wd.Contents)
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(rangeMapWatchRetryDelay):
+			}
+			current, c, err := conn.Watch(ctx, w.path)
+			if err == nil {
+				vm.updateRangeMap(w, current.Contents)
+				changes = c
+				break
+			}
+			log.Errorf("error watching the split table at %s: %v", w.path, err)
+		}
+	}
+}
+
+// updateRangeMap sets the content of a split table, and rebuilds the VSchema if it changed
+func (vm *VSchemaManager) updateRangeMap(w *rangeMapWatch, contents []byte) {
+	vm.rangeMapsMu.Lock()
+	changed := w.err != nil || !bytes.Equal(w.contents, contents)
+	w.contents = contents
+	w.err = nil
+	vm.rangeMapsMu.Unlock()
+
+	if changed {
+		log.Infof("Split table at %s changed", w.path)
+		vm.Rebuild()
+	}
+}
+
 func (vm *VSchemaManager) updateFromSchema(vschema *vindexes.VSchema) {
 	for ksName, ks := range vschema.Keyspaces {
 		vm.updateTableInfo(vschema, ks, ksName)
@@ -223,7 +368,8 @@ func (vm *VSchemaManager) updateTableInfo(vschema *vindexes.VSchema, ks *vindexe
 	// are created in the Vschema, so that later when we try to find the routed tables, we don't end up
 	// getting dummy tables.
 	for tblName, tblInfo := range m {
-		setColumns(ks, tblName, tblInfo.Columns)
+		vTbl := setColumns(ks, tblName, tblInfo.Columns)
+		vTbl.Stats = tblInfo.Stats
 	}
 
 	// Now that we have ensured that all the tables are created, we can start populating the foreign keys
@@ -1,14 +1,20 @@
 package vtgate
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/key"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
 	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 )
 
@@ -840,6 +846,103 @@ func TestVSchemaUpdateWithFKReferenceToInternalTables(t *testing.T) {
 	utils.MustMatch(t, vs, vm.currentVschema, "currentVschema should have same reference as Vschema")
 }
 
+// TestRangeMapSplitTableInTopo tests that the split tables of the range_map vindexes
+// are loaded from the topo, and that the vschema is rebuilt when they change.
+func TestRangeMapSplitTableInTopo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serv := newSandboxForCells(ctx, []string{"aa"})
+	conn, err := serv.topoServer.ConnForCell(ctx, topo.GlobalCell)
+	require.NoError(t, err)
+	_, err = conn.Create(ctx, "range_maps/tenants", []byte(`[{"from": 0, "keyspace_id": "00"}, {"from": 100, "keyspace_id": "80"}]`))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var vs *vindexes.VSchema
+	vm := &VSchemaManager{serv: serv}
+	vm.subscriber = func(vschema *vindexes.VSchema, _ *VSchemaStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		vs = vschema
+	}
+	mapTenant := func(id int64) key.Destination {
+		mu.Lock()
+		defer mu.Unlock()
+		vdx := vs.Keyspaces["ks"].Vindexes["tenant_map"].(vindexes.SingleColumn)
+		dests, err := vdx.Map(ctx, nil, []sqltypes.Value{sqltypes.NewInt64(id)})
+		require.NoError(t, err)
+		return dests[0]
+	}
+
+	srvVSchema := makeTestSrvVSchema("ks", true, nil)
+	srvVSchema.Keyspaces["ks"].Vindexes = map[string]*vschemapb.Vindex{
+		"tenant_map": {Type: "range_map", Params: map[string]string{"topo_path": "range_maps/tenants"}},
+	}
+	vm.VSchemaUpdate(srvVSchema, nil)
+	require.Equal(t, key.DestinationKeyspaceID([]byte{0x80}), mapTenant(150))
+
+	_, err = conn.Update(ctx, "range_maps/tenants", []byte(`[{"from": 0, "keyspace_id": "00"}, {"from": 200, "keyspace_id": "80"}]`), nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return mapTenant(150).String() == key.DestinationKeyspaceID([]byte{0x00}).String()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the watch is stopped once no vindex uses the split table anymore
+	vm.VSchemaUpdate(makeTestSrvVSchema("ks", true, nil), nil)
+	vm.rangeMapsMu.Lock()
+	defer vm.rangeMapsMu.Unlock()
+	require.Empty(t, vm.rangeMaps)
+}
+
+// TestRangeMapSplitTableCreatedLater tests that the split table of a range_map vindex
+// is loaded once it is created in the topo, after the vschema was loaded without it.
+func TestRangeMapSplitTableCreatedLater(t *testing.T) {
+	defer func(delay time.Duration) { rangeMapWatchRetryDelay = delay }(rangeMapWatchRetryDelay)
+	rangeMapWatchRetryDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serv := newSandboxForCells(ctx, []string{"aa"})
+	conn, err := serv.topoServer.ConnForCell(ctx, topo.GlobalCell)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var vs *vindexes.VSchema
+	vm := &VSchemaManager{serv: serv}
+	vm.subscriber = func(vschema *vindexes.VSchema, _ *VSchemaStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		vs = vschema
+	}
+	mapTenant := func(id int64) (key.Destination, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		vdx := vs.Keyspaces["ks"].Vindexes["tenant_map"].(vindexes.SingleColumn)
+		dests, err := vdx.Map(ctx, nil, []sqltypes.Value{sqltypes.NewInt64(id)})
+		if err != nil {
+			return nil, err
+		}
+		return dests[0], nil
+	}
+
+	srvVSchema := makeTestSrvVSchema("ks", true, nil)
+	srvVSchema.Keyspaces["ks"].Vindexes = map[string]*vschemapb.Vindex{
+		"tenant_map": {Type: "range_map", Params: map[string]string{"topo_path": "range_maps/tenants"}},
+	}
+	vm.VSchemaUpdate(srvVSchema, nil)
+	dest, err := mapTenant(150)
+	require.True(t, err != nil || dest.String() == key.DestinationNone{}.String(), "no tenant can be mapped without the split table")
+
+	_, err = conn.Create(ctx, "range_maps/tenants", []byte(`[{"from": 0, "keyspace_id": "00"}, {"from": 100, "keyspace_id": "80"}]`))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		dest, err := mapTenant(150)
+		return err == nil && dest.String() == key.DestinationKeyspaceID([]byte{0x80}).String()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	vm.VSchemaUpdate(makeTestSrvVSchema("ks", true, nil), nil)
+}
+
 // createFkDefinition is a helper function to create a Foreign key definition struct from the columns used in it provided as list of strings.
 func createFkDefinition(childCols []string, parentTableName string, parentCols []string, onUpdate, onDelete sqlparser.ReferenceAction) *sqlparser.ForeignKeyDefinition {
 	pKs, pTbl, _ := sqlparser.NewTestParser().ParseTable(parentTableName)
@@ -68,11 +68,25 @@ var (
 	// plan cache related flag
 	queryPlanCacheMemory int64 = 32 * 1024 * 1024 // 32mb
 
+	// resultCacheMemory enables the result cache when set.
+	resultCacheMemory int64
+
 	maxMemoryRows   = 300000
 	warnMemoryRows  = 30000
 	maxPayloadSize  int
 	warnPayloadSize int
 
+	// scatterConcurrency limits the number of shards a scatter is sent to at once
+	scatterConcurrency int
+
+	// spill to disk related flags
+	spillToDiskDir      string
+	spillToDiskMaxBytes int64
+
+	// memory accounting related flags
+	maxQueryMemory      int64
+	maxTotalQueryMemory int64
+
 	noScatter          bool
 	enableShardRouting bool
 
@@ -100,6 +114,7 @@ var (
 	enableSchemaChangeSignal = true
 	enableViews              bool
 	enableUdfs               bool
+	tableStatsInterval       time.Duration
 
 	// vtgate views flags
 	queryTimeout int
@@ -126,11 +141,17 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&truncateErrorLen, "truncate-error-len", truncateErrorLen, "truncate errors sent to client if they are longer than this value (0 means do not truncate)")
 	fs.IntVar(&streamBufferSize, "stream_buffer_size", streamBufferSize, "the number of bytes sent from vtgate for each stream call. It's recommended to keep this value in sync with vttablet's query-server-config-stream-buffer-size.")
 	fs.Int64Var(&queryPlanCacheMemory, "gate_query_cache_memory", queryPlanCacheMemory, "gate server query cache size in bytes, maximum amount of memory to be cached. vtgate analyzes every incoming query and generate a query plan, these plans are being cached in a lru cache. This config controls the capacity of the lru cache.")
+	fs.Int64Var(&resultCacheMemory, "result-cache-memory", resultCacheMemory, "Maximum amount of memory in bytes used to cache the results of read-only queries. Only the queries with the RESULT_CACHE_TTL directive, or that use tables with a result_cache_ttl_seconds in the VSchema, are cached. 0 disables the result cache.")
 	fs.IntVar(&maxMemoryRows, "max_memory_rows", maxMemoryRows, "Maximum number of rows that will be held in memory for intermediate results as well as the final result.")
 	fs.IntVar(&warnMemoryRows, "warn_memory_rows", warnMemoryRows, "Warning threshold for in-memory results. A row count higher than this amount will cause the VtGateWarnings.ResultsExceeded counter to be incremented.")
+	fs.StringVar(&spillToDiskDir, "spill-to-disk-dir", spillToDiskDir, "Directory where sorts, hash joins and distincts write their intermediate rows once max_memory_rows is exceeded, instead of failing the query. Spilling is disabled if empty.")
+	fs.Int64Var(&spillToDiskMaxBytes, "spill-to-disk-max-bytes", spillToDiskMaxBytes, "Maximum number of bytes a single query can spill to disk (0 means no limit).")
+	fs.Int64Var(&maxQueryMemory, "max-query-memory", maxQueryMemory, "Maximum number of bytes the rows held in memory by a single query can use, for its intermediate results as well as the final result (0 means no limit).")
+	fs.Int64Var(&maxTotalQueryMemory, "max-total-query-memory", maxTotalQueryMemory, "Maximum number of bytes the rows held in memory by all the running queries can use together. The query that goes over the limit fails (0 means no limit).")
 	fs.StringVar(&defaultDDLStrategy, "ddl_strategy", defaultDDLStrategy, "Set default strategy for DDL statements. Override with @@ddl_strategy session variable")
 	fs.StringVar(&dbDDLPlugin, "dbddl_plugin", dbDDLPlugin, "controls how to handle CREATE/DROP DATABASE. use it if you are using your own database provisioning service")
 	fs.BoolVar(&noScatter, "no_scatter", noScatter, "when set to true, the planner will fail instead of producing a plan that includes scatter queries")
+	fs.IntVar(&scatterConcurrency, "scatter-concurrency", scatterConcurrency, "Maximum number of shards vtgate sends the queries of a scatter to at once. The other shards are queried as the first ones respond. Override with the SCATTER_CONCURRENCY query directive (0 means no limit).")
 	fs.BoolVar(&enableShardRouting, "enable-partial-keyspace-migration", enableShardRouting, "(Experimental) Follow shard routing rules: enable only while migrating a keyspace shard by shard. See documentation on Partial MoveTables for more. (default false)")
 	fs.DurationVar(&healthCheckRetryDelay, "healthcheck_retry_delay", healthCheckRetryDelay, "health check retry delay")
 	fs.DurationVar(&healthCheckTimeout, "healthcheck_timeout", healthCheckTimeout, "the health check timeout period")
@@ -150,6 +171,7 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&messageStreamGracePeriod, "message_stream_grace_period", messageStreamGracePeriod, "the amount of time to give for a vttablet to resume if it ends a message stream, usually because of a reparent.")
 	fs.BoolVar(&enableViews, "enable-views", enableViews, "Enable views support in vtgate.")
 	fs.BoolVar(&enableUdfs, "track-udfs", enableUdfs, "Track UDFs in vtgate.")
+	fs.DurationVar(&tableStatsInterval, "table-stats-refresh-interval", tableStatsInterval, "How often the schema tracker reloads the row and index cardinality estimates of the tables from the primary tablets. The planner uses them to choose the join order and algorithm of cross-shard joins. 0 disables table statistics.")
 	fs.BoolVar(&allowKillStmt, "allow-kill-statement", allowKillStmt, "Allows the execution of kill statement")
 	fs.IntVar(&warmingReadsPercent, "warming-reads-percent", 0, "Percentage of reads on the primary to forward to replicas. Useful for keeping buffer pools warm")
 	fs.IntVar(&warmingReadsConcurrency, "warming-reads-concurrency", 500, "Number of concurrent warming reads allowed")
@@ -312,6 +334,7 @@ func Init(
 	var st *vtschema.Tracker
 	if enableSchemaChangeSignal {
 		st = vtschema.NewTracker(gw.hc.Subscribe(), enableViews, enableUdfs, env.Parser())
+		st.SetTableStatsInterval(tableStatsInterval)
 		addKeyspacesToTracker(ctx, srvResolver, st, gw)
 		si = st
 	}
@@ -338,6 +361,12 @@ func Init(
 		log.Fatalf("error initializing query logger: %v", err)
 	}
 
+	if resultCacheMemory > 0 {
+		rc := NewResultCache(resultCacheMemory, !servenv.TestingEndtoend, vstreamResultCacheEvents(vsm))
+		executor.EnableResultCache(rc)
+		servenv.OnClose(rc.Close)
+	}
+
 	// connect the schema tracker with the vschema manager
 	if enableSchemaChangeSignal {
 		st.RegisterSignalReceiver(executor.vm.Rebuild)
@@ -36,6 +36,11 @@ func (vc *contextVCursor) ConnCollation() collations.ID {
 	return collations.CollationBinaryID
 }
 
+// ScatterLimiter returns nil as the streams are never limited.
+func (vc *contextVCursor) ScatterLimiter() *engine.ScatterLimiter {
+	return nil
+}
+
 func (vc *contextVCursor) ExecutePrimitive(ctx context.Context, primitive engine.Primitive, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
 	return primitive.TryExecute(ctx, vc, bindVars, wantfields)
 }
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+/*
+	The lookup differ compares the lookup table of a lookup vindex with its owner table.
+	It works like the table differ: the rows of every shard are merge sorted, here on the
+	from columns of the vindex, and the two sorted streams are walked side by side.
+	Every row holds the from columns followed by the keyspace id. As a lookup vindex maps
+	a from value to one or more keyspace ids, the rows are compared a from value at a time:
+	the keyspace ids the owner table has for it and the lookup table lacks are missing rows,
+	and the ones the lookup table has and the owner table lacks are orphaned rows.
+*/
+
+// LookupRowStreamer streams the rows of one shard to the callback, sorted by the
+// from columns. Every row holds the from columns followed by the keyspace id, and
+// the first result must carry the fields.
+type LookupRowStreamer func(ctx context.Context, callback func(*sqltypes.Result) error) error
+
+// LookupDiffer compares the rows of the lookup table of a lookup vindex with the
+// rows its owner table says it should have.
+type LookupDiffer struct {
+	// FromCollations holds the collation of every from column, which the
+	// shards must use to sort their rows.
+	FromCollations []collations.ID
+	CollationEnv   *collations.Environment
+
+	// Owner and Lookup hold the streamers of every shard of the owner
+	// and lookup tables, keyed by shard.
+	Owner  map[string]LookupRowStreamer
+	Lookup map[string]LookupRowStreamer
+
+	// OnMissing is called with every row missing from the lookup table,
+	// and OnOrphaned with every row of the lookup table which has no
+	// owner. For a given from value, the orphaned rows are reported before
+	// the missing ones. Either can be nil.
+	OnMissing  func(row []sqltypes.Value) error
+	OnOrphaned func(row []sqltypes.Value) error
+}
+
+// LookupDiffReport is the outcome of a LookupDiffer.
+type LookupDiffReport struct {
+	// OwnerRows and LookupRows are the distinct rows found in each table.
+	OwnerRows    int64
+	LookupRows   int64
+	MatchingRows int64
+	MissingRows  int64
+	OrphanedRows int64
+}
+
+// lookupRowReader reads the merge sorted rows of a table one from value at a time.
+type lookupRowReader struct {
+	ld   *LookupDiffer
+	pe   *primitiveExecutor
+	cols []compareColInfo
+	next []sqltypes.Value
+}
+
+// Diff streams the rows of both tables and compares them.
+func (ld *LookupDiffer) Diff(ctx context.Context) (*LookupDiffReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cols := make([]compareColInfo, len(ld.FromCollations))
+	for i, collation := range ld.FromCollations {
+		cols[i] = compareColInfo{colIndex: i, collation: collation, isPK: true}
+	}
+	owner := ld.newRowReader(ctx, ld.Owner, cols, "owner")
+	lookup := ld.newRowReader(ctx, ld.Lookup, cols, "lookup")
+
+	dr := &LookupDiffReport{}
+	ownerRows, err := owner.nextGroup()
+	if err != nil {
+		return nil, err
+	}
+	lookupRows, err := lookup.nextGroup()
+	if err != nil {
+		return nil, err
+	}
+	for ownerRows != nil || lookupRows != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_CANCELED, "context has expired")
+		}
+
+		var c int
+		switch {
+		case ownerRows == nil:
+			c = 1
+		case lookupRows == nil:
+			c = -1
+		default:
+			if c, err = ld.compare(ownerRows[0], lookupRows[0], cols); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case c < 0:
+			for _, row := range ownerRows {
+				dr.OwnerRows++
+				if err := ld.missing(dr, row); err != nil {
+					return nil, err
+				}
+			}
+		case c > 0:
+			for _, row := range lookupRows {
+				dr.LookupRows++
+				if err := ld.orphaned(dr, row); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			// The orphaned rows are reported first, so that a unique lookup table can
+			// be repaired by deleting the wrong row before inserting the right one.
+			ksidCol := len(cols)
+			ownerKsids := make(map[string]bool, len(ownerRows))
+			for _, row := range ownerRows {
+				ownerKsids[row[ksidCol].ToString()] = true
+			}
+			lookupKsids := make(map[string]bool, len(lookupRows))
+			for _, row := range lookupRows {
+				lookupKsids[row[ksidCol].ToString()] = true
+				dr.LookupRows++
+				if ownerKsids[row[ksidCol].ToString()] {
+					dr.MatchingRows++
+					continue
+				}
+				if err := ld.orphaned(dr, row); err != nil {
+					return nil, err
+				}
+			}
+			for _, row := range ownerRows {
+				dr.OwnerRows++
+				if lookupKsids[row[ksidCol].ToString()] {
+					continue
+				}
+				if err := ld.missing(dr, row); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if c <= 0 {
+			if ownerRows, err = owner.nextGroup(); err != nil {
+				return nil, err
+			}
+		}
+		if c >= 0 {
+			if lookupRows, err = lookup.nextGroup(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dr, nil
+}
+
+func (ld *LookupDiffer) missing(dr *LookupDiffReport, row []sqltypes.Value) error {
+	dr.MissingRows++
+	if ld.OnMissing == nil {
+		return nil
+	}
+	return ld.OnMissing(row)
+}
+
+func (ld *LookupDiffer) orphaned(dr *LookupDiffReport, row []sqltypes.Value) error {
+	dr.OrphanedRows++
+	if ld.OnOrphaned == nil {
+		return nil
+	}
+	return ld.OnOrphaned(row)
+}
+
+// newRowReader starts streaming the rows of every shard and merge sorts them.
+func (ld *LookupDiffer) newRowReader(ctx context.Context, streamers map[string]LookupRowStreamer, cols []compareColInfo, name string) *lookupRowReader {
+	participants := make(map[string]*shardStreamer, len(streamers))
+	for shard, streamer := range streamers {
+		participant := &shardStreamer{
+			shard:  shard,
+			result: make(chan *sqltypes.Result, 1),
+		}
+		participants[shard] = participant
+		go func(streamer LookupRowStreamer) {
+			defer close(participant.result)
+			participant.err = streamer(ctx, func(qr *sqltypes.Result) error {
+				select {
+				case participant.result <- qr:
+				case <-ctx.Done():
+					return vterrors.Wrap(ctx.Err(), "LookupRowStreamer")
+				}
+				return nil
+			})
+		}(streamer)
+	}
+	return &lookupRowReader{
+		ld:   ld,
+		pe:   newPrimitiveExecutor(ctx, newMergeSorter(participants, cols, ld.CollationEnv), name),
+		cols: cols,
+	}
+}
+
+// nextGroup returns the rows of the next from value, without the duplicate keyspace
+// ids, or nil once all the rows have been read.
+func (r *lookupRowReader) nextGroup() ([][]sqltypes.Value, error) {
+	if r.next == nil {
+		row, err := r.pe.next()
+		if err != nil || row == nil {
+			return nil, err
+		}
+		r.next = row
+	}
+	ksidCol := len(r.cols)
+	group := [][]sqltypes.Value{r.next}
+	seen := map[string]bool{r.next[ksidCol].ToString(): true}
+	r.next = nil
+	for {
+		row, err := r.pe.next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return group, nil
+		}
+		c, err := r.ld.compare(group[0], row, r.cols)
+		if err != nil {
+			return nil, err
+		}
+		if c != 0 {
+			r.next = row
+			return group, nil
+		}
+		if ksid := row[ksidCol].ToString(); !seen[ksid] {
+			seen[ksid] = true
+			group = append(group, row)
+		}
+	}
+}
+
+func (ld *LookupDiffer) compare(ownerRow, lookupRow []sqltypes.Value, cols []compareColInfo) (int, error) {
+	for _, col := range cols {
+		// If the collation is unknown, use binary collation to compare as bytes.
+		collationID := col.collation
+		if collationID == collations.Unknown {
+			collationID = collations.CollationBinaryID
+		}
+		c, err := evalengine.NullsafeCompare(ownerRow[col.colIndex], lookupRow[col.colIndex], ld.CollationEnv, collationID, nil)
+		if err != nil {
+			return 0, err
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func lookupRowStreamer(results ...*sqltypes.Result) LookupRowStreamer {
+	return func(ctx context.Context, callback func(*sqltypes.Result) error) error {
+		for _, result := range results {
+			if err := callback(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// nextLookupResult returns a result without fields, as the fields are only
+// sent with the first result of a shard.
+func nextLookupResult(fields []*querypb.Field, rows ...string) *sqltypes.Result {
+	result := sqltypes.MakeTestResult(fields, rows...)
+	result.Fields = nil
+	return result
+}
+
+func TestLookupDiffer(t *testing.T) {
+	fields := sqltypes.MakeTestFields("name|keyspace_id", "varchar|varbinary")
+	collationEnv := collations.MySQL8()
+	utf8mb4 := collationEnv.DefaultConnectionCharset()
+
+	var missing, orphaned []string
+	ld := &LookupDiffer{
+		FromCollations: []collations.ID{utf8mb4},
+		CollationEnv:   collationEnv,
+		Owner: map[string]LookupRowStreamer{
+			"-80": lookupRowStreamer(
+				sqltypes.MakeTestResult(fields, "alice|01", "alice|01", "carol|02"),
+				nextLookupResult(fields, "dave|03", "erin|04"),
+			),
+			"80-": lookupRowStreamer(
+				sqltypes.MakeTestResult(fields, "Bob|81", "carol|82", "frank|83"),
+			),
+		},
+		Lookup: map[string]LookupRowStreamer{
+			"-80": lookupRowStreamer(
+				sqltypes.MakeTestResult(fields, "ALICE|01", "bob|81", "carol|02", "carol|09"),
+			),
+			"80-": lookupRowStreamer(
+				sqltypes.MakeTestResult(fields, "dave|05", "gina|06"),
+				nextLookupResult(fields, "gina|07"),
+			),
+		},
+		OnMissing: func(row []sqltypes.Value) error {
+			missing = append(missing, row[0].ToString()+"|"+row[1].ToString())
+			return nil
+		},
+		OnOrphaned: func(row []sqltypes.Value) error {
+			orphaned = append(orphaned, row[0].ToString()+"|"+row[1].ToString())
+			return nil
+		},
+	}
+	dr, err := ld.Diff(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &LookupDiffReport{
+		OwnerRows:    7,
+		LookupRows:   7,
+		MatchingRows: 3,
+		MissingRows:  4,
+		OrphanedRows: 4,
+	}, dr)
+	assert.Equal(t, []string{"carol|82", "dave|03", "erin|04", "frank|83"}, missing)
+	assert.Equal(t, []string{"carol|09", "dave|05", "gina|06", "gina|07"}, orphaned)
+}
+
+func TestLookupDifferErrors(t *testing.T) {
+	fields := sqltypes.MakeTestFields("name|keyspace_id", "varchar|varbinary")
+	collationEnv := collations.MySQL8()
+
+	ld := &LookupDiffer{
+		FromCollations: []collations.ID{collationEnv.DefaultConnectionCharset()},
+		CollationEnv:   collationEnv,
+		Owner: map[string]LookupRowStreamer{
+			"0": lookupRowStreamer(sqltypes.MakeTestResult(fields, "alice|01")),
+		},
+		Lookup: map[string]LookupRowStreamer{
+			"0": func(ctx context.Context, callback func(*sqltypes.Result) error) error {
+				return errors.New("lookup table is gone")
+			},
+		},
+	}
+	_, err := ld.Diff(context.Background())
+	require.ErrorContains(t, err, "lookup table is gone")
+
+	ld.Lookup = map[string]LookupRowStreamer{
+		"0": lookupRowStreamer(sqltypes.MakeTestResult(fields)),
+	}
+	ld.OnMissing = func(row []sqltypes.Value) error {
+		return errors.New("cannot repair")
+	}
+	_, err = ld.Diff(context.Background())
+	require.EqualError(t, err, "cannot repair")
+}